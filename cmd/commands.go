@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"merchshop/internal/repository"
+	"merchshop/internal/sheet"
+	"merchshop/internal/usecase"
+	"merchshop/internal/usecase/report"
+)
+
+// command административная команда, запускается вместо сервера: merchshop <команда> [аргументы]
+type command func(ctx context.Context, useCases *usecase.UseCases, args []string) error
+
+var commands = map[string]command{
+	"report":       reportCommand,
+	"export-user":  exportUserCommand,
+	"verify-audit": verifyAuditCommand,
+}
+
+// runCommand выполняет команду name с теми же конфигом и базой, что у сервера
+func runCommand(name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	db, err := initializeDatabase(cfg.DB.DSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return cmd(ctx, usecase.NewUseCases(repository.NewRepositories(db), cfg), args)
+}
+
+// reportCommand выгружает отчет в файл или stdout:
+//
+//	merchshop report purchases -format xlsx -from 2026-09-01 -to 2026-09-30 -item cup -o september.xlsx
+func reportCommand(ctx context.Context, useCases *usecase.UseCases, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("usage: report <%s> [flags]", strings.Join(report.Kinds, "|"))
+	}
+
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	format := flags.String("format", sheet.FormatCSV, "csv или xlsx")
+	from := flags.String("from", "", "начало периода: YYYY-MM-DD или RFC 3339")
+	to := flags.String("to", "", "конец периода: дата включительно или время RFC 3339 не включая")
+	username := flags.String("user", "", "только операции пользователя")
+	item := flags.String("item", "", "только покупки товара")
+	output := flags.String("o", "", "файл отчета, по умолчанию stdout")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	filter, err := report.ParseFilter(*from, *to, *username, *item)
+	if err != nil {
+		return err
+	}
+
+	req := report.Request{Kind: args[0], Format: *format, Filter: filter}
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return useCases.Report.Export(ctx, os.Stdout, req)
+	}
+
+	return writeFile(*output, func(w io.Writer) error {
+		return useCases.Report.Export(ctx, w, req)
+	})
+}
+
+// exportUserCommand собирает выгрузку персональных данных пользователя сразу, минуя очередь:
+//
+//	merchshop export-user alice -o alice.zip
+func exportUserCommand(ctx context.Context, useCases *usecase.UseCases, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("usage: export-user <username> [-o file.zip]")
+	}
+
+	flags := flag.NewFlagSet("export-user", flag.ContinueOnError)
+	output := flags.String("o", args[0]+".zip", "файл архива")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	u, err := useCases.User.GetByUsername(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	return writeFile(*output, func(w io.Writer) error {
+		return useCases.DataExport.Build(ctx, u.ID, w)
+	})
+}
+
+// verifyAuditCommand пересчитывает цепочку хешей журнала аудита и завершается ошибкой на первой
+// несошедшейся записи:
+//
+//	merchshop verify-audit
+func verifyAuditCommand(ctx context.Context, useCases *usecase.UseCases, args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: verify-audit")
+	}
+
+	result, err := useCases.AuditLog.Verify(ctx)
+	if err != nil {
+		return err
+	}
+
+	if result.BrokenID != 0 {
+		return fmt.Errorf("audit log is broken at entry %d after %d valid entries: %s",
+			result.BrokenID, result.Entries, result.Problem)
+	}
+
+	fmt.Printf("audit log is intact: %d entries, head %s\n", result.Entries, result.Head)
+
+	return nil
+}
+
+// writeFile создает файл path и пишет в него write. Если запись не удалась, недописанный
+// файл удаляется
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return errors.Join(err, os.Remove(path))
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,11 +15,14 @@ import (
 	_ "merchshop/cmd/docs"
 
 	_ "github.com/lib/pq"
+	"google.golang.org/grpc"
 
+	grpcserver "merchshop/internal/api/grpc/server"
 	"merchshop/internal/api/http/auth"
 	"merchshop/internal/api/http/handlers"
 	"merchshop/internal/api/http/router"
 	"merchshop/internal/config"
+	"merchshop/internal/event"
 	"merchshop/internal/repository"
 	"merchshop/internal/usecase"
 )
@@ -35,12 +39,26 @@ import (
 // @name Authorization
 
 func main() {
+	// Административные команды выполняются вместо запуска сервера
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
+
+		return
+	}
+
 	// Загрузка конфигурации
 	cfg, err := loadConfig()
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
+	// Без ключа ссылки на выгрузки подписывались бы пустым ключом, с ключом JWT подделка одного давала бы другое
+	if cfg.Export.SigningKey == "" || cfg.Export.SigningKey == cfg.Auth.SigningKey {
+		log.Fatalf("export.signing_key must be set and differ from auth.signing_key")
+	}
+
 	//Инициализация бд
 	db, err := initializeDatabase(cfg.DB.DSN())
 	if err != nil {
@@ -78,23 +96,80 @@ func main() {
 	defer db.Close()
 
 	// Инициализация use cases
-	useCases := usecase.NewUseCases(repo)
+	useCases := usecase.NewUseCases(repo, cfg)
 
 	// Инициализация хендлеров
-	handler := handlers.NewHandler(
-		useCases.User,
-		useCases.Transaction,
-		useCases.Purchase,
-		useCases.Merch,
-		tokenManager,
-	)
+	handler := handlers.NewHandler(useCases, tokenManager)
 
 	// Инициализация роутера
-	httpRouter := router.NewRouter(handler, tokenManager)
+	httpRouter := router.NewRouter(handler, tokenManager, useCases.User, useCases.AuditLog)
+
+	// Фоновые задачи живут, пока работает сервер
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	if cfg.Events.Backend == config.EventsBackendPostgres {
+		backend := event.NewPostgresBackend(db, cfg.DB.DSN(), cfg.Events.Channel, useCases.Broker)
+		useCases.Events.Subscribe(backend)
+
+		go func() {
+			if err := backend.Listen(workersCtx); err != nil {
+				log.Printf("events listener: %v", err)
+			}
+		}()
+	}
+
+	go runPeriodically(workersCtx, "webhook dispatch", cfg.Webhook.DispatchInterval, func(ctx context.Context) error {
+		_, err := useCases.Webhook.DispatchPending(ctx)
+		return err
+	})
+
+	go runPeriodically(workersCtx, "scheduled transfers", cfg.Schedule.RunInterval, func(ctx context.Context) error {
+		_, err := useCases.Schedule.RunDue(ctx)
+		return err
+	})
+
+	go runPeriodically(workersCtx, "coin requests expiry", cfg.CoinRequest.ExpireInterval, func(ctx context.Context) error {
+		_, err := useCases.CoinRequest.ExpirePending(ctx)
+		return err
+	})
+
+	go runPeriodically(workersCtx, "escrow sweep", cfg.Escrow.SweepInterval, func(ctx context.Context) error {
+		_, _, err := useCases.Escrow.Sweep(ctx)
+		return err
+	})
+
+	go runPeriodically(workersCtx, "fraud scan", cfg.Fraud.ScanInterval, func(ctx context.Context) error {
+		_, err := useCases.Fraud.Scan(ctx)
+		return err
+	})
+
+	go runPeriodically(workersCtx, "wishlist watch", cfg.Wishlist.WatchInterval, func(ctx context.Context) error {
+		_, err := useCases.Wishlist.Watch(ctx)
+		return err
+	})
+
+	go runPeriodically(workersCtx, "leaderboard refresh", cfg.Leaderboard.RefreshInterval, useCases.Leaderboard.Refresh)
+
+	go runPeriodically(workersCtx, "data exports", cfg.Export.ProcessInterval, func(ctx context.Context) error {
+		_, err := useCases.DataExport.Process(ctx)
+		return err
+	})
+
+	go runPeriodically(workersCtx, "audit flush", cfg.Audit.FlushInterval, func(ctx context.Context) error {
+		_, err := useCases.AuditLog.Flush(ctx)
+		return err
+	})
+
+	// Запуск gRPC сервера рядом с HTTP
+	grpcServer := grpcserver.NewGRPCServer(grpcserver.NewServer(useCases, tokenManager), tokenManager)
+	go startGRPCServer(grpcServer, cfg.GRPC.Port)
 
 	// Запуск HTTP сервера
 	startServer(httpRouter, cfg.Server.Port, cfg.Server.ReadTimeout, cfg.Server.WriteTimeout)
 
+	grpcServer.GracefulStop()
+
 }
 
 func loadConfig() (*config.Config, error) {
@@ -119,6 +194,35 @@ func initializeDatabase(dsn string) (*sql.DB, error) {
 	return db, nil
 }
 
+func runPeriodically(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				log.Printf("%s: %v", name, err)
+			}
+		}
+	}
+}
+
+func startGRPCServer(srv *grpc.Server, port int) {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		log.Fatalf("grpc listen: %s\n", err)
+	}
+
+	log.Println("gRPC server is starting")
+
+	if err := srv.Serve(lis); err != nil {
+		log.Fatalf("grpc serve: %s\n", err)
+	}
+}
+
 func startServer(r http.Handler, port int, readTimeout, writeTimeout time.Duration) {
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", port),
@@ -12,6 +12,7 @@ import (
 	"merchshop/internal/api/http/handlers"
 	"merchshop/internal/api/http/middleware"
 	"merchshop/internal/api/http/router"
+	"merchshop/internal/config"
 	"merchshop/internal/repository"
 	"merchshop/internal/usecase"
 
@@ -24,7 +25,7 @@ func TestBuyMerch(t *testing.T) {
 
 	repo := repository.NewRepositories(db)
 
-	useCases := usecase.NewUseCases(repo)
+	useCases := usecase.NewUseCases(repo, &config.Config{})
 
 	tokenManager, err := auth.NewJWTManager("supersecret", 24*time.Hour)
 	if err != nil {
@@ -36,15 +37,9 @@ func TestBuyMerch(t *testing.T) {
 		t.Fatalf("failed to generate token: %v", err)
 	}
 
-	handler := handlers.NewHandler(
-		useCases.User,
-		nil,
-		useCases.Purchase,
-		useCases.Merch,
-		tokenManager,
-	)
+	handler := handlers.NewHandler(useCases, tokenManager)
 
-	r := router.NewRouter(handler, tokenManager)
+	r := router.NewRouter(handler, tokenManager, useCases.User, useCases.AuditLog)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/buy/t-shirt", http.NoBody)
 	req.Header.Set("Authorization", "Bearer "+token)
@@ -67,4 +62,17 @@ func TestBuyMerch(t *testing.T) {
 	err = db.QueryRow("SELECT COUNT(*) FROM purchases WHERE user_id = $1 AND merch_name = 't-shirt'", 1).Scan(&purchaseCount)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, purchaseCount)
+
+	// Покупка по названию товара с вариантами продает вариант по умолчанию
+	var sku string
+
+	err = db.QueryRow("SELECT sku FROM purchases WHERE user_id = $1 AND merch_name = 't-shirt'", 1).Scan(&sku)
+	assert.NoError(t, err)
+	assert.Equal(t, "t-shirt-m", sku)
+
+	var variantStock int
+
+	err = db.QueryRow("SELECT stock FROM merch_variants WHERE sku = 't-shirt-m'").Scan(&variantStock)
+	assert.NoError(t, err)
+	assert.Equal(t, 49, variantStock)
 }
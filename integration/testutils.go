@@ -20,36 +20,202 @@ func SetupTestDB(t *testing.T) *sql.DB {
             username VARCHAR(50) UNIQUE NOT NULL,
 			password_hash TEXT NOT NULL,
             balance BIGINT NOT NULL CHECK (balance >= 0),
-            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+            role VARCHAR(20) NOT NULL DEFAULT 'employee',
+            status VARCHAR(20) NOT NULL DEFAULT 'active',
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            tokens_revoked_at TIMESTAMP WITH TIME ZONE,
+            department VARCHAR(100) NOT NULL DEFAULT ''
         );
 
         CREATE TABLE merchandise (
             name VARCHAR(50) PRIMARY KEY,
-            price BIGINT NOT NULL CHECK (price > 0)
+            price BIGINT NOT NULL CHECK (price > 0),
+            stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+            low_stock_threshold INT NOT NULL DEFAULT 5
+        );
+
+        CREATE TABLE merch_variants (
+            sku VARCHAR(80) PRIMARY KEY,
+            merch_name VARCHAR(50) NOT NULL REFERENCES merchandise(name),
+            attributes JSONB NOT NULL DEFAULT '{}',
+            price BIGINT CHECK (price > 0),
+            stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+            low_stock_threshold INT NOT NULL DEFAULT 5,
+            is_default BOOLEAN NOT NULL DEFAULT FALSE
+        );
+
+        CREATE TABLE promo_codes (
+            code VARCHAR(40) PRIMARY KEY,
+            kind VARCHAR(10) NOT NULL,
+            value INT NOT NULL,
+            max_uses INT NOT NULL DEFAULT 0,
+            per_user_limit INT NOT NULL DEFAULT 0,
+            items TEXT[] NOT NULL DEFAULT '{}',
+            starts_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            ends_at TIMESTAMP WITH TIME ZONE,
+            active BOOLEAN NOT NULL DEFAULT TRUE,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        );
+
+        CREATE TABLE sales (
+            id BIGSERIAL PRIMARY KEY,
+            name VARCHAR(100) NOT NULL,
+            percent INT NOT NULL,
+            items TEXT[] NOT NULL DEFAULT '{}',
+            starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+            ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        );
+
+        CREATE TABLE purchase_rules (
+            merch_name VARCHAR(50) PRIMARY KEY REFERENCES merchandise(name),
+            max_per_user INT NOT NULL DEFAULT 0,
+            roles TEXT[] NOT NULL DEFAULT '{}',
+            departments TEXT[] NOT NULL DEFAULT '{}',
+            min_account_age_seconds BIGINT NOT NULL DEFAULT 0,
+            available_from TIMESTAMP WITH TIME ZONE,
+            available_until TIMESTAMP WITH TIME ZONE,
+            updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        );
+
+        CREATE TABLE notifications (
+            id BIGSERIAL PRIMARY KEY,
+            user_id BIGINT NOT NULL REFERENCES users(id),
+            kind VARCHAR(50) NOT NULL,
+            message TEXT NOT NULL,
+            data JSONB NOT NULL DEFAULT '{}',
+            read_at TIMESTAMP WITH TIME ZONE,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        );
+
+        CREATE TABLE notification_preferences (
+            user_id BIGINT NOT NULL REFERENCES users(id),
+            kind VARCHAR(50) NOT NULL,
+            enabled BOOLEAN NOT NULL,
+            PRIMARY KEY (user_id, kind)
+        );
+
+        CREATE TABLE wishlist_items (
+            user_id BIGINT NOT NULL REFERENCES users(id),
+            item VARCHAR(80) NOT NULL,
+            merch_name VARCHAR(50) NOT NULL REFERENCES merchandise(name),
+            sku VARCHAR(80) REFERENCES merch_variants(sku),
+            last_price BIGINT NOT NULL,
+            affordable_notified BOOLEAN NOT NULL DEFAULT FALSE,
+            added_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (user_id, item)
         );
 
         CREATE TABLE purchases (
             id BIGSERIAL PRIMARY KEY,
             user_id BIGINT NOT NULL REFERENCES users(id),
             merch_name VARCHAR(50) NOT NULL REFERENCES merchandise(name),
+            sku VARCHAR(80) REFERENCES merch_variants(sku),
             quantity INT NOT NULL CHECK (quantity > 0),
-            total_price BIGINT NOT NULL CHECK (total_price > 0),
+            list_price BIGINT NOT NULL,
+            discount BIGINT NOT NULL DEFAULT 0,
+            promo_code VARCHAR(40) REFERENCES promo_codes(code),
+            total_price BIGINT NOT NULL CHECK (total_price >= 0),
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            status VARCHAR(20) NOT NULL DEFAULT 'placed',
+            pickup_location VARCHAR(100),
+            ready_at TIMESTAMP WITH TIME ZONE,
+            delivered_at TIMESTAMP WITH TIME ZONE,
+            cancelled_at TIMESTAMP WITH TIME ZONE,
+            buyer_id BIGINT REFERENCES users(id),
+            gift_message VARCHAR(200)
+        );
+
+        CREATE TABLE transfer_batches (
+            id BIGSERIAL PRIMARY KEY,
+            sender_id BIGINT NOT NULL REFERENCES users(id),
+            total_amount BIGINT NOT NULL CHECK (total_amount > 0),
             created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
         );
+
+        CREATE TABLE transactions (
+            id BIGSERIAL PRIMARY KEY,
+            sender_id BIGINT NOT NULL REFERENCES users(id),
+            receiver_id BIGINT NOT NULL REFERENCES users(id),
+            amount BIGINT NOT NULL CHECK (amount > 0),
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            batch_id BIGINT REFERENCES transfer_batches(id),
+            memo VARCHAR(140) NOT NULL DEFAULT '',
+            reaction VARCHAR(16) NOT NULL DEFAULT '',
+            reacted_at TIMESTAMP WITH TIME ZONE,
+            CONSTRAINT different_users CHECK (sender_id != receiver_id)
+        );
+
+        CREATE TABLE escrow_transfers (
+            id BIGSERIAL PRIMARY KEY,
+            sender_id BIGINT NOT NULL REFERENCES users(id),
+            recipient_username VARCHAR(100) NOT NULL,
+            receiver_id BIGINT REFERENCES users(id),
+            amount BIGINT NOT NULL CHECK (amount > 0),
+            memo VARCHAR(140) NOT NULL DEFAULT '',
+            status VARCHAR(20) NOT NULL DEFAULT 'held',
+            transaction_id BIGINT REFERENCES transactions(id),
+            expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            resolved_at TIMESTAMP WITH TIME ZONE
+        );
+
+        CREATE TABLE transfer_policies (
+            role VARCHAR(20) PRIMARY KEY,
+            daily_limit BIGINT NOT NULL DEFAULT 0 CHECK (daily_limit >= 0),
+            weekly_limit BIGINT NOT NULL DEFAULT 0 CHECK (weekly_limit >= 0),
+            max_transfer BIGINT NOT NULL DEFAULT 0 CHECK (max_transfer >= 0),
+            min_account_age_seconds BIGINT NOT NULL DEFAULT 0 CHECK (min_account_age_seconds >= 0),
+            velocity_count INT NOT NULL DEFAULT 0 CHECK (velocity_count >= 0),
+            velocity_window_seconds BIGINT NOT NULL DEFAULT 0 CHECK (velocity_window_seconds >= 0),
+            updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        );
+
+        CREATE TABLE audit_log (
+            id BIGSERIAL PRIMARY KEY,
+            actor_id BIGINT REFERENCES users(id),
+            action VARCHAR(200) NOT NULL,
+            target_type VARCHAR(30) NOT NULL DEFAULT '',
+            target_id VARCHAR(200) NOT NULL DEFAULT '',
+            request_id VARCHAR(64) NOT NULL DEFAULT '',
+            ip VARCHAR(45) NOT NULL DEFAULT '',
+            before JSONB,
+            after JSONB,
+            created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+            prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+            hash VARCHAR(64) NOT NULL
+        );
+
+        CREATE TABLE audit_outbox (
+            id BIGSERIAL PRIMARY KEY,
+            actor_id BIGINT REFERENCES users(id),
+            action VARCHAR(200) NOT NULL,
+            target_type VARCHAR(30) NOT NULL DEFAULT '',
+            target_id VARCHAR(200) NOT NULL DEFAULT '',
+            request_id VARCHAR(64) NOT NULL DEFAULT '',
+            ip VARCHAR(45) NOT NULL DEFAULT '',
+            before JSONB,
+            after JSONB,
+            created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+        );
     `)
 
 	if err != nil {
 		t.Fatalf("failed to create tables: %v", err)
 	}
 
-	_, err = testDB.Exec("TRUNCATE TABLE users, merchandise, purchases RESTART IDENTITY CASCADE")
+	_, err = testDB.Exec("TRUNCATE TABLE users, merchandise, merch_variants, promo_codes, sales, purchase_rules, notifications, notification_preferences, wishlist_items, purchases, transfer_batches, transactions, escrow_transfers, transfer_policies, audit_log, audit_outbox RESTART IDENTITY CASCADE")
 	if err != nil {
 		t.Fatalf("failed to truncate tables: %v", err)
 	}
 
 	_, err = testDB.Exec(`
         INSERT INTO users (username,password_hash, balance) VALUES ('alice','pass', 1000), ('bob','pass', 500);
-        INSERT INTO merchandise (name, price) VALUES ('t-shirt', 80), ('cup', 20);
+        INSERT INTO merchandise (name, price, stock) VALUES ('t-shirt', 80, 0), ('cup', 20, 100);
+        INSERT INTO merch_variants (sku, merch_name, attributes, stock, is_default) VALUES
+            ('t-shirt-s', 't-shirt', '{"size": "S"}', 50, FALSE),
+            ('t-shirt-m', 't-shirt', '{"size": "M"}', 50, TRUE);
+        INSERT INTO transfer_policies (role, daily_limit) VALUES ('employee', 1000);
     `)
 
 	if err != nil {
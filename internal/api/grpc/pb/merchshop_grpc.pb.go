@@ -0,0 +1,399 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: merchshop.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MerchShop_Auth_FullMethodName          = "/merchshop.v1.MerchShop/Auth"
+	MerchShop_GetInfo_FullMethodName       = "/merchshop.v1.MerchShop/GetInfo"
+	MerchShop_SendCoin_FullMethodName      = "/merchshop.v1.MerchShop/SendCoin"
+	MerchShop_SendCoinBatch_FullMethodName = "/merchshop.v1.MerchShop/SendCoinBatch"
+	MerchShop_React_FullMethodName         = "/merchshop.v1.MerchShop/React"
+	MerchShop_Buy_FullMethodName           = "/merchshop.v1.MerchShop/Buy"
+	MerchShop_ListMerch_FullMethodName     = "/merchshop.v1.MerchShop/ListMerch"
+	MerchShop_StreamHistory_FullMethodName = "/merchshop.v1.MerchShop/StreamHistory"
+)
+
+// MerchShopClient is the client API for MerchShop service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MerchShopClient interface {
+	// Auth выдает JWT, при первом входе создает пользователя. Не требует авторизации
+	Auth(ctx context.Context, in *AuthRequest, opts ...grpc.CallOption) (*AuthResponse, error)
+	GetInfo(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*InfoResponse, error)
+	SendCoin(ctx context.Context, in *SendCoinRequest, opts ...grpc.CallOption) (*SendCoinResponse, error)
+	// SendCoinBatch переводит монеты нескольким получателям атомарно
+	SendCoinBatch(ctx context.Context, in *SendCoinBatchRequest, opts ...grpc.CallOption) (*SendCoinBatchResponse, error)
+	// React ставит реакцию получателя на перевод
+	React(ctx context.Context, in *ReactRequest, opts ...grpc.CallOption) (*ReactResponse, error)
+	Buy(ctx context.Context, in *BuyRequest, opts ...grpc.CallOption) (*BuyResponse, error)
+	ListMerch(ctx context.Context, in *ListMerchRequest, opts ...grpc.CallOption) (*ListMerchResponse, error)
+	// StreamHistory отдает переводы и покупки пользователя, начиная с новых
+	StreamHistory(ctx context.Context, in *StreamHistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HistoryEntry], error)
+}
+
+type merchShopClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMerchShopClient(cc grpc.ClientConnInterface) MerchShopClient {
+	return &merchShopClient{cc}
+}
+
+func (c *merchShopClient) Auth(ctx context.Context, in *AuthRequest, opts ...grpc.CallOption) (*AuthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuthResponse)
+	err := c.cc.Invoke(ctx, MerchShop_Auth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merchShopClient) GetInfo(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*InfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InfoResponse)
+	err := c.cc.Invoke(ctx, MerchShop_GetInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merchShopClient) SendCoin(ctx context.Context, in *SendCoinRequest, opts ...grpc.CallOption) (*SendCoinResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendCoinResponse)
+	err := c.cc.Invoke(ctx, MerchShop_SendCoin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merchShopClient) SendCoinBatch(ctx context.Context, in *SendCoinBatchRequest, opts ...grpc.CallOption) (*SendCoinBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendCoinBatchResponse)
+	err := c.cc.Invoke(ctx, MerchShop_SendCoinBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merchShopClient) React(ctx context.Context, in *ReactRequest, opts ...grpc.CallOption) (*ReactResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReactResponse)
+	err := c.cc.Invoke(ctx, MerchShop_React_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merchShopClient) Buy(ctx context.Context, in *BuyRequest, opts ...grpc.CallOption) (*BuyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BuyResponse)
+	err := c.cc.Invoke(ctx, MerchShop_Buy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merchShopClient) ListMerch(ctx context.Context, in *ListMerchRequest, opts ...grpc.CallOption) (*ListMerchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMerchResponse)
+	err := c.cc.Invoke(ctx, MerchShop_ListMerch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merchShopClient) StreamHistory(ctx context.Context, in *StreamHistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HistoryEntry], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MerchShop_ServiceDesc.Streams[0], MerchShop_StreamHistory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamHistoryRequest, HistoryEntry]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MerchShop_StreamHistoryClient = grpc.ServerStreamingClient[HistoryEntry]
+
+// MerchShopServer is the server API for MerchShop service.
+// All implementations must embed UnimplementedMerchShopServer
+// for forward compatibility.
+type MerchShopServer interface {
+	// Auth выдает JWT, при первом входе создает пользователя. Не требует авторизации
+	Auth(context.Context, *AuthRequest) (*AuthResponse, error)
+	GetInfo(context.Context, *GetInfoRequest) (*InfoResponse, error)
+	SendCoin(context.Context, *SendCoinRequest) (*SendCoinResponse, error)
+	// SendCoinBatch переводит монеты нескольким получателям атомарно
+	SendCoinBatch(context.Context, *SendCoinBatchRequest) (*SendCoinBatchResponse, error)
+	// React ставит реакцию получателя на перевод
+	React(context.Context, *ReactRequest) (*ReactResponse, error)
+	Buy(context.Context, *BuyRequest) (*BuyResponse, error)
+	ListMerch(context.Context, *ListMerchRequest) (*ListMerchResponse, error)
+	// StreamHistory отдает переводы и покупки пользователя, начиная с новых
+	StreamHistory(*StreamHistoryRequest, grpc.ServerStreamingServer[HistoryEntry]) error
+	mustEmbedUnimplementedMerchShopServer()
+}
+
+// UnimplementedMerchShopServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMerchShopServer struct{}
+
+func (UnimplementedMerchShopServer) Auth(context.Context, *AuthRequest) (*AuthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Auth not implemented")
+}
+func (UnimplementedMerchShopServer) GetInfo(context.Context, *GetInfoRequest) (*InfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInfo not implemented")
+}
+func (UnimplementedMerchShopServer) SendCoin(context.Context, *SendCoinRequest) (*SendCoinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendCoin not implemented")
+}
+func (UnimplementedMerchShopServer) SendCoinBatch(context.Context, *SendCoinBatchRequest) (*SendCoinBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendCoinBatch not implemented")
+}
+func (UnimplementedMerchShopServer) React(context.Context, *ReactRequest) (*ReactResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method React not implemented")
+}
+func (UnimplementedMerchShopServer) Buy(context.Context, *BuyRequest) (*BuyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Buy not implemented")
+}
+func (UnimplementedMerchShopServer) ListMerch(context.Context, *ListMerchRequest) (*ListMerchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMerch not implemented")
+}
+func (UnimplementedMerchShopServer) StreamHistory(*StreamHistoryRequest, grpc.ServerStreamingServer[HistoryEntry]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamHistory not implemented")
+}
+func (UnimplementedMerchShopServer) mustEmbedUnimplementedMerchShopServer() {}
+func (UnimplementedMerchShopServer) testEmbeddedByValue()                   {}
+
+// UnsafeMerchShopServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MerchShopServer will
+// result in compilation errors.
+type UnsafeMerchShopServer interface {
+	mustEmbedUnimplementedMerchShopServer()
+}
+
+func RegisterMerchShopServer(s grpc.ServiceRegistrar, srv MerchShopServer) {
+	// If the following call pancis, it indicates UnimplementedMerchShopServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MerchShop_ServiceDesc, srv)
+}
+
+func _MerchShop_Auth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerchShopServer).Auth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerchShop_Auth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerchShopServer).Auth(ctx, req.(*AuthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerchShop_GetInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerchShopServer).GetInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerchShop_GetInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerchShopServer).GetInfo(ctx, req.(*GetInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerchShop_SendCoin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendCoinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerchShopServer).SendCoin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerchShop_SendCoin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerchShopServer).SendCoin(ctx, req.(*SendCoinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerchShop_SendCoinBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendCoinBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerchShopServer).SendCoinBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerchShop_SendCoinBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerchShopServer).SendCoinBatch(ctx, req.(*SendCoinBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerchShop_React_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerchShopServer).React(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerchShop_React_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerchShopServer).React(ctx, req.(*ReactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerchShop_Buy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerchShopServer).Buy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerchShop_Buy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerchShopServer).Buy(ctx, req.(*BuyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerchShop_ListMerch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMerchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerchShopServer).ListMerch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerchShop_ListMerch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerchShopServer).ListMerch(ctx, req.(*ListMerchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerchShop_StreamHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamHistoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MerchShopServer).StreamHistory(m, &grpc.GenericServerStream[StreamHistoryRequest, HistoryEntry]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MerchShop_StreamHistoryServer = grpc.ServerStreamingServer[HistoryEntry]
+
+// MerchShop_ServiceDesc is the grpc.ServiceDesc for MerchShop service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MerchShop_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "merchshop.v1.MerchShop",
+	HandlerType: (*MerchShopServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Auth",
+			Handler:    _MerchShop_Auth_Handler,
+		},
+		{
+			MethodName: "GetInfo",
+			Handler:    _MerchShop_GetInfo_Handler,
+		},
+		{
+			MethodName: "SendCoin",
+			Handler:    _MerchShop_SendCoin_Handler,
+		},
+		{
+			MethodName: "SendCoinBatch",
+			Handler:    _MerchShop_SendCoinBatch_Handler,
+		},
+		{
+			MethodName: "React",
+			Handler:    _MerchShop_React_Handler,
+		},
+		{
+			MethodName: "Buy",
+			Handler:    _MerchShop_Buy_Handler,
+		},
+		{
+			MethodName: "ListMerch",
+			Handler:    _MerchShop_ListMerch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamHistory",
+			Handler:       _MerchShop_StreamHistory_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "merchshop.proto",
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"merchshop/internal/api/grpc/pb"
+	"merchshop/internal/api/http/auth"
+	"merchshop/internal/audit"
+	"merchshop/internal/usecase/user"
+)
+
+type contextKey string
+
+const userIDKey contextKey = "user_id"
+
+// publicMethods не требуют токена
+var publicMethods = map[string]bool{
+	pb.MerchShop_Auth_FullMethodName: true,
+}
+
+// requestIDKey метаданные с ID запроса для журнала аудита, как X-Request-ID в HTTP
+const requestIDKey = "x-request-id"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// UnaryAuthInterceptor проверяет JWT из метаданных authorization: Bearer <token>
+// и что пользователь не деактивирован и его токены не отозваны
+func UnaryAuthInterceptor(tokenManager auth.TokenManager, userUseCase user.UseCase) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = withRequest(ctx)
+
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, tokenManager, userUseCase)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamAuthInterceptor(tokenManager auth.TokenManager, userUseCase user.UseCase) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := withRequest(ss.Context())
+
+		if publicMethods[info.FullMethod] {
+			return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+		}
+
+		ctx, err := authenticate(ctx, tokenManager, userUseCase)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, tokenManager auth.TokenManager, userUseCase user.UseCase) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "Неавторизован")
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "Неавторизован")
+	}
+
+	headerParts := strings.Split(values[0], " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+		return nil, status.Error(codes.Unauthenticated, "Неверный header")
+	}
+
+	claims, err := tokenManager.ParseClaims(headerParts[1])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	if err := userUseCase.Authorize(ctx, claims.UserID, time.Unix(claims.IssuedAt, 0)); err != nil {
+		return nil, status.Error(codes.Unauthenticated, "Неавторизован")
+	}
+
+	ctx = audit.WithActor(ctx, claims.UserID)
+
+	return context.WithValue(ctx, userIDKey, claims.UserID), nil
+}
+
+// withRequest кладет в контекст ID запроса из метаданных и адрес клиента для журнала аудита
+func withRequest(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 && validRequestID.MatchString(values[0]) {
+			id = values[0]
+		}
+	}
+
+	if id == "" {
+		id = audit.NewRequestID()
+	}
+
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	return audit.WithRequest(ctx, audit.Request{ID: id, IP: ip})
+}
+
+func userIDFromContext(ctx context.Context) (int, error) {
+	userID, ok := ctx.Value(userIDKey).(int)
+	if !ok {
+		return 0, status.Error(codes.Unauthenticated, "Неавторизован")
+	}
+
+	return userID, nil
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,413 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"merchshop/internal/api/grpc/pb"
+	"merchshop/internal/api/http/auth"
+	entities "merchshop/internal/entity"
+	"merchshop/internal/usecase"
+	"merchshop/internal/usecase/merch"
+	"merchshop/internal/usecase/policy"
+	"merchshop/internal/usecase/purchase"
+	"merchshop/internal/usecase/transaction"
+	"merchshop/internal/usecase/user"
+)
+
+type Server struct {
+	pb.UnimplementedMerchShopServer
+
+	userUseCase        user.UseCase
+	transactionUseCase transaction.UseCase
+	purchaseUseCase    purchase.UseCase
+	merchUseCase       merch.UseCase
+	tokenManager       auth.TokenManager
+}
+
+func NewServer(useCases *usecase.UseCases, tm auth.TokenManager) *Server {
+	return &Server{
+		userUseCase:        useCases.User,
+		transactionUseCase: useCases.Transaction,
+		purchaseUseCase:    useCases.Purchase,
+		merchUseCase:       useCases.Merch,
+		tokenManager:       tm,
+	}
+}
+
+// NewGRPCServer создает grpc.Server с JWT-интерцепторами и зарегистрированным сервисом
+func NewGRPCServer(s *Server, tokenManager auth.TokenManager) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryAuthInterceptor(tokenManager, s.userUseCase)),
+		grpc.StreamInterceptor(StreamAuthInterceptor(tokenManager, s.userUseCase)),
+	)
+
+	pb.RegisterMerchShopServer(srv, s)
+
+	return srv
+}
+
+func (s *Server) Auth(ctx context.Context, req *pb.AuthRequest) (*pb.AuthResponse, error) {
+	u, err := s.userUseCase.Authenticate(ctx, req.GetUsername(), req.GetPassword())
+	if err != nil {
+		if errors.Is(err, user.ErrInvalidCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "Неавторизован")
+		}
+
+		if errors.Is(err, user.ErrAccountDeactivated) {
+			return nil, status.Error(codes.PermissionDenied, "Аккаунт деактивирован")
+		}
+
+		return nil, status.Error(codes.Internal, "Внутренняя ошибка сервера")
+	}
+
+	token, err := s.tokenManager.NewToken(u.ID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Внутренняя ошибка сервера")
+	}
+
+	return &pb.AuthResponse{Token: token}, nil
+}
+
+func (s *Server) GetInfo(ctx context.Context, _ *pb.GetInfoRequest) (*pb.InfoResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.userUseCase.GetByID(ctx, userID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Внутренняя ошибка сервера")
+	}
+
+	purchases, err := s.purchaseUseCase.GetUserPurchases(ctx, userID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Внутренняя ошибка сервера")
+	}
+
+	sentTx, err := s.transactionUseCase.GetSentTransactions(ctx, userID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Внутренняя ошибка сервера")
+	}
+
+	receivedTx, err := s.transactionUseCase.GetReceivedTransactions(ctx, userID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Внутренняя ошибка сервера")
+	}
+
+	return &pb.InfoResponse{
+		Coins:     int64(u.Balance),
+		Inventory: mapInventory(purchases),
+		CoinHistory: &pb.CoinHistory{
+			Sent:     mapTransactions(sentTx, false),
+			Received: mapTransactions(receivedTx, true),
+		},
+	}, nil
+}
+
+func (s *Server) SendCoin(ctx context.Context, req *pb.SendCoinRequest) (*pb.SendCoinResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	receiver, err := s.userUseCase.GetByUsername(ctx, req.GetToUser())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "Пользователь не найден")
+	}
+
+	if err := s.transactionUseCase.Transfer(ctx, userID, receiver.ID, int(req.GetAmount()), req.GetMemo()); err != nil {
+		return nil, operationError(err)
+	}
+
+	return &pb.SendCoinResponse{}, nil
+}
+
+func (s *Server) SendCoinBatch(ctx context.Context, req *pb.SendCoinBatchRequest) (*pb.SendCoinBatchResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]transaction.Recipient, len(req.GetTransfers()))
+	total := int64(0)
+
+	for i, t := range req.GetTransfers() {
+		recipients[i] = transaction.Recipient{Username: t.GetToUser(), Amount: int(t.GetAmount()), Memo: t.GetMemo()}
+		total += t.GetAmount()
+	}
+
+	batchID, err := s.transactionUseCase.TransferBatch(ctx, userID, recipients)
+	if err != nil {
+		return nil, operationError(err)
+	}
+
+	return &pb.SendCoinBatchResponse{BatchId: int64(batchID), Total: total}, nil
+}
+
+func (s *Server) React(ctx context.Context, req *pb.ReactRequest) (*pb.ReactResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transactionUseCase.React(ctx, userID, int(req.GetTransactionId()), req.GetReaction()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "Перевод не найден")
+		}
+
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &pb.ReactResponse{}, nil
+}
+
+func (s *Server) Buy(ctx context.Context, req *pb.BuyRequest) (*pb.BuyResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetToUser() != "" {
+		recipient, lookupErr := s.userUseCase.GetByUsername(ctx, req.GetToUser())
+		if lookupErr != nil {
+			return nil, status.Error(codes.NotFound, "Пользователь не найден")
+		}
+
+		err = s.purchaseUseCase.Gift(ctx, userID, recipient.ID, 1, req.GetItem(), req.GetPromoCode(), req.GetMessage())
+	} else {
+		err = s.purchaseUseCase.Purchase(ctx, userID, 1, req.GetItem(), req.GetPromoCode())
+	}
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "Товар не найден")
+		}
+
+		return nil, operationError(err)
+	}
+
+	return &pb.BuyResponse{}, nil
+}
+
+func (s *Server) ListMerch(ctx context.Context, _ *pb.ListMerchRequest) (*pb.ListMerchResponse, error) {
+	items, err := s.merchUseCase.List(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Внутренняя ошибка сервера")
+	}
+
+	resp := &pb.ListMerchResponse{Items: make([]*pb.MerchItem, len(items))}
+	for i, item := range items {
+		resp.Items[i] = &pb.MerchItem{Name: item.Name, Price: int64(item.Price), Stock: int64(item.Stock)}
+
+		for _, v := range item.Variants {
+			resp.Items[i].Variants = append(resp.Items[i].Variants, &pb.MerchVariant{
+				Sku:        v.SKU,
+				Attributes: v.Attributes,
+				Price:      int64(v.Price),
+				Stock:      int64(v.Stock),
+			})
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *Server) StreamHistory(_ *pb.StreamHistoryRequest, stream grpc.ServerStreamingServer[pb.HistoryEntry]) error {
+	ctx := stream.Context()
+
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	transactions, err := s.transactionUseCase.GetUserTransactions(ctx, userID)
+	if err != nil {
+		return status.Error(codes.Internal, "Внутренняя ошибка сервера")
+	}
+
+	purchases, err := s.purchaseUseCase.GetUserPurchases(ctx, userID)
+	if err != nil {
+		return status.Error(codes.Internal, "Внутренняя ошибка сервера")
+	}
+
+	gifts, err := s.purchaseUseCase.GetSentGifts(ctx, userID)
+	if err != nil {
+		return status.Error(codes.Internal, "Внутренняя ошибка сервера")
+	}
+
+	entries := make([]*pb.HistoryEntry, 0, len(transactions)+len(purchases)+len(gifts))
+
+	for _, tx := range transactions {
+		entries = append(entries, &pb.HistoryEntry{
+			Id:        int64(tx.ID),
+			CreatedAt: timestamppb.New(tx.CreatedAt),
+			Entry:     &pb.HistoryEntry_Transfer{Transfer: mapTransfer(tx)},
+		})
+	}
+
+	// Полученный подарок оплатил другой пользователь, поэтому цена показывается только оплатившему
+	for _, p := range purchases {
+		purchase := &pb.Purchase{Item: p.MerchName, Quantity: int64(p.Quantity), TotalPrice: int64(p.TotalPrice)}
+		if p.IsGift() {
+			purchase.TotalPrice = 0
+			purchase.FromUser = p.BuyerName
+			purchase.Message = p.GiftMessage
+		}
+
+		entries = append(entries, &pb.HistoryEntry{
+			Id:        int64(p.ID),
+			CreatedAt: timestamppb.New(p.CreatedAt),
+			Entry:     &pb.HistoryEntry_Purchase{Purchase: purchase},
+		})
+	}
+
+	for _, p := range gifts {
+		entries = append(entries, &pb.HistoryEntry{
+			Id:        int64(p.ID),
+			CreatedAt: timestamppb.New(p.CreatedAt),
+			Entry: &pb.HistoryEntry_Purchase{Purchase: &pb.Purchase{
+				Item:       p.MerchName,
+				Quantity:   int64(p.Quantity),
+				TotalPrice: int64(p.TotalPrice),
+				ToUser:     p.Username,
+				Message:    p.GiftMessage,
+			}},
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].GetCreatedAt().AsTime().After(entries[j].GetCreatedAt().AsTime())
+	})
+
+	for _, entry := range entries {
+		if err := stream.Send(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapInventory группирует покупки по товару, внутри товара перечисляет купленные варианты
+// и невыданные заказы. Отмененные заказы пропускаются
+func mapInventory(purchases []entities.Purchase) []*pb.InventoryItem {
+	result := make([]*pb.InventoryItem, 0)
+	items := make(map[string]*pb.InventoryItem)
+	variants := make(map[string]*pb.InventoryVariant)
+
+	for _, p := range purchases {
+		if p.Status == entities.OrderCancelled {
+			continue
+		}
+
+		item, ok := items[p.MerchName]
+		if !ok {
+			item = &pb.InventoryItem{Type: p.MerchName}
+			items[p.MerchName] = item
+			result = append(result, item)
+		}
+
+		item.Quantity += int64(p.Quantity)
+
+		if p.Status == entities.OrderPlaced || p.Status == entities.OrderReady {
+			item.Orders = append(item.Orders, &pb.InventoryOrder{
+				Id:             int64(p.ID),
+				Sku:            p.SKU,
+				Quantity:       int64(p.Quantity),
+				Status:         p.Status,
+				PickupLocation: p.PickupLocation,
+			})
+		}
+
+		if p.SKU == "" {
+			continue
+		}
+
+		variant, ok := variants[p.SKU]
+		if !ok {
+			variant = &pb.InventoryVariant{Sku: p.SKU, Attributes: p.Attributes}
+			variants[p.SKU] = variant
+			item.Variants = append(item.Variants, variant)
+		}
+
+		variant.Quantity += int64(p.Quantity)
+	}
+
+	return result
+}
+
+func mapTransactions(transactions []entities.Transaction, isReceived bool) []*pb.CoinOperation {
+	result := make([]*pb.CoinOperation, len(transactions))
+
+	for i, tx := range transactions {
+		operation := &pb.CoinOperation{
+			Amount:   int64(tx.Amount),
+			Memo:     tx.Memo,
+			Reaction: tx.Reaction,
+			BatchId:  int64(tx.BatchID),
+		}
+
+		if len(tx.Items) == 0 {
+			operation.Id = int64(tx.ID)
+		}
+
+		if isReceived {
+			operation.FromUser = tx.SenderName
+		} else {
+			operation.ToUser = tx.ReceiverName
+		}
+
+		if len(tx.Items) > 0 {
+			operation.Recipients = mapTransactions(tx.Items, false)
+		}
+
+		result[i] = operation
+	}
+
+	return result
+}
+
+func mapTransfer(tx entities.Transaction) *pb.Transfer {
+	transfer := &pb.Transfer{
+		FromUser: tx.SenderName,
+		ToUser:   tx.ReceiverName,
+		Amount:   int64(tx.Amount),
+		BatchId:  int64(tx.BatchID),
+		Memo:     tx.Memo,
+		Reaction: tx.Reaction,
+	}
+
+	for _, item := range tx.Items {
+		transfer.Items = append(transfer.Items, mapTransfer(item))
+	}
+
+	return transfer
+}
+
+// operationError отдает FailedPrecondition с кодом нарушенной политики, PermissionDenied для неактивного
+// аккаунта и ограничений товара, ResourceExhausted, если товар закончился, остальные ошибки InvalidArgument
+func operationError(err error) error {
+	var violation *policy.Violation
+	if errors.As(err, &violation) {
+		return status.Error(codes.FailedPrecondition, violation.Error())
+	}
+
+	if errors.Is(err, user.ErrAccountFrozen) || errors.Is(err, user.ErrAccountDeactivated) ||
+		errors.Is(err, purchase.ErrNotEligible) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	if errors.Is(err, purchase.ErrOutOfStock) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	return status.Error(codes.InvalidArgument, err.Error())
+}
@@ -0,0 +1,204 @@
+package server_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"merchshop/internal/api/grpc/pb"
+	"merchshop/internal/api/grpc/server"
+	"merchshop/internal/api/http/auth"
+	"merchshop/internal/entity"
+	"merchshop/internal/pricing"
+	"merchshop/internal/usecase"
+	"merchshop/internal/usecase/purchase"
+	"merchshop/internal/usecase/transaction"
+)
+
+type mockUserUseCase struct{ mock.Mock }
+
+func (m *mockUserUseCase) Authenticate(ctx context.Context, username, password string) (*entity.User, error) {
+	args := m.Called(ctx, username, password)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *mockUserUseCase) Register(ctx context.Context, username, password string) (*entity.User, error) {
+	args := m.Called(ctx, username, password)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *mockUserUseCase) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *mockUserUseCase) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	args := m.Called(ctx, username)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *mockUserUseCase) Authorize(ctx context.Context, userID int, issuedAt time.Time) error {
+	return m.Called(ctx, userID, issuedAt).Error(0)
+}
+
+type mockPurchaseUseCase struct{ mock.Mock }
+
+func (m *mockPurchaseUseCase) Purchase(ctx context.Context, userID, quantity int, merchName, promoCode string) error {
+	return m.Called(ctx, userID, quantity, merchName, promoCode).Error(0)
+}
+
+func (m *mockPurchaseUseCase) Quote(ctx context.Context, userID, quantity int, merchName, promoCode string) (*pricing.Quote, error) {
+	args := m.Called(ctx, userID, quantity, merchName, promoCode)
+	return args.Get(0).(*pricing.Quote), args.Error(1)
+}
+
+func (m *mockPurchaseUseCase) GetUserPurchases(ctx context.Context, userID int) ([]entity.Purchase, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.Purchase), args.Error(1)
+}
+
+func (m *mockPurchaseUseCase) Gift(ctx context.Context, buyerID, recipientID, quantity int, item, promoCode, message string) error {
+	return m.Called(ctx, buyerID, recipientID, quantity, item, promoCode, message).Error(0)
+}
+
+func (m *mockPurchaseUseCase) GetSentGifts(ctx context.Context, userID int) ([]entity.Purchase, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.Purchase), args.Error(1)
+}
+
+type mockTransactionUseCase struct{ mock.Mock }
+
+func (m *mockTransactionUseCase) Transfer(ctx context.Context, senderID, receiverID int, amount int, memo string) error {
+	return m.Called(ctx, senderID, receiverID, amount, memo).Error(0)
+}
+
+func (m *mockTransactionUseCase) React(ctx context.Context, userID, transactionID int, reaction string) error {
+	return m.Called(ctx, userID, transactionID, reaction).Error(0)
+}
+
+func (m *mockTransactionUseCase) TransferBatch(ctx context.Context, senderID int, recipients []transaction.Recipient) (int, error) {
+	args := m.Called(ctx, senderID, recipients)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockTransactionUseCase) GetUserTransactions(ctx context.Context, userID int) ([]entity.Transaction, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.Transaction), args.Error(1)
+}
+
+func (m *mockTransactionUseCase) GetReceivedTransactions(ctx context.Context, userID int) ([]entity.Transaction, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.Transaction), args.Error(1)
+}
+
+func (m *mockTransactionUseCase) GetSentTransactions(ctx context.Context, userID int) ([]entity.Transaction, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.Transaction), args.Error(1)
+}
+
+func newClient(t *testing.T, useCases *usecase.UseCases, tm auth.TokenManager) pb.MerchShopClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := server.NewGRPCServer(server.NewServer(useCases, tm), tm)
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewMerchShopClient(conn)
+}
+
+func TestGetInfo_RequiresToken(t *testing.T) {
+	tm, err := auth.NewJWTManager("secret", time.Hour)
+	require.NoError(t, err)
+
+	client := newClient(t, &usecase.UseCases{}, tm)
+
+	_, err = client.GetInfo(context.Background(), &pb.GetInfoRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAuth_IsPublic(t *testing.T) {
+	tm, err := auth.NewJWTManager("secret", time.Hour)
+	require.NoError(t, err)
+
+	userUC := new(mockUserUseCase)
+	userUC.On("Authenticate", mock.Anything, "alice", "pass").Return(&entity.User{ID: 7}, nil)
+
+	client := newClient(t, &usecase.UseCases{User: userUC}, tm)
+
+	resp, err := client.Auth(context.Background(), &pb.AuthRequest{Username: "alice", Password: "pass"})
+	require.NoError(t, err)
+
+	userID, err := tm.Parse(resp.GetToken())
+	require.NoError(t, err)
+	require.Equal(t, 7, userID)
+}
+
+func TestGetInfo_Success(t *testing.T) {
+	tm, err := auth.NewJWTManager("secret", time.Hour)
+	require.NoError(t, err)
+
+	userUC := new(mockUserUseCase)
+	purchaseUC := new(mockPurchaseUseCase)
+	txUC := new(mockTransactionUseCase)
+
+	userUC.On("Authorize", mock.Anything, 1, mock.Anything).Return(nil)
+	userUC.On("GetByID", mock.Anything, 1).Return(&entity.User{ID: 1, Balance: 420}, nil)
+	purchaseUC.On("GetUserPurchases", mock.Anything, 1).Return([]entity.Purchase{{MerchName: "cup", Quantity: 2}}, nil)
+	txUC.On("GetSentTransactions", mock.Anything, 1).Return([]entity.Transaction{{ReceiverName: "bob", Amount: 30}}, nil)
+	txUC.On("GetReceivedTransactions", mock.Anything, 1).Return([]entity.Transaction{}, nil)
+
+	client := newClient(t, &usecase.UseCases{User: userUC, Purchase: purchaseUC, Transaction: txUC}, tm)
+
+	token, err := tm.NewToken(1)
+	require.NoError(t, err)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	resp, err := client.GetInfo(ctx, &pb.GetInfoRequest{})
+	require.NoError(t, err)
+
+	require.Equal(t, int64(420), resp.GetCoins())
+	require.Len(t, resp.GetInventory(), 1)
+	require.Equal(t, "bob", resp.GetCoinHistory().GetSent()[0].GetToUser())
+}
+
+func TestBuy_Gift(t *testing.T) {
+	tm, err := auth.NewJWTManager("secret", time.Hour)
+	require.NoError(t, err)
+
+	userUC := new(mockUserUseCase)
+	purchaseUC := new(mockPurchaseUseCase)
+
+	userUC.On("Authorize", mock.Anything, 1, mock.Anything).Return(nil)
+	userUC.On("GetByUsername", mock.Anything, "bob").Return(&entity.User{ID: 2, Username: "bob"}, nil)
+	purchaseUC.On("Gift", mock.Anything, 1, 2, 1, "cup", "", "Спасибо!").Return(purchase.ErrOutOfStock)
+
+	client := newClient(t, &usecase.UseCases{User: userUC, Purchase: purchaseUC}, tm)
+
+	token, err := tm.NewToken(1)
+	require.NoError(t, err)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	_, err = client.Buy(ctx, &pb.BuyRequest{Item: "cup", ToUser: "bob", Message: "Спасибо!"})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	purchaseUC.AssertNotCalled(t, "Purchase", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
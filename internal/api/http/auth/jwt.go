@@ -39,6 +39,15 @@ func (m *JWTManager) NewToken(userID int) (string, error) {
 }
 
 func (m *JWTManager) Parse(accessToken string) (int, error) {
+	claims, err := m.ParseClaims(accessToken)
+	if err != nil {
+		return 0, err
+	}
+
+	return claims.UserID, nil
+}
+
+func (m *JWTManager) ParseClaims(accessToken string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(accessToken, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -48,13 +57,13 @@ func (m *JWTManager) Parse(accessToken string) (int, error) {
 	})
 
 	if err != nil {
-		return 0, fmt.Errorf("invalid token: %w", err)
+		return nil, fmt.Errorf("invalid token: %w", err)
 	}
 
 	claims, ok := token.Claims.(*Claims)
 	if !ok {
-		return 0, errors.New("invalid token claims")
+		return nil, errors.New("invalid token claims")
 	}
 
-	return claims.UserID, nil
+	return claims, nil
 }
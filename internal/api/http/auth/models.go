@@ -11,4 +11,6 @@ type Claims struct {
 type TokenManager interface {
 	NewToken(userID int) (string, error)
 	Parse(accessToken string) (int, error)
+	// ParseClaims нужен, когда кроме пользователя важно время выдачи токена
+	ParseClaims(accessToken string) (*Claims, error)
 }
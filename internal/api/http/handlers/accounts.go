@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+	accountRepo "merchshop/internal/repository/account"
+	"merchshop/internal/repository/user"
+	accountUseCase "merchshop/internal/usecase/account"
+
+	"github.com/gorilla/mux"
+)
+
+// SetAccountStatus godoc
+// @Summary Заморозить или разморозить аккаунт
+// @Description Замороженный аккаунт может входить, но не может переводить, получать и тратить монеты
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param input body models.AccountStatusRequest true "Новый статус"
+// @Success 200 {object} models.Account "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 409 {object} models.ErrorResponse "Аккаунт деактивирован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/status [put]
+func (h *Handler) SetAccountStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	var req models.AccountStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	u, err := h.accountUseCase.SetStatus(r.Context(), id, req.Status)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			writeError(w, http.StatusNotFound, "Не найдено")
+		case errors.Is(err, user.ErrAccountDeactivated):
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			writeError(w, http.StatusBadRequest, err.Error())
+		}
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapAccount(u))
+}
+
+// SetDepartment godoc
+// @Summary Указать отдел сотрудника
+// @Description Отдел используется в ограничениях на покупку товаров
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param input body models.DepartmentRequest true "Отдел"
+// @Success 200 {object} models.Account "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/department [put]
+func (h *Handler) SetDepartment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	var req models.DepartmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	u, err := h.accountUseCase.SetDepartment(r.Context(), id, req.Department)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapAccount(u))
+}
+
+// OffboardUser godoc
+// @Summary Деактивировать аккаунт уволенного сотрудника
+// @Description Отзывает все токены пользователя. С sweepBalance остаток переводится в пул, перевод виден в истории
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param input body models.OffboardRequest false "Параметры деактивации"
+// @Success 200 {object} models.Offboarding "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/offboard [post]
+func (h *Handler) OffboardUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	var req models.OffboardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	result, err := h.accountUseCase.Offboard(r.Context(), id, req.SweepBalance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.Offboarding{
+		UserID:        result.UserID,
+		Swept:         result.Swept,
+		TransactionID: result.TransactionID,
+		DeactivatedAt: result.DeactivatedAt,
+	})
+}
+
+// EraseUser godoc
+// @Summary Стереть персональные данные пользователя
+// @Description Аккаунт должен быть деактивирован. Имя заменяется на "deleted user #<id>", пароль, отдел,
+// @Description уведомления, вишлист и выгрузки удаляются. Переводы и покупки остаются в истории, балансы сходятся.
+// @Description В уведомлениях других пользователей о переводах и подарках и в полях fromUser, toUser, username
+// @Description доставок вебхуков старое имя заменяется новым.
+// @Description Комментарии к переводам и подаркам не меняются: это текст, который видит получатель.
+// @Description Стирание записывается в журнал с администратором и причиной
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param input body models.EraseRequest false "Причина стирания"
+// @Success 200 {object} models.Erasure "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 409 {object} models.ErrorResponse "Аккаунт не деактивирован или уже стерт"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/erase [post]
+func (h *Handler) EraseUser(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	var req models.EraseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	result, err := h.accountUseCase.Erase(r.Context(), adminID, id, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			writeError(w, http.StatusNotFound, "Не найдено")
+		case errors.Is(err, accountUseCase.ErrNotDeactivated), errors.Is(err, accountRepo.ErrAlreadyErased):
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			writeError(w, http.StatusBadRequest, err.Error())
+		}
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.Erasure{
+		UserID:   result.UserID,
+		Username: result.Username,
+		ErasedBy: result.ErasedBy,
+		Reason:   result.Reason,
+		ErasedAt: result.ErasedAt,
+	})
+}
+
+func mapAccount(u *entities.User) models.Account {
+	return models.Account{ID: u.ID, Username: u.Username, Role: u.Role, Department: u.Department, Status: u.Status}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+	"merchshop/internal/usecase/report"
+)
+
+// ListAuditLog godoc
+// @Summary Журнал аудита
+// @Description Записи журнала аудита новые первыми. За следующей страницей передается before из nextBefore
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param actor query int false "Автор действия"
+// @Param action query string false "Начало действия, например admin: или auth."
+// @Param targetType query string false "Тип объекта: user, merch, order и т.д."
+// @Param target query string false "Идентификатор объекта"
+// @Param user query int false "Пользователь, автор или объект действия"
+// @Param from query string false "Начало периода: YYYY-MM-DD или RFC 3339"
+// @Param to query string false "Конец периода, не включая: RFC 3339, или дата YYYY-MM-DD включительно"
+// @Param before query int false "Записи с id меньше before"
+// @Param limit query int false "Максимум записей (до 500)"
+// @Success 200 {object} models.AuditLog "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/audit [get]
+func (h *Handler) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	period, err := report.ParseFilter(query.Get("from"), query.Get("to"), "", "")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := entities.AuditFilter{
+		Action:     query.Get("action"),
+		TargetType: query.Get("targetType"),
+		TargetID:   query.Get("target"),
+		From:       period.From,
+		To:         period.To,
+	}
+
+	var before, limit int
+
+	for name, value := range map[string]*int{
+		"actor":  &filter.ActorID,
+		"user":   &filter.UserID,
+		"before": &before,
+		"limit":  &limit,
+	} {
+		if *value, err = queryInt(r, name); err != nil || *value < 0 {
+			writeError(w, http.StatusBadRequest, "invalid "+name+": "+strconv.Quote(query.Get(name)))
+			return
+		}
+	}
+
+	page, err := h.auditLogUseCase.List(r.Context(), filter, before, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := models.AuditLog{
+		Entries:    make([]models.AuditEntry, len(page.Entries)),
+		NextBefore: page.NextBefore,
+	}
+
+	for i, e := range page.Entries {
+		resp.Entries[i] = models.AuditEntry{
+			ID:         e.ID,
+			ActorID:    e.ActorID,
+			Action:     e.Action,
+			TargetType: e.TargetType,
+			TargetID:   e.TargetID,
+			RequestID:  e.RequestID,
+			IP:         e.IP,
+			Before:     e.Before,
+			After:      e.After,
+			CreatedAt:  e.CreatedAt,
+			PrevHash:   e.PrevHash,
+			Hash:       e.Hash,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -2,10 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"merchshop/internal/api/http/models"
-	"merchshop/internal/config"
+	userUseCase "merchshop/internal/usecase/user"
 )
 
 // Auth godoc
@@ -17,6 +18,7 @@ import (
 // @Success 200 {object} models.InfoResponse "Успешный ответ"
 // @Failure 400 {object} models.ErrorResponse "Неверный запрос"
 // @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Аккаунт деактивирован"
 // @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /auth [post]
 func (h *Handler) Auth(w http.ResponseWriter, r *http.Request) {
@@ -26,19 +28,19 @@ func (h *Handler) Auth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userUseCase.GetByUsername(r.Context(), req.Username)
+	user, err := h.userUseCase.Authenticate(r.Context(), req.Username, req.Password)
 	if err != nil {
-		hashedPassword, _ := config.HashPassword(req.Password)
+		if errors.Is(err, userUseCase.ErrInvalidCredentials) {
+			writeError(w, http.StatusUnauthorized, "Неавторизован")
+			return
+		}
 
-		user, err = h.userUseCase.Register(r.Context(), req.Username, hashedPassword)
-		if err != nil {
-			writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		if errors.Is(err, userUseCase.ErrAccountDeactivated) {
+			writeJSON(w, http.StatusForbidden, models.ErrorResponse{Errors: "Аккаунт деактивирован", Code: codeAccountDeactivated})
 			return
 		}
-	}
 
-	if !(config.ComparePasswords(user.Password, req.Password)) {
-		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
 		return
 	}
 
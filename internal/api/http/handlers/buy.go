@@ -1,23 +1,31 @@
 package handlers
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"net/http"
 
 	"merchshop/internal/api/http/middleware"
-	_ "merchshop/internal/api/http/models"
+	"merchshop/internal/api/http/models"
 
 	"github.com/gorilla/mux"
 )
 
 // Buy godoc
 // @Summary Купить предмет из магазина
+// @Description Товар с вариантами покупается по артикулу варианта, например hoody-pink-m.
+// @Description Цена считается с учетом действующей распродажи и промокода
 // @Tags default
 // @Security BearerAuth
 // @Produce json
-// @Param item path string true "Название предмета"
+// @Param item path string true "Название предмета или артикул варианта"
+// @Param promo query string false "Промокод"
 // @Success 200 {object} models.InfoResponse "Успешный ответ"
 // @Failure 400 {object} models.ErrorResponse "Неверный запрос"
 // @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Операция запрещена политикой переводов, ограничениями товара или аккаунт заморожен"
+// @Failure 409 {object} models.ErrorResponse "Товар закончился"
 // @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /buy/{item} [get]
 func (h *Handler) Buy(w http.ResponseWriter, r *http.Request) {
@@ -28,19 +36,126 @@ func (h *Handler) Buy(w http.ResponseWriter, r *http.Request) {
 	}
 
 	vars := mux.Vars(r)
-	merchName := vars["item"]
+	item := vars["item"]
 
-	merch, err := h.merchUseCase.GetByName(r.Context(), merchName)
+	err := h.purchaseUseCase.Purchase(r.Context(), userID, 1, item, r.URL.Query().Get("promo"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusBadRequest, "Неверный запрос")
+			return
+		}
+
+		writeOperationError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Успешно")
+}
+
+// Gift godoc
+// @Summary Купить предмет в подарок
+// @Description Монеты списываются с покупателя, предмет попадает в инвентарь получателя. Подарок
+// @Description с сообщением виден в истории обоих. Ограничения товара проверяются для получателя
+// @Tags default
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param item path string true "Название предмета или артикул варианта"
+// @Param input body models.GiftRequest true "Кому подарить и сообщение"
+// @Success 200 {string} string "Успешно"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Операция запрещена политикой переводов, ограничениями товара или аккаунт заморожен"
+// @Failure 409 {object} models.ErrorResponse "Товар закончился"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /buy/{item}/gift [post]
+func (h *Handler) Gift(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	var req models.GiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	recipient, err := h.userUseCase.GetByUsername(r.Context(), req.ToUser)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "Неверный запрос")
 		return
 	}
 
-	err = h.purchaseUseCase.Purchase(r.Context(), userID, 1, merch.Name)
+	err = h.purchaseUseCase.Gift(r.Context(), userID, recipient.ID, req.Quantity, mux.Vars(r)["item"], req.PromoCode, req.Message)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusBadRequest, "Неверный запрос")
+			return
+		}
+
+		writeOperationError(w, err)
 		return
 	}
 
 	writeJSON(w, http.StatusOK, "Успешно")
 }
+
+// Quote godoc
+// @Summary Цена покупки со скидками
+// @Description Считает цену с учетом действующей распродажи и промокода, ничего не списывая
+// @Tags default
+// @Security BearerAuth
+// @Produce json
+// @Param item path string true "Название предмета или артикул варианта"
+// @Param quantity query int false "Количество, по умолчанию 1"
+// @Param promo query string false "Промокод"
+// @Success 200 {object} models.Quote "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /merch/{item}/quote [get]
+func (h *Handler) Quote(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	quantity, err := queryInt(r, "quantity")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	item := mux.Vars(r)["item"]
+
+	q, err := h.purchaseUseCase.Quote(r.Context(), userID, quantity, item, r.URL.Query().Get("promo"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusBadRequest, "Неверный запрос")
+			return
+		}
+
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.Quote{
+		Item:      item,
+		Quantity:  quantity,
+		ListPrice: q.ListPrice,
+		Discount:  q.Discount,
+		Total:     q.Total,
+		PromoCode: q.PromoCode,
+	})
+}
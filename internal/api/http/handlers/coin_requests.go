@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateCoinRequest godoc
+// @Summary Запросить монеты у другого пользователя
+// @Description Запрос ждет ответа плательщика и истекает, если тот не ответил вовремя.
+// @Description Одному плательщику можно отправить не больше coinrequest.max_pending открытых запросов
+// @Tags default
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body models.CreateCoinRequest true "У кого и сколько запросить"
+// @Success 201 {object} models.CoinRequest "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /requests [post]
+func (h *Handler) CreateCoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	var req models.CreateCoinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	c, err := h.coinRequestUseCase.Create(r.Context(), userID, req.FromUser, req.Amount, req.Memo)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, mapCoinRequest(*c))
+}
+
+// ListCoinRequests godoc
+// @Summary Входящие и исходящие запросы монет
+// @Tags default
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.CoinRequest "Успешный ответ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /requests [get]
+func (h *Handler) ListCoinRequests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	requests, err := h.coinRequestUseCase.List(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapCoinRequests(requests))
+}
+
+// ApproveCoinRequest godoc
+// @Summary Одобрить запрос монет
+// @Description Монеты переводятся запросившему в той же транзакции
+// @Tags default
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID запроса"
+// @Success 200 {object} models.CoinRequest "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Операция запрещена политикой переводов или аккаунт заморожен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Router /requests/{id}/approve [post]
+func (h *Handler) ApproveCoinRequest(w http.ResponseWriter, r *http.Request) {
+	h.resolveCoinRequest(w, r, h.coinRequestUseCase.Approve)
+}
+
+// DeclineCoinRequest godoc
+// @Summary Отклонить запрос монет
+// @Tags default
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID запроса"
+// @Success 200 {object} models.CoinRequest "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Router /requests/{id}/decline [post]
+func (h *Handler) DeclineCoinRequest(w http.ResponseWriter, r *http.Request) {
+	h.resolveCoinRequest(w, r, h.coinRequestUseCase.Decline)
+}
+
+func (h *Handler) resolveCoinRequest(
+	w http.ResponseWriter,
+	r *http.Request,
+	resolve func(ctx context.Context, payerID, id int) (*entities.CoinRequest, error),
+) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	c, err := resolve(r.Context(), userID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeOperationError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapCoinRequest(*c))
+}
+
+func mapCoinRequest(c entities.CoinRequest) models.CoinRequest {
+	return models.CoinRequest{
+		ID:            c.ID,
+		FromUser:      c.RequesterName,
+		ToUser:        c.PayerName,
+		Amount:        c.Amount,
+		Memo:          c.Memo,
+		Status:        c.Status,
+		TransactionID: c.TransactionID,
+		ExpiresAt:     c.ExpiresAt,
+		CreatedAt:     c.CreatedAt,
+		ResolvedAt:    c.ResolvedAt,
+	}
+}
+
+func mapCoinRequests(requests []entities.CoinRequest) []models.CoinRequest {
+	result := make([]models.CoinRequest, len(requests))
+	for i, c := range requests {
+		result[i] = mapCoinRequest(c)
+	}
+
+	return result
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+)
+
+// SendEscrow godoc
+// @Summary Отправить монеты пользователю, который еще не входил в магазин
+// @Description Монеты списываются сразу и зачисляются при первом входе получателя, а по истечении срока возвращаются отправителю
+// @Tags default
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body models.SendCoinRequest true "Кому и сколько отправить"
+// @Success 201 {object} models.EscrowTransfer "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Операция запрещена политикой переводов или аккаунт заморожен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /escrow [post]
+func (h *Handler) SendEscrow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	var req models.SendCoinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	e, err := h.escrowUseCase.Send(r.Context(), userID, req.ToUser, req.Amount, req.Memo)
+	if err != nil {
+		writeOperationError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, mapEscrow(*e))
+}
+
+// ListEscrow godoc
+// @Summary Удерживаемые и завершенные переводы незарегистрированным пользователям
+// @Tags default
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.EscrowTransfer "Успешный ответ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /escrow [get]
+func (h *Handler) ListEscrow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	escrows, err := h.escrowUseCase.List(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	result := make([]models.EscrowTransfer, len(escrows))
+	for i, e := range escrows {
+		result[i] = mapEscrow(e)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func mapEscrow(e entities.Escrow) models.EscrowTransfer {
+	return models.EscrowTransfer{
+		ID:            e.ID,
+		ToUser:        e.Username,
+		Amount:        e.Amount,
+		Memo:          e.Memo,
+		Status:        e.Status,
+		TransactionID: e.TransactionID,
+		ExpiresAt:     e.ExpiresAt,
+		CreatedAt:     e.CreatedAt,
+		ResolvedAt:    e.ResolvedAt,
+	}
+}
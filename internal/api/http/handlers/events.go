@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+)
+
+const sseHeartbeatInterval = 15 * time.Second
+
+// Events godoc
+// @Summary Поток событий пользователя (Server-Sent Events)
+// @Description Отправляет balance при подключении и после каждой операции, а также coin.sent, coin.received и purchase.completed
+// @Tags default
+// @Security BearerAuth
+// @Produce text/event-stream
+// @Success 200 {object} models.BalanceEvent "Поток событий"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /events [get]
+func (h *Handler) Events(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+
+	// Поток живет дольше WriteTimeout сервера
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	events, unsubscribe := h.broker.Subscribe(userID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := h.writeBalanceEvent(r.Context(), w, userID); err != nil {
+		return
+	}
+
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if err := writeSSE(w, string(e.Type), e); err != nil {
+				return
+			}
+
+			if err := h.writeBalanceEvent(r.Context(), w, userID); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (h *Handler) writeBalanceEvent(ctx context.Context, w http.ResponseWriter, userID int) error {
+	user, err := h.userUseCase.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return writeSSE(w, "balance", models.BalanceEvent{Coins: user.Balance})
+}
+
+func writeSSE(w http.ResponseWriter, name string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload)
+
+	return err
+}
@@ -0,0 +1,45 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"merchshop/internal/api/http/handlers"
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/entity"
+	"merchshop/internal/event"
+	"merchshop/internal/usecase"
+)
+
+func TestEvents_SendsInitialBalance(t *testing.T) {
+	userUC := new(mockUserUseCase)
+	userUC.On("GetByID", mock.Anything, 1).Return(&entity.User{ID: 1, Balance: 700}, nil)
+
+	h := handlers.NewHandler(&usecase.UseCases{User: userUC, Broker: event.NewBroker(0)}, nil)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), middleware.UserIDKey, 1))
+	cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	h.Events(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "event: balance\ndata: {\"coins\":700}\n\n")
+}
+
+func TestEvents_Unauthorized(t *testing.T) {
+	h := handlers.NewHandler(&usecase.UseCases{Broker: event.NewBroker(0)}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	w := httptest.NewRecorder()
+
+	h.Events(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
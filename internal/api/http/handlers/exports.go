@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+	"merchshop/internal/usecase/dataexport"
+)
+
+// RequestDataExport godoc
+// @Summary Выгрузить мои данные
+// @Description Ставит в очередь сборку архива с профилем, переводами, покупками, уведомлениями и записями журнала аудита.
+// @Description Пока предыдущая выгрузка в очереди, возвращает ее. Готовность проверяется через GET /exports/{id}
+// @Tags exports
+// @Security BearerAuth
+// @Produce json
+// @Success 202 {object} models.DataExport "Выгрузка в очереди"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /exports [post]
+func (h *Handler) RequestDataExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	e, err := h.dataExportUseCase.Request(r.Context(), userID, userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, h.mapDataExport(e))
+}
+
+// AdminRequestDataExport godoc
+// @Summary Выгрузить данные пользователя
+// @Description Ставит в очередь выгрузку данных пользователя. Администратор может проверить готовность
+// @Description и скачать архив через GET /exports/{id}
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 202 {object} models.DataExport "Выгрузка в очереди"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/export [post]
+func (h *Handler) AdminRequestDataExport(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	e, err := h.dataExportUseCase.Request(r.Context(), id, adminID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "Не найдено")
+		return
+	}
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, h.mapDataExport(e))
+}
+
+// GetDataExport godoc
+// @Summary Статус выгрузки данных
+// @Description Доступна владельцу данных и тому, кто запросил выгрузку. Для готовой выгрузки выдает
+// @Description короткоживущую ссылку на архив
+// @Tags exports
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID выгрузки"
+// @Success 200 {object} models.DataExport "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /exports/{id} [get]
+func (h *Handler) GetDataExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	e, err := h.dataExportUseCase.Get(r.Context(), userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "Не найдено")
+		return
+	}
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.mapDataExport(e))
+}
+
+// DownloadDataExport godoc
+// @Summary Скачать архив выгрузки
+// @Description Ссылку выдает GET /exports/{id}, она подписана и не требует токена
+// @Tags exports
+// @Produce application/zip
+// @Param id path int true "ID выгрузки"
+// @Param expires query int true "Срок ссылки, unix time"
+// @Param signature query string true "Подпись ссылки"
+// @Success 200 {file} file "Архив"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 403 {object} models.ErrorResponse "Неверная подпись"
+// @Failure 410 {object} models.ErrorResponse "Ссылка или архив истекли"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /exports/{id}/download [get]
+func (h *Handler) DownloadDataExport(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	archive, err := h.dataExportUseCase.Download(r.Context(), id, expires, r.URL.Query().Get("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, dataexport.ErrInvalidLink):
+			writeError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, dataexport.ErrLinkExpired):
+			writeError(w, http.StatusGone, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("data-export-%d.zip", id)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
+
+func (h *Handler) mapDataExport(e *entities.DataExport) models.DataExport {
+	resp := models.DataExport{
+		ID:        e.ID,
+		UserID:    e.UserID,
+		Status:    e.Status,
+		Size:      e.Size,
+		Error:     e.Error,
+		CreatedAt: e.CreatedAt,
+		ReadyAt:   e.ReadyAt,
+		ExpiresAt: e.ExpiresAt,
+	}
+
+	if e.Status == entities.ExportReady {
+		link, expiresAt := h.dataExportUseCase.Link(e)
+		resp.DownloadURL = link
+		resp.DownloadExpiresAt = &expiresAt
+	}
+
+	return resp
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+
+	"github.com/gorilla/mux"
+)
+
+// ListFraudCases godoc
+// @Summary Подозрительные паттерны переводов
+// @Description Кольца A→B→C→A, всплески переводов одного отправителя и воронки от многих отправителей к одному получателю
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "Статус случая (open, dismissed, frozen)"
+// @Param limit query int false "Максимум записей (до 200)"
+// @Success 200 {array} models.FraudCase "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/fraud/cases [get]
+func (h *Handler) ListFraudCases(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cases, err := h.fraudUseCase.List(r.Context(), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp := make([]models.FraudCase, len(cases))
+	for i, c := range cases {
+		resp[i] = mapFraudCase(c)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// DismissFraudCase godoc
+// @Summary Отклонить подозрение
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID случая"
+// @Success 200 {object} models.FraudCase "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/fraud/cases/{id}/dismiss [post]
+func (h *Handler) DismissFraudCase(w http.ResponseWriter, r *http.Request) {
+	h.resolveFraudCase(w, r, h.fraudUseCase.Dismiss)
+}
+
+// FreezeFraudCase godoc
+// @Summary Заморозить аккаунты участников
+// @Description Замороженные аккаунты не могут переводить, получать и тратить монеты
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID случая"
+// @Param input body models.FreezeRequest false "Кого заморозить, по умолчанию всех участников"
+// @Success 200 {object} models.FraudCase "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/fraud/cases/{id}/freeze [post]
+func (h *Handler) FreezeFraudCase(w http.ResponseWriter, r *http.Request) {
+	var req models.FreezeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	h.resolveFraudCase(w, r, func(ctx context.Context, adminID, id int) (*entities.FraudCase, error) {
+		return h.fraudUseCase.Freeze(ctx, adminID, id, req.UserIDs)
+	})
+}
+
+func (h *Handler) resolveFraudCase(
+	w http.ResponseWriter,
+	r *http.Request,
+	resolve func(ctx context.Context, adminID, id int) (*entities.FraudCase, error),
+) {
+	adminID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	c, err := resolve(r.Context(), adminID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapFraudCase(*c))
+}
+
+func mapFraudCase(c entities.FraudCase) models.FraudCase {
+	return models.FraudCase{
+		ID:                c.ID,
+		Kind:              c.Kind,
+		UserIDs:           c.UserIDs,
+		Usernames:         c.Usernames,
+		LastTransactionID: c.LastTransactionID,
+		Transfers:         c.Transfers,
+		Amount:            c.Amount,
+		Status:            c.Status,
+		CreatedAt:         c.CreatedAt,
+		ResolvedAt:        c.ResolvedAt,
+	}
+}
@@ -2,32 +2,81 @@ package handlers
 
 import (
 	"merchshop/internal/api/http/auth"
+	"merchshop/internal/event"
+	"merchshop/internal/usecase"
+	"merchshop/internal/usecase/account"
+	"merchshop/internal/usecase/auditlog"
+	"merchshop/internal/usecase/coinrequest"
+	"merchshop/internal/usecase/dataexport"
+	"merchshop/internal/usecase/escrow"
+	"merchshop/internal/usecase/fraud"
+	"merchshop/internal/usecase/leaderboard"
 	"merchshop/internal/usecase/merch"
+	"merchshop/internal/usecase/notification"
+	"merchshop/internal/usecase/order"
+	"merchshop/internal/usecase/policy"
+	"merchshop/internal/usecase/promo"
 	"merchshop/internal/usecase/purchase"
+	"merchshop/internal/usecase/report"
+	"merchshop/internal/usecase/rule"
+	"merchshop/internal/usecase/schedule"
+	"merchshop/internal/usecase/stats"
 	"merchshop/internal/usecase/transaction"
 	"merchshop/internal/usecase/user"
+	"merchshop/internal/usecase/webhook"
+	"merchshop/internal/usecase/wishlist"
 )
 
 type Handler struct {
-	userUseCase        user.UseCase
-	transactionUseCase transaction.UseCase
-	purchaseUseCase    purchase.UseCase
-	merchUseCase       merch.UseCase
-	tokenManager       auth.TokenManager
+	userUseCase         user.UseCase
+	transactionUseCase  transaction.UseCase
+	purchaseUseCase     purchase.UseCase
+	merchUseCase        merch.UseCase
+	webhookUseCase      webhook.UseCase
+	scheduleUseCase     schedule.UseCase
+	coinRequestUseCase  coinrequest.UseCase
+	escrowUseCase       escrow.UseCase
+	policyUseCase       policy.UseCase
+	fraudUseCase        fraud.UseCase
+	accountUseCase      account.UseCase
+	orderUseCase        order.UseCase
+	promoUseCase        promo.UseCase
+	ruleUseCase         rule.UseCase
+	notificationUseCase notification.UseCase
+	wishlistUseCase     wishlist.UseCase
+	leaderboardUseCase  leaderboard.UseCase
+	statsUseCase        stats.UseCase
+	reportUseCase       report.UseCase
+	dataExportUseCase   dataexport.UseCase
+	auditLogUseCase     auditlog.UseCase
+	broker              *event.Broker
+	tokenManager        auth.TokenManager
 }
 
-func NewHandler(
-	userUseCase user.UseCase,
-	transactionUseCase transaction.UseCase,
-	purchaseUseCase purchase.UseCase,
-	merchUseCase merch.UseCase,
-	tm auth.TokenManager,
-) *Handler {
+func NewHandler(useCases *usecase.UseCases, tm auth.TokenManager) *Handler {
 	return &Handler{
-		userUseCase:        userUseCase,
-		transactionUseCase: transactionUseCase,
-		purchaseUseCase:    purchaseUseCase,
-		merchUseCase:       merchUseCase,
-		tokenManager:       tm,
+		userUseCase:         useCases.User,
+		transactionUseCase:  useCases.Transaction,
+		purchaseUseCase:     useCases.Purchase,
+		merchUseCase:        useCases.Merch,
+		webhookUseCase:      useCases.Webhook,
+		scheduleUseCase:     useCases.Schedule,
+		coinRequestUseCase:  useCases.CoinRequest,
+		escrowUseCase:       useCases.Escrow,
+		policyUseCase:       useCases.Policy,
+		fraudUseCase:        useCases.Fraud,
+		accountUseCase:      useCases.Account,
+		orderUseCase:        useCases.Order,
+		promoUseCase:        useCases.Promo,
+		ruleUseCase:         useCases.Rule,
+		notificationUseCase: useCases.Notification,
+		wishlistUseCase:     useCases.Wishlist,
+		leaderboardUseCase:  useCases.Leaderboard,
+		statsUseCase:        useCases.Stats,
+		reportUseCase:       useCases.Report,
+		dataExportUseCase:   useCases.DataExport,
+		auditLogUseCase:     useCases.AuditLog,
+		broker:              useCases.Broker,
+		tokenManager:        tm,
 	}
 }
@@ -36,6 +36,12 @@ func (h *Handler) Info(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sentGifts, err := h.purchaseUseCase.GetSentGifts(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
 	sentTx, err := h.transactionUseCase.GetSentTransactions(r.Context(), userID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
@@ -48,12 +54,23 @@ func (h *Handler) Info(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	requests, err := h.coinRequestUseCase.List(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
 	resp := models.InfoResponse{
 		Coins:     user.Balance,
 		Inventory: mapInventory(purchases),
 		CoinHistory: models.CoinHistoryInfo{
 			Sent:     mapTransactions(sentTx, false),
 			Received: mapTransactions(receivedTx, true),
+			Requests: mapCoinRequests(requests),
+		},
+		Gifts: models.GiftHistory{
+			Sent:     mapGifts(sentGifts, false),
+			Received: mapGifts(purchases, true),
 		},
 	}
 
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -14,6 +15,9 @@ import (
 	"merchshop/internal/api/http/middleware"
 	"merchshop/internal/api/http/models"
 	"merchshop/internal/entity"
+	"merchshop/internal/pricing"
+	"merchshop/internal/usecase"
+	"merchshop/internal/usecase/transaction"
 )
 
 type mockUserUseCase struct{ mock.Mock }
@@ -45,6 +49,15 @@ func (m *mockUserUseCase) GetByUsername(ctx context.Context, username string) (*
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
+func (m *mockUserUseCase) Authenticate(ctx context.Context, username string, password string) (*entity.User, error) {
+	args := m.Called(ctx, username, password)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *mockUserUseCase) Authorize(ctx context.Context, userID int, issuedAt time.Time) error {
+	return m.Called(ctx, userID, issuedAt).Error(0)
+}
+
 func (m *mockUserUseCase) Register(ctx context.Context, username string, password string) (*entity.User, error) {
 	args := m.Called(ctx, username)
 	return args.Get(0).(*entity.User), args.Error(1)
@@ -55,30 +68,104 @@ func (m *mockTransactionUseCase) GetUserTransactions(ctx context.Context, userID
 	return args.Get(0).([]entity.Transaction), args.Error(1)
 }
 
-func (m *mockTransactionUseCase) Transfer(ctx context.Context, senderID, receiverID int, amount int) error {
-	args := m.Called(ctx, senderID, receiverID, amount)
+func (m *mockTransactionUseCase) Transfer(ctx context.Context, senderID, receiverID int, amount int, memo string) error {
+	args := m.Called(ctx, senderID, receiverID, amount, memo)
+	return args.Error(0)
+}
+
+func (m *mockTransactionUseCase) React(ctx context.Context, userID, transactionID int, reaction string) error {
+	args := m.Called(ctx, userID, transactionID, reaction)
 	return args.Error(0)
 }
 
-func (m *mockPurchaseUseCase) Purchase(ctx context.Context, userID, quantity int, merchName string) error {
-	args := m.Called(ctx, userID, quantity, merchName)
+func (m *mockTransactionUseCase) TransferBatch(ctx context.Context, senderID int, recipients []transaction.Recipient) (int, error) {
+	args := m.Called(ctx, senderID, recipients)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockPurchaseUseCase) Purchase(ctx context.Context, userID, quantity int, merchName, promoCode string) error {
+	args := m.Called(ctx, userID, quantity, merchName, promoCode)
+	return args.Error(0)
+}
+
+func (m *mockPurchaseUseCase) Gift(ctx context.Context, buyerID, recipientID, quantity int, item, promoCode, message string) error {
+	args := m.Called(ctx, buyerID, recipientID, quantity, item, promoCode, message)
 	return args.Error(0)
 }
 
+func (m *mockPurchaseUseCase) GetSentGifts(ctx context.Context, userID int) ([]entity.Purchase, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.Purchase), args.Error(1)
+}
+
+func (m *mockPurchaseUseCase) Quote(ctx context.Context, userID, quantity int, merchName, promoCode string) (*pricing.Quote, error) {
+	args := m.Called(ctx, userID, quantity, merchName, promoCode)
+	return args.Get(0).(*pricing.Quote), args.Error(1)
+}
+
+type mockCoinRequestUseCase struct {
+	mock.Mock
+}
+
+func (m *mockCoinRequestUseCase) Create(ctx context.Context, requesterID int, payer string, amount int, memo string) (*entity.CoinRequest, error) {
+	args := m.Called(ctx, requesterID, payer, amount, memo)
+	return args.Get(0).(*entity.CoinRequest), args.Error(1)
+}
+
+func (m *mockCoinRequestUseCase) List(ctx context.Context, userID int) ([]entity.CoinRequest, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.CoinRequest), args.Error(1)
+}
+
+func (m *mockCoinRequestUseCase) Approve(ctx context.Context, payerID, id int) (*entity.CoinRequest, error) {
+	args := m.Called(ctx, payerID, id)
+	return args.Get(0).(*entity.CoinRequest), args.Error(1)
+}
+
+func (m *mockCoinRequestUseCase) Decline(ctx context.Context, payerID, id int) (*entity.CoinRequest, error) {
+	args := m.Called(ctx, payerID, id)
+	return args.Get(0).(*entity.CoinRequest), args.Error(1)
+}
+
+func (m *mockCoinRequestUseCase) ExpirePending(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
 func TestInfo_Success(t *testing.T) {
 	userUC := new(mockUserUseCase)
 	purchaseUC := new(mockPurchaseUseCase)
 	txUC := new(mockTransactionUseCase)
+	requestUC := new(mockCoinRequestUseCase)
 
 	userID := 1
 	ctx := context.WithValue(context.Background(), middleware.UserIDKey, userID)
 
 	userUC.On("GetByID", mock.Anything, userID).Return(&entity.User{ID: userID, Username: "test", Balance: 100}, nil)
-	purchaseUC.On("GetUserPurchases", mock.Anything, userID).Return([]entity.Purchase{}, nil)
+	purchaseUC.On("GetUserPurchases", mock.Anything, userID).Return([]entity.Purchase{
+		{ID: 4, MerchName: "hoody", SKU: "hoody-pink-m", Attributes: map[string]string{"color": "pink"}, Quantity: 1,
+			Status: entity.OrderReady, PickupLocation: "reception"},
+		{ID: 3, MerchName: "cup", Quantity: 2, Status: entity.OrderDelivered, BuyerID: 2, BuyerName: "bob",
+			GiftMessage: "Спасибо!"},
+		{ID: 2, MerchName: "cup", Quantity: 5, Status: entity.OrderCancelled},
+		{ID: 1, MerchName: "hoody", SKU: "hoody-grey-l", Quantity: 1, Status: entity.OrderDelivered},
+		{MerchName: "hoody", SKU: "hoody-pink-m", Quantity: 1, Status: entity.OrderDelivered},
+	}, nil)
+	purchaseUC.On("GetSentGifts", mock.Anything, userID).Return([]entity.Purchase{
+		{ID: 5, UserID: 3, Username: "carol", BuyerID: userID, MerchName: "pen", Quantity: 1, Status: entity.OrderPlaced},
+	}, nil)
 	txUC.On("GetSentTransactions", mock.Anything, userID).Return([]entity.Transaction{}, nil)
 	txUC.On("GetReceivedTransactions", mock.Anything, userID).Return([]entity.Transaction{}, nil)
+	requestUC.On("List", mock.Anything, userID).Return([]entity.CoinRequest{
+		{ID: 3, RequesterName: "test", PayerName: "bob", Amount: 15, Status: entity.RequestDeclined},
+	}, nil)
 
-	h := handlers.NewHandler(userUC, txUC, purchaseUC, nil, nil)
+	h := handlers.NewHandler(&usecase.UseCases{
+		User:        userUC,
+		Transaction: txUC,
+		Purchase:    purchaseUC,
+		CoinRequest: requestUC,
+	}, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/info", nil).WithContext(ctx)
 	w := httptest.NewRecorder()
@@ -91,10 +178,25 @@ func TestInfo_Success(t *testing.T) {
 	err := json.NewDecoder(w.Body).Decode(&resp)
 	assert.NoError(t, err)
 	assert.Equal(t, 100, resp.Coins)
+	assert.Equal(t, []models.InventoryItem{
+		{Type: "hoody", Quantity: 3, Variants: []models.InventoryVariant{
+			{SKU: "hoody-pink-m", Attributes: map[string]string{"color": "pink"}, Quantity: 2},
+			{SKU: "hoody-grey-l", Quantity: 1},
+		}, Orders: []models.InventoryOrder{
+			{ID: 4, SKU: "hoody-pink-m", Quantity: 1, Status: entity.OrderReady, PickupLocation: "reception"},
+		}},
+		{Type: "cup", Quantity: 2},
+	}, resp.Inventory)
+	assert.Equal(t, models.GiftHistory{
+		Received: []models.Gift{{OrderID: 3, FromUser: "bob", Item: "cup", Quantity: 2, Message: "Спасибо!", Status: entity.OrderDelivered}},
+		Sent:     []models.Gift{{OrderID: 5, ToUser: "carol", Item: "pen", Quantity: 1, Status: entity.OrderPlaced}},
+	}, resp.Gifts)
+	assert.Len(t, resp.CoinHistory.Requests, 1)
+	assert.Equal(t, entity.RequestDeclined, resp.CoinHistory.Requests[0].Status)
 }
 
 func TestInfo_Unauthorized(t *testing.T) {
-	h := handlers.NewHandler(nil, nil, nil, nil, nil)
+	h := handlers.NewHandler(&usecase.UseCases{}, nil)
 	req := httptest.NewRequest(http.MethodGet, "/info", nil)
 	w := httptest.NewRecorder()
 
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+	"merchshop/internal/usecase/leaderboard"
+)
+
+// GetLeaderboard godoc
+// @Summary Рейтинг
+// @Description Топ отправителей (senders), получателей (receivers) или покупателей (spenders) монет.
+// @Description Рейтинги пересчитываются периодически, refreshedAt показывает время пересчета
+// @Tags leaderboard
+// @Security BearerAuth
+// @Produce json
+// @Param board path string true "Рейтинг: senders, receivers или spenders"
+// @Param period query string false "Период: week (по умолчанию), month или all"
+// @Param limit query int false "Сколько мест вернуть (до 100, по умолчанию 10)"
+// @Success 200 {object} models.Leaderboard "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /leaderboard/{board} [get]
+func (h *Handler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	board, err := h.leaderboardUseCase.Top(r.Context(), mux.Vars(r)["board"], r.URL.Query().Get("period"), limit)
+	if errors.Is(err, leaderboard.ErrUnknownBoard) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := models.Leaderboard{
+		Board:   board.Board,
+		Period:  board.Period,
+		Entries: make([]models.LeaderboardEntry, len(board.Entries)),
+	}
+
+	if !board.RefreshedAt.IsZero() {
+		resp.RefreshedAt = &board.RefreshedAt
+	}
+
+	for i, e := range board.Entries {
+		resp.Entries[i] = models.LeaderboardEntry{Rank: e.Rank, User: e.Username, Total: e.Total}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetLeaderboardOptOut godoc
+// @Summary Участие в рейтингах
+// @Description Скрыт ли пользователь из публичных рейтингов
+// @Tags leaderboard
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.LeaderboardOptOut "Успешный ответ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /leaderboard/opt-out [get]
+func (h *Handler) GetLeaderboardOptOut(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	optOut, err := h.leaderboardUseCase.OptedOut(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.LeaderboardOptOut{OptOut: optOut})
+}
+
+// SetLeaderboardOptOut godoc
+// @Summary Скрыть себя из рейтингов
+// @Description optOut=true скрывает пользователя из публичных рейтингов сразу, false возвращает обратно
+// @Tags leaderboard
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body models.LeaderboardOptOut true "Участие в рейтингах"
+// @Success 200 {object} models.LeaderboardOptOut "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /leaderboard/opt-out [put]
+func (h *Handler) SetLeaderboardOptOut(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	var req models.LeaderboardOptOut
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	if err := h.leaderboardUseCase.SetOptOut(r.Context(), userID, req.OptOut); err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, req)
+}
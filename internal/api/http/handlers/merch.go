@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+
+	"github.com/gorilla/mux"
+)
+
+// ListMerch godoc
+// @Summary Каталог товаров с остатками
+// @Tags default
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.MerchItem "Успешный ответ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /merch [get]
+func (h *Handler) ListMerch(w http.ResponseWriter, r *http.Request) {
+	items, err := h.merchUseCase.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := make([]models.MerchItem, len(items))
+	for i, item := range items {
+		resp[i] = models.MerchItem{Name: item.Name, Price: item.Price, Stock: item.Stock}
+
+		for _, v := range item.Variants {
+			resp[i].Variants = append(resp[i].Variants, models.MerchVariant{
+				SKU:        v.SKU,
+				Attributes: v.Attributes,
+				Price:      v.Price,
+				Stock:      v.Stock,
+				Default:    v.Default,
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RestockMerch godoc
+// @Summary Пополнить склад
+// @Description Добавляет quantity к остатку товара без вариантов, варианты пополняются отдельно. lowStockThreshold задает, при каком остатке отправлять предупреждение
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param item path string true "Название предмета"
+// @Param input body models.RestockRequest true "Пополнение"
+// @Success 200 {object} models.MerchStock "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/merch/{item}/restock [post]
+func (h *Handler) RestockMerch(w http.ResponseWriter, r *http.Request) {
+	var req models.RestockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	item, err := h.merchUseCase.Restock(r.Context(), mux.Vars(r)["item"], req.Quantity, req.LowStockThreshold)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.MerchStock{
+		Name:              item.Name,
+		Price:             item.Price,
+		Stock:             item.Stock,
+		LowStockThreshold: item.LowStockThreshold,
+	})
+}
+
+// SaveVariant godoc
+// @Summary Создать или изменить вариант товара
+// @Description Для существующего варианта меняются атрибуты, цена и порог, остаток меняется через пополнение.
+// @Description Первый вариант товара и вариант с default продаются при покупке по названию товара
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param item path string true "Название предмета"
+// @Param sku path string true "Артикул варианта"
+// @Param input body models.VariantRequest true "Вариант"
+// @Success 200 {object} models.VariantStock "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/merch/{item}/variants/{sku} [put]
+func (h *Handler) SaveVariant(w http.ResponseWriter, r *http.Request) {
+	var req models.VariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	v := entities.Variant{
+		SKU:               mux.Vars(r)["sku"],
+		MerchName:         mux.Vars(r)["item"],
+		Attributes:        req.Attributes,
+		PriceOverride:     req.Price,
+		Stock:             req.Stock,
+		LowStockThreshold: defaultLowStockThreshold,
+		Default:           req.Default,
+	}
+
+	if req.LowStockThreshold != nil {
+		v.LowStockThreshold = *req.LowStockThreshold
+	}
+
+	saved, err := h.merchUseCase.SaveVariant(r.Context(), v)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapVariantStock(*saved))
+}
+
+// RestockVariant godoc
+// @Summary Пополнить склад варианта товара
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param item path string true "Название предмета"
+// @Param sku path string true "Артикул варианта"
+// @Param input body models.RestockRequest true "Пополнение"
+// @Success 200 {object} models.VariantStock "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/merch/{item}/variants/{sku}/restock [post]
+func (h *Handler) RestockVariant(w http.ResponseWriter, r *http.Request) {
+	var req models.RestockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	v, err := h.merchUseCase.RestockVariant(r.Context(), vars["item"], vars["sku"], req.Quantity, req.LowStockThreshold)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapVariantStock(*v))
+}
+
+// defaultLowStockThreshold порог нового варианта, если он не указан. Совпадает с умолчанием в схеме
+const defaultLowStockThreshold = 5
+
+func writeVariantError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "Не найдено")
+		return
+	}
+
+	writeError(w, http.StatusBadRequest, err.Error())
+}
+
+func mapVariantStock(v entities.Variant) models.VariantStock {
+	return models.VariantStock{
+		SKU:               v.SKU,
+		Item:              v.MerchName,
+		Attributes:        v.Attributes,
+		Price:             v.Price,
+		Stock:             v.Stock,
+		LowStockThreshold: v.LowStockThreshold,
+		Default:           v.Default,
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+)
+
+// ListNotifications godoc
+// @Summary Уведомления
+// @Description Уведомления новые первыми. За следующей страницей передается before из nextBefore
+// @Tags notifications
+// @Security BearerAuth
+// @Produce json
+// @Param unread query bool false "Только непрочитанные"
+// @Param before query int false "Уведомления с id меньше before"
+// @Param limit query int false "Максимум записей (до 200)"
+// @Success 200 {object} models.NotificationList "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /notifications [get]
+func (h *Handler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	unread := false
+	if raw := r.URL.Query().Get("unread"); raw != "" {
+		var err error
+		if unread, err = strconv.ParseBool(raw); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid unread: "+strconv.Quote(raw))
+			return
+		}
+	}
+
+	before, err := queryInt(r, "before")
+	if err != nil || before < 0 {
+		writeError(w, http.StatusBadRequest, "invalid before: "+strconv.Quote(r.URL.Query().Get("before")))
+		return
+	}
+
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.notificationUseCase.List(r.Context(), userID, unread, before, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := models.NotificationList{
+		Notifications: make([]models.Notification, len(page.Notifications)),
+		Unread:        page.Unread,
+		NextBefore:    page.NextBefore,
+	}
+
+	for i, n := range page.Notifications {
+		resp.Notifications[i] = models.Notification{
+			ID:        n.ID,
+			Kind:      n.Kind,
+			Message:   n.Message,
+			Data:      n.Data,
+			ReadAt:    n.ReadAt,
+			CreatedAt: n.CreatedAt,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// MarkNotificationsRead godoc
+// @Summary Отметить уведомления прочитанными
+// @Description Пустой список ids отмечает все непрочитанные
+// @Tags notifications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body models.MarkReadRequest true "Уведомления"
+// @Success 200 {object} models.MarkReadResponse "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /notifications/read [post]
+func (h *Handler) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	var req models.MarkReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	marked, err := h.notificationUseCase.MarkRead(r.Context(), userID, req.IDs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.MarkReadResponse{Marked: marked})
+}
+
+// GetNotificationPreferences godoc
+// @Summary Настройки уведомлений
+// @Description Все типы уведомлений и включены ли они. По умолчанию включены все
+// @Tags notifications
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.NotificationPreference "Успешный ответ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /notifications/preferences [get]
+func (h *Handler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	prefs, err := h.notificationUseCase.Preferences(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapNotificationPreferences(prefs))
+}
+
+// SetNotificationPreferences godoc
+// @Summary Изменить настройки уведомлений
+// @Description Меняет только переданные типы, остальные остаются как были
+// @Tags notifications
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body []models.NotificationPreference true "Типы уведомлений"
+// @Success 200 {array} models.NotificationPreference "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /notifications/preferences [put]
+func (h *Handler) SetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	var req []models.NotificationPreference
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	prefs := make([]entities.NotificationPreference, len(req))
+	for i, p := range req {
+		prefs[i] = entities.NotificationPreference{Kind: p.Kind, Enabled: p.Enabled}
+	}
+
+	saved, err := h.notificationUseCase.SetPreferences(r.Context(), userID, prefs)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapNotificationPreferences(saved))
+}
+
+func mapNotificationPreferences(prefs []entities.NotificationPreference) []models.NotificationPreference {
+	result := make([]models.NotificationPreference, len(prefs))
+	for i, p := range prefs {
+		result[i] = models.NotificationPreference{Kind: p.Kind, Enabled: p.Enabled}
+	}
+
+	return result
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+
+	"github.com/gorilla/mux"
+)
+
+// CancelOrder godoc
+// @Summary Отменить свой заказ
+// @Description Заказ можно отменить, пока он не выдан. Монеты возвращаются на баланс, товар на склад.
+// @Description Подарок отменяет только оплативший его пользователь
+// @Tags orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 200 {object} models.Order "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /orders/{id}/cancel [post]
+func (h *Handler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	h.updateOrder(w, r, func(ctx context.Context, id int) (*entities.Purchase, error) {
+		return h.orderUseCase.Cancel(ctx, userID, id)
+	})
+}
+
+// ListOrders godoc
+// @Summary Заказы на выдачу
+// @Description Заказы идут в порядке оформления, старые первыми
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "Статус заказа (placed, ready_for_pickup, delivered, cancelled)"
+// @Param limit query int false "Максимум записей (до 200)"
+// @Success 200 {array} models.Order "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/orders [get]
+func (h *Handler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	orders, err := h.orderUseCase.List(r.Context(), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp := make([]models.Order, len(orders))
+	for i, o := range orders {
+		resp[i] = mapOrder(o)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// MarkOrderReady godoc
+// @Summary Заказ готов к выдаче
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Param input body models.ReadyRequest true "Место выдачи"
+// @Success 200 {object} models.Order "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/orders/{id}/ready [post]
+func (h *Handler) MarkOrderReady(w http.ResponseWriter, r *http.Request) {
+	var req models.ReadyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	h.updateOrder(w, r, func(ctx context.Context, id int) (*entities.Purchase, error) {
+		return h.orderUseCase.MarkReady(ctx, id, req.PickupLocation)
+	})
+}
+
+// MarkOrderDelivered godoc
+// @Summary Заказ выдан покупателю
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 200 {object} models.Order "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/orders/{id}/deliver [post]
+func (h *Handler) MarkOrderDelivered(w http.ResponseWriter, r *http.Request) {
+	h.updateOrder(w, r, h.orderUseCase.MarkDelivered)
+}
+
+// AdminCancelOrder godoc
+// @Summary Отменить заказ
+// @Description Заказ можно отменить, пока он не выдан. Монеты возвращаются покупателю, товар на склад
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 200 {object} models.Order "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/orders/{id}/cancel [post]
+func (h *Handler) AdminCancelOrder(w http.ResponseWriter, r *http.Request) {
+	h.updateOrder(w, r, h.orderUseCase.CancelOrder)
+}
+
+func (h *Handler) updateOrder(
+	w http.ResponseWriter,
+	r *http.Request,
+	update func(ctx context.Context, id int) (*entities.Purchase, error),
+) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	o, err := update(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapOrder(*o))
+}
+
+func mapOrder(o entities.Purchase) models.Order {
+	return models.Order{
+		ID:             o.ID,
+		UserID:         o.UserID,
+		Username:       o.Username,
+		Item:           o.MerchName,
+		SKU:            o.SKU,
+		Attributes:     o.Attributes,
+		Quantity:       o.Quantity,
+		ListPrice:      o.ListPrice,
+		Discount:       o.Discount,
+		PromoCode:      o.PromoCode,
+		TotalPrice:     o.TotalPrice,
+		Status:         o.Status,
+		PickupLocation: o.PickupLocation,
+		CreatedAt:      o.CreatedAt,
+		ReadyAt:        o.ReadyAt,
+		DeliveredAt:    o.DeliveredAt,
+		CancelledAt:    o.CancelledAt,
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+
+	"github.com/gorilla/mux"
+)
+
+// ListPolicies godoc
+// @Summary Политики переводов по ролям
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.TransferPolicy "Успешный ответ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/policies [get]
+func (h *Handler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.policyUseCase.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := make([]models.TransferPolicy, len(policies))
+	for i, p := range policies {
+		resp[i] = mapPolicy(p)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SetPolicy godoc
+// @Summary Задать политику переводов для роли
+// @Description Ограничения применяются к переводам и покупкам пользователей роли. Нулевое значение снимает ограничение
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param role path string true "Роль (employee, admin)"
+// @Param input body models.TransferPolicyRequest true "Ограничения"
+// @Success 200 {object} models.TransferPolicy "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/policies/{role} [put]
+func (h *Handler) SetPolicy(w http.ResponseWriter, r *http.Request) {
+	var req models.TransferPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	p, err := h.policyUseCase.Set(r.Context(), entities.TransferPolicy{
+		Role:           mux.Vars(r)["role"],
+		DailyLimit:     req.DailyLimit,
+		WeeklyLimit:    req.WeeklyLimit,
+		MaxTransfer:    req.MaxTransfer,
+		MinAccountAge:  time.Duration(req.MinAccountAgeSeconds) * time.Second,
+		VelocityCount:  req.VelocityCount,
+		VelocityWindow: time.Duration(req.VelocityWindowSeconds) * time.Second,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapPolicy(*p))
+}
+
+// DeletePolicy godoc
+// @Summary Снять ограничения переводов с роли
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param role path string true "Роль"
+// @Success 200 {string} string "Успешно"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/policies/{role} [delete]
+func (h *Handler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	if err := h.policyUseCase.Delete(r.Context(), mux.Vars(r)["role"]); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Успешно")
+}
+
+func mapPolicy(p entities.TransferPolicy) models.TransferPolicy {
+	return models.TransferPolicy{
+		Role:                  p.Role,
+		DailyLimit:            p.DailyLimit,
+		WeeklyLimit:           p.WeeklyLimit,
+		MaxTransfer:           p.MaxTransfer,
+		MinAccountAgeSeconds:  int64(p.MinAccountAge / time.Second),
+		VelocityCount:         p.VelocityCount,
+		VelocityWindowSeconds: int64(p.VelocityWindow / time.Second),
+		UpdatedAt:             p.UpdatedAt,
+	}
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+
+	"github.com/gorilla/mux"
+)
+
+// ListPromos godoc
+// @Summary Промокоды
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.PromoCode "Успешный ответ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/promos [get]
+func (h *Handler) ListPromos(w http.ResponseWriter, r *http.Request) {
+	promos, err := h.promoUseCase.ListPromos(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := make([]models.PromoCode, len(promos))
+	for i, p := range promos {
+		resp[i] = mapPromo(p)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SavePromo godoc
+// @Summary Создать или изменить промокод
+// @Description Промокод не зависит от регистра. Скидка в процентах или фиксированная сумма с заказа,
+// @Description применяется после распродажи. Отключенный промокод (active=false) перестает приниматься
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param code path string true "Промокод"
+// @Param input body models.PromoCodeRequest true "Условия промокода"
+// @Success 200 {object} models.PromoCode "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/promos/{code} [put]
+func (h *Handler) SavePromo(w http.ResponseWriter, r *http.Request) {
+	var req models.PromoCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	p := entities.PromoCode{
+		Code:         mux.Vars(r)["code"],
+		Kind:         req.Kind,
+		Value:        req.Value,
+		MaxUses:      req.MaxUses,
+		PerUserLimit: req.PerUserLimit,
+		Items:        req.Items,
+		EndsAt:       req.EndsAt,
+		Active:       req.Active == nil || *req.Active,
+	}
+
+	if req.StartsAt != nil {
+		p.StartsAt = *req.StartsAt
+	}
+
+	saved, err := h.promoUseCase.SavePromo(r.Context(), p)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapPromo(*saved))
+}
+
+// CreateSale godoc
+// @Summary Запланировать распродажу
+// @Description Скидка в процентах на весь каталог или на перечисленные товары. Из пересекающихся
+// @Description распродаж действует та, что с большей скидкой
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body models.SaleRequest true "Распродажа"
+// @Success 201 {object} models.Sale "Распродажа запланирована"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/sales [post]
+func (h *Handler) CreateSale(w http.ResponseWriter, r *http.Request) {
+	var req models.SaleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	s := entities.Sale{
+		Name:    req.Name,
+		Percent: req.Percent,
+		Items:   req.Items,
+		EndsAt:  req.EndsAt,
+	}
+
+	if req.StartsAt != nil {
+		s.StartsAt = *req.StartsAt
+	}
+
+	created, err := h.promoUseCase.CreateSale(r.Context(), s)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, mapSale(*created))
+}
+
+// ListSales godoc
+// @Summary Распродажи
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Sale "Успешный ответ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/sales [get]
+func (h *Handler) ListSales(w http.ResponseWriter, r *http.Request) {
+	sales, err := h.promoUseCase.ListSales(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := make([]models.Sale, len(sales))
+	for i, s := range sales {
+		resp[i] = mapSale(s)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// DeleteSale godoc
+// @Summary Отменить распродажу
+// @Description Уже сделанные покупки сохраняют свою скидку
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID распродажи"
+// @Success 200 {string} string "Успешно"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/sales/{id} [delete]
+func (h *Handler) DeleteSale(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	if err := h.promoUseCase.DeleteSale(r.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Успешно")
+}
+
+func mapPromo(p entities.PromoCode) models.PromoCode {
+	items := p.Items
+	if items == nil {
+		items = []string{}
+	}
+
+	return models.PromoCode{
+		Code:         p.Code,
+		Kind:         p.Kind,
+		Value:        p.Value,
+		MaxUses:      p.MaxUses,
+		PerUserLimit: p.PerUserLimit,
+		Items:        items,
+		StartsAt:     p.StartsAt,
+		EndsAt:       p.EndsAt,
+		Active:       p.Active,
+		Uses:         p.Uses,
+		CreatedAt:    p.CreatedAt,
+	}
+}
+
+func mapSale(s entities.Sale) models.Sale {
+	items := s.Items
+	if items == nil {
+		items = []string{}
+	}
+
+	return models.Sale{
+		ID:        s.ID,
+		Name:      s.Name,
+		Percent:   s.Percent,
+		Items:     items,
+		StartsAt:  s.StartsAt,
+		EndsAt:    s.EndsAt,
+		CreatedAt: s.CreatedAt,
+	}
+}
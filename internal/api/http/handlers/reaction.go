@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+
+	"github.com/gorilla/mux"
+)
+
+// React godoc
+// @Summary Поставить реакцию на полученный перевод
+// @Description Допустимые реакции: thanks, 👍, ❤️, 🎉, 🙏, 😊, 🔥, 👏. Повторный вызов заменяет реакцию
+// @Tags default
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID перевода"
+// @Param input body models.ReactionRequest true "Реакция"
+// @Success 200 {string} string "Успешно"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /transactions/{id}/reaction [put]
+func (h *Handler) React(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	transactionID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	var req models.ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	if err := h.transactionUseCase.React(r.Context(), userID, transactionID, req.Reaction); err != nil {
+		// Реагировать можно только на переводы, полученные самим пользователем
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Успешно")
+}
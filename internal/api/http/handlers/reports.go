@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"merchshop/internal/sheet"
+	"merchshop/internal/usecase/report"
+)
+
+// ExportReport godoc
+// @Summary Выгрузка отчета
+// @Description Переводы (transactions), покупки (purchases) или балансы с оборотами за период (balances)
+// @Description в CSV или XLSX. Файл отдается потоком по мере чтения из базы.
+// @Description Лист XLSX вмещает 1 048 576 строк, более длинная выгрузка обрывается. Текст, похожий на формулу, в CSV начинается с апострофа
+// @Tags admin
+// @Security BearerAuth
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param kind path string true "Отчет: transactions, purchases или balances"
+// @Param format query string false "Формат: csv (по умолчанию) или xlsx"
+// @Param from query string false "Начало периода: YYYY-MM-DD или RFC 3339"
+// @Param to query string false "Конец периода, не включая: RFC 3339, или дата YYYY-MM-DD включительно"
+// @Param user query string false "Имя пользователя"
+// @Param item query string false "Товар, только для purchases"
+// @Success 200 {file} file "Отчет"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/reports/{kind} [get]
+func (h *Handler) ExportReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := report.ParseFilter(query.Get("from"), query.Get("to"), query.Get("user"), query.Get("item"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req := report.Request{Kind: mux.Vars(r)["kind"], Format: query.Get("format"), Filter: filter}
+	if req.Format == "" {
+		req.Format = sheet.FormatCSV
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rc := http.NewResponseController(w)
+
+	// Большая выгрузка идет дольше WriteTimeout сервера
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", req.Kind, time.Now().UTC().Format("20060102-150405"), req.Format)
+
+	w.Header().Set("Content-Type", sheet.ContentType(req.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	// Статус уже отправлен: оборванная выгрузка видна клиенту только как неполный файл
+	if err := h.reportUseCase.Export(r.Context(), w, req); err != nil {
+		log.Printf("report: export %s: %v", req.Kind, err)
+	}
+}
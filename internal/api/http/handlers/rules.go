@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+
+	"github.com/gorilla/mux"
+)
+
+// ListPurchaseRules godoc
+// @Summary Ограничения на покупку товаров
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.PurchaseRule "Успешный ответ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/rules [get]
+func (h *Handler) ListPurchaseRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.ruleUseCase.ListRules(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := make([]models.PurchaseRule, len(rules))
+	for i, rule := range rules {
+		resp[i] = mapPurchaseRule(rule)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SavePurchaseRule godoc
+// @Summary Задать ограничения на покупку товара
+// @Description Лимит на человека, роли, отделы, минимальный стаж и окно продаж. Лимит считается по всем
+// @Description покупкам товара, кроме отмененных, и проверяется в транзакции покупки
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param item path string true "Название товара"
+// @Param input body models.PurchaseRuleRequest true "Ограничения"
+// @Success 200 {object} models.PurchaseRule "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/merch/{item}/rule [put]
+func (h *Handler) SavePurchaseRule(w http.ResponseWriter, r *http.Request) {
+	var req models.PurchaseRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	saved, err := h.ruleUseCase.SaveRule(r.Context(), entities.PurchaseRule{
+		Item:           mux.Vars(r)["item"],
+		MaxPerUser:     req.MaxPerUser,
+		Roles:          req.Roles,
+		Departments:    req.Departments,
+		MinAccountAge:  time.Duration(req.MinAccountAgeSeconds) * time.Second,
+		AvailableFrom:  req.AvailableFrom,
+		AvailableUntil: req.AvailableUntil,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapPurchaseRule(*saved))
+}
+
+// DeletePurchaseRule godoc
+// @Summary Снять ограничения с товара
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param item path string true "Название товара"
+// @Success 200 {string} string "Успешно"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/merch/{item}/rule [delete]
+func (h *Handler) DeletePurchaseRule(w http.ResponseWriter, r *http.Request) {
+	if err := h.ruleUseCase.DeleteRule(r.Context(), mux.Vars(r)["item"]); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Успешно")
+}
+
+func mapPurchaseRule(r entities.PurchaseRule) models.PurchaseRule {
+	roles, departments := r.Roles, r.Departments
+	if roles == nil {
+		roles = []string{}
+	}
+
+	if departments == nil {
+		departments = []string{}
+	}
+
+	return models.PurchaseRule{
+		Item:                 r.Item,
+		MaxPerUser:           r.MaxPerUser,
+		Roles:                roles,
+		Departments:          departments,
+		MinAccountAgeSeconds: int64(r.MinAccountAge / time.Second),
+		AvailableFrom:        r.AvailableFrom,
+		AvailableUntil:       r.AvailableUntil,
+		UpdatedAt:            r.UpdatedAt,
+	}
+}
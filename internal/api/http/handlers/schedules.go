@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+	"merchshop/internal/usecase/schedule"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateSchedule godoc
+// @Summary Запланировать перевод
+// @Description Разовый перевод в runAt или повторяющийся по cron ("минута час день месяц день_недели", UTC)
+// @Tags default
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body models.ScheduleRequest true "Кому, сколько и когда отправить"
+// @Success 201 {object} models.ScheduleResponse "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /schedules [post]
+func (h *Handler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	var req models.ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	s, err := h.scheduleUseCase.Create(r.Context(), userID, schedule.Params{
+		ToUser: req.ToUser,
+		Amount: req.Amount,
+		Memo:   req.Memo,
+		RunAt:  req.RunAt,
+		Cron:   req.Cron,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, mapSchedule(*s))
+}
+
+// ListSchedules godoc
+// @Summary Список запланированных переводов
+// @Tags default
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.ScheduleResponse "Успешный ответ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /schedules [get]
+func (h *Handler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	schedules, err := h.scheduleUseCase.List(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := make([]models.ScheduleResponse, len(schedules))
+	for i, s := range schedules {
+		resp[i] = mapSchedule(s)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// PauseSchedule godoc
+// @Summary Приостановить запланированный перевод
+// @Tags default
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID запланированного перевода"
+// @Success 200 {string} string "Успешно"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Router /schedules/{id}/pause [post]
+func (h *Handler) PauseSchedule(w http.ResponseWriter, r *http.Request) {
+	h.changeSchedule(w, r, h.scheduleUseCase.Pause)
+}
+
+// ResumeSchedule godoc
+// @Summary Возобновить запланированный перевод
+// @Description Срабатывания, пропущенные за время паузы, не выполняются
+// @Tags default
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID запланированного перевода"
+// @Success 200 {string} string "Успешно"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Router /schedules/{id}/resume [post]
+func (h *Handler) ResumeSchedule(w http.ResponseWriter, r *http.Request) {
+	h.changeSchedule(w, r, h.scheduleUseCase.Resume)
+}
+
+// CancelSchedule godoc
+// @Summary Отменить запланированный перевод
+// @Tags default
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID запланированного перевода"
+// @Success 200 {string} string "Успешно"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Router /schedules/{id} [delete]
+func (h *Handler) CancelSchedule(w http.ResponseWriter, r *http.Request) {
+	h.changeSchedule(w, r, h.scheduleUseCase.Cancel)
+}
+
+func (h *Handler) changeSchedule(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, senderID, id int) error) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	if err := change(r.Context(), userID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Успешно")
+}
+
+func mapSchedule(s entities.ScheduledTransfer) models.ScheduleResponse {
+	return models.ScheduleResponse{
+		ID:        s.ID,
+		ToUser:    s.ReceiverName,
+		Amount:    s.Amount,
+		Memo:      s.Memo,
+		Cron:      s.Cron,
+		Status:    s.Status,
+		NextRunAt: s.NextRunAt,
+		LastRunAt: s.LastRunAt,
+		LastError: s.LastError,
+		Failures:  s.Failures,
+		CreatedAt: s.CreatedAt,
+	}
+}
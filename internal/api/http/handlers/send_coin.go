@@ -18,6 +18,7 @@ import (
 // @Success 200 {object} models.InfoResponse "Успешный ответ"
 // @Failure 400 {object} models.ErrorResponse "Неверный запрос"
 // @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Операция запрещена политикой переводов или аккаунт заморожен"
 // @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /sendCoin [post]
 func (h *Handler) SendCoin(w http.ResponseWriter, r *http.Request) {
@@ -40,9 +41,9 @@ func (h *Handler) SendCoin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.transactionUseCase.Transfer(r.Context(), userID, receiver.ID, req.Amount)
+	err = h.transactionUseCase.Transfer(r.Context(), userID, receiver.ID, req.Amount, req.Memo)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeOperationError(w, err)
 		return
 	}
 
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+	"merchshop/internal/usecase/transaction"
+)
+
+// SendCoinBatch godoc
+// @Summary Отправить монеты нескольким пользователям одной операцией
+// @Description Списание и зачисления выполняются атомарно: если хотя бы один получатель не найден или не хватает монет, перевод не выполняется
+// @Tags default
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body models.SendCoinBatchRequest true "Получатели и суммы"
+// @Success 200 {object} models.SendCoinBatchResponse "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Операция запрещена политикой переводов или аккаунт заморожен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /sendCoin/batch [post]
+func (h *Handler) SendCoinBatch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	var req models.SendCoinBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	recipients := make([]transaction.Recipient, len(req.Transfers))
+	total := 0
+
+	for i, t := range req.Transfers {
+		recipients[i] = transaction.Recipient{Username: t.ToUser, Amount: t.Amount, Memo: t.Memo}
+		total += t.Amount
+	}
+
+	batchID, err := h.transactionUseCase.TransferBatch(r.Context(), userID, recipients)
+	if err != nil {
+		writeOperationError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.SendCoinBatchResponse{BatchID: batchID, Total: total})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+)
+
+// GetStats godoc
+// @Summary Личная статистика
+// @Description Суммы переводов и покупок за неделю, месяц и все время, главные собеседники по переводам,
+// @Description траты по товарам и баланс на конец каждого дня
+// @Tags stats
+// @Security BearerAuth
+// @Produce json
+// @Param days query int false "За сколько дней вернуть баланс (до 365, по умолчанию 30)"
+// @Success 200 {object} models.Stats "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /stats [get]
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	days, err := queryInt(r, "days")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stats, err := h.statsUseCase.Get(r.Context(), userID, days)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := models.Stats{
+		Totals:         make([]models.PeriodTotals, len(stats.Totals)),
+		Counterparties: make([]models.Counterparty, len(stats.Counterparties)),
+		Items:          make([]models.ItemSpending, len(stats.Items)),
+		Balance:        make([]models.BalancePoint, len(stats.Balance)),
+	}
+
+	for i, t := range stats.Totals {
+		resp.Totals[i] = models.PeriodTotals{Period: t.Period, Sent: t.Sent, Received: t.Received, Spent: t.Spent}
+	}
+
+	for i, c := range stats.Counterparties {
+		resp.Counterparties[i] = models.Counterparty{User: c.Username, Sent: c.Sent, Received: c.Received, Transfers: c.Transfers}
+	}
+
+	for i, item := range stats.Items {
+		resp.Items[i] = models.ItemSpending{Item: item.Item, Quantity: item.Quantity, Spent: item.Spent, Orders: item.Orders}
+	}
+
+	for i, p := range stats.Balance {
+		resp.Balance[i] = models.BalancePoint{Date: p.Day.Format("2006-01-02"), Balance: p.Balance}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
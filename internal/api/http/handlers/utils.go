@@ -2,10 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"merchshop/internal/api/http/models"
 	entities "merchshop/internal/entity"
+	"merchshop/internal/usecase/policy"
+	"merchshop/internal/usecase/purchase"
+	"merchshop/internal/usecase/user"
 )
 
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -17,26 +23,143 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	}
 }
 
+const (
+	codeAccountFrozen      = "account_frozen"
+	codeAccountDeactivated = "account_deactivated"
+	codeOutOfStock         = "out_of_stock"
+	codeNotEligible        = "not_eligible"
+)
+
 func writeError(w http.ResponseWriter, status int, message string) {
 	writeJSON(w, status, models.ErrorResponse{Errors: message})
 }
 
+// writeOperationError отвечает 403 с кодом, если списание запрещено политикой, аккаунт неактивен
+// или покупатель не проходит ограничения товара, 409, если товар закончился, иначе 400
+func writeOperationError(w http.ResponseWriter, err error) {
+	var violation *policy.Violation
+	if errors.As(err, &violation) {
+		writeJSON(w, http.StatusForbidden, models.ErrorResponse{Errors: violation.Message, Code: violation.Code})
+		return
+	}
+
+	if errors.Is(err, user.ErrAccountFrozen) {
+		writeJSON(w, http.StatusForbidden, models.ErrorResponse{Errors: err.Error(), Code: codeAccountFrozen})
+		return
+	}
+
+	if errors.Is(err, user.ErrAccountDeactivated) {
+		writeJSON(w, http.StatusForbidden, models.ErrorResponse{Errors: err.Error(), Code: codeAccountDeactivated})
+		return
+	}
+
+	if errors.Is(err, purchase.ErrNotEligible) {
+		writeJSON(w, http.StatusForbidden, models.ErrorResponse{Errors: err.Error(), Code: codeNotEligible})
+		return
+	}
+
+	if errors.Is(err, purchase.ErrOutOfStock) {
+		writeJSON(w, http.StatusConflict, models.ErrorResponse{Errors: err.Error(), Code: codeOutOfStock})
+		return
+	}
+
+	writeError(w, http.StatusBadRequest, err.Error())
+}
+
+// queryInt читает необязательный целочисленный query-параметр. Если параметра нет, возвращает 0
+func queryInt(r *http.Request, name string) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %q", name, raw)
+	}
+
+	return value, nil
+}
+
 func mapInventory(purchases []entities.Purchase) []models.InventoryItem {
-	// Создаем map для группировки товаров
-	inventory := make(map[string]int)
+	// Группируем покупки по товару, сохраняя порядок первого появления. Отмененные заказы
+	// возвращены на склад и в инвентарь не попадают
+	result := make([]models.InventoryItem, 0)
+	items := make(map[string]int)
+	variants := make(map[string]int)
 
-	// Группируем покупки по типу товара
 	for _, purchase := range purchases {
-		inventory[purchase.MerchName] += purchase.Quantity
+		// Отмененный заказ возвращен на склад
+		if purchase.Status == entities.OrderCancelled {
+			continue
+		}
+
+		idx, ok := items[purchase.MerchName]
+		if !ok {
+			idx = len(result)
+			items[purchase.MerchName] = idx
+			result = append(result, models.InventoryItem{Type: purchase.MerchName})
+		}
+
+		result[idx].Quantity += purchase.Quantity
+
+		if purchase.Status == entities.OrderPlaced || purchase.Status == entities.OrderReady {
+			result[idx].Orders = append(result[idx].Orders, models.InventoryOrder{
+				ID:             purchase.ID,
+				SKU:            purchase.SKU,
+				Quantity:       purchase.Quantity,
+				Status:         purchase.Status,
+				PickupLocation: purchase.PickupLocation,
+			})
+		}
+
+		if purchase.SKU == "" {
+			continue
+		}
+
+		// Внутри товара показываем, какие варианты куплены
+		vIdx, ok := variants[purchase.SKU]
+		if !ok {
+			vIdx = len(result[idx].Variants)
+			variants[purchase.SKU] = vIdx
+			result[idx].Variants = append(result[idx].Variants, models.InventoryVariant{
+				SKU:        purchase.SKU,
+				Attributes: purchase.Attributes,
+			})
+		}
+
+		result[idx].Variants[vIdx].Quantity += purchase.Quantity
 	}
 
-	// Преобразуем map в slice для ответа
-	result := make([]models.InventoryItem, 0, len(inventory))
-	for itemType, quantity := range inventory {
-		result = append(result, models.InventoryItem{
-			Type:     itemType,
-			Quantity: quantity,
-		})
+	return result
+}
+
+// mapGifts переводит подарки в историю. Из полученных покупок берутся только подарки
+func mapGifts(purchases []entities.Purchase, isReceived bool) []models.Gift {
+	result := make([]models.Gift, 0)
+
+	for _, p := range purchases {
+		if !p.IsGift() {
+			continue
+		}
+
+		gift := models.Gift{
+			OrderID:   p.ID,
+			Item:      p.MerchName,
+			SKU:       p.SKU,
+			Quantity:  p.Quantity,
+			Message:   p.GiftMessage,
+			Status:    p.Status,
+			CreatedAt: p.CreatedAt,
+		}
+
+		if isReceived {
+			gift.FromUser = p.BuyerName
+		} else {
+			gift.ToUser = p.Username
+		}
+
+		result = append(result, gift)
 	}
 
 	return result
@@ -47,7 +170,15 @@ func mapTransactions(transactions []entities.Transaction, isReceived bool) []mod
 
 	for i, tx := range transactions {
 		operation := models.CoinOperation{
-			Amount: tx.Amount,
+			Amount:   tx.Amount,
+			Memo:     tx.Memo,
+			Reaction: tx.Reaction,
+			BatchID:  tx.BatchID,
+		}
+
+		// Сгруппированный пакет не является отдельным переводом
+		if len(tx.Items) == 0 {
+			operation.ID = tx.ID
 		}
 
 		if isReceived {
@@ -56,6 +187,10 @@ func mapTransactions(transactions []entities.Transaction, isReceived bool) []mod
 			operation.ToUser = tx.ReceiverName
 		}
 
+		if len(tx.Items) > 0 {
+			operation.Recipients = mapTransactions(tx.Items, false)
+		}
+
 		result[i] = operation
 	}
 
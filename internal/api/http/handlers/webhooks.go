@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/webhook"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateWebhook godoc
+// @Summary Создать подписку на события
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body models.WebhookRequest true "URL, события и секрет для подписи"
+// @Success 201 {object} models.WebhookResponse "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks [post]
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	hook, err := h.webhookUseCase.Create(r.Context(), req.URL, req.Events, req.Secret)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp := mapWebhook(*hook)
+	resp.Secret = hook.Secret
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// ListWebhooks godoc
+// @Summary Список подписок на события
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.WebhookResponse "Успешный ответ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks [get]
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.webhookUseCase.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := make([]models.WebhookResponse, len(hooks))
+	for i, hook := range hooks {
+		resp[i] = mapWebhook(hook)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// DeleteWebhook godoc
+// @Summary Отключить подписку на события
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID вебхука"
+// @Success 200 {string} string "Успешно"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный запрос")
+		return
+	}
+
+	if err := h.webhookUseCase.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Успешно")
+}
+
+// ListWebhookDeliveries godoc
+// @Summary Журнал доставки вебхуков
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param webhookId query int false "ID вебхука"
+// @Param status query string false "Статус доставки (pending, delivered, failed)"
+// @Param limit query int false "Максимум записей (до 100)"
+// @Success 200 {array} models.WebhookDelivery "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 403 {object} models.ErrorResponse "Доступ запрещен"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks/deliveries [get]
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := queryInt(r, "webhookId")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deliveries, err := h.webhookUseCase.ListDeliveries(r.Context(), webhook.DeliveryFilter{
+		WebhookID: webhookID,
+		Status:    r.URL.Query().Get("status"),
+		Limit:     limit,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := make([]models.WebhookDelivery, len(deliveries))
+	for i, d := range deliveries {
+		resp[i] = models.WebhookDelivery{
+			ID:             d.ID,
+			WebhookID:      d.WebhookID,
+			Event:          d.EventType,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			NextAttemptAt:  d.NextAttemptAt,
+			LastStatusCode: d.LastStatusCode,
+			LastError:      d.LastError,
+			CreatedAt:      d.CreatedAt,
+			DeliveredAt:    d.DeliveredAt,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func mapWebhook(hook entities.Webhook) models.WebhookResponse {
+	return models.WebhookResponse{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Events:    hook.Events,
+		Active:    hook.Active,
+		CreatedAt: hook.CreatedAt,
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/api/http/models"
+	entities "merchshop/internal/entity"
+	"merchshop/internal/usecase/wishlist"
+
+	"github.com/gorilla/mux"
+)
+
+// ListWishlist godoc
+// @Summary Список желаний
+// @Description Для каждого товара текущая цена со скидкой распродажи и сколько монет не хватает
+// @Tags wishlist
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.WishlistItem "Успешный ответ"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /wishlist [get]
+func (h *Handler) ListWishlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	items, err := h.wishlistUseCase.List(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	resp := make([]models.WishlistItem, len(items))
+	for i, item := range items {
+		resp[i] = mapWishlistItem(item)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// AddToWishlist godoc
+// @Summary Добавить товар в список желаний
+// @Description Товар с вариантами добавляется по артикулу. Когда цена снизится или монет станет хватать,
+// @Description придет уведомление
+// @Tags wishlist
+// @Security BearerAuth
+// @Produce json
+// @Param item path string true "Артикул варианта или название товара"
+// @Success 200 {object} models.WishlistItem "Успешный ответ"
+// @Failure 400 {object} models.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /wishlist/{item} [put]
+func (h *Handler) AddToWishlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	item, err := h.wishlistUseCase.Add(r.Context(), userID, mux.Vars(r)["item"])
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapWishlistItem(*item))
+}
+
+// RemoveFromWishlist godoc
+// @Summary Убрать товар из списка желаний
+// @Tags wishlist
+// @Security BearerAuth
+// @Produce json
+// @Param item path string true "Артикул варианта или название товара"
+// @Success 200 {string} string "Успешно"
+// @Failure 401 {object} models.ErrorResponse "Неавторизован"
+// @Failure 404 {object} models.ErrorResponse "Не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /wishlist/{item} [delete]
+func (h *Handler) RemoveFromWishlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Неавторизован")
+		return
+	}
+
+	if err := h.wishlistUseCase.Remove(r.Context(), userID, mux.Vars(r)["item"]); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, "Успешно")
+}
+
+func mapWishlistItem(item entities.WishlistItem) models.WishlistItem {
+	return models.WishlistItem{
+		Item:        item.Item,
+		MerchName:   item.MerchName,
+		SKU:         item.SKU,
+		ListPrice:   item.ListPrice,
+		Price:       wishlist.Price(item),
+		CoinsNeeded: wishlist.CoinsNeeded(item),
+		AddedAt:     item.AddedAt,
+	}
+}
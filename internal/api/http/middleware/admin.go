@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/usecase/user"
+)
+
+// AdminMiddleware пропускает дальше только пользователей с ролью администратора.
+// Должен стоять после AuthMiddleware
+func AdminMiddleware(userUseCase user.UseCase) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := r.Context().Value(UserIDKey).(int)
+			if !ok {
+				WriteError(w, http.StatusUnauthorized, "Неавторизован")
+				return
+			}
+
+			u, err := userUseCase.GetByID(r.Context(), userID)
+			if err != nil {
+				WriteError(w, http.StatusUnauthorized, "Неавторизован")
+				return
+			}
+
+			if u.Role != entities.RoleAdmin {
+				WriteError(w, http.StatusForbidden, "Доступ запрещен")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
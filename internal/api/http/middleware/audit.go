@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"merchshop/internal/audit"
+	entities "merchshop/internal/entity"
+	"merchshop/internal/usecase/user"
+)
+
+// maxAuditBody сколько тела запроса попадает в журнал. Обрезанное тело не JSON и не пишется
+const maxAuditBody = 64 << 10
+
+// adminTargets тип объекта по первому сегменту админского маршрута
+var adminTargets = map[string]string{
+	"users":    audit.TargetUser,
+	"merch":    audit.TargetMerch,
+	"webhooks": "webhook",
+	"policies": "policy",
+	"fraud":    "fraud_case",
+	"promos":   "promo",
+	"sales":    "sale",
+	"orders":   audit.TargetOrder,
+	"rules":    "rule",
+	"reports":  "report",
+	"audit":    "audit",
+}
+
+var pathVariable = regexp.MustCompile(`\{([^}:]+)`)
+
+// accountState состояние аккаунта в журнале. Имени нет, чтобы стирание данных не оставляло его в журнале
+type accountState struct {
+	Role       string `json:"role"`
+	Department string `json:"department"`
+	Status     string `json:"status"`
+}
+
+// AuditMiddleware пишет в журнал аудита изменения через админские маршруты: успешные запросы,
+// кроме GET, и любые попытки обратиться к ним без прав администратора. Для аккаунтов пишется
+// состояние до и после, для остальных объектов в After попадает тело запроса без паролей и секретов.
+// Должен стоять до AdminMiddleware, иначе не увидит отказы
+func AuditMiddleware(recorder audit.Recorder, userUseCase user.UseCase) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := r.URL.Path
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					route = template
+				}
+			}
+
+			route = strings.TrimPrefix(route, "/api/admin")
+			targetType, targetID := adminTarget(route, mux.Vars(r))
+			changes := r.Method != http.MethodGet
+
+			var body []byte
+
+			if changes {
+				var err error
+
+				body, err = io.ReadAll(io.LimitReader(r.Body, maxAuditBody))
+				if err != nil {
+					WriteError(w, http.StatusBadRequest, "Неверный запрос")
+					return
+				}
+
+				r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+			}
+
+			var before json.RawMessage
+			if changes && targetType == audit.TargetUser {
+				before = accountSnapshot(r.Context(), userUseCase, targetID)
+			}
+
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+
+			entry := entities.AuditEntry{TargetType: targetType, TargetID: targetID}
+
+			switch {
+			case sw.status == http.StatusForbidden:
+				entry.Action = audit.ActionForbidden
+				entry.After = audit.Payload(map[string]string{"route": r.Method + " " + route})
+			case changes && sw.status < http.StatusBadRequest:
+				entry.Action = audit.ActionAdminPrefix + r.Method + " " + route
+				entry.Before = before
+				entry.After = audit.Redact(body)
+
+				if targetType == audit.TargetUser {
+					entry.After = accountSnapshot(r.Context(), userUseCase, targetID)
+				}
+			default:
+				return
+			}
+
+			recorder.Record(r.Context(), entry)
+		})
+	}
+}
+
+// adminTarget тип объекта по маршруту и его идентификатор из переменных пути через "/"
+func adminTarget(route string, vars map[string]string) (string, string) {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+
+	targetType, ok := adminTargets[segment]
+	if !ok {
+		targetType = segment
+	}
+
+	var ids []string
+
+	for _, match := range pathVariable.FindAllStringSubmatch(route, -1) {
+		ids = append(ids, vars[match[1]])
+	}
+
+	return targetType, strings.Join(ids, "/")
+}
+
+func accountSnapshot(ctx context.Context, userUseCase user.UseCase, targetID string) json.RawMessage {
+	id, err := strconv.Atoi(targetID)
+	if err != nil {
+		return nil
+	}
+
+	u, err := userUseCase.GetByID(ctx, id)
+	if err != nil {
+		return nil
+	}
+
+	return audit.Payload(accountState{Role: u.Role, Department: u.Department, Status: u.Status})
+}
+
+// statusWriter запоминает код ответа обработчика
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap дает http.ResponseController добраться до исходного writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -4,15 +4,20 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"merchshop/internal/api/http/auth"
+	"merchshop/internal/audit"
+	"merchshop/internal/usecase/user"
 )
 
 type contextKey string
 
 const UserIDKey contextKey = "user_id"
 
-func AuthMiddleware(tokenManager auth.TokenManager) func(http.Handler) http.Handler {
+// AuthMiddleware пропускает запросы с действующим токеном. Токены деактивированных
+// пользователей и выданные до отзыва отклоняются
+func AuthMiddleware(tokenManager auth.TokenManager, userUseCase user.UseCase) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -27,13 +32,19 @@ func AuthMiddleware(tokenManager auth.TokenManager) func(http.Handler) http.Hand
 				return
 			}
 
-			userID, err := tokenManager.Parse(headerParts[1])
+			claims, err := tokenManager.ParseClaims(headerParts[1])
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusUnauthorized)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			if err := userUseCase.Authorize(r.Context(), claims.UserID, time.Unix(claims.IssuedAt, 0)); err != nil {
+				http.Error(w, "Неавторизован", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = audit.WithActor(ctx, claims.UserID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"regexp"
+
+	"merchshop/internal/audit"
+)
+
+// RequestIDHeader ID запроса, его можно передать с запросом, иначе он создается. Возвращается в ответе
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestMiddleware кладет в контекст ID запроса и IP клиента для журнала аудита.
+// IP берется из соединения: заголовкам прокси без настроенного доверия верить нельзя
+func RequestMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = audit.NewRequestID()
+		}
+
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := audit.WithRequest(r.Context(), audit.Request{ID: id, IP: ip})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
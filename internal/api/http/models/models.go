@@ -1,5 +1,10 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // AuthRequest модель запроса авторизации
 // swagger:model AuthRequest
 type AuthRequest struct {
@@ -18,6 +23,35 @@ type AuthResponse struct {
 type SendCoinRequest struct {
 	ToUser string `json:"toUser"`
 	Amount int    `json:"amount"`
+	Memo   string `json:"memo,omitempty"`
+}
+
+// GiftRequest покупка в подарок. Quantity по умолчанию 1
+// swagger:model GiftRequest
+type GiftRequest struct {
+	ToUser    string `json:"toUser"`
+	Quantity  int    `json:"quantity,omitempty"`
+	Message   string `json:"message,omitempty"`
+	PromoCode string `json:"promo,omitempty"`
+}
+
+// ReactionRequest реакция получателя на перевод
+// swagger:model ReactionRequest
+type ReactionRequest struct {
+	Reaction string `json:"reaction"`
+}
+
+// SendCoinBatchRequest модель пакетной передачи коинов
+// swagger:model SendCoinBatchRequest
+type SendCoinBatchRequest struct {
+	Transfers []SendCoinRequest `json:"transfers"`
+}
+
+// SendCoinBatchResponse результат пакетной передачи коинов
+// swagger:model SendCoinBatchResponse
+type SendCoinBatchResponse struct {
+	BatchID int `json:"batchId"`
+	Total   int `json:"total"`
 }
 
 // InfoResponse модель ответа информации
@@ -26,13 +60,56 @@ type InfoResponse struct {
 	Coins       int             `json:"coins"`
 	Inventory   []InventoryItem `json:"inventory"`
 	CoinHistory CoinHistoryInfo `json:"coinHistory"`
+	Gifts       GiftHistory     `json:"gifts"`
+}
+
+// GiftHistory подарки, оплаченные пользователем и полученные им
+// swagger:model GiftHistory
+type GiftHistory struct {
+	Received []Gift `json:"received"`
+	Sent     []Gift `json:"sent"`
+}
+
+// Gift подарок. У полученного заполнен FromUser, у отправленного ToUser
+// swagger:model Gift
+type Gift struct {
+	OrderID   int       `json:"orderId"`
+	FromUser  string    `json:"fromUser,omitempty"`
+	ToUser    string    `json:"toUser,omitempty"`
+	Item      string    `json:"item"`
+	SKU       string    `json:"sku,omitempty"`
+	Quantity  int       `json:"quantity"`
+	Message   string    `json:"message,omitempty"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
-// InventoryItem элемент инвентаря
+// InventoryItem элемент инвентаря. Quantity суммарно по всем вариантам товара
 // swagger:model InventoryItem
 type InventoryItem struct {
-	Type     string `json:"type"`
-	Quantity int    `json:"quantity"`
+	Type     string             `json:"type"`
+	Quantity int                `json:"quantity"`
+	Variants []InventoryVariant `json:"variants,omitempty"`
+	// Orders заказы товара, которые еще не выданы
+	Orders []InventoryOrder `json:"orders,omitempty"`
+}
+
+// InventoryOrder невыданный заказ и где его забрать
+// swagger:model InventoryOrder
+type InventoryOrder struct {
+	ID             int    `json:"id"`
+	SKU            string `json:"sku,omitempty"`
+	Quantity       int    `json:"quantity"`
+	Status         string `json:"status"`
+	PickupLocation string `json:"pickupLocation,omitempty"`
+}
+
+// InventoryVariant купленный вариант товара
+// swagger:model InventoryVariant
+type InventoryVariant struct {
+	SKU        string            `json:"sku"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Quantity   int               `json:"quantity"`
 }
 
 // CoinHistoryInfo история коинов
@@ -40,18 +117,559 @@ type InventoryItem struct {
 type CoinHistoryInfo struct {
 	Received []CoinOperation `json:"received"`
 	Sent     []CoinOperation `json:"sent"`
+	Requests []CoinRequest   `json:"requests"`
 }
 
 // CoinOperation операция с коинами
 // swagger:model CoinOperation
 type CoinOperation struct {
-	FromUser string `json:"fromUser,omitempty"`
-	ToUser   string `json:"toUser,omitempty"`
-	Amount   int    `json:"amount"`
+	ID         int             `json:"id,omitempty"`
+	FromUser   string          `json:"fromUser,omitempty"`
+	ToUser     string          `json:"toUser,omitempty"`
+	Amount     int             `json:"amount"`
+	Memo       string          `json:"memo,omitempty"`
+	Reaction   string          `json:"reaction,omitempty"`
+	BatchID    int             `json:"batchId,omitempty"`
+	Recipients []CoinOperation `json:"recipients,omitempty"`
 }
 
 // ErrorResponse модель ошибок
 // swagger:model ErrorResponse
 type ErrorResponse struct {
 	Errors string `json:"errors"`
+	// Code код нарушенной политики переводов, если операция отклонена ею
+	Code string `json:"code,omitempty"`
+}
+
+// WebhookRequest модель создания вебхука
+// swagger:model WebhookRequest
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+// WebhookResponse подписка на события. Секрет возвращается только при создании
+// swagger:model WebhookResponse
+type WebhookResponse struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookDelivery запись журнала доставки вебхука
+// swagger:model WebhookDelivery
+type WebhookDelivery struct {
+	ID             int        `json:"id"`
+	WebhookID      int        `json:"webhookId"`
+	Event          string     `json:"event"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt"`
+	LastStatusCode int        `json:"lastStatusCode,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+}
+
+// BalanceEvent событие balance в потоке /events
+// swagger:model BalanceEvent
+type BalanceEvent struct {
+	Coins int `json:"coins"`
+}
+
+// ScheduleRequest запланированный перевод. Без cron перевод разовый и выполняется в runAt
+// swagger:model ScheduleRequest
+type ScheduleRequest struct {
+	ToUser string    `json:"toUser"`
+	Amount int       `json:"amount"`
+	Memo   string    `json:"memo,omitempty"`
+	RunAt  time.Time `json:"runAt,omitempty"`
+	Cron   string    `json:"cron,omitempty"`
+}
+
+// ScheduleResponse запланированный перевод и его состояние
+// swagger:model ScheduleResponse
+type ScheduleResponse struct {
+	ID        int        `json:"id"`
+	ToUser    string     `json:"toUser"`
+	Amount    int        `json:"amount"`
+	Memo      string     `json:"memo,omitempty"`
+	Cron      string     `json:"cron,omitempty"`
+	Status    string     `json:"status"`
+	NextRunAt time.Time  `json:"nextRunAt"`
+	LastRunAt *time.Time `json:"lastRunAt,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+	Failures  int        `json:"failures,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// CreateCoinRequest запрос монет у другого пользователя
+// swagger:model CreateCoinRequest
+type CreateCoinRequest struct {
+	FromUser string `json:"fromUser"`
+	Amount   int    `json:"amount"`
+	Memo     string `json:"memo,omitempty"`
+}
+
+// CoinRequest запрос монет: fromUser просит toUser перевести amount
+// swagger:model CoinRequest
+type CoinRequest struct {
+	ID            int        `json:"id"`
+	FromUser      string     `json:"fromUser"`
+	ToUser        string     `json:"toUser"`
+	Amount        int        `json:"amount"`
+	Memo          string     `json:"memo,omitempty"`
+	Status        string     `json:"status"`
+	TransactionID int        `json:"transactionId,omitempty"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ResolvedAt    *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// EscrowTransfer перевод, удерживаемый до первого входа получателя
+// swagger:model EscrowTransfer
+type EscrowTransfer struct {
+	ID            int        `json:"id"`
+	ToUser        string     `json:"toUser"`
+	Amount        int        `json:"amount"`
+	Memo          string     `json:"memo,omitempty"`
+	Status        string     `json:"status"`
+	TransactionID int        `json:"transactionId,omitempty"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ResolvedAt    *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// TransferPolicyRequest ограничения исходящих операций для роли, 0 снимает ограничение
+// swagger:model TransferPolicyRequest
+type TransferPolicyRequest struct {
+	DailyLimit            int   `json:"dailyLimit"`
+	WeeklyLimit           int   `json:"weeklyLimit"`
+	MaxTransfer           int   `json:"maxTransfer"`
+	MinAccountAgeSeconds  int64 `json:"minAccountAgeSeconds"`
+	VelocityCount         int   `json:"velocityCount"`
+	VelocityWindowSeconds int64 `json:"velocityWindowSeconds"`
+}
+
+// TransferPolicy политика переводов роли
+// swagger:model TransferPolicy
+type TransferPolicy struct {
+	Role                  string    `json:"role"`
+	DailyLimit            int       `json:"dailyLimit"`
+	WeeklyLimit           int       `json:"weeklyLimit"`
+	MaxTransfer           int       `json:"maxTransfer"`
+	MinAccountAgeSeconds  int64     `json:"minAccountAgeSeconds"`
+	VelocityCount         int       `json:"velocityCount"`
+	VelocityWindowSeconds int64     `json:"velocityWindowSeconds"`
+	UpdatedAt             time.Time `json:"updatedAt"`
+}
+
+// FraudCase подозрительный паттерн переводов. У воронки первым идет получатель
+// swagger:model FraudCase
+type FraudCase struct {
+	ID                int        `json:"id"`
+	Kind              string     `json:"kind"`
+	UserIDs           []int      `json:"userIds"`
+	Usernames         []string   `json:"usernames"`
+	LastTransactionID int        `json:"lastTransactionId"`
+	Transfers         int        `json:"transfers"`
+	Amount            int        `json:"amount"`
+	Status            string     `json:"status"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	ResolvedAt        *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// FreezeRequest какие участники случая замораживаются, пустой список замораживает всех
+// swagger:model FreezeRequest
+type FreezeRequest struct {
+	UserIDs []int `json:"userIds,omitempty"`
+}
+
+// Account статус аккаунта пользователя
+// swagger:model Account
+type Account struct {
+	ID         int    `json:"id"`
+	Username   string `json:"username"`
+	Role       string `json:"role"`
+	Department string `json:"department"`
+	Status     string `json:"status"`
+}
+
+// DepartmentRequest отдел сотрудника, пустая строка убирает отдел
+// swagger:model DepartmentRequest
+type DepartmentRequest struct {
+	Department string `json:"department"`
+}
+
+// AccountStatusRequest новый статус: active или frozen
+// swagger:model AccountStatusRequest
+type AccountStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// OffboardRequest параметры деактивации, sweepBalance переводит остаток в пул
+// swagger:model OffboardRequest
+type OffboardRequest struct {
+	SweepBalance bool `json:"sweepBalance"`
+}
+
+// Offboarding итог деактивации. transactionId есть, если остаток переведен в пул
+// swagger:model Offboarding
+type Offboarding struct {
+	UserID        int       `json:"userId"`
+	Swept         int       `json:"swept"`
+	TransactionID int       `json:"transactionId,omitempty"`
+	DeactivatedAt time.Time `json:"deactivatedAt"`
+}
+
+// EraseRequest причина стирания данных, например номер обращения сотрудника
+// swagger:model EraseRequest
+type EraseRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Erasure запись журнала стираний. username обезличенное имя, под которым пользователь остается в истории
+// swagger:model Erasure
+type Erasure struct {
+	UserID   int       `json:"userId"`
+	Username string    `json:"username"`
+	ErasedBy int       `json:"erasedBy"`
+	Reason   string    `json:"reason"`
+	ErasedAt time.Time `json:"erasedAt"`
+}
+
+// AuditEntry запись журнала аудита. before и after состояние объекта или тело запроса,
+// hash считается от записи и prevHash предыдущей
+// swagger:model AuditEntry
+type AuditEntry struct {
+	ID         int             `json:"id"`
+	ActorID    int             `json:"actorId,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"targetType,omitempty"`
+	TargetID   string          `json:"targetId,omitempty"`
+	RequestID  string          `json:"requestId,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After      json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	PrevHash   string          `json:"prevHash"`
+	Hash       string          `json:"hash"`
+}
+
+// AuditLog страница журнала аудита. NextBefore передается в before за следующей страницей
+// swagger:model AuditLog
+type AuditLog struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextBefore int          `json:"nextBefore,omitempty"`
+}
+
+// MerchItem товар каталога с остатком на складе. Товар с вариантами покупается по артикулу варианта
+// swagger:model MerchItem
+type MerchItem struct {
+	Name     string         `json:"name"`
+	Price    int            `json:"price"`
+	Stock    int            `json:"stock"`
+	Variants []MerchVariant `json:"variants,omitempty"`
+}
+
+// MerchVariant вариант товара со своей ценой и остатком
+// swagger:model MerchVariant
+type MerchVariant struct {
+	SKU        string            `json:"sku"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Price      int               `json:"price"`
+	Stock      int               `json:"stock"`
+	Default    bool              `json:"default,omitempty"`
+}
+
+// VariantRequest атрибуты варианта. price переопределяет цену товара, stock задает начальный остаток нового варианта,
+// default делает вариант тем, что продается при покупке по названию товара
+// swagger:model VariantRequest
+type VariantRequest struct {
+	Attributes        map[string]string `json:"attributes"`
+	Price             *int              `json:"price,omitempty"`
+	Stock             int               `json:"stock"`
+	LowStockThreshold *int              `json:"lowStockThreshold,omitempty"`
+	Default           bool              `json:"default,omitempty"`
+}
+
+// VariantStock вариант товара с остатком и порогом предупреждения
+// swagger:model VariantStock
+type VariantStock struct {
+	SKU               string            `json:"sku"`
+	Item              string            `json:"item"`
+	Attributes        map[string]string `json:"attributes,omitempty"`
+	Price             int               `json:"price"`
+	Stock             int               `json:"stock"`
+	LowStockThreshold int               `json:"lowStockThreshold"`
+	Default           bool              `json:"default"`
+}
+
+// RestockRequest пополнение склада. lowStockThreshold меняет порог предупреждения
+// swagger:model RestockRequest
+type RestockRequest struct {
+	Quantity          int  `json:"quantity"`
+	LowStockThreshold *int `json:"lowStockThreshold,omitempty"`
+}
+
+// MerchStock остаток товара и порог предупреждения
+// swagger:model MerchStock
+type MerchStock struct {
+	Name              string `json:"name"`
+	Price             int    `json:"price"`
+	Stock             int    `json:"stock"`
+	LowStockThreshold int    `json:"lowStockThreshold"`
+}
+
+// Order заказ на выдачу купленного товара
+// swagger:model Order
+type Order struct {
+	ID             int               `json:"id"`
+	UserID         int               `json:"userId"`
+	Username       string            `json:"username"`
+	Item           string            `json:"item"`
+	SKU            string            `json:"sku,omitempty"`
+	Attributes     map[string]string `json:"attributes,omitempty"`
+	Quantity       int               `json:"quantity"`
+	ListPrice      int               `json:"listPrice"`
+	Discount       int               `json:"discount"`
+	PromoCode      string            `json:"promoCode,omitempty"`
+	TotalPrice     int               `json:"totalPrice"`
+	Status         string            `json:"status"`
+	PickupLocation string            `json:"pickupLocation,omitempty"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	ReadyAt        *time.Time        `json:"readyAt,omitempty"`
+	DeliveredAt    *time.Time        `json:"deliveredAt,omitempty"`
+	CancelledAt    *time.Time        `json:"cancelledAt,omitempty"`
+}
+
+// ReadyRequest где покупатель заберет заказ
+// swagger:model ReadyRequest
+type ReadyRequest struct {
+	PickupLocation string `json:"pickupLocation"`
+}
+
+// Quote цена покупки со скидками. ListPrice цена без скидок
+// swagger:model Quote
+type Quote struct {
+	Item      string `json:"item"`
+	Quantity  int    `json:"quantity"`
+	ListPrice int    `json:"listPrice"`
+	Discount  int    `json:"discount"`
+	Total     int    `json:"total"`
+	PromoCode string `json:"promoCode,omitempty"`
+}
+
+// WishlistItem товар из списка желаний. Price цена со скидкой распродажи, CoinsNeeded сколько
+// монет не хватает до покупки
+// swagger:model WishlistItem
+type WishlistItem struct {
+	Item        string    `json:"item"`
+	MerchName   string    `json:"merchName"`
+	SKU         string    `json:"sku,omitempty"`
+	ListPrice   int       `json:"listPrice"`
+	Price       int       `json:"price"`
+	CoinsNeeded int       `json:"coinsNeeded"`
+	AddedAt     time.Time `json:"addedAt"`
+}
+
+// Notification уведомление в приложении. Состав data зависит от kind
+// swagger:model Notification
+type Notification struct {
+	ID        int            `json:"id"`
+	Kind      string         `json:"kind"`
+	Message   string         `json:"message"`
+	Data      map[string]any `json:"data"`
+	ReadAt    *time.Time     `json:"readAt,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// NotificationList страница уведомлений. NextBefore передается в before за следующей страницей
+// swagger:model NotificationList
+type NotificationList struct {
+	Notifications []Notification `json:"notifications"`
+	Unread        int            `json:"unread"`
+	NextBefore    int            `json:"nextBefore,omitempty"`
+}
+
+// MarkReadRequest уведомления, которые нужно отметить прочитанными. Пустой список отмечает все
+// swagger:model MarkReadRequest
+type MarkReadRequest struct {
+	IDs []int `json:"ids"`
+}
+
+// MarkReadResponse сколько уведомлений отмечено прочитанными
+// swagger:model MarkReadResponse
+type MarkReadResponse struct {
+	Marked int `json:"marked"`
+}
+
+// NotificationPreference включен ли тип уведомлений
+// swagger:model NotificationPreference
+type NotificationPreference struct {
+	Kind    string `json:"kind"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Leaderboard рейтинг за период. refreshedAt время последнего пересчета, пустое если рейтинг пуст
+// swagger:model Leaderboard
+type Leaderboard struct {
+	Board       string             `json:"board"`
+	Period      string             `json:"period"`
+	RefreshedAt *time.Time         `json:"refreshedAt,omitempty"`
+	Entries     []LeaderboardEntry `json:"entries"`
+}
+
+// LeaderboardEntry место в рейтинге. У пользователей с одинаковой суммой одно место
+// swagger:model LeaderboardEntry
+type LeaderboardEntry struct {
+	Rank  int    `json:"rank"`
+	User  string `json:"user"`
+	Total int    `json:"total"`
+}
+
+// LeaderboardOptOut скрыт ли пользователь из публичных рейтингов
+// swagger:model LeaderboardOptOut
+type LeaderboardOptOut struct {
+	OptOut bool `json:"optOut"`
+}
+
+// Stats личная статистика. Неделя и месяц отсчитываются назад от текущего момента
+// swagger:model Stats
+type Stats struct {
+	Totals         []PeriodTotals `json:"totals"`
+	Counterparties []Counterparty `json:"counterparties"`
+	Items          []ItemSpending `json:"items"`
+	Balance        []BalancePoint `json:"balance"`
+}
+
+// PeriodTotals сколько монет отправлено, получено и потрачено за период week, month или all
+// swagger:model PeriodTotals
+type PeriodTotals struct {
+	Period   string `json:"period"`
+	Sent     int    `json:"sent"`
+	Received int    `json:"received"`
+	Spent    int    `json:"spent"`
+}
+
+// Counterparty пользователь, с которым обменивались монетами: sent отправлено ему, received получено от него
+// swagger:model Counterparty
+type Counterparty struct {
+	User      string `json:"user"`
+	Sent      int    `json:"sent"`
+	Received  int    `json:"received"`
+	Transfers int    `json:"transfers"`
+}
+
+// ItemSpending траты на товар, включая оплаченные подарки
+// swagger:model ItemSpending
+type ItemSpending struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+	Spent    int    `json:"spent"`
+	Orders   int    `json:"orders"`
+}
+
+// BalancePoint баланс на конец дня (UTC), дата в формате YYYY-MM-DD
+// swagger:model BalancePoint
+type BalancePoint struct {
+	Date    string `json:"date"`
+	Balance int    `json:"balance"`
+}
+
+// DataExport выгрузка личных данных. Пока статус pending, архив собирается. Для готовой выгрузки
+// downloadUrl ссылка на архив, действующая до downloadExpiresAt; каждый запрос выдает новую ссылку
+// swagger:model DataExport
+type DataExport struct {
+	ID                int        `json:"id"`
+	UserID            int        `json:"userId"`
+	Status            string     `json:"status"`
+	Size              int        `json:"size,omitempty"`
+	Error             string     `json:"error,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	ReadyAt           *time.Time `json:"readyAt,omitempty"`
+	ExpiresAt         *time.Time `json:"expiresAt,omitempty"`
+	DownloadURL       string     `json:"downloadUrl,omitempty"`
+	DownloadExpiresAt *time.Time `json:"downloadExpiresAt,omitempty"`
+}
+
+// PurchaseRuleRequest ограничения на покупку товара. 0 в maxPerUser и minAccountAgeSeconds и пустые
+// списки ничего не ограничивают
+// swagger:model PurchaseRuleRequest
+type PurchaseRuleRequest struct {
+	MaxPerUser           int        `json:"maxPerUser"`
+	Roles                []string   `json:"roles,omitempty"`
+	Departments          []string   `json:"departments,omitempty"`
+	MinAccountAgeSeconds int64      `json:"minAccountAgeSeconds"`
+	AvailableFrom        *time.Time `json:"availableFrom,omitempty"`
+	AvailableUntil       *time.Time `json:"availableUntil,omitempty"`
+}
+
+// PurchaseRule ограничения на покупку товара
+// swagger:model PurchaseRule
+type PurchaseRule struct {
+	Item                 string     `json:"item"`
+	MaxPerUser           int        `json:"maxPerUser"`
+	Roles                []string   `json:"roles"`
+	Departments          []string   `json:"departments"`
+	MinAccountAgeSeconds int64      `json:"minAccountAgeSeconds"`
+	AvailableFrom        *time.Time `json:"availableFrom,omitempty"`
+	AvailableUntil       *time.Time `json:"availableUntil,omitempty"`
+	UpdatedAt            time.Time  `json:"updatedAt"`
+}
+
+// PromoCodeRequest условия промокода. Value процент скидки или сумма в монетах, 0 в maxUses
+// и perUserLimit снимает ограничение, пустой items подходит ко всем товарам
+// swagger:model PromoCodeRequest
+type PromoCodeRequest struct {
+	Kind         string     `json:"kind"`
+	Value        int        `json:"value"`
+	MaxUses      int        `json:"maxUses"`
+	PerUserLimit int        `json:"perUserLimit"`
+	Items        []string   `json:"items,omitempty"`
+	StartsAt     *time.Time `json:"startsAt,omitempty"`
+	EndsAt       *time.Time `json:"endsAt,omitempty"`
+	Active       *bool      `json:"active,omitempty"`
+}
+
+// PromoCode промокод и число его использований
+// swagger:model PromoCode
+type PromoCode struct {
+	Code         string     `json:"code"`
+	Kind         string     `json:"kind"`
+	Value        int        `json:"value"`
+	MaxUses      int        `json:"maxUses"`
+	PerUserLimit int        `json:"perUserLimit"`
+	Items        []string   `json:"items"`
+	StartsAt     time.Time  `json:"startsAt"`
+	EndsAt       *time.Time `json:"endsAt,omitempty"`
+	Active       bool       `json:"active"`
+	Uses         int        `json:"uses"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// SaleRequest распродажа, пустой items означает весь каталог
+// swagger:model SaleRequest
+type SaleRequest struct {
+	Name     string     `json:"name"`
+	Percent  int        `json:"percent"`
+	Items    []string   `json:"items,omitempty"`
+	StartsAt *time.Time `json:"startsAt,omitempty"`
+	EndsAt   time.Time  `json:"endsAt"`
+}
+
+// Sale распродажа
+// swagger:model Sale
+type Sale struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Percent   int       `json:"percent"`
+	Items     []string  `json:"items"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedAt time.Time `json:"createdAt"`
 }
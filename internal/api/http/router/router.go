@@ -6,6 +6,8 @@ import (
 	"merchshop/internal/api/http/auth"
 	"merchshop/internal/api/http/handlers"
 	"merchshop/internal/api/http/middleware"
+	"merchshop/internal/audit"
+	"merchshop/internal/usecase/user"
 
 	_ "merchshop/cmd/docs"
 
@@ -14,17 +16,89 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func NewRouter(h *handlers.Handler, tokenManager auth.TokenManager) *mux.Router {
+// NewRouter recorder получает изменения через админские маршруты и отказы в доступе к ним
+func NewRouter(h *handlers.Handler, tokenManager auth.TokenManager, userUseCase user.UseCase, recorder audit.Recorder) *mux.Router {
 	r := mux.NewRouter()
+	r.Use(middleware.RequestMiddleware)
 
 	r.HandleFunc("/api/auth", h.Auth).Methods(http.MethodPost)
+	// Ссылка на архив подписана и работает без токена
+	r.HandleFunc("/api/exports/{id:[0-9]+}/download", h.DownloadDataExport).Methods(http.MethodGet)
 
 	api := r.PathPrefix("/api").Subrouter()
-	api.Use(middleware.AuthMiddleware(tokenManager))
+	api.Use(middleware.AuthMiddleware(tokenManager, userUseCase))
 
 	api.HandleFunc("/info", h.Info).Methods(http.MethodGet)
 	api.HandleFunc("/sendCoin", h.SendCoin).Methods(http.MethodPost)
+	api.HandleFunc("/sendCoin/batch", h.SendCoinBatch).Methods(http.MethodPost)
+	api.HandleFunc("/transactions/{id:[0-9]+}/reaction", h.React).Methods(http.MethodPut)
 	api.HandleFunc("/buy/{item}", h.Buy).Methods(http.MethodGet)
+	api.HandleFunc("/buy/{item}/gift", h.Gift).Methods(http.MethodPost)
+	api.HandleFunc("/merch", h.ListMerch).Methods(http.MethodGet)
+	api.HandleFunc("/merch/{item}/quote", h.Quote).Methods(http.MethodGet)
+	api.HandleFunc("/orders/{id:[0-9]+}/cancel", h.CancelOrder).Methods(http.MethodPost)
+	api.HandleFunc("/wishlist", h.ListWishlist).Methods(http.MethodGet)
+	api.HandleFunc("/wishlist/{item}", h.AddToWishlist).Methods(http.MethodPut)
+	api.HandleFunc("/wishlist/{item}", h.RemoveFromWishlist).Methods(http.MethodDelete)
+	api.HandleFunc("/notifications", h.ListNotifications).Methods(http.MethodGet)
+	api.HandleFunc("/notifications/read", h.MarkNotificationsRead).Methods(http.MethodPost)
+	api.HandleFunc("/notifications/preferences", h.GetNotificationPreferences).Methods(http.MethodGet)
+	api.HandleFunc("/notifications/preferences", h.SetNotificationPreferences).Methods(http.MethodPut)
+	api.HandleFunc("/stats", h.GetStats).Methods(http.MethodGet)
+	api.HandleFunc("/exports", h.RequestDataExport).Methods(http.MethodPost)
+	api.HandleFunc("/exports/{id:[0-9]+}", h.GetDataExport).Methods(http.MethodGet)
+	api.HandleFunc("/leaderboard/opt-out", h.GetLeaderboardOptOut).Methods(http.MethodGet)
+	api.HandleFunc("/leaderboard/opt-out", h.SetLeaderboardOptOut).Methods(http.MethodPut)
+	api.HandleFunc("/leaderboard/{board}", h.GetLeaderboard).Methods(http.MethodGet)
+	api.HandleFunc("/events", h.Events).Methods(http.MethodGet)
+	api.HandleFunc("/requests", h.CreateCoinRequest).Methods(http.MethodPost)
+	api.HandleFunc("/requests", h.ListCoinRequests).Methods(http.MethodGet)
+	api.HandleFunc("/requests/{id:[0-9]+}/approve", h.ApproveCoinRequest).Methods(http.MethodPost)
+	api.HandleFunc("/requests/{id:[0-9]+}/decline", h.DeclineCoinRequest).Methods(http.MethodPost)
+	api.HandleFunc("/escrow", h.SendEscrow).Methods(http.MethodPost)
+	api.HandleFunc("/escrow", h.ListEscrow).Methods(http.MethodGet)
+	api.HandleFunc("/schedules", h.CreateSchedule).Methods(http.MethodPost)
+	api.HandleFunc("/schedules", h.ListSchedules).Methods(http.MethodGet)
+	api.HandleFunc("/schedules/{id:[0-9]+}/pause", h.PauseSchedule).Methods(http.MethodPost)
+	api.HandleFunc("/schedules/{id:[0-9]+}/resume", h.ResumeSchedule).Methods(http.MethodPost)
+	api.HandleFunc("/schedules/{id:[0-9]+}", h.CancelSchedule).Methods(http.MethodDelete)
+
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.AuditMiddleware(recorder, userUseCase))
+	admin.Use(middleware.AdminMiddleware(userUseCase))
+
+	admin.HandleFunc("/webhooks", h.CreateWebhook).Methods(http.MethodPost)
+	admin.HandleFunc("/webhooks", h.ListWebhooks).Methods(http.MethodGet)
+	admin.HandleFunc("/webhooks/deliveries", h.ListWebhookDeliveries).Methods(http.MethodGet)
+	admin.HandleFunc("/webhooks/{id:[0-9]+}", h.DeleteWebhook).Methods(http.MethodDelete)
+	admin.HandleFunc("/policies", h.ListPolicies).Methods(http.MethodGet)
+	admin.HandleFunc("/policies/{role}", h.SetPolicy).Methods(http.MethodPut)
+	admin.HandleFunc("/policies/{role}", h.DeletePolicy).Methods(http.MethodDelete)
+	admin.HandleFunc("/fraud/cases", h.ListFraudCases).Methods(http.MethodGet)
+	admin.HandleFunc("/fraud/cases/{id:[0-9]+}/dismiss", h.DismissFraudCase).Methods(http.MethodPost)
+	admin.HandleFunc("/fraud/cases/{id:[0-9]+}/freeze", h.FreezeFraudCase).Methods(http.MethodPost)
+	admin.HandleFunc("/users/{id:[0-9]+}/status", h.SetAccountStatus).Methods(http.MethodPut)
+	admin.HandleFunc("/users/{id:[0-9]+}/offboard", h.OffboardUser).Methods(http.MethodPost)
+	admin.HandleFunc("/users/{id:[0-9]+}/erase", h.EraseUser).Methods(http.MethodPost)
+	admin.HandleFunc("/users/{id:[0-9]+}/department", h.SetDepartment).Methods(http.MethodPut)
+	admin.HandleFunc("/users/{id:[0-9]+}/export", h.AdminRequestDataExport).Methods(http.MethodPost)
+	admin.HandleFunc("/merch/{item}/restock", h.RestockMerch).Methods(http.MethodPost)
+	admin.HandleFunc("/merch/{item}/variants/{sku}", h.SaveVariant).Methods(http.MethodPut)
+	admin.HandleFunc("/merch/{item}/variants/{sku}/restock", h.RestockVariant).Methods(http.MethodPost)
+	admin.HandleFunc("/merch/{item}/rule", h.SavePurchaseRule).Methods(http.MethodPut)
+	admin.HandleFunc("/merch/{item}/rule", h.DeletePurchaseRule).Methods(http.MethodDelete)
+	admin.HandleFunc("/rules", h.ListPurchaseRules).Methods(http.MethodGet)
+	admin.HandleFunc("/promos", h.ListPromos).Methods(http.MethodGet)
+	admin.HandleFunc("/promos/{code}", h.SavePromo).Methods(http.MethodPut)
+	admin.HandleFunc("/sales", h.CreateSale).Methods(http.MethodPost)
+	admin.HandleFunc("/sales", h.ListSales).Methods(http.MethodGet)
+	admin.HandleFunc("/sales/{id:[0-9]+}", h.DeleteSale).Methods(http.MethodDelete)
+	admin.HandleFunc("/orders", h.ListOrders).Methods(http.MethodGet)
+	admin.HandleFunc("/reports/{kind}", h.ExportReport).Methods(http.MethodGet)
+	admin.HandleFunc("/orders/{id:[0-9]+}/ready", h.MarkOrderReady).Methods(http.MethodPost)
+	admin.HandleFunc("/orders/{id:[0-9]+}/deliver", h.MarkOrderDelivered).Methods(http.MethodPost)
+	admin.HandleFunc("/orders/{id:[0-9]+}/cancel", h.AdminCancelOrder).Methods(http.MethodPost)
+	admin.HandleFunc("/audit", h.ListAuditLog).Methods(http.MethodGet)
 
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
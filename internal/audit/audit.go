@@ -0,0 +1,276 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	entities "merchshop/internal/entity"
+)
+
+// Действия, которые попадают в журнал. Действия администраторов записываются как "admin:<метод> <маршрут>"
+const (
+	ActionRegister    = "auth.register"
+	ActionLogin       = "auth.login"
+	ActionLoginFailed = "auth.login_failed"
+	// ActionForbidden обращение к админскому маршруту без прав администратора
+	ActionForbidden = "auth.forbidden"
+	ActionTransfer  = "coin.transfer"
+	ActionPurchase  = "merch.purchase"
+	// ActionOrderCancel отмена заказа с возвратом монет оплатившему
+	ActionOrderCancel = "merch.order_cancel"
+	// ActionEscrowHold и ActionEscrowRefund списание монет в удержание и их возврат по истечении срока
+	ActionEscrowHold   = "coin.escrow_hold"
+	ActionEscrowRefund = "coin.escrow_refund"
+
+	ActionAdminPrefix = "admin:"
+)
+
+// Типы объектов действий. Неудачный вход пишется только для существующего аккаунта, целью
+// остается пользователь
+const (
+	TargetUser   = "user"
+	TargetMerch  = "merch"
+	TargetOrder  = "order"
+	TargetEscrow = "escrow"
+)
+
+// Recorder дописывает запись в журнал аудита. ID запроса, IP и автора, если он не указан,
+// берет из контекста. Ошибка записи не прерывает уже выполненную операцию
+type Recorder interface {
+	Record(ctx context.Context, e entities.AuditEntry)
+}
+
+// Discard ничего не записывает
+var Discard Recorder = discard{}
+
+type discard struct{}
+
+func (discard) Record(ctx context.Context, e entities.AuditEntry) {}
+
+// Request откуда пришел запрос. ActorID 0, пока пользователь не прошел аутентификацию
+type Request struct {
+	ID      string
+	IP      string
+	ActorID int
+}
+
+type contextKey struct{}
+
+// WithRequest кладет в контекст данные запроса, их подставляет Recorder
+func WithRequest(ctx context.Context, r Request) context.Context {
+	return context.WithValue(ctx, contextKey{}, r)
+}
+
+// WithActor дополняет данные запроса пользователем, прошедшим аутентификацию
+func WithActor(ctx context.Context, actorID int) context.Context {
+	r := RequestFrom(ctx)
+	r.ActorID = actorID
+
+	return WithRequest(ctx, r)
+}
+
+// RequestFrom данные запроса из контекста. У фоновых задач они пустые
+func RequestFrom(ctx context.Context) Request {
+	r, _ := ctx.Value(contextKey{}).(Request)
+	return r
+}
+
+// Stamp подставляет в запись ID запроса, IP и автора, если он не указан
+func Stamp(ctx context.Context, e entities.AuditEntry) entities.AuditEntry {
+	req := RequestFrom(ctx)
+
+	if e.ActorID == 0 {
+		e.ActorID = req.ActorID
+	}
+
+	e.RequestID = req.ID
+	e.IP = req.IP
+
+	return e
+}
+
+// Transfer запись о переводе. Журнал не стирается, поэтому в нем только ID и сумма, без имен
+// и комментария. Автор тот, кто вызвал перевод, у фоновых переводов его нет
+func Transfer(senderID, receiverID, amount int) entities.AuditEntry {
+	return entities.AuditEntry{
+		Action:     ActionTransfer,
+		TargetType: TargetUser,
+		TargetID:   strconv.Itoa(receiverID),
+		After:      Payload(map[string]int{"senderId": senderID, "receiverId": receiverID, "amount": amount}),
+	}
+}
+
+// Purchase запись о покупке от имени оплатившего. Сообщение к подарку не пишется
+func Purchase(p entities.Purchase) entities.AuditEntry {
+	buyerID := p.UserID
+	if p.IsGift() {
+		buyerID = p.BuyerID
+	}
+
+	return entities.AuditEntry{
+		ActorID:    buyerID,
+		Action:     ActionPurchase,
+		TargetType: TargetMerch,
+		TargetID:   p.MerchName,
+		After: Payload(map[string]any{
+			"orderId": p.ID, "buyerId": buyerID, "userId": p.UserID, "sku": p.SKU, "quantity": p.Quantity,
+			"total": p.TotalPrice,
+		}),
+	}
+}
+
+// OrderCancel запись об отмене заказа. Автор тот, кто отменил: покупатель или администратор
+func OrderCancel(orderID, buyerID int, sku string, quantity, refund int) entities.AuditEntry {
+	return entities.AuditEntry{
+		Action:     ActionOrderCancel,
+		TargetType: TargetOrder,
+		TargetID:   strconv.Itoa(orderID),
+		After: Payload(map[string]any{
+			"orderId": orderID, "buyerId": buyerID, "sku": sku, "quantity": quantity, "refund": refund,
+		}),
+	}
+}
+
+// EscrowHold запись о списании монет в удержание. Имя получателя не пишется, как и в Transfer
+func EscrowHold(e entities.Escrow) entities.AuditEntry {
+	return escrowEntry(ActionEscrowHold, e)
+}
+
+// EscrowRefund запись о возврате удержанных монет отправителю
+func EscrowRefund(e entities.Escrow) entities.AuditEntry {
+	return escrowEntry(ActionEscrowRefund, e)
+}
+
+func escrowEntry(action string, e entities.Escrow) entities.AuditEntry {
+	return entities.AuditEntry{
+		Action:     action,
+		TargetType: TargetEscrow,
+		TargetID:   strconv.Itoa(e.ID),
+		After:      Payload(map[string]int{"escrowId": e.ID, "senderId": e.SenderID, "amount": e.Amount}),
+	}
+}
+
+// NewRequestID ID для запроса, который пришел без своего
+func NewRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// Payload сериализует состояние объекта для before или after, nil остается пустым
+func Payload(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	return b
+}
+
+// redactedKeys поля, значения которых не пишутся в журнал
+var redactedKeys = []string{"password", "secret"}
+
+// Redact прячет пароли и секреты в JSON-объекте. Не JSON отбрасывается
+func Redact(raw []byte) json.RawMessage {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil {
+		if json.Valid(raw) {
+			return raw
+		}
+
+		return nil
+	}
+
+	for _, key := range redactedKeys {
+		if _, ok := object[key]; ok {
+			object[key] = json.RawMessage(`"***"`)
+		}
+	}
+
+	return Payload(object)
+}
+
+// chainRecord то, что покрывает хеш записи. Поля идут в фиксированном порядке
+type chainRecord struct {
+	Prev       string          `json:"prev"`
+	ActorID    int             `json:"actorId"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"targetType"`
+	TargetID   string          `json:"targetId"`
+	RequestID  string          `json:"requestId"`
+	IP         string          `json:"ip"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  string          `json:"createdAt"`
+}
+
+// Hash хеш записи e, следующей за записью с хешем prev. Каждая запись покрывает предыдущую,
+// поэтому изменение или удаление любой записи ломает цепочку после нее. CreatedAt должен быть
+// с точностью до микросекунд, как его хранит Postgres
+func Hash(prev string, e entities.AuditEntry) (string, error) {
+	before, err := Canonical(e.Before)
+	if err != nil {
+		return "", fmt.Errorf("before: %w", err)
+	}
+
+	after, err := Canonical(e.After)
+	if err != nil {
+		return "", fmt.Errorf("after: %w", err)
+	}
+
+	b, err := json.Marshal(chainRecord{
+		Prev:       prev,
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		RequestID:  e.RequestID,
+		IP:         e.IP,
+		Before:     before,
+		After:      after,
+		CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Canonical приводит JSON к одному виду: ключи по алфавиту, без пробелов. JSONB в Postgres
+// переставляет ключи, поэтому хеш считается от канонической формы, а не от исходных байтов
+func Canonical(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return json.RawMessage("null"), nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
@@ -8,9 +8,21 @@ import (
 )
 
 type Config struct {
-	Server ServerConfig
-	DB     DatabaseConfig
-	Auth   AuthConfig
+	Server      ServerConfig
+	GRPC        GRPCConfig
+	DB          DatabaseConfig
+	Auth        AuthConfig
+	Webhook     WebhookConfig
+	Events      EventsConfig
+	Schedule    ScheduleConfig
+	CoinRequest CoinRequestConfig
+	Escrow      EscrowConfig
+	Fraud       FraudConfig
+	Offboarding OffboardingConfig
+	Wishlist    WishlistConfig
+	Leaderboard LeaderboardConfig
+	Export      ExportConfig
+	Audit       AuditConfig
 }
 
 type ServerConfig struct {
@@ -19,6 +31,10 @@ type ServerConfig struct {
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 }
 
+type GRPCConfig struct {
+	Port int `mapstructure:"port"`
+}
+
 type DatabaseConfig struct {
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
@@ -33,6 +49,83 @@ type AuthConfig struct {
 	TokenTTL   time.Duration `mapstructure:"token_ttl"`
 }
 
+type WebhookConfig struct {
+	Timeout          time.Duration `mapstructure:"timeout"`
+	DispatchInterval time.Duration `mapstructure:"dispatch_interval"`
+	MaxAttempts      int           `mapstructure:"max_attempts"`
+	BaseBackoff      time.Duration `mapstructure:"base_backoff"`
+	MaxBackoff       time.Duration `mapstructure:"max_backoff"`
+}
+
+type ScheduleConfig struct {
+	RunInterval time.Duration `mapstructure:"run_interval"`
+	BatchSize   int           `mapstructure:"batch_size"`
+	MaxFailures int           `mapstructure:"max_failures"`
+}
+
+type CoinRequestConfig struct {
+	// TTL время, в течение которого плательщик может ответить на запрос
+	TTL            time.Duration `mapstructure:"ttl"`
+	ExpireInterval time.Duration `mapstructure:"expire_interval"`
+	// MaxPending сколько открытых запросов можно отправить одному плательщику
+	MaxPending int `mapstructure:"max_pending"`
+}
+
+type EscrowConfig struct {
+	// TTL время, после которого невостребованный перевод возвращается отправителю
+	TTL           time.Duration `mapstructure:"ttl"`
+	SweepInterval time.Duration `mapstructure:"sweep_interval"`
+}
+
+type FraudConfig struct {
+	ScanInterval  time.Duration `mapstructure:"scan_interval"`
+	Window        time.Duration `mapstructure:"window"`
+	BurstWindow   time.Duration `mapstructure:"burst_window"`
+	BurstOps      int           `mapstructure:"burst_ops"`
+	FunnelSenders int           `mapstructure:"funnel_senders"`
+}
+
+type WishlistConfig struct {
+	// WatchInterval как часто проверять цены и балансы для уведомлений
+	WatchInterval time.Duration `mapstructure:"watch_interval"`
+}
+
+type LeaderboardConfig struct {
+	// RefreshInterval как часто пересчитывать рейтинги. Рейтинг отстает от операций не больше чем на него
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+type ExportConfig struct {
+	// ProcessInterval как часто собирать выгрузки личных данных из очереди
+	ProcessInterval time.Duration `mapstructure:"process_interval"`
+	// LinkTTL сколько действует ссылка на скачивание, Retention сколько хранится готовый архив
+	LinkTTL   time.Duration `mapstructure:"link_ttl"`
+	Retention time.Duration `mapstructure:"retention"`
+	// SigningKey подписывает ссылки на скачивание. Отдельный от ключа JWT, чтобы ключ одного не давал подделать другое
+	SigningKey string `mapstructure:"signing_key"`
+}
+
+type AuditConfig struct {
+	// FlushInterval как часто переносить в цепочку записи, которые не перенеслись сразу после операции
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+type OffboardingConfig struct {
+	// PoolUsername аккаунт, в который переводится остаток уволенного сотрудника
+	PoolUsername string `mapstructure:"pool_username"`
+}
+
+const (
+	EventsBackendMemory   = "memory"
+	EventsBackendPostgres = "postgres"
+)
+
+type EventsConfig struct {
+	// Backend "memory" для одной реплики или "postgres" (LISTEN/NOTIFY) для нескольких
+	Backend string `mapstructure:"backend"`
+	Channel string `mapstructure:"channel"`
+}
+
 func LoadConfig(path string) (*Config, error) {
 	viper.AddConfigPath(path)
 	viper.SetConfigName("config")
@@ -40,6 +133,24 @@ func LoadConfig(path string) (*Config, error) {
 
 	viper.AutomaticEnv()
 
+	viper.SetDefault("grpc.port", 9090)
+	viper.SetDefault("webhook.timeout", 10*time.Second)
+	viper.SetDefault("webhook.dispatch_interval", 5*time.Second)
+	viper.SetDefault("events.backend", EventsBackendMemory)
+	viper.SetDefault("schedule.run_interval", 30*time.Second)
+	viper.SetDefault("coinrequest.ttl", 72*time.Hour)
+	viper.SetDefault("coinrequest.expire_interval", time.Minute)
+	viper.SetDefault("coinrequest.max_pending", 3)
+	viper.SetDefault("escrow.ttl", 30*24*time.Hour)
+	viper.SetDefault("escrow.sweep_interval", time.Minute)
+	viper.SetDefault("fraud.scan_interval", 5*time.Minute)
+	viper.SetDefault("wishlist.watch_interval", time.Minute)
+	viper.SetDefault("leaderboard.refresh_interval", 10*time.Minute)
+	viper.SetDefault("export.process_interval", 10*time.Second)
+	viper.SetDefault("export.link_ttl", 15*time.Minute)
+	viper.SetDefault("export.retention", 24*time.Hour)
+	viper.SetDefault("audit.flush_interval", 10*time.Second)
+
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
@@ -0,0 +1,49 @@
+package eligibility
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	entities "merchshop/internal/entity"
+)
+
+// ErrNotEligible покупатель не проходит ограничения товара
+var ErrNotEligible = errors.New("not eligible")
+
+// Check проверяет, что buyer может купить quantity штук товара с правилом rule. bought сколько
+// штук покупатель уже купил, не считая отмененных заказов
+func Check(rule entities.PurchaseRule, buyer entities.User, bought, quantity int, now time.Time) error {
+	switch {
+	case rule.AvailableFrom != nil && now.Before(*rule.AvailableFrom):
+		return fmt.Errorf("%w: %s goes on sale at %s", ErrNotEligible, rule.Item, rule.AvailableFrom.Format(time.RFC3339))
+	case rule.AvailableUntil != nil && !now.Before(*rule.AvailableUntil):
+		return fmt.Errorf("%w: %s is no longer on sale", ErrNotEligible, rule.Item)
+	case !allowed(rule.Roles, buyer.Role):
+		return fmt.Errorf("%w: %s is not available for role %s", ErrNotEligible, rule.Item, buyer.Role)
+	case !allowed(rule.Departments, buyer.Department):
+		return fmt.Errorf("%w: %s is not available for your department", ErrNotEligible, rule.Item)
+	case now.Sub(buyer.CreatedAt) < rule.MinAccountAge:
+		return fmt.Errorf("%w: %s requires an account older than %s", ErrNotEligible, rule.Item, rule.MinAccountAge)
+	case rule.MaxPerUser > 0 && bought+quantity > rule.MaxPerUser:
+		return fmt.Errorf("%w: %s is limited to %d per person, already bought %d", ErrNotEligible, rule.Item,
+			rule.MaxPerUser, bought)
+	}
+
+	return nil
+}
+
+// allowed пустой список разрешает всем
+func allowed(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package eligibility_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/eligibility"
+	entities "merchshop/internal/entity"
+)
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	buyer := entities.User{Role: "employee", Department: "sales", CreatedAt: now.AddDate(0, -2, 0)}
+
+	tests := []struct {
+		name   string
+		rule   entities.PurchaseRule
+		bought int
+		err    string
+	}{
+		{"no limits", entities.PurchaseRule{Item: "cup"}, 10, ""},
+		{"within limit", entities.PurchaseRule{Item: "cup", MaxPerUser: 2}, 1, ""},
+		{"over limit", entities.PurchaseRule{Item: "cup", MaxPerUser: 1}, 1,
+			"not eligible: cup is limited to 1 per person, already bought 1"},
+		{"role", entities.PurchaseRule{Item: "cup", Roles: []string{"admin"}}, 0,
+			"not eligible: cup is not available for role employee"},
+		{"department", entities.PurchaseRule{Item: "cup", Departments: []string{"sales", "hr"}}, 0, ""},
+		{"other department", entities.PurchaseRule{Item: "cup", Departments: []string{"hr"}}, 0,
+			"not eligible: cup is not available for your department"},
+		{"tenure", entities.PurchaseRule{Item: "cup", MinAccountAge: 90 * 24 * time.Hour}, 0,
+			"not eligible: cup requires an account older than 2160h0m0s"},
+		{"not released", entities.PurchaseRule{Item: "cup", AvailableFrom: &later}, 0,
+			"not eligible: cup goes on sale at 2024-05-01T13:00:00Z"},
+		{"window closed", entities.PurchaseRule{Item: "cup", AvailableUntil: &earlier}, 0,
+			"not eligible: cup is no longer on sale"},
+		{"window open", entities.PurchaseRule{Item: "cup", AvailableFrom: &earlier, AvailableUntil: &later}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := eligibility.Check(tt.rule, buyer, tt.bought, 1, now)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, tt.err)
+			assert.ErrorIs(t, err, eligibility.ErrNotEligible)
+		})
+	}
+}
@@ -1,18 +1,83 @@
 package entity
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	RoleEmployee = "employee"
+	RoleAdmin    = "admin"
+)
+
+const (
+	UserActive      = "active"
+	UserFrozen      = "frozen"
+	UserDeactivated = "deactivated"
+)
 
 type User struct {
-	ID        int
-	Username  string
-	Password  string
-	Balance   int
-	CreatedAt time.Time
+	ID         int
+	Username   string
+	Password   string
+	Balance    int
+	Role       string
+	Department string
+	Status     string
+	CreatedAt  time.Time
+
+	// TokensRevokedAt токены, выданные до этого момента, недействительны
+	TokensRevokedAt *time.Time
+}
+
+// DeletedUsername имя, под которым стертый пользователь виден в истории. К нему добавляется
+// номер аккаунта, потому что имена уникальны
+const DeletedUsername = "deleted user"
+
+// Erasure запись о стирании персональных данных пользователя
+type Erasure struct {
+	UserID   int
+	ErasedBy int
+	// Username обезличенное имя, старое нигде не сохраняется
+	Username string
+	Reason   string
+	ErasedAt time.Time
+}
+
+// Offboarding итог деактивации аккаунта
+type Offboarding struct {
+	UserID int
+	// PoolID и TransactionID заполнены, если остаток переведен в пул
+	PoolID        int
+	Swept         int
+	TransactionID int
+	DeactivatedAt time.Time
 }
 
 type Merchandise struct {
 	Name  string
 	Price int
+	// Stock у товара с вариантами равен сумме остатков вариантов, собственный остаток такого товара не продается
+	Stock int
+	// LowStockThreshold при падении остатка до этого значения отправляется предупреждение
+	LowStockThreshold int
+
+	// Variants варианты товара. Товар с вариантами покупается только по артикулу варианта
+	Variants []Variant
+}
+
+// Variant вариант товара (размер, цвет) со своим артикулом и остатком
+type Variant struct {
+	SKU        string
+	MerchName  string
+	Attributes map[string]string
+	// PriceOverride своя цена варианта, Price итоговая цена с учетом цены товара
+	PriceOverride     *int
+	Price             int
+	Stock             int
+	LowStockThreshold int
+	// Default вариант продается при покупке по названию товара
+	Default bool
 }
 
 type Transaction struct {
@@ -22,14 +87,448 @@ type Transaction struct {
 	SenderName   string
 	ReceiverName string
 	Amount       int
+	BatchID      int
+	Memo         string
+	Reaction     string
 	CreatedAt    time.Time
+
+	// Items переводы пакета, если запись объединяет пакетный перевод отправителя
+	Items []Transaction
 }
 
+type BatchTransferItem struct {
+	ReceiverID int
+	Amount     int
+	Memo       string
+}
+
+const (
+	OrderPlaced    = "placed"
+	OrderReady     = "ready_for_pickup"
+	OrderDelivered = "delivered"
+	OrderCancelled = "cancelled"
+)
+
+// Purchase покупка и заказ на выдачу товара. Отмененный до выдачи заказ возвращает монеты покупателю
 type Purchase struct {
 	ID         int
 	UserID     int
+	Username   string
 	MerchName  string
+	SKU        string
+	Attributes map[string]string
 	Quantity   int
 	TotalPrice int
 	CreatedAt  time.Time
+
+	// ListPrice цена без скидок, Discount скидка распродажи и промокода PromoCode
+	ListPrice int
+	Discount  int
+	PromoCode string
+
+	Status         string
+	PickupLocation string
+	ReadyAt        *time.Time
+	DeliveredAt    *time.Time
+	CancelledAt    *time.Time
+
+	// BuyerID оплативший подарок пользователь, у обычной покупки 0. Товар получает UserID
+	BuyerID     int
+	BuyerName   string
+	GiftMessage string
+}
+
+// IsGift покупка оплачена другим пользователем
+func (p Purchase) IsGift() bool {
+	return p.BuyerID != 0
+}
+
+const (
+	PromoPercent = "percent"
+	PromoFixed   = "fixed"
+)
+
+// PromoCode промокод на скидку в процентах или фиксированную сумму с заказа. Items ограничивает
+// товары, пустой список подходит ко всем. Нулевые MaxUses и PerUserLimit снимают ограничение.
+// Uses число покупок с промокодом без отмененных
+type PromoCode struct {
+	Code         string
+	Kind         string
+	Value        int
+	MaxUses      int
+	PerUserLimit int
+	Items        []string
+	StartsAt     time.Time
+	EndsAt       *time.Time
+	Active       bool
+	Uses         int
+	CreatedAt    time.Time
+}
+
+// Sale распродажа со скидкой Percent на товары Items, пустой список означает весь каталог
+type Sale struct {
+	ID        int
+	Name      string
+	Percent   int
+	Items     []string
+	StartsAt  time.Time
+	EndsAt    time.Time
+	CreatedAt time.Time
+}
+
+// PurchaseRule ограничения на покупку товара Item. Нулевые и пустые поля не ограничивают
+type PurchaseRule struct {
+	Item string
+	// MaxPerUser сколько штук товара один пользователь может купить за все время, не считая отмененных заказов
+	MaxPerUser  int
+	Roles       []string
+	Departments []string
+	// MinAccountAge минимальный стаж: сколько времени прошло с регистрации аккаунта
+	MinAccountAge  time.Duration
+	AvailableFrom  *time.Time
+	AvailableUntil *time.Time
+	UpdatedAt      time.Time
+}
+
+const (
+	NotifyCoinReceived = "coin.received"
+	NotifyGiftReceived = "gift.received"
+	NotifyOrderUpdated = "order.updated"
+	NotifyPriceDrop    = "wishlist.price_drop"
+	NotifyAffordable   = "wishlist.affordable"
+)
+
+// NotificationKinds типы уведомлений, которые пользователь может отключить
+var NotificationKinds = []string{
+	NotifyCoinReceived, NotifyGiftReceived, NotifyOrderUpdated, NotifyPriceDrop, NotifyAffordable,
+}
+
+// NotificationPreference включен ли у пользователя тип уведомлений Kind
+type NotificationPreference struct {
+	Kind    string
+	Enabled bool
+}
+
+// Notification уведомление в приложении. Data подробности для клиента, зависят от Kind
+type Notification struct {
+	ID        int
+	UserID    int
+	Kind      string
+	Message   string
+	Data      map[string]any
+	ReadAt    *time.Time
+	CreatedAt time.Time
+}
+
+// WishlistItem товар, на который копит пользователь. Item артикул варианта или название товара
+// без вариантов
+type WishlistItem struct {
+	UserID    int
+	Item      string
+	MerchName string
+	SKU       string
+	// ListPrice цена без скидок, SalePercent скидка лучшей действующей распродажи
+	ListPrice   int
+	SalePercent int
+	Balance     int
+	// LastPrice цена, о которой пользователь уже знает. AffordableNotified пользователь уже
+	// получил уведомление, что монет хватает
+	LastPrice          int
+	AffordableNotified bool
+	AddedAt            time.Time
+}
+
+// Рейтинги: кто больше всех отправил, получил и потратил монет
+const (
+	BoardSenders   = "senders"
+	BoardReceivers = "receivers"
+	BoardSpenders  = "spenders"
+)
+
+// Периоды рейтингов: неделя и месяц отсчитываются назад от последнего пересчета
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+	PeriodAll   = "all"
+)
+
+var (
+	LeaderboardBoards  = []string{BoardSenders, BoardReceivers, BoardSpenders}
+	LeaderboardPeriods = []string{PeriodWeek, PeriodMonth, PeriodAll}
+)
+
+// LeaderboardEntry место пользователя в рейтинге. RefreshedAt когда рейтинг пересчитывался
+type LeaderboardEntry struct {
+	Rank        int
+	UserID      int
+	Username    string
+	Total       int
+	RefreshedAt time.Time
+}
+
+// PeriodTotals сколько монет пользователь отправил, получил и потратил за период Period
+type PeriodTotals struct {
+	Period   string
+	Sent     int
+	Received int
+	Spent    int
+}
+
+// Counterparty с кем пользователь обменивался монетами: Sent отправлено ему, Received получено от него
+type Counterparty struct {
+	UserID    int
+	Username  string
+	Sent      int
+	Received  int
+	Transfers int
+}
+
+// ItemSpending сколько монет пользователь потратил на товар Item, включая оплаченные подарки
+type ItemSpending struct {
+	Item     string
+	Quantity int
+	Spent    int
+	Orders   int
+}
+
+// BalanceChange на сколько изменился баланс пользователя за день Day
+type BalanceChange struct {
+	Day   time.Time
+	Delta int
+}
+
+// ReportFilter отбор строк отчета. Нулевые поля не ограничивают. Username отбирает операции,
+// где пользователь участвует с любой стороны, Item только покупки товара
+type ReportFilter struct {
+	From     *time.Time
+	To       *time.Time
+	Username string
+	Item     string
+}
+
+// UserBalance строка отчета по балансам: текущий баланс и обороты за период отчета
+type UserBalance struct {
+	UserID     int
+	Username   string
+	Department string
+	Status     string
+	Balance    int
+	Received   int
+	Sent       int
+	Spent      int
+}
+
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+	ExportExpired = "expired"
+)
+
+// DataExport выгрузка личных данных пользователя UserID. RequestedBy сам пользователь или
+// администратор. Готовый архив можно скачать до ExpiresAt
+type DataExport struct {
+	ID          int
+	UserID      int
+	RequestedBy int
+	Status      string
+	Size        int
+	Error       string
+	CreatedAt   time.Time
+	ReadyAt     *time.Time
+	ExpiresAt   *time.Time
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+type Webhook struct {
+	ID        int
+	URL       string
+	Events    []string
+	Secret    string
+	Active    bool
+	CreatedAt time.Time
+}
+
+type WebhookDelivery struct {
+	ID             int
+	WebhookID      int
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+const (
+	ScheduleActive    = "active"
+	SchedulePaused    = "paused"
+	ScheduleCancelled = "cancelled"
+	ScheduleCompleted = "completed"
+	ScheduleFailed    = "failed"
+)
+
+const (
+	RunRunning           = "running"
+	RunSucceeded         = "succeeded"
+	RunInsufficientFunds = "insufficient_funds"
+	RunFailed            = "failed"
+)
+
+// ScheduledTransfer отложенный или повторяющийся перевод. Cron пустой у разового перевода
+type ScheduledTransfer struct {
+	ID           int
+	SenderID     int
+	ReceiverID   int
+	ReceiverName string
+	Amount       int
+	Memo         string
+	Cron         string
+	Status       string
+	NextRunAt    time.Time
+	LastRunAt    *time.Time
+	LastError    string
+	Failures     int
+	CreatedAt    time.Time
+}
+
+const (
+	RequestPending  = "pending"
+	RequestApproved = "approved"
+	RequestDeclined = "declined"
+	RequestExpired  = "expired"
+)
+
+// CoinRequest запрос монет: RequesterID просит PayerID перевести Amount
+type CoinRequest struct {
+	ID            int
+	RequesterID   int
+	PayerID       int
+	RequesterName string
+	PayerName     string
+	Amount        int
+	Memo          string
+	Status        string
+	TransactionID int
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+	ResolvedAt    *time.Time
+}
+
+const (
+	EscrowHeld     = "held"
+	EscrowClaimed  = "claimed"
+	EscrowRefunded = "refunded"
+)
+
+// Escrow перевод пользователю, который еще ни разу не входил. Монеты списаны с отправителя
+// и ждут регистрации Username, после истечения ExpiresAt возвращаются отправителю
+type Escrow struct {
+	ID            int
+	SenderID      int
+	SenderName    string
+	Username      string
+	ReceiverID    int
+	Amount        int
+	Memo          string
+	Status        string
+	TransactionID int
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+	ResolvedAt    *time.Time
+}
+
+// TransferPolicy ограничения исходящих операций для роли. Нулевое значение поля снимает ограничение
+type TransferPolicy struct {
+	Role           string
+	DailyLimit     int
+	WeeklyLimit    int
+	MaxTransfer    int
+	MinAccountAge  time.Duration
+	VelocityCount  int
+	VelocityWindow time.Duration
+	UpdatedAt      time.Time
+}
+
+// PolicyUsage исходящие монеты и число операций пользователя в окнах политики
+type PolicyUsage struct {
+	Day        int
+	Week       int
+	Operations int
+}
+
+const (
+	FraudRing   = "ring"
+	FraudBurst  = "burst"
+	FraudFunnel = "funnel"
+)
+
+const (
+	CaseOpen      = "open"
+	CaseDismissed = "dismissed"
+	CaseFrozen    = "frozen"
+)
+
+// FraudCase подозрительный паттерн переводов. У кольца UserIDs идут по направлению перевода,
+// у воронки первым идет получатель. LastTransactionID отличает новую активность тех же
+// участников от уже рассмотренной
+type FraudCase struct {
+	ID                int
+	Kind              string
+	UserIDs           []int
+	Usernames         []string
+	LastTransactionID int
+	Transfers         int
+	Amount            int
+	Status            string
+	CreatedAt         time.Time
+	ResolvedAt        *time.Time
+	ResolvedBy        int
+}
+
+// AuditEntry запись журнала аудита. ActorID 0 у действий системы и неудачных входов.
+// Before и After состояние объекта до и после действия, любое из них может быть пустым.
+// Hash покрывает запись и PrevHash, хеш предыдущей записи
+type AuditEntry struct {
+	ID         int
+	ActorID    int
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	IP         string
+	Before     json.RawMessage
+	After      json.RawMessage
+	CreatedAt  time.Time
+	PrevHash   string
+	Hash       string
+}
+
+// AuditFilter отбор записей журнала, пустые поля не ограничивают. Action отбирает по префиксу,
+// UserID записи, где пользователь автор или объект действия
+type AuditFilter struct {
+	ActorID    int
+	Action     string
+	TargetType string
+	TargetID   string
+	UserID     int
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditVerification итог проверки цепочки. BrokenID первая запись, на которой цепочка не сходится,
+// Head хеш последней проверенной записи
+type AuditVerification struct {
+	Entries  int
+	Head     string
+	BrokenID int
+	Problem  string
 }
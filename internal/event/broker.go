@@ -0,0 +1,67 @@
+package event
+
+import (
+	"context"
+	"sync"
+)
+
+const defaultSubscriberBuffer = 16
+
+// Broker раздает события подключенным клиентам текущего процесса (например, SSE-потокам).
+// Медленный подписчик не блокирует публикацию: если его буфер заполнен, событие для него теряется
+type Broker struct {
+	mu     sync.RWMutex
+	subs   map[int]map[chan Event]struct{}
+	buffer int
+}
+
+func NewBroker(buffer int) *Broker {
+	if buffer <= 0 {
+		buffer = defaultSubscriberBuffer
+	}
+
+	return &Broker{
+		subs:   make(map[int]map[chan Event]struct{}),
+		buffer: buffer,
+	}
+}
+
+// Subscribe подписывает на события пользователя. Возвращенную функцию нужно вызвать при отключении клиента
+func (b *Broker) Subscribe(userID int) (<-chan Event, func()) {
+	ch := make(chan Event, b.buffer)
+
+	b.mu.Lock()
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[chan Event]struct{})
+	}
+	b.subs[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(b.subs[userID], ch)
+			if len(b.subs[userID]) == 0 {
+				delete(b.subs, userID)
+			}
+
+			close(ch)
+		})
+	}
+}
+
+func (b *Broker) Publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subs[e.UserID] {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
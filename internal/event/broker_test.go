@@ -0,0 +1,72 @@
+package event_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/event"
+)
+
+func TestBroker_DeliversOnlyToAddressee(t *testing.T) {
+	b := event.NewBroker(4)
+
+	alice, unsubAlice := b.Subscribe(1)
+	defer unsubAlice()
+
+	bob, unsubBob := b.Subscribe(2)
+	defer unsubBob()
+
+	b.Publish(context.Background(), event.Event{Type: event.CoinReceived, UserID: 2})
+
+	assert.Len(t, bob, 1)
+	assert.Len(t, alice, 0)
+	assert.Equal(t, event.CoinReceived, (<-bob).Type)
+}
+
+func TestBroker_SlowSubscriberDoesNotBlock(t *testing.T) {
+	b := event.NewBroker(1)
+
+	ch, unsubscribe := b.Subscribe(1)
+	defer unsubscribe()
+
+	b.Publish(context.Background(), event.Event{Type: event.CoinSent, UserID: 1})
+	b.Publish(context.Background(), event.Event{Type: event.CoinReceived, UserID: 1})
+
+	assert.Len(t, ch, 1)
+	assert.Equal(t, event.CoinSent, (<-ch).Type)
+}
+
+func TestBroker_UnsubscribeClosesChannel(t *testing.T) {
+	b := event.NewBroker(1)
+
+	ch, unsubscribe := b.Subscribe(1)
+	unsubscribe()
+	unsubscribe()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	b.Publish(context.Background(), event.Event{Type: event.CoinSent, UserID: 1})
+}
+
+func TestBus_FansOutToSubscribers(t *testing.T) {
+	bus := event.NewBus()
+	b1 := event.NewBroker(1)
+	b2 := event.NewBroker(1)
+	bus.Subscribe(b1)
+	bus.Subscribe(b2)
+
+	ch1, unsub1 := b1.Subscribe(3)
+	defer unsub1()
+
+	ch2, unsub2 := b2.Subscribe(3)
+	defer unsub2()
+
+	bus.Publish(context.Background(), event.Event{Type: event.PurchaseCompleted, UserID: 3})
+
+	e := <-ch1
+	assert.False(t, e.OccurredAt.IsZero())
+	assert.Equal(t, event.PurchaseCompleted, (<-ch2).Type)
+}
@@ -0,0 +1,179 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	CoinSent          Type = "coin.sent"
+	CoinReceived      Type = "coin.received"
+	PurchaseCompleted Type = "purchase.completed"
+	CoinReaction      Type = "coin.reaction"
+	ScheduleFailed    Type = "schedule.failed"
+	RequestCreated    Type = "request.created"
+	RequestResolved   Type = "request.resolved"
+	UserRegistered    Type = "user.registered"
+	EscrowClaimed     Type = "escrow.claimed"
+	EscrowRefunded    Type = "escrow.refunded"
+	UserDeactivated   Type = "user.deactivated"
+	MerchLowStock     Type = "merch.low_stock"
+	OrderUpdated      Type = "order.updated"
+	GiftReceived      Type = "gift.received"
+)
+
+// Types все типы событий, на которые можно подписаться
+var Types = []Type{
+	CoinSent, CoinReceived, PurchaseCompleted, CoinReaction, ScheduleFailed, RequestCreated, RequestResolved,
+	UserRegistered, EscrowClaimed, EscrowRefunded, UserDeactivated, MerchLowStock, OrderUpdated,
+	GiftReceived,
+}
+
+func IsKnown(t Type) bool {
+	for _, known := range Types {
+		if known == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Event доменное событие, которое usecase'ы публикуют после успешной операции
+type Event struct {
+	Type       Type      `json:"type"`
+	UserID     int       `json:"userId"`
+	Data       any       `json:"data"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+type CoinTransfer struct {
+	FromUser string `json:"fromUser"`
+	ToUser   string `json:"toUser"`
+	Amount   int    `json:"amount"`
+	BatchID  int    `json:"batchId,omitempty"`
+	Memo     string `json:"memo,omitempty"`
+}
+
+type Reaction struct {
+	TransactionID int    `json:"transactionId"`
+	FromUser      string `json:"fromUser"`
+	Reaction      string `json:"reaction"`
+}
+
+// ScheduleFailure неудачное срабатывание запланированного перевода
+type ScheduleFailure struct {
+	ScheduleID int    `json:"scheduleId"`
+	ToUser     string `json:"toUser"`
+	Amount     int    `json:"amount"`
+	Reason     string `json:"reason"`
+	Status     string `json:"status"`
+}
+
+// CoinRequest запрос монет FromUser к ToUser и его статус
+type CoinRequest struct {
+	RequestID int    `json:"requestId"`
+	FromUser  string `json:"fromUser"`
+	ToUser    string `json:"toUser"`
+	Amount    int    `json:"amount"`
+	Memo      string `json:"memo,omitempty"`
+	Status    string `json:"status"`
+}
+
+type Registration struct {
+	Username string `json:"username"`
+}
+
+// LowStock остаток товара опустился до порога. Событие не привязано к пользователю
+type LowStock struct {
+	Item      string `json:"item"`
+	Stock     int    `json:"stock"`
+	Threshold int    `json:"threshold"`
+}
+
+// Offboarding деактивация аккаунта. PoolUser и Swept заполнены, если остаток переведен в пул
+type Offboarding struct {
+	Username string `json:"username"`
+	PoolUser string `json:"poolUser,omitempty"`
+	Swept    int    `json:"swept,omitempty"`
+}
+
+// Escrow перевод, удерживаемый до регистрации получателя
+type Escrow struct {
+	EscrowID int    `json:"escrowId"`
+	ToUser   string `json:"toUser"`
+	Amount   int    `json:"amount"`
+	Status   string `json:"status"`
+}
+
+type Purchase struct {
+	Item       string `json:"item"`
+	SKU        string `json:"sku,omitempty"`
+	Quantity   int    `json:"quantity"`
+	TotalPrice int    `json:"totalPrice"`
+	ListPrice  int    `json:"listPrice"`
+	Discount   int    `json:"discount,omitempty"`
+	PromoCode  string `json:"promoCode,omitempty"`
+	// ToUser получатель, если покупка оплачена в подарок
+	ToUser string `json:"toUser,omitempty"`
+}
+
+// Gift подарок, полученный от FromUser
+type Gift struct {
+	OrderID  int    `json:"orderId"`
+	FromUser string `json:"fromUser"`
+	Item     string `json:"item"`
+	SKU      string `json:"sku,omitempty"`
+	Quantity int    `json:"quantity"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Order смена статуса заказа. Refund заполнен у отмененного заказа
+type Order struct {
+	OrderID        int    `json:"orderId"`
+	Item           string `json:"item"`
+	SKU            string `json:"sku,omitempty"`
+	Quantity       int    `json:"quantity"`
+	Status         string `json:"status"`
+	PickupLocation string `json:"pickupLocation,omitempty"`
+	Refund         int    `json:"refund,omitempty"`
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, e Event)
+}
+
+// Bus раздает события всем подписчикам
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []Publisher
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(p Publisher) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers = append(b.subscribers, p)
+}
+
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+
+	// Подписчик может сам опубликовать событие, поэтому блокировка не держится во время рассылки
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+
+	for _, s := range subscribers {
+		s.Publish(ctx, e)
+	}
+}
@@ -0,0 +1,86 @@
+package event
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const DefaultNotifyChannel = "merchshop_events"
+
+// PostgresBackend пересылает события через LISTEN/NOTIFY, чтобы их получили клиенты,
+// подключенные к любой реплике. Publish отправляет NOTIFY, Listen доставляет полученное в локальный брокер
+type PostgresBackend struct {
+	db      *sql.DB
+	dsn     string
+	channel string
+	local   Publisher
+}
+
+func NewPostgresBackend(db *sql.DB, dsn, channel string, local Publisher) *PostgresBackend {
+	if channel == "" {
+		channel = DefaultNotifyChannel
+	}
+
+	return &PostgresBackend{
+		db:      db,
+		dsn:     dsn,
+		channel: channel,
+		local:   local,
+	}
+}
+
+func (p *PostgresBackend) Publish(ctx context.Context, e Event) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("events: failed to marshal %s event: %v", e.Type, err)
+		return
+	}
+
+	if _, err := p.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, p.channel, string(payload)); err != nil {
+		log.Printf("events: failed to notify %s event: %v", e.Type, err)
+	}
+}
+
+// Listen блокируется до отмены ctx
+func (p *PostgresBackend) Listen(ctx context.Context) error {
+	listener := pq.NewListener(p.dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("events: listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(p.channel); err != nil {
+		return fmt.Errorf("listen %s: %w", p.channel, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			// nil приходит после переподключения, события за время разрыва потеряны
+			if n == nil {
+				continue
+			}
+
+			var e Event
+			if err := json.Unmarshal([]byte(n.Extra), &e); err != nil {
+				log.Printf("events: failed to unmarshal notification: %v", err)
+				continue
+			}
+
+			p.local.Publish(ctx, e)
+		case <-time.After(90 * time.Second):
+			if err := listener.Ping(); err != nil {
+				log.Printf("events: listener ping: %v", err)
+			}
+		}
+	}
+}
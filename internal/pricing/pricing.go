@@ -0,0 +1,97 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	entities "merchshop/internal/entity"
+)
+
+// ErrInvalidPromo промокод не существует или не подходит к покупке
+var ErrInvalidPromo = errors.New("invalid promo code")
+
+// Quote цена покупки после скидок. ListPrice цена всего количества без скидок
+type Quote struct {
+	ListPrice int
+	Discount  int
+	Total     int
+	SaleID    int
+	PromoCode string
+}
+
+// NormalizeCode промокоды не зависят от регистра и пробелов по краям
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// CheckPromo проверяет, что промокод можно применить к товару item. userUses сколько раз
+// покупатель уже использовал промокод
+func CheckPromo(p entities.PromoCode, item string, userUses int, now time.Time) error {
+	switch {
+	case !p.Active:
+		return fmt.Errorf("%w: %s is disabled", ErrInvalidPromo, p.Code)
+	case now.Before(p.StartsAt):
+		return fmt.Errorf("%w: %s is not active yet", ErrInvalidPromo, p.Code)
+	case p.EndsAt != nil && !now.Before(*p.EndsAt):
+		return fmt.Errorf("%w: %s has expired", ErrInvalidPromo, p.Code)
+	case p.MaxUses > 0 && p.Uses >= p.MaxUses:
+		return fmt.Errorf("%w: %s has been used up", ErrInvalidPromo, p.Code)
+	case p.PerUserLimit > 0 && userUses >= p.PerUserLimit:
+		return fmt.Errorf("%w: %s already used %d times", ErrInvalidPromo, p.Code, userUses)
+	case !Applies(p.Items, item):
+		return fmt.Errorf("%w: %s does not apply to %s", ErrInvalidPromo, p.Code, item)
+	}
+
+	return nil
+}
+
+// Applies пустой список товаров подходит ко всему каталогу
+func Applies(items []string, item string) bool {
+	if len(items) == 0 {
+		return true
+	}
+
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply считает цену quantity единиц по цене unitPrice. Распродажа снижает цену единицы,
+// промокод применяется к сумме после распродажи. Итог не опускается ниже нуля
+func Apply(unitPrice, quantity int, sale *entities.Sale, promo *entities.PromoCode) Quote {
+	q := Quote{ListPrice: unitPrice * quantity}
+
+	unit := unitPrice
+	if sale != nil {
+		unit -= unitPrice * sale.Percent / 100
+		q.SaleID = sale.ID
+	}
+
+	total := unit * quantity
+
+	if promo != nil {
+		switch promo.Kind {
+		case entities.PromoPercent:
+			total -= total * promo.Value / 100
+		case entities.PromoFixed:
+			total -= promo.Value
+		}
+
+		q.PromoCode = promo.Code
+	}
+
+	if total < 0 {
+		total = 0
+	}
+
+	q.Total = total
+	q.Discount = q.ListPrice - total
+
+	return q
+}
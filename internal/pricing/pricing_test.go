@@ -0,0 +1,83 @@
+package pricing_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/pricing"
+)
+
+func TestApply(t *testing.T) {
+	sale := &entities.Sale{ID: 3, Percent: 15}
+	percent := &entities.PromoCode{Code: "TEN", Kind: entities.PromoPercent, Value: 10}
+	fixed := &entities.PromoCode{Code: "MINUS50", Kind: entities.PromoFixed, Value: 50}
+
+	tests := []struct {
+		name  string
+		sale  *entities.Sale
+		promo *entities.PromoCode
+		want  pricing.Quote
+	}{
+		{"list price", nil, nil, pricing.Quote{ListPrice: 160, Total: 160}},
+		{"sale per unit", sale, nil, pricing.Quote{ListPrice: 160, Discount: 24, Total: 136, SaleID: 3}},
+		{"percent promo", nil, percent, pricing.Quote{ListPrice: 160, Discount: 16, Total: 144, PromoCode: "TEN"}},
+		{"promo after sale", sale, percent, pricing.Quote{ListPrice: 160, Discount: 37, Total: 123, SaleID: 3, PromoCode: "TEN"}},
+		{"fixed promo", nil, fixed, pricing.Quote{ListPrice: 160, Discount: 50, Total: 110, PromoCode: "MINUS50"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, pricing.Apply(80, 2, tc.sale, tc.promo))
+		})
+	}
+
+	// Фиксированная скидка больше цены делает покупку бесплатной, но не отрицательной
+	q := pricing.Apply(10, 1, nil, fixed)
+	assert.Equal(t, 0, q.Total)
+	assert.Equal(t, 10, q.Discount)
+}
+
+func TestCheckPromo(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	ended := now.Add(-time.Hour)
+
+	valid := entities.PromoCode{
+		Code:         "SPRING",
+		Kind:         entities.PromoPercent,
+		Value:        20,
+		MaxUses:      100,
+		PerUserLimit: 1,
+		Items:        []string{"hoody", "cup"},
+		StartsAt:     now.Add(-24 * time.Hour),
+		Active:       true,
+		Uses:         5,
+	}
+
+	assert.NoError(t, pricing.CheckPromo(valid, "cup", 0, now))
+
+	tests := []struct {
+		name     string
+		modify   func(p *entities.PromoCode)
+		item     string
+		userUses int
+	}{
+		{"disabled", func(p *entities.PromoCode) { p.Active = false }, "cup", 0},
+		{"not started", func(p *entities.PromoCode) { p.StartsAt = now.Add(time.Minute) }, "cup", 0},
+		{"expired", func(p *entities.PromoCode) { p.EndsAt = &ended }, "cup", 0},
+		{"used up", func(p *entities.PromoCode) { p.Uses = 100 }, "cup", 0},
+		{"per user limit", func(p *entities.PromoCode) {}, "cup", 1},
+		{"other item", func(p *entities.PromoCode) {}, "pen", 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := valid
+			tc.modify(&p)
+
+			assert.ErrorIs(t, pricing.CheckPromo(p, tc.item, tc.userUses, now), pricing.ErrInvalidPromo)
+		})
+	}
+}
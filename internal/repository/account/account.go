@@ -0,0 +1,276 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"merchshop/internal/audit"
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/auditlog"
+	"merchshop/internal/repository/user"
+)
+
+// ErrAlreadyErased данные пользователя уже стерты
+var ErrAlreadyErased = errors.New("user is already erased")
+
+type Repository interface {
+	// SetStatus меняет статус аккаунта, если пользователя нет, возвращает sql.ErrNoRows.
+	// Деактивированный аккаунт не меняется, для него возвращается user.ErrAccountDeactivated
+	SetStatus(ctx context.Context, userID int, status string) error
+	// SetDepartment меняет отдел сотрудника, если пользователя нет, возвращает sql.ErrNoRows
+	SetDepartment(ctx context.Context, userID int, department string) error
+	// Offboard деактивирует аккаунт и отзывает его токены. Если poolID не 0, остаток баланса
+	// переводится в пул обычным переводом в той же транзакции
+	Offboard(ctx context.Context, userID, poolID int, memo string) (*entities.Offboarding, error)
+	// Erase обезличивает деактивированный аккаунт: меняет имя на username, стирает пароль и удаляет
+	// личные данные, которые не входят в историю операций. Старое имя заменяется в уведомлениях
+	// контрагентов о переводах и подарках и в полях с именами в доставках вебхуков.
+	// Комментарии к переводам и подаркам остаются как есть.
+	// Повторное стирание дает ErrAlreadyErased
+	Erase(ctx context.Context, userID, erasedBy int, username, reason string) (*entities.Erasure, error)
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewAccountRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+func (r *Repo) SetStatus(ctx context.Context, userID int, status string) error {
+	const query = `
+        UPDATE users
+        SET status = $2
+        WHERE id = $1 AND status <> 'deactivated'`
+
+	err := r.updateUser(ctx, query, userID, status)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Отличаем отсутствующего пользователя от уволенного
+		var current string
+		if err := r.db.QueryRowContext(ctx, `SELECT status FROM users WHERE id = $1`, userID).Scan(&current); err != nil {
+			return fmt.Errorf("set status of user %d: %w", userID, err)
+		}
+
+		return fmt.Errorf("set status of user %d: %w", userID, user.ErrAccountDeactivated)
+	}
+
+	if err != nil {
+		return fmt.Errorf("set status of user %d: %w", userID, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) SetDepartment(ctx context.Context, userID int, department string) error {
+	const query = `
+        UPDATE users
+        SET department = $2
+        WHERE id = $1`
+
+	if err := r.updateUser(ctx, query, userID, department); err != nil {
+		return fmt.Errorf("set department of user %d: %w", userID, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) updateUser(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *Repo) Offboard(ctx context.Context, userID, poolID int, memo string) (*entities.Offboarding, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	const deactivate = `
+        UPDATE users
+        SET status = 'deactivated', tokens_revoked_at = NOW()
+        WHERE id = $1 AND status <> 'deactivated'
+        RETURNING balance, tokens_revoked_at`
+
+	var (
+		result  = entities.Offboarding{UserID: userID}
+		balance int
+	)
+
+	err = tx.QueryRowContext(ctx, deactivate, userID).Scan(&balance, &result.DeactivatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("deactivate user %d: %w", userID, err)
+	}
+
+	if poolID != 0 && balance > 0 {
+		const sweep = `
+            UPDATE users
+            SET balance = CASE WHEN id = $1 THEN 0 ELSE balance + $3 END
+            WHERE id IN ($1, $2)`
+
+		if _, err = tx.ExecContext(ctx, sweep, userID, poolID, balance); err != nil {
+			return nil, fmt.Errorf("sweep balance of user %d: %w", userID, err)
+		}
+
+		const insertTx = `
+            INSERT INTO transactions (sender_id, receiver_id, amount, memo)
+            VALUES ($1, $2, $3, $4)
+            RETURNING id`
+
+		if err = tx.QueryRowContext(ctx, insertTx, userID, poolID, balance, memo).Scan(&result.TransactionID); err != nil {
+			return nil, fmt.Errorf("insert sweep transaction: %w", err)
+		}
+
+		if err = auditlog.Enqueue(ctx, tx, audit.Transfer(userID, poolID, balance)); err != nil {
+			return nil, err
+		}
+
+		result.PoolID = poolID
+		result.Swept = balance
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return &result, nil
+}
+
+func (r *Repo) Erase(ctx context.Context, userID, erasedBy int, username, reason string) (*entities.Erasure, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	const record = `
+        INSERT INTO user_erasures (user_id, erased_by, reason)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id) DO NOTHING
+        RETURNING erased_at`
+
+	result := entities.Erasure{UserID: userID, ErasedBy: erasedBy, Username: username, Reason: reason}
+
+	err = tx.QueryRowContext(ctx, record, userID, erasedBy, reason).Scan(&result.ErasedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("erase user %d: %w", userID, ErrAlreadyErased)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("record erasure of user %d: %w", userID, err)
+	}
+
+	var oldUsername string
+
+	err = tx.QueryRowContext(ctx, `
+        SELECT username FROM users WHERE id = $1 AND status = 'deactivated' FOR UPDATE`, userID).Scan(&oldUsername)
+	if err != nil {
+		return nil, fmt.Errorf("anonymize user %d: %w", userID, err)
+	}
+
+	// Пустой хеш не совпадает ни с одним паролем, а отзыв токенов закрывает уже выданные
+	const anonymize = `
+        UPDATE users
+        SET username = $2, password_hash = '', department = '', leaderboard_opt_out = TRUE,
+            tokens_revoked_at = NOW()
+        WHERE id = $1 AND status = 'deactivated'`
+
+	res, err := tx.ExecContext(ctx, anonymize, userID, username)
+	if err != nil {
+		return nil, fmt.Errorf("anonymize user %d: %w", userID, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("anonymize user %d: %w", userID, sql.ErrNoRows)
+	}
+
+	// Получатель эскроу записан по имени, в истории его заменяет обезличенное
+	const escrows = `
+        UPDATE escrow_transfers
+        SET recipient_username = $2
+        WHERE receiver_id = $1`
+
+	if _, err = tx.ExecContext(ctx, escrows, userID, username); err != nil {
+		return nil, fmt.Errorf("anonymize escrow transfers of user %d: %w", userID, err)
+	}
+
+	// Уведомления о переводах и подарках находятся по fromUserId, старые уведомления без него по fromUser.
+	// Текст собирается заново по шаблону transaction.CoinReceived и подарка из purchase
+	const notifications = `
+        UPDATE notifications
+        SET data = jsonb_set(data, '{fromUser}', to_jsonb($3::text)),
+            message = CASE kind
+                WHEN 'coin.received' THEN format('Перевод от %s: %s монет', $3::text, data->>'amount')
+                ELSE format('%s дарит вам %s', $3::text, data->>'item') END
+        WHERE kind IN ('coin.received', 'gift.received') AND user_id <> $1
+          AND (data @> jsonb_build_object('fromUserId', $1::bigint)
+               OR NOT data ? 'fromUserId' AND data @> jsonb_build_object('fromUser', $2::text))`
+
+	if _, err = tx.ExecContext(ctx, notifications, userID, oldUsername, username); err != nil {
+		return nil, fmt.Errorf("anonymize notifications about user %d: %w", userID, err)
+	}
+
+	// В событиях вебхуков имя пользователя лежит в data.fromUser, data.toUser и data.username
+	const deliveries = `
+        UPDATE webhook_deliveries
+        SET payload = jsonb_replace_username(jsonb_replace_username(jsonb_replace_username(payload,
+                '{data,fromUser}', $1, $2), '{data,toUser}', $1, $2), '{data,username}', $1, $2)
+        WHERE payload @> jsonb_build_object('data', jsonb_build_object('fromUser', $1::text))
+           OR payload @> jsonb_build_object('data', jsonb_build_object('toUser', $1::text))
+           OR payload @> jsonb_build_object('data', jsonb_build_object('username', $1::text))`
+
+	if _, err = tx.ExecContext(ctx, deliveries, oldUsername, username); err != nil {
+		return nil, fmt.Errorf("anonymize webhook deliveries about user %d: %w", userID, err)
+	}
+
+	// Переводы и покупки остаются: на них держатся балансы и история других пользователей
+	personal := []string{
+		`DELETE FROM notifications WHERE user_id = $1`,
+		`DELETE FROM notification_preferences WHERE user_id = $1`,
+		`DELETE FROM wishlist_items WHERE user_id = $1`,
+		`DELETE FROM data_exports WHERE user_id = $1`,
+	}
+
+	for _, query := range personal {
+		if _, err = tx.ExecContext(ctx, query, userID); err != nil {
+			return nil, fmt.Errorf("delete personal data of user %d: %w", userID, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,193 @@
+package account_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/repository/account"
+	"merchshop/internal/repository/user"
+)
+
+// остаток переводится в пул в той же транзакции, что и деактивация
+func TestRepo_Offboard_Sweep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := account.NewAccountRepository(db)
+	revokedAt := time.Now()
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`UPDATE users\s+SET status = 'deactivated', tokens_revoked_at = NOW\(\)`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "tokens_revoked_at"}).AddRow(350, revokedAt))
+
+	mock.ExpectExec(`UPDATE users\s+SET balance = CASE WHEN id = \$1 THEN 0 ELSE balance \+ \$3 END`).
+		WithArgs(7, 1, 350).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(7, 1, 350, "sweep").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(99))
+
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	result, err := repo.Offboard(context.Background(), 7, 1, "sweep")
+	require.NoError(t, err)
+	require.Equal(t, 350, result.Swept)
+	require.Equal(t, 99, result.TransactionID)
+	require.Equal(t, 1, result.PoolID)
+	require.Equal(t, revokedAt, result.DeactivatedAt)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// без пула баланс не трогается
+func TestRepo_Offboard_NoSweep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := account.NewAccountRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`UPDATE users\s+SET status = 'deactivated'`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "tokens_revoked_at"}).AddRow(350, time.Now()))
+
+	mock.ExpectCommit()
+
+	result, err := repo.Offboard(context.Background(), 7, 0, "sweep")
+	require.NoError(t, err)
+	require.Zero(t, result.Swept)
+	require.Zero(t, result.TransactionID)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepo_SetStatus_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := account.NewAccountRepository(db)
+
+	mock.ExpectExec(`UPDATE users\s+SET status = \$2`).
+		WithArgs(404, "frozen").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectQuery(`SELECT status FROM users WHERE id = \$1`).
+		WithArgs(404).
+		WillReturnError(sql.ErrNoRows)
+
+	err = repo.SetStatus(context.Background(), 404, "frozen")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// уволенного нельзя вернуть в работу сменой статуса
+func TestRepo_SetStatus_Deactivated(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := account.NewAccountRepository(db)
+
+	mock.ExpectExec(`UPDATE users\s+SET status = \$2\s+WHERE id = \$1 AND status <> 'deactivated'`).
+		WithArgs(7, "active").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectQuery(`SELECT status FROM users WHERE id = \$1`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("deactivated"))
+
+	err = repo.SetStatus(context.Background(), 7, "active")
+	require.ErrorIs(t, err, user.ErrAccountDeactivated)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// имя и пароль обезличиваются, личные данные удаляются в той же транзакции
+func TestRepo_Erase(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := account.NewAccountRepository(db)
+	erasedAt := time.Now()
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`INSERT INTO user_erasures \(user_id, erased_by, reason\)`).
+		WithArgs(7, 1, "request").
+		WillReturnRows(sqlmock.NewRows([]string{"erased_at"}).AddRow(erasedAt))
+
+	mock.ExpectQuery(`SELECT username FROM users WHERE id = \$1 AND status = 'deactivated' FOR UPDATE`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"username"}).AddRow("alice"))
+
+	mock.ExpectExec(`UPDATE users\s+SET username = \$2, password_hash = ''`).
+		WithArgs(7, "deleted user #7").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`UPDATE escrow_transfers\s+SET recipient_username = \$2`).
+		WithArgs(7, "deleted user #7").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// имя уходит и из уведомлений и вебхуков других пользователей
+	mock.ExpectExec(`UPDATE notifications\s+SET data = jsonb_set\(data, '\{fromUser\}', to_jsonb\(\$3::text\)\)`).
+		WithArgs(7, "alice", "deleted user #7").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	mock.ExpectExec(`UPDATE webhook_deliveries\s+SET payload = jsonb_replace_username`).
+		WithArgs("alice", "deleted user #7").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	for _, table := range []string{"notifications", "notification_preferences", "wishlist_items", "data_exports"} {
+		mock.ExpectExec(`DELETE FROM ` + table + ` WHERE user_id = \$1`).
+			WithArgs(7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	mock.ExpectCommit()
+
+	result, err := repo.Erase(context.Background(), 7, 1, "deleted user #7", "request")
+	require.NoError(t, err)
+	require.Equal(t, "deleted user #7", result.Username)
+	require.Equal(t, erasedAt, result.ErasedAt)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// повторное стирание ничего не меняет
+func TestRepo_Erase_Twice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := account.NewAccountRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`INSERT INTO user_erasures`).
+		WithArgs(7, 1, "").
+		WillReturnRows(sqlmock.NewRows([]string{"erased_at"}))
+
+	mock.ExpectRollback()
+
+	_, err = repo.Erase(context.Background(), 7, 1, "deleted user #7", "")
+	require.ErrorIs(t, err, account.ErrAlreadyErased)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
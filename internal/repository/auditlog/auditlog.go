@@ -0,0 +1,336 @@
+package auditlog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"merchshop/internal/audit"
+	entities "merchshop/internal/entity"
+)
+
+// appendLock ключ advisory-блокировки, под которой записи выстраиваются в одну цепочку
+const appendLock = 7_220_050
+
+type Repository interface {
+	// Append дописывает запись в конец цепочки: берет хеш последней записи и считает хеш новой.
+	// CreatedAt, если пустой, ставится текущим временем
+	Append(ctx context.Context, e entities.AuditEntry) (*entities.AuditEntry, error)
+	// Flush переносит в цепочку до limit записей, сохраненных через Enqueue, в порядке сохранения.
+	// Возвращает число перенесенных
+	Flush(ctx context.Context, limit int) (int, error)
+	// List записи по фильтру, новые первыми. Ненулевой before возвращает записи старше него
+	List(ctx context.Context, f entities.AuditFilter, before, limit int) ([]entities.AuditEntry, error)
+	// Walk передает в fn записи по фильтру в порядке цепочки, не накапливая их в памяти.
+	// Ошибка fn прерывает чтение и возвращается как есть
+	Walk(ctx context.Context, f entities.AuditFilter, fn func(entities.AuditEntry) error) error
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewAuditLogRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+const entryColumns = `id, COALESCE(actor_id, 0), action, target_type, target_id, request_id, ip, before, after,
+               created_at, prev_hash, hash`
+
+// Фильтр в параметрах $1-$7, пустые значения не ограничивают
+const filterClause = `($1 = 0 OR actor_id = $1)
+          AND ($2 = '' OR starts_with(action, $2))
+          AND ($3 = '' OR target_type = $3)
+          AND ($4 = '' OR target_id = $4)
+          AND ($5 = 0 OR actor_id = $5 OR target_type = 'user' AND target_id = $5::text)
+          AND ($6::timestamptz IS NULL OR created_at >= $6) AND ($7::timestamptz IS NULL OR created_at < $7)`
+
+func filterArgs(f entities.AuditFilter) []any {
+	return []any{f.ActorID, f.Action, f.TargetType, f.TargetID, f.UserID, f.From, f.To}
+}
+
+func scanEntry(row interface{ Scan(...any) error }) (*entities.AuditEntry, error) {
+	var (
+		e             entities.AuditEntry
+		before, after []byte
+	)
+
+	err := row.Scan(&e.ID, &e.ActorID, &e.Action, &e.TargetType, &e.TargetID, &e.RequestID, &e.IP, &before, &after,
+		&e.CreatedAt, &e.PrevHash, &e.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Before, e.After = before, after
+
+	return &e, nil
+}
+
+// nullJSON передает пустой payload как NULL, остальные строкой, чтобы драйвер не счел их bytea
+func nullJSON(raw []byte) any {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	return string(raw)
+}
+
+func (r *Repo) Append(ctx context.Context, e entities.AuditEntry) (*entities.AuditEntry, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	prev, err := lockChain(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	entry, err := chain(ctx, tx, prev, e)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Enqueue сохраняет запись в транзакции tx самой операции, так что запись и операция
+// фиксируются вместе. В цепочку запись переносит Flush
+func Enqueue(ctx context.Context, tx *sql.Tx, e entities.AuditEntry) error {
+	e = audit.Stamp(ctx, e)
+
+	const insert = `
+        INSERT INTO audit_outbox (actor_id, action, target_type, target_id, request_id, ip, before, after)
+        VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7::jsonb, $8::jsonb)`
+
+	_, err := tx.ExecContext(ctx, insert, e.ActorID, e.Action, e.TargetType, e.TargetID, e.RequestID, e.IP,
+		nullJSON(e.Before), nullJSON(e.After))
+	if err != nil {
+		return fmt.Errorf("enqueue audit entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) Flush(ctx context.Context, limit int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	prev, err := lockChain(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	const pending = `
+        SELECT id, COALESCE(actor_id, 0), action, target_type, target_id, request_id, ip, before, after, created_at
+        FROM audit_outbox
+        ORDER BY id
+        LIMIT $1`
+
+	rows, err := tx.QueryContext(ctx, pending, limit)
+	if err != nil {
+		return 0, fmt.Errorf("query audit outbox: %w", err)
+	}
+
+	var (
+		queued []entities.AuditEntry
+		ids    []int64
+	)
+
+	for rows.Next() {
+		var (
+			e             entities.AuditEntry
+			id            int64
+			before, after []byte
+		)
+
+		err := rows.Scan(&id, &e.ActorID, &e.Action, &e.TargetType, &e.TargetID, &e.RequestID, &e.IP, &before, &after,
+			&e.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan audit outbox: %w", err)
+		}
+
+		e.Before, e.After = before, after
+		queued = append(queued, e)
+		ids = append(ids, id)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	if len(queued) == 0 {
+		return 0, nil
+	}
+
+	for _, e := range queued {
+		entry, err := chain(ctx, tx, prev, e)
+		if err != nil {
+			return 0, err
+		}
+
+		prev = entry.Hash
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM audit_outbox WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return 0, fmt.Errorf("delete flushed audit entries: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return len(queued), nil
+}
+
+// lockChain берет блокировку цепочки и возвращает хеш последней записи. Блокировка держится
+// до конца транзакции, так что две реплики не продолжат цепочку от одной и той же записи
+func lockChain(ctx context.Context, tx *sql.Tx) (string, error) {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, appendLock); err != nil {
+		return "", fmt.Errorf("lock audit log: %w", err)
+	}
+
+	var prev string
+
+	err := tx.QueryRowContext(ctx, `SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&prev)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("get last audit entry: %w", err)
+	}
+
+	return prev, nil
+}
+
+// chain дописывает запись e после записи с хешем prev
+func chain(ctx context.Context, tx *sql.Tx, prev string, e entities.AuditEntry) (*entities.AuditEntry, error) {
+	var err error
+
+	e.PrevHash = prev
+	e.CreatedAt = e.CreatedAt.UTC().Truncate(time.Microsecond)
+
+	// В базе payload лежит в канонической форме, проверка цепочки получит те же байты
+	if e.Before, err = canonicalPayload(e.Before); err != nil {
+		return nil, fmt.Errorf("encode before: %w", err)
+	}
+
+	if e.After, err = canonicalPayload(e.After); err != nil {
+		return nil, fmt.Errorf("encode after: %w", err)
+	}
+
+	if e.Hash, err = audit.Hash(e.PrevHash, e); err != nil {
+		return nil, fmt.Errorf("hash audit entry: %w", err)
+	}
+
+	const insert = `
+        INSERT INTO audit_log (actor_id, action, target_type, target_id, request_id, ip, before, after,
+                               created_at, prev_hash, hash)
+        VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7::jsonb, $8::jsonb, $9, $10, $11)
+        RETURNING id`
+
+	err = tx.QueryRowContext(ctx, insert, e.ActorID, e.Action, e.TargetType, e.TargetID, e.RequestID, e.IP,
+		nullJSON(e.Before), nullJSON(e.After), e.CreatedAt, e.PrevHash, e.Hash).Scan(&e.ID)
+	if err != nil {
+		return nil, fmt.Errorf("insert audit entry: %w", err)
+	}
+
+	return &e, nil
+}
+
+func canonicalPayload(raw []byte) ([]byte, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	return audit.Canonical(raw)
+}
+
+func (r *Repo) List(ctx context.Context, f entities.AuditFilter, before, limit int) ([]entities.AuditEntry, error) {
+	const query = `
+        SELECT ` + entryColumns + `
+        FROM audit_log
+        WHERE ` + filterClause + ` AND ($8 = 0 OR id < $8)
+        ORDER BY id DESC
+        LIMIT $9`
+
+	rows, err := r.db.QueryContext(ctx, query, append(filterArgs(f), before, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []entities.AuditEntry
+
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+
+		entries = append(entries, *e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *Repo) Walk(ctx context.Context, f entities.AuditFilter, fn func(entities.AuditEntry) error) error {
+	const query = `
+        SELECT ` + entryColumns + `
+        FROM audit_log
+        WHERE ` + filterClause + `
+        ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, filterArgs(f)...)
+	if err != nil {
+		return fmt.Errorf("query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return fmt.Errorf("scan audit entry: %w", err)
+		}
+
+		if err := fn(*e); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return nil
+}
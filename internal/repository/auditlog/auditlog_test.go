@@ -0,0 +1,192 @@
+package auditlog_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/audit"
+	"merchshop/internal/entity"
+	"merchshop/internal/repository/auditlog"
+)
+
+// новая запись продолжает цепочку от хеша последней
+func TestAuditLog_Append(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := auditlog.NewAuditLogRepository(db)
+	prev := "4f2a0c8e2b1d9a7f6e5c4b3a29180716f5e4d3c2b1a09f8e7d6c5b4a39281706"
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).
+		WithArgs(7_220_050).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectQuery(`SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(prev))
+
+	mock.ExpectQuery(`INSERT INTO audit_log`).
+		WithArgs(1, audit.ActionLogin, audit.TargetUser, "1", "req-1", "10.0.0.1", nil, `{"a":2,"b":1}`,
+			sqlmock.AnyArg(), prev, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+
+	mock.ExpectCommit()
+
+	e, err := repo.Append(context.Background(), entity.AuditEntry{
+		ActorID:    1,
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   "1",
+		RequestID:  "req-1",
+		IP:         "10.0.0.1",
+		After:      json.RawMessage(`{"b": 1, "a": 2}`),
+		CreatedAt:  time.Date(2026, 10, 19, 12, 0, 0, 123456789, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 12, e.ID)
+	require.Equal(t, prev, e.PrevHash)
+	require.Equal(t, 123456000, e.CreatedAt.Nanosecond())
+
+	want, err := audit.Hash(prev, *e)
+	require.NoError(t, err)
+	require.Equal(t, want, e.Hash)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// записи из очереди дописываются по порядку, каждая от хеша предыдущей, и удаляются из очереди
+func TestAuditLog_Flush(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := auditlog.NewAuditLogRepository(db)
+	created := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).
+		WithArgs(7_220_050).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectQuery(`SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("head"))
+
+	mock.ExpectQuery(`SELECT id, COALESCE\(actor_id, 0\), action, target_type, target_id, request_id, ip, before, after, created_at\s+FROM audit_outbox`).
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "actor_id", "action", "target_type", "target_id", "request_id", "ip", "before", "after", "created_at"}).
+			AddRow(4, 1, audit.ActionTransfer, audit.TargetUser, "2", "req-1", "10.0.0.1", nil, []byte(`{"amount":50}`), created).
+			AddRow(5, 2, audit.ActionPurchase, audit.TargetMerch, "cup", "", "", nil, []byte(`{"total":20}`), created))
+
+	first := entity.AuditEntry{
+		ActorID: 1, Action: audit.ActionTransfer, TargetType: audit.TargetUser, TargetID: "2", RequestID: "req-1",
+		IP: "10.0.0.1", After: json.RawMessage(`{"amount":50}`), CreatedAt: created, PrevHash: "head",
+	}
+	firstHash, err := audit.Hash("head", first)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(`INSERT INTO audit_log`).
+		WithArgs(1, audit.ActionTransfer, audit.TargetUser, "2", "req-1", "10.0.0.1", nil, `{"amount":50}`,
+			created, "head", firstHash).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+
+	mock.ExpectQuery(`INSERT INTO audit_log`).
+		WithArgs(2, audit.ActionPurchase, audit.TargetMerch, "cup", "", "", nil, `{"total":20}`,
+			created, firstHash, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(13))
+
+	mock.ExpectExec(`DELETE FROM audit_outbox WHERE id = ANY\(\$1\)`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	mock.ExpectCommit()
+
+	flushed, err := repo.Flush(context.Background(), 100)
+	require.NoError(t, err)
+	require.Equal(t, 2, flushed)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// запись в очереди берет ID запроса и IP из контекста
+func TestEnqueue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WithArgs(3, audit.ActionTransfer, audit.TargetUser, "2", "req-1", "10.0.0.1", nil,
+			`{"amount":50,"receiverId":2,"senderId":1}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ctx := audit.WithRequest(context.Background(), audit.Request{ID: "req-1", IP: "10.0.0.1", ActorID: 3})
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, auditlog.Enqueue(ctx, tx, audit.Transfer(1, 2, 50)))
+	require.NoError(t, tx.Commit())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// первая запись начинает цепочку с пустого хеша
+func TestAuditLog_Append_First(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := auditlog.NewAuditLogRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT hash FROM audit_log`).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`INSERT INTO audit_log`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	e, err := repo.Append(context.Background(), entity.AuditEntry{Action: audit.ActionRegister})
+	require.NoError(t, err)
+	require.Empty(t, e.PrevHash)
+	require.Len(t, e.Hash, 64)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// записи пользователя: он автор или объект действия
+func TestAuditLog_Walk_ByUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := auditlog.NewAuditLogRepository(db)
+
+	mock.ExpectQuery(`FROM audit_log WHERE .* target_type = 'user' AND target_id = \$5::text\) .* ORDER BY id`).
+		WithArgs(0, "", "", "", 7, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "actor_id", "action", "target_type", "target_id", "request_id", "ip", "before", "after",
+			"created_at", "prev_hash", "hash",
+		}).
+			AddRow(3, 7, audit.ActionLogin, audit.TargetUser, "7", "", "", nil, nil, time.Now(), "", "h3").
+			AddRow(5, 1, "admin:PUT /users/{id}/status", audit.TargetUser, "7", "", "", []byte(`{}`), nil,
+				time.Now(), "h4", "h5"))
+
+	var ids []int
+
+	err = repo.Walk(context.Background(), entity.AuditFilter{UserID: 7}, func(e entity.AuditEntry) error {
+		ids = append(ids, e.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 5}, ids)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,291 @@
+package coinrequest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"merchshop/internal/audit"
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/auditlog"
+	"merchshop/internal/repository/policy"
+	"merchshop/internal/repository/transaction"
+)
+
+// ErrTooManyPending у запросившего уже есть maxPending открытых запросов к этому плательщику
+var ErrTooManyPending = errors.New("too many pending coin requests")
+
+type Repository interface {
+	// Create создает запрос, если у пары запросивший-плательщик меньше maxPending открытых запросов
+	Create(ctx context.Context, requesterID, payerID, amount int, memo string, expiresAt time.Time, maxPending int) (*entities.CoinRequest, error)
+	GetByID(ctx context.Context, id int) (*entities.CoinRequest, error)
+	// ListByUser возвращает входящие и исходящие запросы пользователя, новые первыми
+	ListByUser(ctx context.Context, userID int) ([]entities.CoinRequest, error)
+	// Approve переводит монеты плательщика и закрывает запрос в одной транзакции, в ней же выполняется guard
+	Approve(ctx context.Context, id, payerID int, guard *policy.Guard) (*entities.CoinRequest, error)
+	Decline(ctx context.Context, id, payerID int) (*entities.CoinRequest, error)
+	// ExpirePending закрывает просроченные запросы и возвращает их
+	ExpirePending(ctx context.Context) ([]entities.CoinRequest, error)
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewCoinRequestRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+const requestColumns = `c.id, c.requester_id, c.payer_id, rq.username, p.username, c.amount, c.memo, c.status,
+               COALESCE(c.transaction_id, 0), c.expires_at, c.created_at, c.resolved_at`
+
+const requestJoins = `
+        JOIN users rq ON c.requester_id = rq.id
+        JOIN users p ON c.payer_id = p.id`
+
+func scanRequest(row interface{ Scan(...any) error }) (*entities.CoinRequest, error) {
+	var c entities.CoinRequest
+
+	err := row.Scan(
+		&c.ID, &c.RequesterID, &c.PayerID, &c.RequesterName, &c.PayerName, &c.Amount, &c.Memo, &c.Status,
+		&c.TransactionID, &c.ExpiresAt, &c.CreatedAt, &c.ResolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r *Repo) Create(
+	ctx context.Context,
+	requesterID, payerID, amount int,
+	memo string,
+	expiresAt time.Time,
+	maxPending int,
+) (*entities.CoinRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	// Блокировка запросившего не дает параллельным запросам обойти лимит
+	const lockRequester = `SELECT id FROM users WHERE id = $1 FOR UPDATE`
+
+	if _, err = tx.ExecContext(ctx, lockRequester, requesterID); err != nil {
+		return nil, fmt.Errorf("lock requester: %w", err)
+	}
+
+	const countPending = `
+        SELECT COUNT(*)
+        FROM coin_requests
+        WHERE requester_id = $1 AND payer_id = $2 AND status = 'pending' AND expires_at > NOW()`
+
+	var pending int
+	if err = tx.QueryRowContext(ctx, countPending, requesterID, payerID).Scan(&pending); err != nil {
+		return nil, fmt.Errorf("count pending coin requests: %w", err)
+	}
+
+	if pending >= maxPending {
+		return nil, fmt.Errorf("%w: %d", ErrTooManyPending, pending)
+	}
+
+	const query = `
+        WITH c AS (
+            INSERT INTO coin_requests (requester_id, payer_id, amount, memo, expires_at)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING *
+        )
+        SELECT ` + requestColumns + `
+        FROM c` + requestJoins
+
+	c, err := scanRequest(tx.QueryRowContext(ctx, query, requesterID, payerID, amount, memo, expiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create coin request: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return c, nil
+}
+
+func (r *Repo) GetByID(ctx context.Context, id int) (*entities.CoinRequest, error) {
+	const query = `
+        SELECT ` + requestColumns + `
+        FROM coin_requests c` + requestJoins + `
+        WHERE c.id = $1`
+
+	c, err := scanRequest(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coin request by id: %w", err)
+	}
+
+	return c, nil
+}
+
+func (r *Repo) queryRequests(ctx context.Context, query string, args ...interface{}) ([]entities.CoinRequest, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query coin requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []entities.CoinRequest
+
+	for rows.Next() {
+		c, err := scanRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan coin request: %w", err)
+		}
+
+		requests = append(requests, *c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return requests, nil
+}
+
+func (r *Repo) ListByUser(ctx context.Context, userID int) ([]entities.CoinRequest, error) {
+	const query = `
+        SELECT ` + requestColumns + `
+        FROM coin_requests c` + requestJoins + `
+        WHERE c.requester_id = $1 OR c.payer_id = $1
+        ORDER BY c.id DESC`
+
+	return r.queryRequests(ctx, query, userID)
+}
+
+func (r *Repo) Approve(ctx context.Context, id, payerID int, guard *policy.Guard) (*entities.CoinRequest, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	const lockRequest = `
+        SELECT requester_id, amount, memo
+        FROM coin_requests
+        WHERE id = $1 AND payer_id = $2 AND status = 'pending' AND expires_at > NOW()
+        FOR UPDATE`
+
+	var (
+		requesterID, amount int
+		memo                string
+	)
+
+	if err = tx.QueryRowContext(ctx, lockRequest, id, payerID).Scan(&requesterID, &amount, &memo); err != nil {
+		return nil, fmt.Errorf("lock coin request: %w", err)
+	}
+
+	if err = guard.CheckInTx(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	const updatePayer = `
+        UPDATE users
+        SET balance = balance - $1
+        WHERE id = $2 AND balance >= $1`
+
+	result, err := tx.ExecContext(ctx, updatePayer, amount, payerID)
+	if err != nil {
+		return nil, fmt.Errorf("update payer balance: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, transaction.ErrInsufficientFunds
+	}
+
+	const updateRequester = `
+        UPDATE users
+        SET balance = balance + $1
+        WHERE id = $2`
+
+	if _, err = tx.ExecContext(ctx, updateRequester, amount, requesterID); err != nil {
+		return nil, fmt.Errorf("update requester balance: %w", err)
+	}
+
+	const insertTx = `
+        INSERT INTO transactions (sender_id, receiver_id, amount, memo)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id`
+
+	var transactionID int
+	if err = tx.QueryRowContext(ctx, insertTx, payerID, requesterID, amount, memo).Scan(&transactionID); err != nil {
+		return nil, fmt.Errorf("insert transaction: %w", err)
+	}
+
+	if err = auditlog.Enqueue(ctx, tx, audit.Transfer(payerID, requesterID, amount)); err != nil {
+		return nil, err
+	}
+
+	const closeRequest = `
+        UPDATE coin_requests
+        SET status = 'approved', transaction_id = $2, resolved_at = NOW()
+        WHERE id = $1`
+
+	if _, err = tx.ExecContext(ctx, closeRequest, id, transactionID); err != nil {
+		return nil, fmt.Errorf("close coin request: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+func (r *Repo) Decline(ctx context.Context, id, payerID int) (*entities.CoinRequest, error) {
+	const query = `
+        WITH c AS (
+            UPDATE coin_requests
+            SET status = 'declined', resolved_at = NOW()
+            WHERE id = $1 AND payer_id = $2 AND status = 'pending' AND expires_at > NOW()
+            RETURNING *
+        )
+        SELECT ` + requestColumns + `
+        FROM c` + requestJoins
+
+	c, err := scanRequest(r.db.QueryRowContext(ctx, query, id, payerID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decline coin request: %w", err)
+	}
+
+	return c, nil
+}
+
+func (r *Repo) ExpirePending(ctx context.Context) ([]entities.CoinRequest, error) {
+	const query = `
+        WITH c AS (
+            UPDATE coin_requests
+            SET status = 'expired', resolved_at = expires_at
+            WHERE status = 'pending' AND expires_at <= NOW()
+            RETURNING *
+        )
+        SELECT ` + requestColumns + `
+        FROM c` + requestJoins
+
+	return r.queryRequests(ctx, query)
+}
@@ -0,0 +1,165 @@
+package coinrequest_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/repository/coinrequest"
+	"merchshop/internal/repository/transaction"
+)
+
+var requestColumns = []string{
+	"id", "requester_id", "payer_id", "requester_name", "payer_name", "amount", "memo", "status",
+	"transaction_id", "expires_at", "created_at", "resolved_at",
+}
+
+// одобрение запроса: перевод и закрытие запроса в одной транзакции
+func TestRepo_Approve_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := coinrequest.NewCoinRequestRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT requester_id, amount, memo\s+FROM coin_requests`).
+		WithArgs(5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"requester_id", "amount", "memo"}).AddRow(1, 30, "обед"))
+
+	mock.ExpectExec(`UPDATE users\s+SET balance = balance - \$1`).
+		WithArgs(30, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`UPDATE users\s+SET balance = balance \+ \$1`).
+		WithArgs(30, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(2, 1, 30, "обед").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`UPDATE coin_requests\s+SET status = 'approved'`).
+		WithArgs(5, 11).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT c.id, c.requester_id`).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(requestColumns).
+			AddRow(5, 1, 2, "alice", "bob", 30, "обед", "approved", 11, now, now, now))
+
+	c, err := repo.Approve(context.Background(), 5, 2, nil)
+	require.NoError(t, err)
+	require.Equal(t, "approved", c.Status)
+	require.Equal(t, 11, c.TransactionID)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// у плательщика не хватает монет, запрос остается открытым
+func TestRepo_Approve_InsufficientFunds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := coinrequest.NewCoinRequestRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT requester_id, amount, memo\s+FROM coin_requests`).
+		WithArgs(5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"requester_id", "amount", "memo"}).AddRow(1, 30, ""))
+
+	mock.ExpectExec(`UPDATE users\s+SET balance = balance - \$1`).
+		WithArgs(30, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectRollback()
+
+	_, err = repo.Approve(context.Background(), 5, 2, nil)
+	require.ErrorIs(t, err, transaction.ErrInsufficientFunds)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// запрос уже закрыт или просрочен
+func TestRepo_Approve_NotPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := coinrequest.NewCoinRequestRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT requester_id, amount, memo\s+FROM coin_requests`).
+		WithArgs(5, 2).
+		WillReturnError(sql.ErrNoRows)
+
+	mock.ExpectRollback()
+
+	_, err = repo.Approve(context.Background(), 5, 2, nil)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// просроченные запросы закрываются пачкой
+func TestRepo_ExpirePending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := coinrequest.NewCoinRequestRepository(db)
+
+	now := time.Now()
+
+	mock.ExpectQuery(`UPDATE coin_requests\s+SET status = 'expired'`).
+		WillReturnRows(sqlmock.NewRows(requestColumns).
+			AddRow(5, 1, 2, "alice", "bob", 30, "", "expired", 0, now, now, now))
+
+	expired, err := repo.ExpirePending(context.Background())
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	require.Equal(t, "expired", expired[0].Status)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// открытых запросов к плательщику уже столько, сколько можно, новый не создается
+func TestRepo_Create_TooManyPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := coinrequest.NewCoinRequestRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`SELECT id FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM coin_requests`).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	mock.ExpectRollback()
+
+	_, err = repo.Create(context.Background(), 1, 2, 30, "", time.Now().Add(time.Hour), 3)
+	require.ErrorIs(t, err, coinrequest.ErrTooManyPending)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,160 @@
+package dataexport
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	entities "merchshop/internal/entity"
+)
+
+type Repository interface {
+	// Create ставит выгрузку в очередь. Если у пользователя уже есть выгрузка в очереди,
+	// возвращает ее
+	Create(ctx context.Context, userID, requestedBy int) (*entities.DataExport, error)
+	Get(ctx context.Context, id int) (*entities.DataExport, error)
+	// Claim забирает выгрузку из очереди и держит ее за собой lease, чтобы другие реплики
+	// не собирали ее повторно. Если очередь пуста, возвращает sql.ErrNoRows
+	Claim(ctx context.Context, lease time.Duration) (*entities.DataExport, error)
+	Complete(ctx context.Context, id int, archive []byte, expiresAt time.Time) error
+	Fail(ctx context.Context, id int, reason string) error
+	// Archive возвращает готовый архив. Если архива нет или он истек, возвращает sql.ErrNoRows
+	Archive(ctx context.Context, id int) ([]byte, error)
+	// Expire удаляет истекшие архивы и возвращает их число
+	Expire(ctx context.Context) (int, error)
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewDataExportRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+const exportColumns = `id, user_id, requested_by, status, size, error, created_at, ready_at, expires_at`
+
+func scanExport(row interface{ Scan(...any) error }) (*entities.DataExport, error) {
+	var e entities.DataExport
+
+	err := row.Scan(&e.ID, &e.UserID, &e.RequestedBy, &e.Status, &e.Size, &e.Error, &e.CreatedAt, &e.ReadyAt, &e.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+func (r *Repo) Create(ctx context.Context, userID, requestedBy int) (*entities.DataExport, error) {
+	const insert = `
+        INSERT INTO data_exports (user_id, requested_by)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) WHERE status = 'pending' DO NOTHING
+        RETURNING ` + exportColumns
+
+	e, err := scanExport(r.db.QueryRowContext(ctx, insert, userID, requestedBy))
+	if err == nil {
+		return e, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("create data export for user %d: %w", userID, err)
+	}
+
+	const pending = `SELECT ` + exportColumns + ` FROM data_exports WHERE user_id = $1 AND status = 'pending'`
+
+	e, err = scanExport(r.db.QueryRowContext(ctx, pending, userID))
+	if err != nil {
+		return nil, fmt.Errorf("get pending data export of user %d: %w", userID, err)
+	}
+
+	return e, nil
+}
+
+func (r *Repo) Get(ctx context.Context, id int) (*entities.DataExport, error) {
+	const query = `SELECT ` + exportColumns + ` FROM data_exports WHERE id = $1`
+
+	e, err := scanExport(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("get data export %d: %w", id, err)
+	}
+
+	return e, nil
+}
+
+func (r *Repo) Claim(ctx context.Context, lease time.Duration) (*entities.DataExport, error) {
+	const query = `
+        UPDATE data_exports
+        SET leased_until = NOW() + $1 * INTERVAL '1 second'
+        WHERE id = (
+            SELECT id
+            FROM data_exports
+            WHERE status = 'pending' AND (leased_until IS NULL OR leased_until <= NOW())
+            ORDER BY id
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + exportColumns
+
+	e, err := scanExport(r.db.QueryRowContext(ctx, query, lease.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("claim data export: %w", err)
+	}
+
+	return e, nil
+}
+
+func (r *Repo) Complete(ctx context.Context, id int, archive []byte, expiresAt time.Time) error {
+	const query = `
+        UPDATE data_exports
+        SET status = 'ready', archive = $2, size = $3, ready_at = NOW(), expires_at = $4, leased_until = NULL
+        WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, archive, len(archive), expiresAt); err != nil {
+		return fmt.Errorf("complete data export %d: %w", id, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) Fail(ctx context.Context, id int, reason string) error {
+	const query = `UPDATE data_exports SET status = 'failed', error = $2, leased_until = NULL WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, reason); err != nil {
+		return fmt.Errorf("fail data export %d: %w", id, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) Archive(ctx context.Context, id int) ([]byte, error) {
+	const query = `SELECT archive FROM data_exports WHERE id = $1 AND status = 'ready' AND expires_at > NOW()`
+
+	var archive []byte
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&archive); err != nil {
+		return nil, fmt.Errorf("get archive of data export %d: %w", id, err)
+	}
+
+	return archive, nil
+}
+
+func (r *Repo) Expire(ctx context.Context) (int, error) {
+	const query = `
+        UPDATE data_exports
+        SET status = 'expired', archive = NULL
+        WHERE status = 'ready' AND expires_at <= NOW()`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("expire data exports: %w", err)
+	}
+
+	expired, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return int(expired), nil
+}
@@ -0,0 +1,60 @@
+package dataexport_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/repository/dataexport"
+)
+
+var exportRowColumns = []string{
+	"id", "user_id", "requested_by", "status", "size", "error", "created_at", "ready_at", "expires_at",
+}
+
+// Тест повторного запроса выгрузки, пока предыдущая еще в очереди
+func TestDataExport_Create_ReturnsPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := dataexport.NewDataExportRepository(db)
+
+	mock.ExpectQuery(`INSERT INTO data_exports \(user_id, requested_by\) VALUES \(\$1, \$2\) `+
+		`ON CONFLICT \(user_id\) WHERE status = 'pending' DO NOTHING`).
+		WithArgs(1, 1).
+		WillReturnError(sql.ErrNoRows)
+
+	mock.ExpectQuery(`SELECT .* FROM data_exports WHERE user_id = \$1 AND status = 'pending'`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(exportRowColumns).
+			AddRow(5, 1, 1, "pending", 0, "", time.Now(), nil, nil))
+
+	e, err := repo.Create(context.Background(), 1, 1)
+	require.NoError(t, err)
+	require.Equal(t, 5, e.ID)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест пустой очереди выгрузок
+func TestDataExport_Claim_Empty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := dataexport.NewDataExportRepository(db)
+
+	mock.ExpectQuery(`UPDATE data_exports SET leased_until = NOW\(\) \+ \$1 \* INTERVAL '1 second' .* FOR UPDATE SKIP LOCKED`).
+		WithArgs(float64(600)).
+		WillReturnRows(sqlmock.NewRows(exportRowColumns))
+
+	_, err = repo.Claim(context.Background(), 10*time.Minute)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,324 @@
+package escrow
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"merchshop/internal/audit"
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/auditlog"
+	"merchshop/internal/repository/policy"
+	"merchshop/internal/repository/transaction"
+)
+
+type Repository interface {
+	// Create списывает монеты отправителя и удерживает их для username. guard выполняется в той же транзакции
+	Create(ctx context.Context, senderID int, username string, amount int, memo string, expiresAt time.Time, guard *policy.Guard) (*entities.Escrow, error)
+	ListBySender(ctx context.Context, senderID int) ([]entities.Escrow, error)
+	// Claim зачисляет пользователю все удерживаемые для его имени переводы и возвращает их
+	Claim(ctx context.Context, receiverID int, username string) ([]entities.Escrow, error)
+	// ListClaimable возвращает пользователей, для которых есть удерживаемые переводы
+	ListClaimable(ctx context.Context, limit int) ([]entities.User, error)
+	// RefundExpired возвращает отправителям просроченные переводы
+	RefundExpired(ctx context.Context, limit int) ([]entities.Escrow, error)
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewEscrowRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+const escrowColumns = `e.id, e.sender_id, s.username, e.recipient_username, COALESCE(e.receiver_id, 0), e.amount,
+               e.memo, e.status, COALESCE(e.transaction_id, 0), e.expires_at, e.created_at, e.resolved_at`
+
+func scanEscrow(row interface{ Scan(...any) error }) (*entities.Escrow, error) {
+	var e entities.Escrow
+
+	err := row.Scan(
+		&e.ID, &e.SenderID, &e.SenderName, &e.Username, &e.ReceiverID, &e.Amount,
+		&e.Memo, &e.Status, &e.TransactionID, &e.ExpiresAt, &e.CreatedAt, &e.ResolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+func (r *Repo) queryEscrows(ctx context.Context, q interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}, query string, args ...interface{}) ([]entities.Escrow, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query escrow transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var escrows []entities.Escrow
+
+	for rows.Next() {
+		e, err := scanEscrow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan escrow transfer: %w", err)
+		}
+
+		escrows = append(escrows, *e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return escrows, nil
+}
+
+func (r *Repo) Create(
+	ctx context.Context,
+	senderID int,
+	username string,
+	amount int,
+	memo string,
+	expiresAt time.Time,
+	guard *policy.Guard,
+) (*entities.Escrow, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	if err = guard.CheckInTx(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	const updateSender = `
+        UPDATE users
+        SET balance = balance - $1
+        WHERE id = $2 AND balance >= $1`
+
+	result, err := tx.ExecContext(ctx, updateSender, amount, senderID)
+	if err != nil {
+		return nil, fmt.Errorf("update sender balance: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, transaction.ErrInsufficientFunds
+	}
+
+	const insertEscrow = `
+        WITH e AS (
+            INSERT INTO escrow_transfers (sender_id, recipient_username, amount, memo, expires_at)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING *
+        )
+        SELECT ` + escrowColumns + `
+        FROM e
+        JOIN users s ON e.sender_id = s.id`
+
+	e, err := scanEscrow(tx.QueryRowContext(ctx, insertEscrow, senderID, username, amount, memo, expiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("insert escrow transfer: %w", err)
+	}
+
+	if err = auditlog.Enqueue(ctx, tx, audit.EscrowHold(*e)); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return e, nil
+}
+
+func (r *Repo) ListBySender(ctx context.Context, senderID int) ([]entities.Escrow, error) {
+	const query = `
+        SELECT ` + escrowColumns + `
+        FROM escrow_transfers e
+        JOIN users s ON e.sender_id = s.id
+        WHERE e.sender_id = $1
+        ORDER BY e.id DESC`
+
+	return r.queryEscrows(ctx, r.db, query, senderID)
+}
+
+func (r *Repo) Claim(ctx context.Context, receiverID int, username string) ([]entities.Escrow, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	// Блокировка строк не дает фоновому возврату забрать те же переводы
+	const lockHeld = `
+        SELECT ` + escrowColumns + `
+        FROM escrow_transfers e
+        JOIN users s ON e.sender_id = s.id
+        WHERE e.recipient_username = $1 AND e.status = 'held'
+        ORDER BY e.id
+        FOR UPDATE OF e`
+
+	escrows, err := r.queryEscrows(ctx, tx, lockHeld, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(escrows) == 0 {
+		return nil, nil
+	}
+
+	const insertTx = `
+        INSERT INTO transactions (sender_id, receiver_id, amount, memo)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id`
+
+	const markClaimed = `
+        UPDATE escrow_transfers
+        SET status = 'claimed', receiver_id = $2, transaction_id = $3, resolved_at = NOW()
+        WHERE id = $1
+        RETURNING resolved_at`
+
+	total := 0
+
+	for i := range escrows {
+		e := &escrows[i]
+
+		if err = tx.QueryRowContext(ctx, insertTx, e.SenderID, receiverID, e.Amount, e.Memo).Scan(&e.TransactionID); err != nil {
+			return nil, fmt.Errorf("insert transaction: %w", err)
+		}
+
+		if err = tx.QueryRowContext(ctx, markClaimed, e.ID, receiverID, e.TransactionID).Scan(&e.ResolvedAt); err != nil {
+			return nil, fmt.Errorf("mark escrow claimed: %w", err)
+		}
+
+		if err = auditlog.Enqueue(ctx, tx, audit.Transfer(e.SenderID, receiverID, e.Amount)); err != nil {
+			return nil, err
+		}
+
+		e.Status = entities.EscrowClaimed
+		e.ReceiverID = receiverID
+		total += e.Amount
+	}
+
+	const updateReceiver = `
+        UPDATE users
+        SET balance = balance + $1
+        WHERE id = $2`
+
+	if _, err = tx.ExecContext(ctx, updateReceiver, total, receiverID); err != nil {
+		return nil, fmt.Errorf("update receiver balance: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return escrows, nil
+}
+
+func (r *Repo) ListClaimable(ctx context.Context, limit int) ([]entities.User, error) {
+	const query = `
+        SELECT DISTINCT u.id, u.username
+        FROM escrow_transfers e
+        JOIN users u ON u.username = e.recipient_username
+        WHERE e.status = 'held'
+        LIMIT $1`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query claimable escrow users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []entities.User
+
+	for rows.Next() {
+		var u entities.User
+
+		if err := rows.Scan(&u.ID, &u.Username); err != nil {
+			return nil, fmt.Errorf("scan claimable escrow user: %w", err)
+		}
+
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *Repo) RefundExpired(ctx context.Context, limit int) ([]entities.Escrow, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	const query = `
+        WITH due AS (
+            SELECT id
+            FROM escrow_transfers
+            WHERE status = 'held' AND expires_at <= NOW()
+            ORDER BY expires_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        ), e AS (
+            UPDATE escrow_transfers t
+            SET status = 'refunded', resolved_at = NOW()
+            FROM due
+            WHERE t.id = due.id
+            RETURNING t.*
+        ), refund AS (
+            UPDATE users u
+            SET balance = u.balance + totals.amount
+            FROM (SELECT sender_id, SUM(amount) AS amount FROM e GROUP BY sender_id) totals
+            WHERE u.id = totals.sender_id
+        )
+        SELECT ` + escrowColumns + `
+        FROM e
+        JOIN users s ON e.sender_id = s.id`
+
+	refunded, err := r.queryEscrows(ctx, tx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range refunded {
+		if err = auditlog.Enqueue(ctx, tx, audit.EscrowRefund(e)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return refunded, nil
+}
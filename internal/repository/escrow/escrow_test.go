@@ -0,0 +1,160 @@
+package escrow_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/repository/escrow"
+	"merchshop/internal/repository/transaction"
+)
+
+var escrowColumns = []string{
+	"id", "sender_id", "sender_name", "recipient_username", "receiver_id", "amount",
+	"memo", "status", "transaction_id", "expires_at", "created_at", "resolved_at",
+}
+
+// монеты списываются у отправителя при создании удержания
+func TestRepo_Create_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := escrow.NewEscrowRepository(db)
+
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`UPDATE users\s+SET balance = balance - \$1`).
+		WithArgs(50, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectQuery(`INSERT INTO escrow_transfers`).
+		WithArgs(1, "newbie", 50, "welcome", expiresAt).
+		WillReturnRows(sqlmock.NewRows(escrowColumns).
+			AddRow(3, 1, "alice", "newbie", 0, 50, "welcome", "held", 0, expiresAt, now, nil))
+
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WithArgs(0, "coin.escrow_hold", "escrow", "3", "", "", nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	e, err := repo.Create(context.Background(), 1, "newbie", 50, "welcome", expiresAt, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, e.ID)
+	require.Equal(t, "held", e.Status)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// у отправителя не хватает монет
+func TestRepo_Create_InsufficientFunds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := escrow.NewEscrowRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`UPDATE users\s+SET balance = balance - \$1`).
+		WithArgs(50, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectRollback()
+
+	_, err = repo.Create(context.Background(), 1, "newbie", 50, "", time.Now(), nil)
+	require.ErrorIs(t, err, transaction.ErrInsufficientFunds)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// все удерживаемые переводы зачисляются получателю одной транзакцией
+func TestRepo_Claim_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := escrow.NewEscrowRepository(db)
+
+	now := time.Now()
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`FROM escrow_transfers e.*FOR UPDATE OF e`).
+		WithArgs("newbie").
+		WillReturnRows(sqlmock.NewRows(escrowColumns).
+			AddRow(3, 1, "alice", "newbie", 0, 50, "", "held", 0, now, now, nil).
+			AddRow(4, 2, "bob", "newbie", 0, 20, "", "held", 0, now, now, nil))
+
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(1, 7, 50, "").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectQuery(`UPDATE escrow_transfers\s+SET status = 'claimed'`).
+		WithArgs(3, 7, 11).
+		WillReturnRows(sqlmock.NewRows([]string{"resolved_at"}).AddRow(now))
+
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(2, 7, 20, "").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+	mock.ExpectQuery(`UPDATE escrow_transfers\s+SET status = 'claimed'`).
+		WithArgs(4, 7, 12).
+		WillReturnRows(sqlmock.NewRows([]string{"resolved_at"}).AddRow(now))
+
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`UPDATE users\s+SET balance = balance \+ \$1`).
+		WithArgs(70, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	claimed, err := repo.Claim(context.Background(), 7, "newbie")
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	require.Equal(t, "claimed", claimed[0].Status)
+	require.Equal(t, 12, claimed[1].TransactionID)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// просроченные переводы возвращаются отправителям одним запросом, каждый возврат попадает в журнал
+func TestRepo_RefundExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := escrow.NewEscrowRepository(db)
+
+	now := time.Now()
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SET status = 'refunded'`).
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows(escrowColumns).
+			AddRow(3, 1, "alice", "ghost", 0, 50, "", "refunded", 0, now, now, now))
+
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WithArgs(0, "coin.escrow_refund", "escrow", "3", "", "", nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	refunded, err := repo.RefundExpired(context.Background(), 100)
+	require.NoError(t, err)
+	require.Len(t, refunded, 1)
+	require.Equal(t, "refunded", refunded[0].Status)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,313 @@
+package fraud
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	entities "merchshop/internal/entity"
+)
+
+type Repository interface {
+	// FindRings ищет круговые переводы A→B→C→A, замкнувшиеся за window
+	FindRings(ctx context.Context, since time.Time, window time.Duration) ([]entities.FraudCase, error)
+	// FindBursts ищет отправителей, сделавших не меньше minOps операций за window. Пакет считается одной операцией
+	FindBursts(ctx context.Context, since time.Time, window time.Duration, minOps int) ([]entities.FraudCase, error)
+	// FindFunnels ищет получателей, которым перевели не меньше minSenders разных отправителей
+	FindFunnels(ctx context.Context, since time.Time, minSenders int) ([]entities.FraudCase, error)
+	// Flag сохраняет случай, если те же участники не ждут рассмотрения и эта активность еще не рассматривалась
+	Flag(ctx context.Context, c entities.FraudCase) (bool, error)
+	GetByID(ctx context.Context, id int) (*entities.FraudCase, error)
+	List(ctx context.Context, status string, limit int) ([]entities.FraudCase, error)
+	Dismiss(ctx context.Context, id, adminID int) error
+	// Freeze закрывает случай и замораживает аккаунты userIDs в одной транзакции. Уволенные и уже
+	// замороженные аккаунты не меняются
+	Freeze(ctx context.Context, id, adminID int, userIDs []int) error
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewFraudRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+func (r *Repo) queryFindings(ctx context.Context, kind, query string, args ...interface{}) ([]entities.FraudCase, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query %s findings: %w", kind, err)
+	}
+	defer rows.Close()
+
+	var findings []entities.FraudCase
+
+	for rows.Next() {
+		var (
+			c       = entities.FraudCase{Kind: kind}
+			userIDs pq.Int64Array
+		)
+
+		if err := rows.Scan(&userIDs, &c.LastTransactionID, &c.Transfers, &c.Amount); err != nil {
+			return nil, fmt.Errorf("scan %s finding: %w", kind, err)
+		}
+
+		c.UserIDs = toInts(userIDs)
+		findings = append(findings, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return findings, nil
+}
+
+func (r *Repo) FindRings(ctx context.Context, since time.Time, window time.Duration) ([]entities.FraudCase, error) {
+	// Кольцо начинается с участника с наименьшим id, так одно кольцо не находится трижды.
+	// В amount попадают монеты, прошедшие полный круг
+	const query = `
+        SELECT ARRAY[t1.sender_id, t2.sender_id, t3.sender_id],
+               MAX(GREATEST(t1.id, t2.id, t3.id)),
+               COUNT(DISTINCT t1.id) + COUNT(DISTINCT t2.id) + COUNT(DISTINCT t3.id),
+               MAX(LEAST(t1.amount, t2.amount, t3.amount))
+        FROM transactions t1
+        JOIN transactions t2 ON t2.sender_id = t1.receiver_id
+            AND t2.receiver_id <> t1.sender_id
+            AND t2.created_at BETWEEN t1.created_at AND t1.created_at + $2 * INTERVAL '1 second'
+        JOIN transactions t3 ON t3.sender_id = t2.receiver_id
+            AND t3.receiver_id = t1.sender_id
+            AND t3.created_at BETWEEN t2.created_at AND t1.created_at + $2 * INTERVAL '1 second'
+        WHERE t1.created_at >= $1
+          AND t1.sender_id < t2.sender_id AND t1.sender_id < t3.sender_id
+        GROUP BY t1.sender_id, t2.sender_id, t3.sender_id`
+
+	return r.queryFindings(ctx, entities.FraudRing, query, since, window.Seconds())
+}
+
+func (r *Repo) FindBursts(ctx context.Context, since time.Time, window time.Duration, minOps int) ([]entities.FraudCase, error) {
+	const query = `
+        WITH ops AS (
+            SELECT sender_id, MAX(id) AS last_id, COUNT(*) AS transfers, SUM(amount) AS amount,
+                   MIN(created_at) AS created_at
+            FROM transactions
+            WHERE created_at >= $1
+            GROUP BY sender_id, COALESCE(batch_id, -id)
+        ), windows AS (
+            SELECT sender_id, last_id,
+                   COUNT(*) OVER w AS ops,
+                   SUM(transfers) OVER w AS transfers,
+                   SUM(amount) OVER w AS amount
+            FROM ops
+            WINDOW w AS (
+                PARTITION BY sender_id ORDER BY created_at
+                RANGE BETWEEN $2 * INTERVAL '1 second' PRECEDING AND CURRENT ROW
+            )
+        )
+        SELECT ARRAY[sender_id], MAX(last_id), MAX(transfers), MAX(amount)
+        FROM windows
+        WHERE ops >= $3
+        GROUP BY sender_id`
+
+	return r.queryFindings(ctx, entities.FraudBurst, query, since, window.Seconds(), minOps)
+}
+
+func (r *Repo) FindFunnels(ctx context.Context, since time.Time, minSenders int) ([]entities.FraudCase, error) {
+	const query = `
+        SELECT receiver_id || array_agg(DISTINCT sender_id ORDER BY sender_id),
+               MAX(id), COUNT(*), SUM(amount)
+        FROM transactions
+        WHERE created_at >= $1
+        GROUP BY receiver_id
+        HAVING COUNT(DISTINCT sender_id) >= $2`
+
+	return r.queryFindings(ctx, entities.FraudFunnel, query, since, minSenders)
+}
+
+func (r *Repo) Flag(ctx context.Context, c entities.FraudCase) (bool, error) {
+	const query = `
+        INSERT INTO fraud_cases (kind, user_ids, last_transaction_id, transfers, amount)
+        SELECT $1::varchar, $2::bigint[], $3::bigint, $4::int, $5::bigint
+        WHERE NOT EXISTS (
+            SELECT 1
+            FROM fraud_cases
+            WHERE kind = $1 AND user_ids = $2 AND (status = 'open' OR last_transaction_id >= $3)
+        )`
+
+	result, err := r.db.ExecContext(ctx, query, c.Kind, pq.Array(toInt64s(c.UserIDs)), c.LastTransactionID, c.Transfers, c.Amount)
+	if err != nil {
+		return false, fmt.Errorf("insert fraud case: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+const caseColumns = `c.id, c.kind, c.user_ids,
+               ARRAY(
+                   SELECT u.username
+                   FROM unnest(c.user_ids) WITH ORDINALITY m(id, pos)
+                   JOIN users u ON u.id = m.id
+                   ORDER BY m.pos
+               ),
+               c.last_transaction_id, c.transfers, c.amount, c.status, c.created_at, c.resolved_at,
+               COALESCE(c.resolved_by, 0)`
+
+func scanCase(row interface{ Scan(...any) error }) (*entities.FraudCase, error) {
+	var (
+		c       entities.FraudCase
+		userIDs pq.Int64Array
+	)
+
+	err := row.Scan(&c.ID, &c.Kind, &userIDs, pq.Array(&c.Usernames), &c.LastTransactionID, &c.Transfers,
+		&c.Amount, &c.Status, &c.CreatedAt, &c.ResolvedAt, &c.ResolvedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	c.UserIDs = toInts(userIDs)
+
+	return &c, nil
+}
+
+func (r *Repo) GetByID(ctx context.Context, id int) (*entities.FraudCase, error) {
+	const query = `
+        SELECT ` + caseColumns + `
+        FROM fraud_cases c
+        WHERE c.id = $1`
+
+	c, err := scanCase(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("get fraud case %d: %w", id, err)
+	}
+
+	return c, nil
+}
+
+func (r *Repo) List(ctx context.Context, status string, limit int) ([]entities.FraudCase, error) {
+	const query = `
+        SELECT ` + caseColumns + `
+        FROM fraud_cases c
+        WHERE $1 = '' OR c.status = $1
+        ORDER BY c.id DESC
+        LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query fraud cases: %w", err)
+	}
+	defer rows.Close()
+
+	var cases []entities.FraudCase
+
+	for rows.Next() {
+		c, err := scanCase(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan fraud case: %w", err)
+		}
+
+		cases = append(cases, *c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return cases, nil
+}
+
+func (r *Repo) Dismiss(ctx context.Context, id, adminID int) error {
+	const query = `
+        UPDATE fraud_cases
+        SET status = 'dismissed', resolved_at = NOW(), resolved_by = $2
+        WHERE id = $1 AND status = 'open'`
+
+	result, err := r.db.ExecContext(ctx, query, id, adminID)
+	if err != nil {
+		return fmt.Errorf("dismiss fraud case %d: %w", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("dismiss fraud case %d: %w", id, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *Repo) Freeze(ctx context.Context, id, adminID int, userIDs []int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	const closeCase = `
+        UPDATE fraud_cases
+        SET status = 'frozen', resolved_at = NOW(), resolved_by = $2
+        WHERE id = $1 AND status = 'open'`
+
+	result, err := tx.ExecContext(ctx, closeCase, id, adminID)
+	if err != nil {
+		return fmt.Errorf("close fraud case %d: %w", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("close fraud case %d: %w", id, sql.ErrNoRows)
+	}
+
+	// Замороженным можно входить, поэтому уволенный аккаунт заморозка вернула бы к жизни
+	const freezeUsers = `
+        UPDATE users
+        SET status = 'frozen'
+        WHERE id = ANY($1) AND status = 'active'`
+
+	if _, err = tx.ExecContext(ctx, freezeUsers, pq.Array(toInt64s(userIDs))); err != nil {
+		return fmt.Errorf("freeze users: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func toInts(values []int64) []int {
+	result := make([]int, len(values))
+	for i, v := range values {
+		result[i] = int(v)
+	}
+
+	return result
+}
+
+func toInt64s(values []int) []int64 {
+	result := make([]int64, len(values))
+	for i, v := range values {
+		result[i] = int64(v)
+	}
+
+	return result
+}
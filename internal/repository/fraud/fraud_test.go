@@ -0,0 +1,108 @@
+package fraud_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/fraud"
+)
+
+var findingColumns = []string{"user_ids", "last_transaction_id", "transfers", "amount"}
+
+// кольцо возвращается один раз, начиная с участника с наименьшим id
+func TestRepo_FindRings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := fraud.NewFraudRepository(db)
+
+	since := time.Now().Add(-24 * time.Hour)
+
+	mock.ExpectQuery(`FROM transactions t1\s+JOIN transactions t2`).
+		WithArgs(since, float64(86400)).
+		WillReturnRows(sqlmock.NewRows(findingColumns).AddRow("{1,2,3}", 42, 3, 100))
+
+	rings, err := repo.FindRings(context.Background(), since, 24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, rings, 1)
+	require.Equal(t, entities.FraudRing, rings[0].Kind)
+	require.Equal(t, []int{1, 2, 3}, rings[0].UserIDs)
+	require.Equal(t, 42, rings[0].LastTransactionID)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// повторная находка без новой активности не создает случай
+func TestRepo_Flag_AlreadyReviewed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := fraud.NewFraudRepository(db)
+
+	mock.ExpectExec(`INSERT INTO fraud_cases`).
+		WithArgs("burst", sqlmock.AnyArg(), 42, 12, 600).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	created, err := repo.Flag(context.Background(), entities.FraudCase{
+		Kind: entities.FraudBurst, UserIDs: []int{7}, LastTransactionID: 42, Transfers: 12, Amount: 600,
+	})
+	require.NoError(t, err)
+	require.False(t, created)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// заморозка закрывает случай и блокирует аккаунты в одной транзакции
+func TestRepo_Freeze_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := fraud.NewFraudRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`UPDATE fraud_cases\s+SET status = 'frozen'`).
+		WithArgs(5, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`UPDATE users\s+SET status = 'frozen'\s+WHERE id = ANY\(\$1\) AND status = 'active'`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Freeze(context.Background(), 5, 1, []int{2, 3}))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// случай уже рассмотрен другим администратором
+func TestRepo_Freeze_NotOpen(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := fraud.NewFraudRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`UPDATE fraud_cases\s+SET status = 'frozen'`).
+		WithArgs(5, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectRollback()
+
+	err = repo.Freeze(context.Background(), 5, 1, []int{2})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,105 @@
+package leaderboard
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	entities "merchshop/internal/entity"
+)
+
+type Repository interface {
+	// Refresh пересчитывает суммы рейтингов. Чтение рейтингов во время пересчета не блокируется
+	Refresh(ctx context.Context) error
+	// Top возвращает первые limit мест рейтинга board за период period. Пользователи, скрывшие
+	// себя из рейтингов, и деактивированные аккаунты в нем не участвуют
+	Top(ctx context.Context, board, period string, limit int) ([]entities.LeaderboardEntry, error)
+	// OptedOut скрыл ли пользователь себя из рейтингов
+	OptedOut(ctx context.Context, userID int) (bool, error)
+	// SetOptOut скрывает пользователя из рейтингов или возвращает обратно. Если пользователя
+	// нет, возвращает sql.ErrNoRows
+	SetOptOut(ctx context.Context, userID int, optOut bool) error
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewLeaderboardRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+func (r *Repo) Refresh(ctx context.Context) error {
+	const query = `REFRESH MATERIALIZED VIEW CONCURRENTLY leaderboard_totals`
+
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("refresh leaderboard totals: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) Top(ctx context.Context, board, period string, limit int) ([]entities.LeaderboardEntry, error) {
+	// Места считаются только среди видимых пользователей, чтобы в рейтинге не было пропусков
+	const query = `
+        SELECT RANK() OVER (ORDER BY l.total DESC), l.user_id, u.username, l.total, l.refreshed_at
+        FROM leaderboard_totals l
+        JOIN users u ON u.id = l.user_id
+        WHERE l.board = $1 AND l.period = $2 AND NOT u.leaderboard_opt_out AND u.status <> 'deactivated'
+        ORDER BY l.total DESC, u.username
+        LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, board, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query %s leaderboard for %s: %w", board, period, err)
+	}
+	defer rows.Close()
+
+	var entries []entities.LeaderboardEntry
+
+	for rows.Next() {
+		var e entities.LeaderboardEntry
+		if err := rows.Scan(&e.Rank, &e.UserID, &e.Username, &e.Total, &e.RefreshedAt); err != nil {
+			return nil, fmt.Errorf("scan leaderboard entry: %w", err)
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *Repo) OptedOut(ctx context.Context, userID int) (bool, error) {
+	const query = `SELECT leaderboard_opt_out FROM users WHERE id = $1`
+
+	var optOut bool
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&optOut); err != nil {
+		return false, fmt.Errorf("get leaderboard opt-out of user %d: %w", userID, err)
+	}
+
+	return optOut, nil
+}
+
+func (r *Repo) SetOptOut(ctx context.Context, userID int, optOut bool) error {
+	const query = `UPDATE users SET leaderboard_opt_out = $2 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, userID, optOut)
+	if err != nil {
+		return fmt.Errorf("set leaderboard opt-out of user %d: %w", userID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("set leaderboard opt-out of user %d: %w", userID, sql.ErrNoRows)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package leaderboard_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/repository/leaderboard"
+)
+
+// Тест чтения рейтинга без скрытых и деактивированных пользователей
+func TestLeaderboard_Top(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := leaderboard.NewLeaderboardRepository(db)
+
+	refreshedAt := time.Now()
+
+	mock.ExpectQuery(`SELECT RANK\(\) OVER \(ORDER BY l.total DESC\), .* FROM leaderboard_totals l JOIN users u ON u.id = l.user_id `+
+		`WHERE l.board = \$1 AND l.period = \$2 AND NOT u.leaderboard_opt_out AND u.status <> 'deactivated' .* LIMIT \$3`).
+		WithArgs("senders", "week", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"rank", "user_id", "username", "total", "refreshed_at"}).
+			AddRow(1, 2, "bob", 300, refreshedAt).
+			AddRow(1, 3, "carol", 300, refreshedAt).
+			AddRow(3, 1, "alice", 50, refreshedAt))
+
+	entries, err := repo.Top(context.Background(), "senders", "week", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, 1, entries[1].Rank)
+	require.Equal(t, "carol", entries[1].Username)
+	require.Equal(t, 3, entries[2].Rank)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест пересчета рейтингов без блокировки чтения
+func TestLeaderboard_Refresh(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := leaderboard.NewLeaderboardRepository(db)
+
+	mock.ExpectExec(`REFRESH MATERIALIZED VIEW CONCURRENTLY leaderboard_totals`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.Refresh(context.Background()))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест скрытия несуществующего пользователя из рейтингов
+func TestLeaderboard_SetOptOut_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := leaderboard.NewLeaderboardRepository(db)
+
+	mock.ExpectExec(`UPDATE users SET leaderboard_opt_out = \$2 WHERE id = \$1`).
+		WithArgs(42, true).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.SetOptOut(context.Background(), 42, true)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
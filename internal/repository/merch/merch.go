@@ -3,14 +3,67 @@ package merch
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	entities "merchshop/internal/entity"
 )
 
 type Repository interface {
+	// GetByName возвращает товар вместе с его вариантами
 	GetByName(ctx context.Context, name string) (*entities.Merchandise, error)
 	List(ctx context.Context) ([]entities.Merchandise, error)
+	// Restock добавляет quantity к остатку и, если threshold не nil, меняет порог предупреждения.
+	// Товар с вариантами пополняется через RestockVariant
+	Restock(ctx context.Context, name string, quantity int, threshold *int) (*entities.Merchandise, error)
+	GetVariant(ctx context.Context, sku string) (*entities.Variant, error)
+	// SaveVariant создает вариант или меняет атрибуты, цену и порог существующего. Остаток меняется через RestockVariant.
+	// Default делает вариант вариантом по умолчанию вместо прежнего, false его не снимает.
+	// Артикул не может совпадать с названием товара, иначе Resolve отдал бы вариант вместо товара
+	SaveVariant(ctx context.Context, v entities.Variant) error
+	RestockVariant(ctx context.Context, merchName, sku string, quantity int, threshold *int) (*entities.Variant, error)
+}
+
+// Resolve находит вариант по артикулу, а товар по названию. Товар с вариантами продается
+// вариантом по умолчанию, товар без вариантов возвращается как вариант с пустым артикулом
+func Resolve(ctx context.Context, r Repository, item string) (*entities.Variant, error) {
+	variant, err := r.GetVariant(ctx, item)
+	if err == nil {
+		return variant, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get variant %s: %w", item, err)
+	}
+
+	merch, err := r.GetByName(ctx, item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merchandise %s: %w", item, err)
+	}
+
+	for _, v := range merch.Variants {
+		if v.Default {
+			return &v, nil
+		}
+	}
+
+	if len(merch.Variants) > 0 {
+		skus := make([]string, len(merch.Variants))
+		for i, v := range merch.Variants {
+			skus[i] = v.SKU
+		}
+
+		return nil, fmt.Errorf("%s comes in variants, buy one of: %s", item, strings.Join(skus, ", "))
+	}
+
+	return &entities.Variant{
+		MerchName:         merch.Name,
+		Price:             merch.Price,
+		Stock:             merch.Stock,
+		LowStockThreshold: merch.LowStockThreshold,
+	}, nil
 }
 
 type Repo struct {
@@ -21,27 +74,90 @@ func NewMerchRepository(db *sql.DB) Repository {
 	return &Repo{db: db}
 }
 
+func scanMerch(row interface{ Scan(...any) error }) (*entities.Merchandise, error) {
+	var merch entities.Merchandise
+
+	if err := row.Scan(&merch.Name, &merch.Price, &merch.Stock, &merch.LowStockThreshold); err != nil {
+		return nil, err
+	}
+
+	return &merch, nil
+}
+
+const variantColumns = `v.sku, v.merch_name, v.attributes, v.price, COALESCE(v.price, m.price), v.stock, v.low_stock_threshold,
+       v.is_default`
+
+func scanVariant(row interface{ Scan(...any) error }) (*entities.Variant, error) {
+	var (
+		v             entities.Variant
+		attributes    []byte
+		priceOverride sql.NullInt64
+	)
+
+	err := row.Scan(&v.SKU, &v.MerchName, &attributes, &priceOverride, &v.Price, &v.Stock, &v.LowStockThreshold, &v.Default)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(attributes, &v.Attributes); err != nil {
+		return nil, fmt.Errorf("decode attributes of %s: %w", v.SKU, err)
+	}
+
+	if priceOverride.Valid {
+		price := int(priceOverride.Int64)
+		v.PriceOverride = &price
+	}
+
+	return &v, nil
+}
+
 func (r *Repo) GetByName(ctx context.Context, name string) (*entities.Merchandise, error) {
 	const query = `
-       SELECT name, price
+       SELECT name, price, stock, low_stock_threshold
        FROM merchandise
        WHERE name = $1`
 
-	var merch entities.Merchandise
-	err := r.db.QueryRowContext(ctx, query, name).
-		Scan(&merch.Name, &merch.Price)
-
+	merch, err := scanMerch(r.db.QueryRowContext(ctx, query, name))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get merchandise by name: %w", err)
 	}
 
-	return &merch, nil
+	const variantsQuery = `
+       SELECT ` + variantColumns + `
+       FROM merch_variants v
+       JOIN merchandise m ON m.name = v.merch_name
+       WHERE v.merch_name = $1
+       ORDER BY v.sku`
+
+	variants, err := r.queryVariants(ctx, variantsQuery, name)
+	if err != nil {
+		return nil, err
+	}
+
+	setVariants(merch, variants)
+
+	return merch, nil
+}
+
+// setVariants прикрепляет варианты к товару. Товар с вариантами продается только ими,
+// поэтому его остаток считается по вариантам
+func setVariants(merch *entities.Merchandise, variants []entities.Variant) {
+	merch.Variants = variants
+	if len(variants) == 0 {
+		return
+	}
+
+	merch.Stock = 0
+	for _, v := range variants {
+		merch.Stock += v.Stock
+	}
 }
 
 func (r *Repo) List(ctx context.Context) ([]entities.Merchandise, error) {
 	const query = `
-		SELECT *
-		FROM merchandise`
+		SELECT name, price, stock, low_stock_threshold
+		FROM merchandise
+		ORDER BY name`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -52,19 +168,187 @@ func (r *Repo) List(ctx context.Context) ([]entities.Merchandise, error) {
 	var merchItems []entities.Merchandise
 
 	for rows.Next() {
-		var merch entities.Merchandise
-
-		if err := rows.Scan(&merch.Name, &merch.Price); err != nil {
+		merch, err := scanMerch(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan merchandise: %w", err)
 		}
 
-		merchItems = append(merchItems, merch)
+		merchItems = append(merchItems, *merch)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error after scanning merchandise: %w", err)
 	}
 
+	const variantsQuery = `
+		SELECT ` + variantColumns + `
+		FROM merch_variants v
+		JOIN merchandise m ON m.name = v.merch_name
+		ORDER BY v.merch_name, v.sku`
+
+	variants, err := r.queryVariants(ctx, variantsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	byMerch := make(map[string][]entities.Variant)
+	for _, v := range variants {
+		byMerch[v.MerchName] = append(byMerch[v.MerchName], v)
+	}
+
+	for i := range merchItems {
+		setVariants(&merchItems[i], byMerch[merchItems[i].Name])
+	}
+
 	return merchItems, err
 
 }
+
+func (r *Repo) queryVariants(ctx context.Context, query string, args ...interface{}) ([]entities.Variant, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query variants: %w", err)
+	}
+	defer rows.Close()
+
+	var variants []entities.Variant
+
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan variant: %w", err)
+		}
+
+		variants = append(variants, *v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning variants: %w", err)
+	}
+
+	return variants, nil
+}
+
+func (r *Repo) Restock(ctx context.Context, name string, quantity int, threshold *int) (*entities.Merchandise, error) {
+	const query = `
+       UPDATE merchandise
+       SET stock = stock + $2, low_stock_threshold = COALESCE($3, low_stock_threshold)
+       WHERE name = $1
+       RETURNING name, price, stock, low_stock_threshold`
+
+	merch, err := scanMerch(r.db.QueryRowContext(ctx, query, name, quantity, threshold))
+	if err != nil {
+		return nil, fmt.Errorf("failed to restock merchandise %s: %w", name, err)
+	}
+
+	return merch, nil
+}
+
+func (r *Repo) GetVariant(ctx context.Context, sku string) (*entities.Variant, error) {
+	const query = `
+       SELECT ` + variantColumns + `
+       FROM merch_variants v
+       JOIN merchandise m ON m.name = v.merch_name
+       WHERE v.sku = $1`
+
+	v, err := scanVariant(r.db.QueryRowContext(ctx, query, sku))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant %s: %w", sku, err)
+	}
+
+	return v, nil
+}
+
+func (r *Repo) SaveVariant(ctx context.Context, v entities.Variant) error {
+	attributes, err := json.Marshal(v.Attributes)
+	if err != nil {
+		return fmt.Errorf("encode attributes of %s: %w", v.SKU, err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	const nameTaken = `SELECT EXISTS (SELECT 1 FROM merchandise WHERE name = $1)`
+
+	var taken bool
+
+	if err = tx.QueryRowContext(ctx, nameTaken, v.SKU).Scan(&taken); err != nil {
+		return fmt.Errorf("check merchandise name %s: %w", v.SKU, err)
+	}
+
+	if taken {
+		return fmt.Errorf("sku %s is a merchandise name", v.SKU)
+	}
+
+	if v.Default {
+		const clearDefault = `
+       UPDATE merch_variants
+       SET is_default = FALSE
+       WHERE merch_name = $1 AND sku <> $2 AND is_default`
+
+		if _, err = tx.ExecContext(ctx, clearDefault, v.MerchName, v.SKU); err != nil {
+			return fmt.Errorf("clear default variant of %s: %w", v.MerchName, err)
+		}
+	}
+
+	// Артикул, занятый другим товаром, не перезаписывается. Первый вариант товара становится
+	// вариантом по умолчанию, чтобы покупка по названию не сломалась
+	const query = `
+       INSERT INTO merch_variants (sku, merch_name, attributes, price, stock, low_stock_threshold, is_default)
+       VALUES ($1, $2, $3, $4, $5, $6, $7 OR NOT EXISTS (SELECT 1 FROM merch_variants WHERE merch_name = $2))
+       ON CONFLICT (sku) DO UPDATE
+       SET attributes = EXCLUDED.attributes, price = EXCLUDED.price, low_stock_threshold = EXCLUDED.low_stock_threshold,
+           is_default = merch_variants.is_default OR $7
+       WHERE merch_variants.merch_name = EXCLUDED.merch_name`
+
+	result, err := tx.ExecContext(ctx, query, v.SKU, v.MerchName, attributes, v.PriceOverride, v.Stock, v.LowStockThreshold, v.Default)
+	if err != nil {
+		return fmt.Errorf("save variant %s: %w", v.SKU, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("sku %s belongs to another merchandise", v.SKU)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) RestockVariant(ctx context.Context, merchName, sku string, quantity int, threshold *int) (*entities.Variant, error) {
+	const query = `
+       UPDATE merch_variants
+       SET stock = stock + $3, low_stock_threshold = COALESCE($4, low_stock_threshold)
+       WHERE merch_name = $1 AND sku = $2`
+
+	result, err := r.db.ExecContext(ctx, query, merchName, sku, quantity, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restock variant %s: %w", sku, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("failed to restock variant %s: %w", sku, sql.ErrNoRows)
+	}
+
+	return r.GetVariant(ctx, sku)
+}
@@ -12,6 +12,8 @@ import (
 	"merchshop/internal/repository/merch"
 )
 
+var variantColumns = []string{"sku", "merch_name", "attributes", "price", "coalesce", "stock", "low_stock_threshold", "is_default"}
+
 // Тест на успешное получение товара по имени
 func TestMerch_GetByName_Success(t *testing.T) {
 	db, mock, err := sqlmock.New()
@@ -20,18 +22,33 @@ func TestMerch_GetByName_Success(t *testing.T) {
 
 	repo := merch.NewMerchRepository(db)
 
+	override := 90
 	expected := &entity.Merchandise{
-		Name:  "t-shirt",
-		Price: 80,
+		Name:              "t-shirt",
+		Price:             80,
+		Stock:             10,
+		LowStockThreshold: 5,
+		Variants: []entity.Variant{
+			{SKU: "t-shirt-l", MerchName: "t-shirt", Attributes: map[string]string{"size": "L"}, PriceOverride: &override, Price: 90, Stock: 3, LowStockThreshold: 5},
+			{SKU: "t-shirt-m", MerchName: "t-shirt", Attributes: map[string]string{"size": "M"}, Price: 80, Stock: 7, LowStockThreshold: 5, Default: true},
+		},
 	}
 
-	rows := sqlmock.NewRows([]string{"name", "price"}).
-		AddRow(expected.Name, expected.Price)
+	rows := sqlmock.NewRows([]string{"name", "price", "stock", "low_stock_threshold"}).
+		AddRow(expected.Name, expected.Price, 100, expected.LowStockThreshold)
 
-	mock.ExpectQuery(`SELECT name, price FROM merchandise WHERE name = \$1`).
+	mock.ExpectQuery(`SELECT name, price, stock, low_stock_threshold FROM merchandise WHERE name = \$1`).
 		WithArgs(expected.Name).
 		WillReturnRows(rows)
 
+	// собственный остаток товара с вариантами не показывается, вместо него сумма по вариантам
+
+	mock.ExpectQuery(`FROM merch_variants v\s+JOIN merchandise m ON m.name = v.merch_name\s+WHERE v.merch_name = \$1`).
+		WithArgs(expected.Name).
+		WillReturnRows(sqlmock.NewRows(variantColumns).
+			AddRow("t-shirt-l", "t-shirt", []byte(`{"size": "L"}`), 90, 90, 3, 5, false).
+			AddRow("t-shirt-m", "t-shirt", []byte(`{"size": "M"}`), nil, 80, 7, 5, true))
+
 	ctx := context.Background()
 	got, err := repo.GetByName(ctx, expected.Name)
 
@@ -49,7 +66,7 @@ func TestMerch_GetByName_NotFound(t *testing.T) {
 
 	repo := merch.NewMerchRepository(db)
 
-	mock.ExpectQuery(`SELECT name, price FROM merchandise WHERE name = \$1`).
+	mock.ExpectQuery(`SELECT name, price, stock, low_stock_threshold FROM merchandise WHERE name = \$1`).
 		WithArgs("Unknown").
 		WillReturnError(sql.ErrNoRows)
 
@@ -70,11 +87,15 @@ func TestMerch_List_Success(t *testing.T) {
 
 	repo := merch.NewMerchRepository(db)
 
-	rows := sqlmock.NewRows([]string{"name", "price"}).
-		AddRow("powerbank", 200).
-		AddRow("t-shirt", 80)
+	rows := sqlmock.NewRows([]string{"name", "price", "stock", "low_stock_threshold"}).
+		AddRow("powerbank", 200, 3, 5).
+		AddRow("t-shirt", 80, 40, 5)
+
+	mock.ExpectQuery(`SELECT name, price, stock, low_stock_threshold FROM merchandise ORDER BY name`).WillReturnRows(rows)
 
-	mock.ExpectQuery(`SELECT \* FROM merchandise`).WillReturnRows(rows)
+	mock.ExpectQuery(`FROM merch_variants v\s+JOIN merchandise m ON m.name = v.merch_name\s+ORDER BY v.merch_name, v.sku`).
+		WillReturnRows(sqlmock.NewRows(variantColumns).
+			AddRow("t-shirt-m", "t-shirt", []byte(`{"size": "M"}`), nil, 80, 7, 5, true))
 
 	ctx := context.Background()
 	items, err := repo.List(ctx)
@@ -83,6 +104,8 @@ func TestMerch_List_Success(t *testing.T) {
 	require.Len(t, items, 2)
 	require.Equal(t, "powerbank", items[0].Name)
 	require.Equal(t, 80, items[1].Price)
+	require.Empty(t, items[0].Variants)
+	require.Len(t, items[1].Variants, 1)
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
@@ -95,7 +118,7 @@ func TestMerch_List_QueryError(t *testing.T) {
 
 	repo := merch.NewMerchRepository(db)
 
-	mock.ExpectQuery(`SELECT \* FROM merchandise`).
+	mock.ExpectQuery(`SELECT name, price, stock, low_stock_threshold FROM merchandise ORDER BY name`).
 		WillReturnError(sql.ErrConnDone)
 
 	ctx := context.Background()
@@ -115,10 +138,10 @@ func TestMerch_List_ScanError(t *testing.T) {
 
 	repo := merch.NewMerchRepository(db)
 
-	rows := sqlmock.NewRows([]string{"name", "price"}).
-		AddRow("wallet", "not int")
+	rows := sqlmock.NewRows([]string{"name", "price", "stock", "low_stock_threshold"}).
+		AddRow("wallet", "not int", 1, 5)
 
-	mock.ExpectQuery(`SELECT \* FROM merchandise`).WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT name, price, stock, low_stock_threshold FROM merchandise ORDER BY name`).WillReturnRows(rows)
 
 	ctx := context.Background()
 	items, err := repo.List(ctx)
@@ -128,3 +151,128 @@ func TestMerch_List_ScanError(t *testing.T) {
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+// Тест пополнения остатка без изменения порога
+func TestMerch_Restock_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := merch.NewMerchRepository(db)
+
+	mock.ExpectQuery(`UPDATE merchandise\s+SET stock = stock \+ \$2`).
+		WithArgs("cup", 20, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "price", "stock", "low_stock_threshold"}).
+			AddRow("cup", 20, 23, 5))
+
+	got, err := repo.Restock(context.Background(), "cup", 20, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, 23, got.Stock)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Артикул другого товара не перезаписывается
+func TestMerch_SaveVariant_ForeignSKU(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := merch.NewMerchRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM merchandise WHERE name = \$1\)`).
+		WithArgs("cup-red").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`INSERT INTO merch_variants`).
+		WithArgs("cup-red", "hoody", []byte(`{"color":"red"}`), nil, 0, 5, false).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.SaveVariant(context.Background(), entity.Variant{
+		SKU: "cup-red", MerchName: "hoody", Attributes: map[string]string{"color": "red"}, LowStockThreshold: 5,
+	})
+	require.ErrorContains(t, err, "belongs to another merchandise")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Артикул, совпадающий с названием товара, перехватил бы покупку этого товара
+func TestMerch_SaveVariant_SKUIsMerchName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := merch.NewMerchRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM merchandise WHERE name = \$1\)`).
+		WithArgs("cup").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	err = repo.SaveVariant(context.Background(), entity.Variant{
+		SKU: "cup", MerchName: "hoody", Attributes: map[string]string{"color": "red"}, LowStockThreshold: 5,
+	})
+	require.ErrorContains(t, err, "is a merchandise name")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// новый вариант по умолчанию снимает этот признак с прежнего в той же транзакции
+func TestMerch_SaveVariant_Default(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := merch.NewMerchRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM merchandise WHERE name = \$1\)`).
+		WithArgs("t-shirt-l").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`UPDATE merch_variants\s+SET is_default = FALSE`).
+		WithArgs("t-shirt", "t-shirt-l").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO merch_variants`).
+		WithArgs("t-shirt-l", "t-shirt", []byte(`{"size":"L"}`), nil, 0, 5, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.SaveVariant(context.Background(), entity.Variant{
+		SKU: "t-shirt-l", MerchName: "t-shirt", Attributes: map[string]string{"size": "L"}, LowStockThreshold: 5, Default: true,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// покупка по названию товара с вариантами достается варианту по умолчанию
+func TestResolve_ByNameUsesDefaultVariant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := merch.NewMerchRepository(db)
+
+	mock.ExpectQuery(`FROM merch_variants v\s+JOIN merchandise m ON m.name = v.merch_name\s+WHERE v.sku = \$1`).
+		WithArgs("t-shirt").
+		WillReturnError(sql.ErrNoRows)
+
+	mock.ExpectQuery(`SELECT name, price, stock, low_stock_threshold FROM merchandise WHERE name = \$1`).
+		WithArgs("t-shirt").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "price", "stock", "low_stock_threshold"}).AddRow("t-shirt", 80, 0, 5))
+
+	mock.ExpectQuery(`WHERE v.merch_name = \$1`).
+		WithArgs("t-shirt").
+		WillReturnRows(sqlmock.NewRows(variantColumns).
+			AddRow("t-shirt-l", "t-shirt", []byte(`{"size": "L"}`), nil, 80, 3, 5, false).
+			AddRow("t-shirt-m", "t-shirt", []byte(`{"size": "M"}`), nil, 80, 7, 5, true))
+
+	v, err := merch.Resolve(context.Background(), repo, "t-shirt")
+	require.NoError(t, err)
+	require.Equal(t, "t-shirt-m", v.SKU)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,195 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	entities "merchshop/internal/entity"
+)
+
+type Repository interface {
+	// Create сохраняет уведомление. Если пользователь отключил этот тип, ничего не создает
+	// и возвращает sql.ErrNoRows
+	Create(ctx context.Context, n entities.Notification) (*entities.Notification, error)
+	// List возвращает уведомления пользователя, новые первыми. Ненулевой before возвращает
+	// уведомления старше него, unreadOnly только непрочитанные
+	List(ctx context.Context, userID int, unreadOnly bool, before, limit int) ([]entities.Notification, error)
+	CountUnread(ctx context.Context, userID int) (int, error)
+	// MarkRead отмечает прочитанными уведомления ids, пустой список отмечает все. Возвращает
+	// число отмеченных
+	MarkRead(ctx context.Context, userID int, ids []int) (int, error)
+	// Preferences возвращает явно сохраненные настройки пользователя
+	Preferences(ctx context.Context, userID int) ([]entities.NotificationPreference, error)
+	SetPreference(ctx context.Context, userID int, p entities.NotificationPreference) error
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+const notificationColumns = `id, user_id, kind, message, data, read_at, created_at`
+
+func scanNotification(row interface{ Scan(...any) error }) (*entities.Notification, error) {
+	var (
+		n    entities.Notification
+		data []byte
+	)
+
+	if err := row.Scan(&n.ID, &n.UserID, &n.Kind, &n.Message, &data, &n.ReadAt, &n.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &n.Data); err != nil {
+		return nil, fmt.Errorf("decode data of notification %d: %w", n.ID, err)
+	}
+
+	return &n, nil
+}
+
+func (r *Repo) Create(ctx context.Context, n entities.Notification) (*entities.Notification, error) {
+	const query = `
+        INSERT INTO notifications (user_id, kind, message, data)
+        SELECT $1::bigint, $2::varchar, $3::text, $4::jsonb
+        WHERE NOT EXISTS (
+            SELECT 1
+            FROM notification_preferences
+            WHERE user_id = $1 AND kind = $2 AND NOT enabled
+        )
+        RETURNING ` + notificationColumns
+
+	data := []byte(`{}`)
+	if n.Data != nil {
+		encoded, err := json.Marshal(n.Data)
+		if err != nil {
+			return nil, fmt.Errorf("encode notification data: %w", err)
+		}
+
+		data = encoded
+	}
+
+	created, err := scanNotification(r.db.QueryRowContext(ctx, query, n.UserID, n.Kind, n.Message, data))
+	if err != nil {
+		return nil, fmt.Errorf("create notification for user %d: %w", n.UserID, err)
+	}
+
+	return created, nil
+}
+
+func (r *Repo) List(ctx context.Context, userID int, unreadOnly bool, before, limit int) ([]entities.Notification, error) {
+	const query = `
+        SELECT ` + notificationColumns + `
+        FROM notifications
+        WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL) AND ($3 = 0 OR id < $3)
+        ORDER BY id DESC
+        LIMIT $4`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, unreadOnly, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []entities.Notification
+
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan notification: %w", err)
+		}
+
+		notifications = append(notifications, *n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (r *Repo) CountUnread(ctx context.Context, userID int) (int, error) {
+	const query = `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`
+
+	var unread int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&unread); err != nil {
+		return 0, fmt.Errorf("count unread notifications of user %d: %w", userID, err)
+	}
+
+	return unread, nil
+}
+
+func (r *Repo) MarkRead(ctx context.Context, userID int, ids []int) (int, error) {
+	const query = `
+        UPDATE notifications
+        SET read_at = NOW()
+        WHERE user_id = $1 AND read_at IS NULL AND (cardinality($2::bigint[]) = 0 OR id = ANY($2))`
+
+	// nil ушел бы в запрос как NULL, а не пустой массив
+	if ids == nil {
+		ids = []int{}
+	}
+
+	result, err := r.db.ExecContext(ctx, query, userID, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("mark notifications of user %d read: %w", userID, err)
+	}
+
+	marked, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return int(marked), nil
+}
+
+func (r *Repo) Preferences(ctx context.Context, userID int) ([]entities.NotificationPreference, error) {
+	const query = `
+        SELECT kind, enabled
+        FROM notification_preferences
+        WHERE user_id = $1
+        ORDER BY kind`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	var prefs []entities.NotificationPreference
+
+	for rows.Next() {
+		var p entities.NotificationPreference
+		if err := rows.Scan(&p.Kind, &p.Enabled); err != nil {
+			return nil, fmt.Errorf("scan notification preference: %w", err)
+		}
+
+		prefs = append(prefs, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return prefs, nil
+}
+
+func (r *Repo) SetPreference(ctx context.Context, userID int, p entities.NotificationPreference) error {
+	const query = `
+        INSERT INTO notification_preferences (user_id, kind, enabled)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id, kind) DO UPDATE SET enabled = EXCLUDED.enabled`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, p.Kind, p.Enabled); err != nil {
+		return fmt.Errorf("save notification preference %s of user %d: %w", p.Kind, userID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package notification_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/repository/notification"
+)
+
+// Тест создания уведомления без данных: data сохраняется пустым объектом
+func TestNotification_Create_EmptyData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := notification.NewNotificationRepository(db)
+
+	mock.ExpectQuery(`INSERT INTO notifications \(user_id, kind, message, data\)`).
+		WithArgs(1, "wishlist.affordable", "hello", []byte(`{}`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "kind", "message", "data", "read_at", "created_at"}).
+			AddRow(5, 1, "wishlist.affordable", "hello", []byte(`{}`), nil, time.Now()))
+
+	n, err := repo.Create(context.Background(), entity.Notification{UserID: 1, Kind: "wishlist.affordable", Message: "hello"})
+	require.NoError(t, err)
+	require.Equal(t, 5, n.ID)
+	require.Empty(t, n.Data)
+	require.Nil(t, n.ReadAt)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест создания уведомления отключенного типа: запись не создается
+func TestNotification_Create_Disabled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := notification.NewNotificationRepository(db)
+
+	mock.ExpectQuery(`INSERT INTO notifications .* WHERE NOT EXISTS \( SELECT 1 FROM notification_preferences `+
+		`WHERE user_id = \$1 AND kind = \$2 AND NOT enabled \)`).
+		WithArgs(1, "coin.received", "hello", []byte(`{}`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "kind", "message", "data", "read_at", "created_at"}))
+
+	_, err = repo.Create(context.Background(), entity.Notification{UserID: 1, Kind: "coin.received", Message: "hello"})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест постраничного списка непрочитанных уведомлений
+func TestNotification_List_UnreadBefore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := notification.NewNotificationRepository(db)
+
+	mock.ExpectQuery(`FROM notifications WHERE user_id = \$1 AND \(NOT \$2 OR read_at IS NULL\) `+
+		`AND \(\$3 = 0 OR id < \$3\) ORDER BY id DESC LIMIT \$4`).
+		WithArgs(1, true, 10, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "kind", "message", "data", "read_at", "created_at"}).
+			AddRow(9, 1, "coin.received", "a", []byte(`{"amount": 5}`), nil, time.Now()).
+			AddRow(8, 1, "gift.received", "b", []byte(`{}`), nil, time.Now()))
+
+	list, err := repo.List(context.Background(), 1, true, 10, 2)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, float64(5), list[0].Data["amount"])
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест отметки всех уведомлений прочитанными
+func TestNotification_MarkRead_All(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := notification.NewNotificationRepository(db)
+
+	mock.ExpectExec(`UPDATE notifications SET read_at = NOW\(\) WHERE user_id = \$1 AND read_at IS NULL`).
+		WithArgs(1, "{}").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	marked, err := repo.MarkRead(context.Background(), 1, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, marked)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,212 @@
+package policy
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	entities "merchshop/internal/entity"
+)
+
+type Repository interface {
+	GetByRole(ctx context.Context, role string) (*entities.TransferPolicy, error)
+	List(ctx context.Context) ([]entities.TransferPolicy, error)
+	Upsert(ctx context.Context, p entities.TransferPolicy) (*entities.TransferPolicy, error)
+	Delete(ctx context.Context, role string) error
+	// Usage считает исходящие монеты пользователя с dayStart и weekStart и число операций с velocityStart
+	Usage(ctx context.Context, userID int, dayStart, weekStart, velocityStart time.Time) (*entities.PolicyUsage, error)
+}
+
+// Guard проверка политики по расходам, которую репозиторий выполняет в транзакции списания.
+// Nil Guard ничего не проверяет
+type Guard struct {
+	UserID        int
+	DayStart      time.Time
+	WeekStart     time.Time
+	VelocityStart time.Time
+	// Allow возвращает ошибку, если операция вместе с расходами usage нарушает политику
+	Allow func(usage entities.PolicyUsage) error
+}
+
+// CheckInTx блокирует строку пользователя, считает его расходы и вызывает Allow. Параллельные
+// списания того же пользователя ждут блокировку и видят расходы друг друга
+func (g *Guard) CheckInTx(ctx context.Context, tx *sql.Tx) error {
+	if g == nil {
+		return nil
+	}
+
+	const lockUser = `SELECT id FROM users WHERE id = $1 FOR UPDATE`
+
+	if _, err := tx.ExecContext(ctx, lockUser, g.UserID); err != nil {
+		return fmt.Errorf("lock user %d: %w", g.UserID, err)
+	}
+
+	spent, err := usage(ctx, tx, g.UserID, g.DayStart, g.WeekStart, g.VelocityStart)
+	if err != nil {
+		return err
+	}
+
+	return g.Allow(*spent)
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewPolicyRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+const policyColumns = `role, daily_limit, weekly_limit, max_transfer, min_account_age_seconds,
+               velocity_count, velocity_window_seconds, updated_at`
+
+func scanPolicy(row interface{ Scan(...any) error }) (*entities.TransferPolicy, error) {
+	var (
+		p             entities.TransferPolicy
+		minAgeSeconds int64
+		windowSeconds int64
+	)
+
+	err := row.Scan(&p.Role, &p.DailyLimit, &p.WeeklyLimit, &p.MaxTransfer, &minAgeSeconds,
+		&p.VelocityCount, &windowSeconds, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	p.MinAccountAge = time.Duration(minAgeSeconds) * time.Second
+	p.VelocityWindow = time.Duration(windowSeconds) * time.Second
+
+	return &p, nil
+}
+
+func (r *Repo) GetByRole(ctx context.Context, role string) (*entities.TransferPolicy, error) {
+	const query = `
+        SELECT ` + policyColumns + `
+        FROM transfer_policies
+        WHERE role = $1`
+
+	p, err := scanPolicy(r.db.QueryRowContext(ctx, query, role))
+	if err != nil {
+		return nil, fmt.Errorf("get policy for role %s: %w", role, err)
+	}
+
+	return p, nil
+}
+
+func (r *Repo) List(ctx context.Context) ([]entities.TransferPolicy, error) {
+	const query = `
+        SELECT ` + policyColumns + `
+        FROM transfer_policies
+        ORDER BY role`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []entities.TransferPolicy
+
+	for rows.Next() {
+		p, err := scanPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan policy: %w", err)
+		}
+
+		policies = append(policies, *p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return policies, nil
+}
+
+func (r *Repo) Upsert(ctx context.Context, p entities.TransferPolicy) (*entities.TransferPolicy, error) {
+	const query = `
+        INSERT INTO transfer_policies (role, daily_limit, weekly_limit, max_transfer, min_account_age_seconds,
+                                       velocity_count, velocity_window_seconds)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (role) DO UPDATE
+        SET daily_limit = EXCLUDED.daily_limit,
+            weekly_limit = EXCLUDED.weekly_limit,
+            max_transfer = EXCLUDED.max_transfer,
+            min_account_age_seconds = EXCLUDED.min_account_age_seconds,
+            velocity_count = EXCLUDED.velocity_count,
+            velocity_window_seconds = EXCLUDED.velocity_window_seconds,
+            updated_at = NOW()
+        RETURNING ` + policyColumns
+
+	saved, err := scanPolicy(r.db.QueryRowContext(ctx, query,
+		p.Role, p.DailyLimit, p.WeeklyLimit, p.MaxTransfer, int64(p.MinAccountAge/time.Second),
+		p.VelocityCount, int64(p.VelocityWindow/time.Second),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("upsert policy for role %s: %w", p.Role, err)
+	}
+
+	return saved, nil
+}
+
+func (r *Repo) Delete(ctx context.Context, role string) error {
+	const query = `DELETE FROM transfer_policies WHERE role = $1`
+
+	result, err := r.db.ExecContext(ctx, query, role)
+	if err != nil {
+		return fmt.Errorf("delete policy for role %s: %w", role, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("delete policy for role %s: %w", role, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *Repo) Usage(ctx context.Context, userID int, dayStart, weekStart, velocityStart time.Time) (*entities.PolicyUsage, error) {
+	return usage(ctx, r.db, userID, dayStart, weekStart, velocityStart)
+}
+
+func usage(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, userID int, dayStart, weekStart, velocityStart time.Time) (*entities.PolicyUsage, error) {
+	// Удерживаемые переводы учитываются, пока не зачислены: после зачисления они попадают в transactions.
+	// Отмененные заказы не учитываются, монеты за них возвращены. Подарок тратит монеты оплатившего
+	const query = `
+        WITH outgoing AS (
+            SELECT amount, created_at
+            FROM transactions
+            WHERE sender_id = $1 AND created_at >= LEAST($2::timestamptz, $3::timestamptz, $4::timestamptz)
+            UNION ALL
+            SELECT total_price, created_at
+            FROM purchases
+            WHERE (user_id = $1 AND buyer_id IS NULL OR buyer_id = $1) AND status <> 'cancelled'
+              AND created_at >= LEAST($2::timestamptz, $3::timestamptz, $4::timestamptz)
+            UNION ALL
+            SELECT amount, created_at
+            FROM escrow_transfers
+            WHERE sender_id = $1 AND status = 'held'
+              AND created_at >= LEAST($2::timestamptz, $3::timestamptz, $4::timestamptz)
+        )
+        SELECT COALESCE(SUM(amount) FILTER (WHERE created_at >= $2), 0),
+               COALESCE(SUM(amount) FILTER (WHERE created_at >= $3), 0),
+               COUNT(*) FILTER (WHERE created_at >= $4)
+        FROM outgoing`
+
+	var usage entities.PolicyUsage
+
+	err := q.QueryRowContext(ctx, query, userID, dayStart, weekStart, velocityStart).
+		Scan(&usage.Day, &usage.Week, &usage.Operations)
+	if err != nil {
+		return nil, fmt.Errorf("get usage for user %d: %w", userID, err)
+	}
+
+	return &usage, nil
+}
@@ -0,0 +1,133 @@
+package policy_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/policy"
+)
+
+var policyColumns = []string{
+	"role", "daily_limit", "weekly_limit", "max_transfer", "min_account_age_seconds",
+	"velocity_count", "velocity_window_seconds", "updated_at",
+}
+
+// длительности хранятся в секундах
+func TestRepo_Upsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := policy.NewPolicyRepository(db)
+
+	now := time.Now()
+
+	mock.ExpectQuery(`INSERT INTO transfer_policies`).
+		WithArgs("employee", 500, 2000, 200, int64(86400), 10, int64(3600)).
+		WillReturnRows(sqlmock.NewRows(policyColumns).
+			AddRow("employee", 500, 2000, 200, 86400, 10, 3600, now))
+
+	p, err := repo.Upsert(context.Background(), entities.TransferPolicy{
+		Role:           "employee",
+		DailyLimit:     500,
+		WeeklyLimit:    2000,
+		MaxTransfer:    200,
+		MinAccountAge:  24 * time.Hour,
+		VelocityCount:  10,
+		VelocityWindow: time.Hour,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 24*time.Hour, p.MinAccountAge)
+	require.Equal(t, time.Hour, p.VelocityWindow)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// у роли нет политики
+func TestRepo_GetByRole_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := policy.NewPolicyRepository(db)
+
+	mock.ExpectQuery(`FROM transfer_policies`).
+		WithArgs("employee").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = repo.GetByRole(context.Background(), "employee")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// расходы считаются одним запросом по переводам, покупкам и удержаниям
+func TestRepo_Usage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := policy.NewPolicyRepository(db)
+
+	now := time.Now()
+	day, week, velocity := now.Add(-24*time.Hour), now.Add(-7*24*time.Hour), now.Add(-time.Hour)
+
+	mock.ExpectQuery(`WITH outgoing AS`).
+		WithArgs(1, day, week, velocity).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "week", "operations"}).AddRow(120, 700, 3))
+
+	usage, err := repo.Usage(context.Background(), 1, day, week, velocity)
+	require.NoError(t, err)
+	require.Equal(t, entities.PolicyUsage{Day: 120, Week: 700, Operations: 3}, *usage)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// расходы считаются после блокировки пользователя, нарушение откатывает транзакцию
+func TestGuard_CheckInTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	guard := &policy.Guard{
+		UserID:        1,
+		DayStart:      now.Add(-24 * time.Hour),
+		WeekStart:     now.Add(-7 * 24 * time.Hour),
+		VelocityStart: now.Add(-time.Hour),
+		Allow: func(usage entities.PolicyUsage) error {
+			if usage.Day+50 > 100 {
+				return errors.New("daily limit exceeded")
+			}
+
+			return nil
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT id FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`WITH outgoing AS`).
+		WithArgs(1, guard.DayStart, guard.WeekStart, guard.VelocityStart).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "week", "operations"}).AddRow(60, 60, 1))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+
+	require.EqualError(t, guard.CheckInTx(context.Background(), tx), "daily limit exceeded")
+	require.NoError(t, tx.Rollback())
+
+	var none *policy.Guard
+	require.NoError(t, none.CheckInTx(context.Background(), tx))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
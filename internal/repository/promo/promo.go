@@ -0,0 +1,222 @@
+package promo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	entities "merchshop/internal/entity"
+)
+
+type Repository interface {
+	// SavePromo создает промокод или обновляет его условия
+	SavePromo(ctx context.Context, p entities.PromoCode) error
+	GetPromo(ctx context.Context, code string) (*entities.PromoCode, error)
+	ListPromos(ctx context.Context) ([]entities.PromoCode, error)
+	// CountUserUses сколько неотмененных покупок пользователь оплатил с промокодом, включая подарки
+	CountUserUses(ctx context.Context, code string, userID int) (int, error)
+
+	CreateSale(ctx context.Context, s entities.Sale) (*entities.Sale, error)
+	ListSales(ctx context.Context) ([]entities.Sale, error)
+	DeleteSale(ctx context.Context, id int) error
+	// ActiveSale возвращает распродажу с наибольшей скидкой на товар в момент at или sql.ErrNoRows
+	ActiveSale(ctx context.Context, item string, at time.Time) (*entities.Sale, error)
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewPromoRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+const promoColumns = `c.code, c.kind, c.value, c.max_uses, c.per_user_limit, c.items, c.starts_at, c.ends_at,
+               c.active, c.created_at,
+               (SELECT COUNT(*) FROM purchases p WHERE p.promo_code = c.code AND p.status <> 'cancelled')`
+
+func scanPromo(row interface{ Scan(...any) error }) (*entities.PromoCode, error) {
+	var p entities.PromoCode
+
+	err := row.Scan(&p.Code, &p.Kind, &p.Value, &p.MaxUses, &p.PerUserLimit, pq.Array(&p.Items), &p.StartsAt,
+		&p.EndsAt, &p.Active, &p.CreatedAt, &p.Uses)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (r *Repo) SavePromo(ctx context.Context, p entities.PromoCode) error {
+	const query = `
+        INSERT INTO promo_codes (code, kind, value, max_uses, per_user_limit, items, starts_at, ends_at, active)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        ON CONFLICT (code) DO UPDATE
+        SET kind = EXCLUDED.kind,
+            value = EXCLUDED.value,
+            max_uses = EXCLUDED.max_uses,
+            per_user_limit = EXCLUDED.per_user_limit,
+            items = EXCLUDED.items,
+            starts_at = EXCLUDED.starts_at,
+            ends_at = EXCLUDED.ends_at,
+            active = EXCLUDED.active`
+
+	_, err := r.db.ExecContext(ctx, query, p.Code, p.Kind, p.Value, p.MaxUses, p.PerUserLimit,
+		pq.Array(p.Items), p.StartsAt, p.EndsAt, p.Active)
+	if err != nil {
+		return fmt.Errorf("save promo code %s: %w", p.Code, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) GetPromo(ctx context.Context, code string) (*entities.PromoCode, error) {
+	const query = `
+        SELECT ` + promoColumns + `
+        FROM promo_codes c
+        WHERE c.code = $1`
+
+	p, err := scanPromo(r.db.QueryRowContext(ctx, query, code))
+	if err != nil {
+		return nil, fmt.Errorf("get promo code %s: %w", code, err)
+	}
+
+	return p, nil
+}
+
+func (r *Repo) ListPromos(ctx context.Context) ([]entities.PromoCode, error) {
+	const query = `
+        SELECT ` + promoColumns + `
+        FROM promo_codes c
+        ORDER BY c.created_at DESC, c.code`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query promo codes: %w", err)
+	}
+	defer rows.Close()
+
+	var promos []entities.PromoCode
+
+	for rows.Next() {
+		p, err := scanPromo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan promo code: %w", err)
+		}
+
+		promos = append(promos, *p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return promos, nil
+}
+
+func (r *Repo) CountUserUses(ctx context.Context, code string, userID int) (int, error) {
+	const query = `
+        SELECT COUNT(*)
+        FROM purchases
+        WHERE promo_code = $1 AND COALESCE(buyer_id, user_id) = $2 AND status <> 'cancelled'`
+
+	var uses int
+	if err := r.db.QueryRowContext(ctx, query, code, userID).Scan(&uses); err != nil {
+		return 0, fmt.Errorf("count uses of promo code %s: %w", code, err)
+	}
+
+	return uses, nil
+}
+
+const saleColumns = `id, name, percent, items, starts_at, ends_at, created_at`
+
+func scanSale(row interface{ Scan(...any) error }) (*entities.Sale, error) {
+	var s entities.Sale
+
+	if err := row.Scan(&s.ID, &s.Name, &s.Percent, pq.Array(&s.Items), &s.StartsAt, &s.EndsAt, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (r *Repo) CreateSale(ctx context.Context, s entities.Sale) (*entities.Sale, error) {
+	const query = `
+        INSERT INTO sales (name, percent, items, starts_at, ends_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING ` + saleColumns
+
+	created, err := scanSale(r.db.QueryRowContext(ctx, query, s.Name, s.Percent, pq.Array(s.Items), s.StartsAt, s.EndsAt))
+	if err != nil {
+		return nil, fmt.Errorf("insert sale: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *Repo) ListSales(ctx context.Context) ([]entities.Sale, error) {
+	const query = `
+        SELECT ` + saleColumns + `
+        FROM sales
+        ORDER BY starts_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query sales: %w", err)
+	}
+	defer rows.Close()
+
+	var sales []entities.Sale
+
+	for rows.Next() {
+		s, err := scanSale(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan sale: %w", err)
+		}
+
+		sales = append(sales, *s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return sales, nil
+}
+
+func (r *Repo) DeleteSale(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sales WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete sale %d: %w", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("delete sale %d: %w", id, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *Repo) ActiveSale(ctx context.Context, item string, at time.Time) (*entities.Sale, error) {
+	const query = `
+        SELECT ` + saleColumns + `
+        FROM sales
+        WHERE starts_at <= $2 AND ends_at > $2 AND (cardinality(items) = 0 OR $1 = ANY(items))
+        ORDER BY percent DESC, id
+        LIMIT 1`
+
+	s, err := scanSale(r.db.QueryRowContext(ctx, query, item, at))
+	if err != nil {
+		return nil, fmt.Errorf("get active sale for %s: %w", item, err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,77 @@
+package promo_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/repository/promo"
+)
+
+// Тест получения промокода вместе с числом использований
+func TestPromo_GetPromo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := promo.NewPromoRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT c.code, c.kind, .* FROM purchases p WHERE p.promo_code = c.code AND p.status <> 'cancelled'\) ` +
+		`FROM promo_codes c WHERE c.code = \$1`).
+		WithArgs("SPRING").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"code", "kind", "value", "max_uses", "per_user_limit", "items", "starts_at", "ends_at", "active",
+			"created_at", "uses",
+		}).AddRow("SPRING", "percent", 20, 100, 1, "{hoody,cup}", now, nil, true, now, 42))
+
+	p, err := repo.GetPromo(context.Background(), "SPRING")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hoody", "cup"}, p.Items)
+	require.Equal(t, 42, p.Uses)
+	require.Nil(t, p.EndsAt)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест поиска распродажи, когда ни одна не действует
+func TestPromo_ActiveSale_None(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := promo.NewPromoRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery(`FROM sales WHERE starts_at <= \$2 AND ends_at > \$2 AND \(cardinality\(items\) = 0 OR \$1 = ANY\(items\)\) `+
+		`ORDER BY percent DESC, id LIMIT 1`).
+		WithArgs("cup", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "percent", "items", "starts_at", "ends_at", "created_at"}))
+
+	_, err = repo.ActiveSale(context.Background(), "cup", now)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест удаления несуществующей распродажи
+func TestPromo_DeleteSale_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := promo.NewPromoRepository(db)
+
+	mock.ExpectExec(`DELETE FROM sales WHERE id = \$1`).
+		WithArgs(9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.DeleteSale(context.Background(), 9)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,14 +3,47 @@ package purchase
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/lib/pq"
+
+	"merchshop/internal/audit"
+	"merchshop/internal/eligibility"
 	entities "merchshop/internal/entity"
+	"merchshop/internal/pricing"
+	"merchshop/internal/repository/auditlog"
+	"merchshop/internal/repository/policy"
 )
 
+// ErrOutOfStock на складе меньше товара, чем покупается
+var ErrOutOfStock = errors.New("out of stock")
+
 type Repository interface {
-	CreatePurchase(ctx context.Context, userId int, merchName string, quantity int) error
+	// CreatePurchase списывает монеты и товар со склада и возвращает покупку и оставшийся остаток.
+	// Если sku не пустой, цена и остаток берутся у варианта. Цена считается с учетом распродаж
+	// и промокода promoCode, если он не пустой. Ограничения товара и guard проверяются в той же транзакции
+	CreatePurchase(ctx context.Context, userId int, merchName, sku string, quantity int, promoCode string, guard *policy.Guard) (*entities.Purchase, int, error)
+	// CreateGift покупает товар за счет buyerID в инвентарь recipientID в той же транзакции, что
+	// и CreatePurchase. Ограничения товара проверяются для получателя, лимиты промокода для покупателя
+	CreateGift(ctx context.Context, buyerID, recipientID int, merchName, sku string, quantity int, promoCode, message string, guard *policy.Guard) (*entities.Purchase, int, error)
+	// GetByUserId возвращает инвентарь пользователя, включая полученные подарки
 	GetByUserId(ctx context.Context, userId int) ([]entities.Purchase, error)
+	// GetGiftsSent возвращает подарки, оплаченные пользователем, новые первыми
+	GetGiftsSent(ctx context.Context, buyerID int) ([]entities.Purchase, error)
+	GetOrder(ctx context.Context, id int) (*entities.Purchase, error)
+	// ListOrders возвращает заказы в статусе status, пустой статус возвращает все
+	ListOrders(ctx context.Context, status string, limit int) ([]entities.Purchase, error)
+	// MarkReady и MarkDelivered переводят заказ на следующий шаг выдачи. Заказ не в том
+	// статусе дает sql.ErrNoRows
+	MarkReady(ctx context.Context, id int, location string) error
+	MarkDelivered(ctx context.Context, id int) error
+	// Cancel отменяет невыданный заказ, возвращает монеты покупателю и товар на склад.
+	// Ненулевой userID отменяет только оплаченный этим пользователем заказ или подарок.
+	// Возвращает сумму возврата
+	Cancel(ctx context.Context, id, userID int) (int, error)
 }
 
 type Repo struct {
@@ -21,10 +54,27 @@ func NewPurchaseRepository(db *sql.DB) Repository {
 	return &Repo{db: db}
 }
 
-func (r *Repo) CreatePurchase(ctx context.Context, userId int, merchName string, quantity int) error {
+func (r *Repo) CreatePurchase(ctx context.Context, userId int, merchName, sku string, quantity int, promoCode string, guard *policy.Guard) (*entities.Purchase, int, error) {
+	return r.create(ctx, userId, userId, merchName, sku, quantity, promoCode, "", guard)
+}
+
+func (r *Repo) CreateGift(ctx context.Context, buyerID, recipientID int, merchName, sku string, quantity int, promoCode, message string, guard *policy.Guard) (*entities.Purchase, int, error) {
+	return r.create(ctx, buyerID, recipientID, merchName, sku, quantity, promoCode, message, guard)
+}
+
+// create списывает монеты с buyerID, а товар кладет в инвентарь userId. Для обычной покупки
+// это один и тот же пользователь
+func (r *Repo) create(
+	ctx context.Context,
+	buyerID, userId int,
+	merchName, sku string,
+	quantity int,
+	promoCode, message string,
+	guard *policy.Guard,
+) (*entities.Purchase, int, error) {
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
-		return fmt.Errorf("begin transaction: %w", err)
+		return nil, 0, fmt.Errorf("begin transaction: %w", err)
 	}
 
 	defer func() {
@@ -33,61 +83,284 @@ func (r *Repo) CreatePurchase(ctx context.Context, userId int, merchName string,
 		}
 	}()
 
-	// Получаем цену товара
-	var price int
+	// Получаем цену товара и блокируем остаток до конца транзакции
+	var (
+		price, stock int
+		item         = merchName
+	)
 
-	err = tx.QueryRowContext(ctx, `
-       SELECT price 
+	if sku == "" {
+		err = tx.QueryRowContext(ctx, `
+       SELECT price, stock
        FROM merchandise 
-       WHERE name = $1`, merchName).Scan(&price)
+       WHERE name = $1
+       FOR UPDATE`, merchName).Scan(&price, &stock)
+	} else {
+		item = sku
+		err = tx.QueryRowContext(ctx, `
+       SELECT COALESCE(v.price, m.price), v.stock
+       FROM merch_variants v
+       JOIN merchandise m ON m.name = v.merch_name
+       WHERE v.sku = $1 AND v.merch_name = $2
+       FOR UPDATE OF v`, sku, merchName).Scan(&price, &stock)
+	}
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("get merchandise price: %w", err)
+	}
 
+	if stock < quantity {
+		return nil, 0, fmt.Errorf("%w: %s has %d left", ErrOutOfStock, item, stock)
+	}
+
+	if err := checkRuleInTx(ctx, tx, userId, merchName, quantity); err != nil {
+		return nil, 0, err
+	}
+
+	quote, err := priceInTx(ctx, tx, buyerID, merchName, price, quantity, promoCode)
 	if err != nil {
-		return fmt.Errorf("get merchandise price: %w", err)
+		return nil, 0, err
 	}
 
-	totalPrice := price * quantity
+	if err = guard.CheckInTx(ctx, tx); err != nil {
+		return nil, 0, err
+	}
 
-	// Списываем деньги с баланса пользователя
+	// Списываем деньги с баланса покупателя
 	result, err := tx.ExecContext(ctx, `
        UPDATE users 
        SET balance = balance - $1 
-       WHERE id = $2 AND balance >= $1`, totalPrice, userId)
+       WHERE id = $2 AND balance >= $1`, quote.Total, buyerID)
 	if err != nil {
-		return fmt.Errorf("update user balance: %w", err)
+		return nil, 0, fmt.Errorf("update user balance: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("get rows affected: %w", err)
+		return nil, 0, fmt.Errorf("get rows affected: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("insufficient funds")
+		return nil, 0, fmt.Errorf("insufficient funds")
+	}
+
+	// Списываем товар со склада
+	if sku == "" {
+		_, err = tx.ExecContext(ctx, `
+       UPDATE merchandise
+       SET stock = stock - $1
+       WHERE name = $2`, quantity, merchName)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+       UPDATE merch_variants
+       SET stock = stock - $1
+       WHERE sku = $2`, quantity, sku)
+	}
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("update merchandise stock: %w", err)
+	}
+
+	purchase := entities.Purchase{
+		UserID:     userId,
+		MerchName:  merchName,
+		SKU:        sku,
+		Quantity:   quantity,
+		TotalPrice: quote.Total,
+		ListPrice:  quote.ListPrice,
+		Discount:   quote.Discount,
+		PromoCode:  quote.PromoCode,
+	}
+
+	if buyerID != userId {
+		purchase.BuyerID = buyerID
+		purchase.GiftMessage = message
 	}
 
 	// Создаем запись о покупке
-	_, err = tx.ExecContext(ctx, `
-       INSERT INTO purchases (user_id, merch_name, quantity, total_price)
-       VALUES ($1, $2, $3, $4)`, userId, merchName, quantity, totalPrice)
+	err = tx.QueryRowContext(ctx, `
+       INSERT INTO purchases (user_id, merch_name, sku, quantity, list_price, discount, promo_code, total_price,
+                              buyer_id, gift_message)
+       VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, NULLIF($7, ''), $8, NULLIF($9, 0), NULLIF($10, ''))
+       RETURNING id, status, created_at`, userId, merchName, sku, quantity, quote.ListPrice, quote.Discount,
+		quote.PromoCode, quote.Total, purchase.BuyerID, purchase.GiftMessage).
+		Scan(&purchase.ID, &purchase.Status, &purchase.CreatedAt)
 
 	if err != nil {
-		return fmt.Errorf("create purchase record: %w", err)
+		return nil, 0, fmt.Errorf("create purchase record: %w", err)
+	}
+
+	if err = auditlog.Enqueue(ctx, tx, audit.Purchase(purchase)); err != nil {
+		return nil, 0, err
 	}
 
 	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("commit transaction: %w", err)
+		return nil, 0, fmt.Errorf("commit transaction: %w", err)
 	}
 
-	return nil
+	return &purchase, stock - quantity, nil
 }
 
-func (r *Repo) GetByUserId(ctx context.Context, userId int) ([]entities.Purchase, error) {
-	rows, err := r.db.QueryContext(ctx, `
-       SELECT id, user_id, merch_name, quantity, total_price, created_at
+// checkRuleInTx проверяет ограничения товара для получателя. Его строка блокируется, чтобы его
+// параллельные покупки не превысили лимит на человека
+func checkRuleInTx(ctx context.Context, tx *sql.Tx, userId int, merchName string, quantity int) error {
+	var (
+		rule          = entities.PurchaseRule{Item: merchName}
+		minAgeSeconds int64
+	)
+
+	err := tx.QueryRowContext(ctx, `
+       SELECT max_per_user, roles, departments, min_account_age_seconds, available_from, available_until
+       FROM purchase_rules
+       WHERE merch_name = $1`, merchName).Scan(&rule.MaxPerUser, pq.Array(&rule.Roles), pq.Array(&rule.Departments),
+		&minAgeSeconds, &rule.AvailableFrom, &rule.AvailableUntil)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("get purchase rule: %w", err)
+	}
+
+	rule.MinAccountAge = time.Duration(minAgeSeconds) * time.Second
+
+	var buyer entities.User
+	err = tx.QueryRowContext(ctx, `
+       SELECT role, department, created_at
+       FROM users
+       WHERE id = $1
+       FOR UPDATE`, userId).Scan(&buyer.Role, &buyer.Department, &buyer.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("get buyer: %w", err)
+	}
+
+	var bought int
+	if rule.MaxPerUser > 0 {
+		err = tx.QueryRowContext(ctx, `
+       SELECT COALESCE(SUM(quantity), 0)
+       FROM purchases
+       WHERE user_id = $1 AND merch_name = $2 AND status <> 'cancelled'`, userId, merchName).Scan(&bought)
+		if err != nil {
+			return fmt.Errorf("count bought merchandise: %w", err)
+		}
+	}
+
+	return eligibility.Check(rule, buyer, bought, quantity, time.Now())
+}
+
+// priceInTx считает цену по распродаже и промокоду, действующим на момент покупки. Промокод
+// блокируется, чтобы параллельные покупки не превысили лимиты использований
+func priceInTx(ctx context.Context, tx *sql.Tx, userId int, merchName string, price, quantity int, promoCode string) (pricing.Quote, error) {
+	now := time.Now()
+
+	var (
+		sale *entities.Sale
+		s    entities.Sale
+	)
+
+	err := tx.QueryRowContext(ctx, `
+       SELECT id, percent
+       FROM sales
+       WHERE starts_at <= $2 AND ends_at > $2 AND (cardinality(items) = 0 OR $1 = ANY(items))
+       ORDER BY percent DESC, id
+       LIMIT 1`, merchName, now).Scan(&s.ID, &s.Percent)
+
+	switch {
+	case err == nil:
+		sale = &s
+	case !errors.Is(err, sql.ErrNoRows):
+		return pricing.Quote{}, fmt.Errorf("get active sale: %w", err)
+	}
+
+	if promoCode == "" {
+		return pricing.Apply(price, quantity, sale, nil), nil
+	}
+
+	var promo entities.PromoCode
+	err = tx.QueryRowContext(ctx, `
+       SELECT code, kind, value, max_uses, per_user_limit, items, starts_at, ends_at, active
+       FROM promo_codes
+       WHERE code = $1
+       FOR UPDATE`, promoCode).Scan(&promo.Code, &promo.Kind, &promo.Value, &promo.MaxUses, &promo.PerUserLimit,
+		pq.Array(&promo.Items), &promo.StartsAt, &promo.EndsAt, &promo.Active)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return pricing.Quote{}, fmt.Errorf("%w: %s not found", pricing.ErrInvalidPromo, promoCode)
+	}
+
+	if err != nil {
+		return pricing.Quote{}, fmt.Errorf("get promo code: %w", err)
+	}
+
+	var userUses int
+	err = tx.QueryRowContext(ctx, `
+       SELECT COUNT(*), COUNT(*) FILTER (WHERE COALESCE(buyer_id, user_id) = $2)
        FROM purchases
-       WHERE user_id = $1
-       ORDER BY created_at DESC`, userId)
+       WHERE promo_code = $1 AND status <> 'cancelled'`, promoCode, userId).Scan(&promo.Uses, &userUses)
+	if err != nil {
+		return pricing.Quote{}, fmt.Errorf("count promo code uses: %w", err)
+	}
+
+	if err := pricing.CheckPromo(promo, merchName, userUses, now); err != nil {
+		return pricing.Quote{}, err
+	}
+
+	return pricing.Apply(price, quantity, sale, &promo), nil
+}
+
+const purchaseColumns = `p.id, p.user_id, u.username, p.merch_name, COALESCE(p.sku, ''),
+              COALESCE(v.attributes, '{}'), p.quantity, p.list_price, p.discount, COALESCE(p.promo_code, ''),
+              p.total_price, p.created_at, p.status,
+              COALESCE(p.pickup_location, ''), p.ready_at, p.delivered_at, p.cancelled_at,
+              COALESCE(p.buyer_id, 0), COALESCE(b.username, ''), COALESCE(p.gift_message, '')`
+
+const purchaseJoins = `
+       FROM purchases p
+       JOIN users u ON u.id = p.user_id
+       LEFT JOIN users b ON b.id = p.buyer_id
+       LEFT JOIN merch_variants v ON v.sku = p.sku`
+
+func scanPurchase(row interface{ Scan(...any) error }) (*entities.Purchase, error) {
+	var (
+		purchase   entities.Purchase
+		attributes []byte
+	)
+
+	if err := row.Scan(
+		&purchase.ID,
+		&purchase.UserID,
+		&purchase.Username,
+		&purchase.MerchName,
+		&purchase.SKU,
+		&attributes,
+		&purchase.Quantity,
+		&purchase.ListPrice,
+		&purchase.Discount,
+		&purchase.PromoCode,
+		&purchase.TotalPrice,
+		&purchase.CreatedAt,
+		&purchase.Status,
+		&purchase.PickupLocation,
+		&purchase.ReadyAt,
+		&purchase.DeliveredAt,
+		&purchase.CancelledAt,
+		&purchase.BuyerID,
+		&purchase.BuyerName,
+		&purchase.GiftMessage,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(attributes, &purchase.Attributes); err != nil {
+		return nil, fmt.Errorf("decode attributes of purchase %d: %w", purchase.ID, err)
+	}
+
+	return &purchase, nil
+}
 
+func (r *Repo) queryPurchases(ctx context.Context, query string, args ...interface{}) ([]entities.Purchase, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query purchases: %w", err)
 	}
@@ -97,20 +370,12 @@ func (r *Repo) GetByUserId(ctx context.Context, userId int) ([]entities.Purchase
 	var purchases []entities.Purchase
 
 	for rows.Next() {
-		var purchase entities.Purchase
-
-		if err := rows.Scan(
-			&purchase.ID,
-			&purchase.UserID,
-			&purchase.MerchName,
-			&purchase.Quantity,
-			&purchase.TotalPrice,
-			&purchase.CreatedAt,
-		); err != nil {
+		purchase, err := scanPurchase(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan purchase: %w", err)
 		}
 
-		purchases = append(purchases, purchase)
+		purchases = append(purchases, *purchase)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -119,3 +384,132 @@ func (r *Repo) GetByUserId(ctx context.Context, userId int) ([]entities.Purchase
 
 	return purchases, nil
 }
+
+func (r *Repo) GetByUserId(ctx context.Context, userId int) ([]entities.Purchase, error) {
+	return r.queryPurchases(ctx, `
+       SELECT `+purchaseColumns+purchaseJoins+`
+       WHERE p.user_id = $1
+       ORDER BY p.created_at DESC`, userId)
+}
+
+func (r *Repo) GetGiftsSent(ctx context.Context, buyerID int) ([]entities.Purchase, error) {
+	return r.queryPurchases(ctx, `
+       SELECT `+purchaseColumns+purchaseJoins+`
+       WHERE p.buyer_id = $1
+       ORDER BY p.created_at DESC`, buyerID)
+}
+
+func (r *Repo) GetOrder(ctx context.Context, id int) (*entities.Purchase, error) {
+	purchase, err := scanPurchase(r.db.QueryRowContext(ctx, `
+       SELECT `+purchaseColumns+purchaseJoins+`
+       WHERE p.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("get order %d: %w", id, err)
+	}
+
+	return purchase, nil
+}
+
+func (r *Repo) ListOrders(ctx context.Context, status string, limit int) ([]entities.Purchase, error) {
+	// Склад выдает заказы в порядке очереди, поэтому старые идут первыми
+	return r.queryPurchases(ctx, `
+       SELECT `+purchaseColumns+purchaseJoins+`
+       WHERE $1 = '' OR p.status = $1
+       ORDER BY p.id
+       LIMIT $2`, status, limit)
+}
+
+func (r *Repo) MarkReady(ctx context.Context, id int, location string) error {
+	return r.advance(ctx, id, `
+       UPDATE purchases
+       SET status = 'ready_for_pickup', ready_at = NOW(), pickup_location = $2
+       WHERE id = $1 AND status = 'placed'`, id, location)
+}
+
+func (r *Repo) MarkDelivered(ctx context.Context, id int) error {
+	return r.advance(ctx, id, `
+       UPDATE purchases
+       SET status = 'delivered', delivered_at = NOW()
+       WHERE id = $1 AND status = 'ready_for_pickup'`, id)
+}
+
+func (r *Repo) advance(ctx context.Context, id int, query string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("update order %d: %w", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("update order %d: %w", id, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *Repo) Cancel(ctx context.Context, id, userID int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	var (
+		buyerID, quantity, refund int
+		merchName, sku            string
+	)
+
+	err = tx.QueryRowContext(ctx, `
+       UPDATE purchases
+       SET status = 'cancelled', cancelled_at = NOW()
+       WHERE id = $1 AND status IN ('placed', 'ready_for_pickup') AND ($2 = 0 OR COALESCE(buyer_id, user_id) = $2)
+       RETURNING COALESCE(buyer_id, user_id), merch_name, COALESCE(sku, ''), quantity, total_price`, id, userID).
+		Scan(&buyerID, &merchName, &sku, &quantity, &refund)
+	if err != nil {
+		return 0, fmt.Errorf("cancel order %d: %w", id, err)
+	}
+
+	// Возвращаем монеты покупателю
+	if _, err = tx.ExecContext(ctx, `
+       UPDATE users
+       SET balance = balance + $1
+       WHERE id = $2`, refund, buyerID); err != nil {
+		return 0, fmt.Errorf("refund order %d: %w", id, err)
+	}
+
+	// Возвращаем товар на склад
+	if sku == "" {
+		_, err = tx.ExecContext(ctx, `
+       UPDATE merchandise
+       SET stock = stock + $1
+       WHERE name = $2`, quantity, merchName)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+       UPDATE merch_variants
+       SET stock = stock + $1
+       WHERE sku = $2`, quantity, sku)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("restock order %d: %w", id, err)
+	}
+
+	if err = auditlog.Enqueue(ctx, tx, audit.OrderCancel(id, buyerID, sku, quantity, refund)); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return refund, nil
+}
@@ -3,7 +3,6 @@ package purchase_test
 import (
 	"context"
 	"database/sql"
-	"regexp"
 	"testing"
 	"time"
 
@@ -11,6 +10,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"merchshop/internal/eligibility"
+	"merchshop/internal/pricing"
 	"merchshop/internal/repository/purchase"
 )
 
@@ -33,25 +34,38 @@ func TestPurchase_Create_Success(t *testing.T) {
 
 	mock.ExpectBegin()
 
-	rowPrice := sqlmock.NewRows([]string{"price"}).AddRow(price)
+	rowPrice := sqlmock.NewRows([]string{"price", "stock"}).AddRow(price, 10)
 
-	mock.ExpectQuery(`SELECT price FROM merchandise WHERE name = \$1`).
+	mock.ExpectQuery(`SELECT price, stock FROM merchandise WHERE name = \$1 FOR UPDATE`).
 		WithArgs(merchName).
 		WillReturnRows(rowPrice)
 
+	expectNoRule(mock, merchName)
+	expectNoSale(mock, merchName)
+
 	mock.ExpectExec(`UPDATE users SET balance = balance - \$1`).
 		WithArgs(totalPrice, userID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	mock.ExpectExec(`INSERT INTO purchases`).
-		WithArgs(userID, merchName, quantity, totalPrice).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE merchandise SET stock = stock - \$1`).
+		WithArgs(quantity, merchName).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectQuery(`INSERT INTO purchases`).
+		WithArgs(userID, merchName, "", quantity, totalPrice, 0, "", totalPrice, 0, "").
+		WillReturnRows(insertedRows(1))
+
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	mock.ExpectCommit()
 
 	ctx := context.Background()
-	err = repo.CreatePurchase(ctx, userID, merchName, quantity)
+	p, left, err := repo.CreatePurchase(ctx, userID, merchName, "", quantity, "", nil)
 	require.NoError(t, err)
+	require.Equal(t, 8, left)
+	require.Equal(t, 1, p.ID)
+	require.Equal(t, totalPrice, p.TotalPrice)
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
@@ -75,12 +89,15 @@ func TestPurchase_Create_InsufficientFunds(t *testing.T) {
 
 	mock.ExpectBegin()
 
-	rowPrice := sqlmock.NewRows([]string{"price"}).AddRow(price)
+	rowPrice := sqlmock.NewRows([]string{"price", "stock"}).AddRow(price, 10)
 
-	mock.ExpectQuery(`SELECT price FROM merchandise WHERE name = \$1`).
+	mock.ExpectQuery(`SELECT price, stock FROM merchandise WHERE name = \$1 FOR UPDATE`).
 		WithArgs(merchName).
 		WillReturnRows(rowPrice)
 
+	expectNoRule(mock, merchName)
+	expectNoSale(mock, merchName)
+
 	mock.ExpectExec(`UPDATE users SET balance = balance - \$1`).
 		WithArgs(totalPrice, userID).
 		WillReturnResult(sqlmock.NewResult(0, 0)) // 0 row affected
@@ -88,7 +105,7 @@ func TestPurchase_Create_InsufficientFunds(t *testing.T) {
 	mock.ExpectRollback()
 
 	ctx := context.Background()
-	err = repo.CreatePurchase(ctx, userID, merchName, quantity)
+	_, _, err = repo.CreatePurchase(ctx, userID, merchName, "", quantity, "", nil)
 	require.ErrorContains(t, err, "insufficient funds")
 
 	require.NoError(t, mock.ExpectationsWereMet())
@@ -110,14 +127,14 @@ func TestRepo_Purchase_MerchNotFound(t *testing.T) {
 
 	mock.ExpectBegin()
 
-	mock.ExpectQuery(`SELECT price FROM merchandise WHERE name = \$1`).
+	mock.ExpectQuery(`SELECT price, stock FROM merchandise WHERE name = \$1 FOR UPDATE`).
 		WithArgs(merchName).
 		WillReturnError(sql.ErrNoRows)
 
 	mock.ExpectRollback()
 
 	ctx := context.Background()
-	err = repo.CreatePurchase(ctx, userID, merchName, quantity)
+	_, _, err = repo.CreatePurchase(ctx, userID, merchName, "", quantity, "", nil)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "get merchandise price")
 
@@ -126,6 +143,161 @@ func TestRepo_Purchase_MerchNotFound(t *testing.T) {
 	}
 }
 
+// Тест покупки, когда товара на складе меньше, чем нужно
+func TestPurchase_Create_OutOfStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := purchase.NewPurchaseRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT price, stock FROM merchandise WHERE name = \$1 FOR UPDATE`).
+		WithArgs("pink-hoody").
+		WillReturnRows(sqlmock.NewRows([]string{"price", "stock"}).AddRow(500, 1))
+
+	mock.ExpectRollback()
+
+	_, _, err = repo.CreatePurchase(context.Background(), 1, "pink-hoody", "", 2, "", nil)
+	require.ErrorIs(t, err, purchase.ErrOutOfStock)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест покупки варианта: цена и остаток берутся у варианта
+func TestPurchase_Create_Variant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := purchase.NewPurchaseRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT COALESCE\(v.price, m.price\), v.stock FROM merch_variants v`).
+		WithArgs("hoody-pink-m", "hoody").
+		WillReturnRows(sqlmock.NewRows([]string{"price", "stock"}).AddRow(500, 4))
+
+	expectNoRule(mock, "hoody")
+	expectNoSale(mock, "hoody")
+
+	mock.ExpectExec(`UPDATE users SET balance = balance - \$1`).
+		WithArgs(500, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`UPDATE merch_variants SET stock = stock - \$1 WHERE sku = \$2`).
+		WithArgs(1, "hoody-pink-m").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectQuery(`INSERT INTO purchases`).
+		WithArgs(1, "hoody", "hoody-pink-m", 1, 500, 0, "", 500, 0, "").
+		WillReturnRows(insertedRows(2))
+
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	_, left, err := repo.CreatePurchase(context.Background(), 1, "hoody", "hoody-pink-m", 1, "", nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, left)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест покупки по распродаже с промокодом: промокод применяется к цене после распродажи
+func TestPurchase_Create_SaleAndPromo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := purchase.NewPurchaseRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT price, stock FROM merchandise WHERE name = \$1 FOR UPDATE`).
+		WithArgs("cup").
+		WillReturnRows(sqlmock.NewRows([]string{"price", "stock"}).AddRow(20, 10))
+
+	expectNoRule(mock, "cup")
+
+	mock.ExpectQuery(`SELECT id, percent FROM sales`).
+		WithArgs("cup", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "percent"}).AddRow(4, 50))
+
+	mock.ExpectQuery(`SELECT code, kind, value, max_uses, per_user_limit, items, starts_at, ends_at, active ` +
+		`FROM promo_codes WHERE code = \$1 FOR UPDATE`).
+		WithArgs("MINUS5").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"code", "kind", "value", "max_uses", "per_user_limit", "items", "starts_at", "ends_at", "active",
+		}).AddRow("MINUS5", "fixed", 5, 10, 1, "{cup}", time.Now().Add(-time.Hour), nil, true))
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\), COUNT\(\*\) FILTER \(WHERE COALESCE\(buyer_id, user_id\) = \$2\) FROM purchases`).
+		WithArgs("MINUS5", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"uses", "user_uses"}).AddRow(3, 0))
+
+	mock.ExpectExec(`UPDATE users SET balance = balance - \$1`).
+		WithArgs(15, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`UPDATE merchandise SET stock = stock - \$1`).
+		WithArgs(2, "cup").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectQuery(`INSERT INTO purchases`).
+		WithArgs(1, "cup", "", 2, 40, 25, "MINUS5", 15, 0, "").
+		WillReturnRows(insertedRows(3))
+
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	p, _, err := repo.CreatePurchase(context.Background(), 1, "cup", "", 2, "MINUS5", nil)
+	require.NoError(t, err)
+	require.Equal(t, 40, p.ListPrice)
+	require.Equal(t, 25, p.Discount)
+	require.Equal(t, 15, p.TotalPrice)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест покупки с промокодом, который покупатель уже использовал
+func TestPurchase_Create_PromoPerUserLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := purchase.NewPurchaseRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT price, stock FROM merchandise WHERE name = \$1 FOR UPDATE`).
+		WithArgs("cup").
+		WillReturnRows(sqlmock.NewRows([]string{"price", "stock"}).AddRow(20, 10))
+
+	expectNoRule(mock, "cup")
+	expectNoSale(mock, "cup")
+
+	mock.ExpectQuery(`FROM promo_codes WHERE code = \$1 FOR UPDATE`).
+		WithArgs("WELCOME").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"code", "kind", "value", "max_uses", "per_user_limit", "items", "starts_at", "ends_at", "active",
+		}).AddRow("WELCOME", "percent", 10, 0, 1, "{}", time.Now().Add(-time.Hour), nil, true))
+
+	mock.ExpectQuery(`FROM purchases WHERE promo_code = \$1`).
+		WithArgs("WELCOME", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"uses", "user_uses"}).AddRow(7, 1))
+
+	mock.ExpectRollback()
+
+	_, _, err = repo.CreatePurchase(context.Background(), 1, "cup", "", 1, "WELCOME", nil)
+	require.ErrorIs(t, err, pricing.ErrInvalidPromo)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 // Тест получения покупок по пользователю
 func TestPurchase_GetByUserId(t *testing.T) {
 	db, mock, err := sqlmock.New()
@@ -137,15 +309,14 @@ func TestPurchase_GetByUserId(t *testing.T) {
 	userID := 1
 	now := time.Now()
 
-	mock.ExpectQuery(regexp.QuoteMeta(`
-       SELECT id, user_id, merch_name, quantity, total_price, created_at
-       FROM purchases
-       WHERE user_id = $1
-       ORDER BY created_at DESC`)).
+	mock.ExpectQuery(`SELECT p.id, p.user_id, u.username, .* FROM purchases p JOIN users u ON u.id = p.user_id ` +
+		`LEFT JOIN users b ON b.id = p.buyer_id LEFT JOIN merch_variants v ON v.sku = p.sku ` +
+		`WHERE p.user_id = \$1 ORDER BY p.created_at DESC`).
 		WithArgs(userID).
-		WillReturnRows(sqlmock.NewRows([]string{
-			"id", "user_id", "merch_name", "quantity", "total_price", "created_at",
-		}).AddRow(1, userID, "hoody", 2, 600, now))
+		WillReturnRows(purchaseRows().
+			AddRow(1, userID, "alice", "hoody", "hoody-pink-m", []byte(`{"size": "M", "color": "pink"}`), 2, 1000,
+				400, "SPRING", 600, now,
+				"ready_for_pickup", "reception", now, nil, nil, 2, "bob", "С днем рождения!"))
 
 	ctx := context.Background()
 	purchases, err := repo.GetByUserId(ctx, userID)
@@ -153,6 +324,223 @@ func TestPurchase_GetByUserId(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, purchases, 1)
 	require.Equal(t, "hoody", purchases[0].MerchName)
+	require.Equal(t, "hoody-pink-m", purchases[0].SKU)
+	require.Equal(t, "pink", purchases[0].Attributes["color"])
+	require.Equal(t, 400, purchases[0].Discount)
+	require.Equal(t, "SPRING", purchases[0].PromoCode)
+	require.Equal(t, "ready_for_pickup", purchases[0].Status)
+	require.Equal(t, "reception", purchases[0].PickupLocation)
+	require.NotNil(t, purchases[0].ReadyAt)
+	require.True(t, purchases[0].IsGift())
+	require.Equal(t, "bob", purchases[0].BuyerName)
+	require.Equal(t, "С днем рождения!", purchases[0].GiftMessage)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест покупки лимитированного товара сверх лимита на человека
+func TestPurchase_Create_RuleLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := purchase.NewPurchaseRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT price, stock FROM merchandise WHERE name = \$1 FOR UPDATE`).
+		WithArgs("pink-hoody").
+		WillReturnRows(sqlmock.NewRows([]string{"price", "stock"}).AddRow(500, 10))
+
+	mock.ExpectQuery(`SELECT max_per_user, roles, departments, min_account_age_seconds, available_from, available_until ` +
+		`FROM purchase_rules WHERE merch_name = \$1`).
+		WithArgs("pink-hoody").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"max_per_user", "roles", "departments", "min_account_age_seconds", "available_from", "available_until",
+		}).AddRow(1, "{}", "{}", 0, nil, nil))
+
+	mock.ExpectQuery(`SELECT role, department, created_at FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"role", "department", "created_at"}).
+			AddRow("employee", "", time.Now().AddDate(-1, 0, 0)))
+
+	mock.ExpectQuery(`SELECT COALESCE\(SUM\(quantity\), 0\) FROM purchases WHERE user_id = \$1 AND merch_name = \$2 AND status <> 'cancelled'`).
+		WithArgs(1, "pink-hoody").
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(1))
+
+	mock.ExpectRollback()
+
+	_, _, err = repo.CreatePurchase(context.Background(), 1, "pink-hoody", "", 1, "", nil)
+	require.ErrorIs(t, err, eligibility.ErrNotEligible)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест подарка: монеты списываются с покупателя, ограничения товара проверяются у получателя
+func TestPurchase_CreateGift(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := purchase.NewPurchaseRepository(db)
+
+	const (
+		buyerID     = 1
+		recipientID = 2
+	)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT price, stock FROM merchandise WHERE name = \$1 FOR UPDATE`).
+		WithArgs("pink-hoody").
+		WillReturnRows(sqlmock.NewRows([]string{"price", "stock"}).AddRow(500, 10))
+
+	mock.ExpectQuery(`FROM purchase_rules WHERE merch_name = \$1`).
+		WithArgs("pink-hoody").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"max_per_user", "roles", "departments", "min_account_age_seconds", "available_from", "available_until",
+		}).AddRow(1, "{}", "{}", 0, nil, nil))
+
+	mock.ExpectQuery(`SELECT role, department, created_at FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(recipientID).
+		WillReturnRows(sqlmock.NewRows([]string{"role", "department", "created_at"}).
+			AddRow("employee", "", time.Now().AddDate(-1, 0, 0)))
+
+	mock.ExpectQuery(`SELECT COALESCE\(SUM\(quantity\), 0\) FROM purchases WHERE user_id = \$1`).
+		WithArgs(recipientID, "pink-hoody").
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0))
+
+	expectNoSale(mock, "pink-hoody")
+
+	mock.ExpectExec(`UPDATE users SET balance = balance - \$1`).
+		WithArgs(500, buyerID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`UPDATE merchandise SET stock = stock - \$1`).
+		WithArgs(1, "pink-hoody").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectQuery(`INSERT INTO purchases`).
+		WithArgs(recipientID, "pink-hoody", "", 1, 500, 0, "", 500, buyerID, "Спасибо за релиз").
+		WillReturnRows(insertedRows(3))
+
+	// запись о подарке от имени оплатившего, без сообщения получателю
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WithArgs(1, "merch.purchase", "merch", "pink-hoody", "", "", nil,
+			`{"buyerId":1,"orderId":3,"quantity":1,"sku":"","total":500,"userId":2}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	p, _, err := repo.CreateGift(context.Background(), buyerID, recipientID, "pink-hoody", "", 1, "", "Спасибо за релиз", nil)
+	require.NoError(t, err)
+	require.Equal(t, recipientID, p.UserID)
+	require.Equal(t, buyerID, p.BuyerID)
+	require.True(t, p.IsGift())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func expectNoRule(mock sqlmock.Sqlmock, merchName string) {
+	mock.ExpectQuery(`FROM purchase_rules WHERE merch_name = \$1`).
+		WithArgs(merchName).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"max_per_user", "roles", "departments", "min_account_age_seconds", "available_from", "available_until",
+		}))
+}
+
+func expectNoSale(mock sqlmock.Sqlmock, merchName string) {
+	mock.ExpectQuery(`SELECT id, percent FROM sales`).
+		WithArgs(merchName, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "percent"}))
+}
+
+func insertedRows(id int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "status", "created_at"}).AddRow(id, "placed", time.Now())
+}
+
+func purchaseRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "user_id", "username", "merch_name", "sku", "attributes", "quantity", "list_price", "discount",
+		"promo_code", "total_price", "created_at", "status", "pickup_location", "ready_at", "delivered_at", "cancelled_at",
+		"buyer_id", "buyer_name", "gift_message",
+	})
+}
+
+// Тест выдачи заказа, который еще не готов
+func TestPurchase_MarkDelivered_WrongStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := purchase.NewPurchaseRepository(db)
+
+	mock.ExpectExec(`UPDATE purchases SET status = 'delivered', delivered_at = NOW\(\) ` +
+		`WHERE id = \$1 AND status = 'ready_for_pickup'`).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.MarkDelivered(context.Background(), 7)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест отмены заказа варианта: монеты возвращаются покупателю, товар на склад
+func TestPurchase_Cancel_Variant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := purchase.NewPurchaseRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`UPDATE purchases SET status = 'cancelled', cancelled_at = NOW\(\) `+
+		`WHERE id = \$1 AND status IN \('placed', 'ready_for_pickup'\) AND \(\$2 = 0 OR COALESCE\(buyer_id, user_id\) = \$2\) `+
+		`RETURNING COALESCE\(buyer_id, user_id\), merch_name, COALESCE\(sku, ''\), quantity, total_price`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "merch_name", "sku", "quantity", "total_price"}).
+			AddRow(1, "hoody", "hoody-pink-m", 2, 1000))
+
+	mock.ExpectExec(`UPDATE users SET balance = balance \+ \$1 WHERE id = \$2`).
+		WithArgs(1000, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`UPDATE merch_variants SET stock = stock \+ \$1 WHERE sku = \$2`).
+		WithArgs(2, "hoody-pink-m").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// возврат монет попадает в журнал в той же транзакции
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WithArgs(0, "merch.order_cancel", "order", "7", "", "", nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	refund, err := repo.Cancel(context.Background(), 7, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1000, refund)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест отмены уже выданного или чужого заказа
+func TestPurchase_Cancel_NotCancellable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := purchase.NewPurchaseRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE purchases SET status = 'cancelled'`).
+		WithArgs(7, 2).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = repo.Cancel(context.Background(), 7, 2)
+	require.ErrorIs(t, err, sql.ErrNoRows)
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,128 @@
+package report
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	entities "merchshop/internal/entity"
+)
+
+// Repository читает строки отчетов по одной и передает их в fn, не накапливая в памяти.
+// Ошибка fn прерывает чтение и возвращается как есть
+type Repository interface {
+	// Transactions переводы в порядке id
+	Transactions(ctx context.Context, f entities.ReportFilter, fn func(entities.Transaction) error) error
+	// Purchases покупки в порядке id, включая отмененные
+	Purchases(ctx context.Context, f entities.ReportFilter, fn func(entities.Purchase) error) error
+	// Balances пользователи с текущим балансом и оборотами за период фильтра
+	Balances(ctx context.Context, f entities.ReportFilter, fn func(entities.UserBalance) error) error
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+// Период фильтра в параметрах $1 и $2, пустая граница не ограничивает
+const inPeriod = `($1::timestamptz IS NULL OR %[1]s >= $1) AND ($2::timestamptz IS NULL OR %[1]s < $2)`
+
+// each выполняет запрос и вызывает scan для каждой строки
+func (r *Repo) each(ctx context.Context, query string, args []any, scan func(rows *sql.Rows) error) error {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query report: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) Transactions(ctx context.Context, f entities.ReportFilter, fn func(entities.Transaction) error) error {
+	query := `
+        SELECT t.id, t.sender_id, s.username, t.receiver_id, rc.username, t.amount, COALESCE(t.batch_id, 0),
+               t.memo, t.created_at
+        FROM transactions t
+        JOIN users s ON s.id = t.sender_id
+        JOIN users rc ON rc.id = t.receiver_id
+        WHERE ` + fmt.Sprintf(inPeriod, "t.created_at") + ` AND ($3 = '' OR s.username = $3 OR rc.username = $3)
+        ORDER BY t.id`
+
+	return r.each(ctx, query, []any{f.From, f.To, f.Username}, func(rows *sql.Rows) error {
+		var t entities.Transaction
+
+		err := rows.Scan(&t.ID, &t.SenderID, &t.SenderName, &t.ReceiverID, &t.ReceiverName, &t.Amount, &t.BatchID,
+			&t.Memo, &t.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("scan transaction: %w", err)
+		}
+
+		return fn(t)
+	})
+}
+
+func (r *Repo) Purchases(ctx context.Context, f entities.ReportFilter, fn func(entities.Purchase) error) error {
+	query := `
+        SELECT p.id, p.created_at, p.user_id, u.username, COALESCE(p.buyer_id, 0), COALESCE(b.username, ''),
+               p.merch_name, COALESCE(p.sku, ''), p.quantity, p.list_price, p.discount, COALESCE(p.promo_code, ''),
+               p.total_price, p.status, p.cancelled_at
+        FROM purchases p
+        JOIN users u ON u.id = p.user_id
+        LEFT JOIN users b ON b.id = p.buyer_id
+        WHERE ` + fmt.Sprintf(inPeriod, "p.created_at") + `
+          AND ($3 = '' OR u.username = $3 OR b.username = $3) AND ($4 = '' OR p.merch_name = $4)
+        ORDER BY p.id`
+
+	return r.each(ctx, query, []any{f.From, f.To, f.Username, f.Item}, func(rows *sql.Rows) error {
+		var p entities.Purchase
+
+		err := rows.Scan(&p.ID, &p.CreatedAt, &p.UserID, &p.Username, &p.BuyerID, &p.BuyerName,
+			&p.MerchName, &p.SKU, &p.Quantity, &p.ListPrice, &p.Discount, &p.PromoCode,
+			&p.TotalPrice, &p.Status, &p.CancelledAt)
+		if err != nil {
+			return fmt.Errorf("scan purchase: %w", err)
+		}
+
+		return fn(p)
+	})
+}
+
+func (r *Repo) Balances(ctx context.Context, f entities.ReportFilter, fn func(entities.UserBalance) error) error {
+	// Траты считаются тому, кто платил: подарок уходит в траты дарителя
+	query := `
+        SELECT u.id, u.username, u.department, u.status, u.balance,
+               COALESCE((SELECT SUM(t.amount) FROM transactions t
+                         WHERE t.receiver_id = u.id AND ` + fmt.Sprintf(inPeriod, "t.created_at") + `), 0),
+               COALESCE((SELECT SUM(t.amount) FROM transactions t
+                         WHERE t.sender_id = u.id AND ` + fmt.Sprintf(inPeriod, "t.created_at") + `), 0),
+               COALESCE((SELECT SUM(p.total_price) FROM purchases p
+                         WHERE (p.user_id = u.id AND p.buyer_id IS NULL OR p.buyer_id = u.id)
+                           AND p.status <> 'cancelled' AND ` + fmt.Sprintf(inPeriod, "p.created_at") + `), 0)
+        FROM users u
+        WHERE $3 = '' OR u.username = $3
+        ORDER BY u.id`
+
+	return r.each(ctx, query, []any{f.From, f.To, f.Username}, func(rows *sql.Rows) error {
+		var b entities.UserBalance
+
+		err := rows.Scan(&b.UserID, &b.Username, &b.Department, &b.Status, &b.Balance, &b.Received, &b.Sent, &b.Spent)
+		if err != nil {
+			return fmt.Errorf("scan balance: %w", err)
+		}
+
+		return fn(b)
+	})
+}
@@ -0,0 +1,79 @@
+package report_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/repository/report"
+)
+
+// Тест выгрузки переводов за период с отбором по пользователю
+func TestReport_Transactions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := report.NewReportRepository(db)
+
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT t.id, .* FROM transactions t .* WHERE \(\$1::timestamptz IS NULL OR t.created_at >= \$1\) `+
+		`AND \(\$2::timestamptz IS NULL OR t.created_at < \$2\) AND \(\$3 = '' OR s.username = \$3 OR rc.username = \$3\) ORDER BY t.id`).
+		WithArgs(&from, &to, "alice").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "sender_id", "sender", "receiver_id", "receiver", "amount", "batch_id", "memo", "created_at",
+		}).
+			AddRow(1, 1, "alice", 2, "bob", 10, 0, "", from).
+			AddRow(2, 3, "carol", 1, "alice", 20, 0, "спасибо", from))
+
+	var ids []int
+
+	err = repo.Transactions(context.Background(), entity.ReportFilter{From: &from, To: &to, Username: "alice"},
+		func(t entity.Transaction) error {
+			ids = append(ids, t.ID)
+			return nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, ids)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест остановки выгрузки покупок по ошибке записи строки
+func TestReport_Purchases_StopsOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := report.NewReportRepository(db)
+
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT p.id, .* FROM purchases p .* AND \(\$4 = '' OR p.merch_name = \$4\) ORDER BY p.id`).
+		WithArgs(nil, nil, "", "cup").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "created_at", "user_id", "username", "buyer_id", "buyer", "merch_name", "sku", "quantity",
+			"list_price", "discount", "promo_code", "total_price", "status", "cancelled_at",
+		}).
+			AddRow(1, now, 1, "alice", 0, "", "cup", "", 1, 20, 0, "", 20, "delivered", nil).
+			AddRow(2, now, 2, "bob", 1, "alice", "cup", "", 1, 20, 0, "", 20, "cancelled", now))
+
+	errClosed := errors.New("connection closed")
+	calls := 0
+
+	err = repo.Purchases(context.Background(), entity.ReportFilter{Item: "cup"}, func(p entity.Purchase) error {
+		calls++
+		return errClosed
+	})
+	require.ErrorIs(t, err, errClosed)
+	require.Equal(t, 1, calls)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,24 +3,72 @@ package repository
 import (
 	"database/sql"
 
+	"merchshop/internal/repository/account"
+	"merchshop/internal/repository/auditlog"
+	"merchshop/internal/repository/coinrequest"
+	"merchshop/internal/repository/dataexport"
+	"merchshop/internal/repository/escrow"
+	"merchshop/internal/repository/fraud"
+	"merchshop/internal/repository/leaderboard"
 	"merchshop/internal/repository/merch"
+	"merchshop/internal/repository/notification"
+	"merchshop/internal/repository/policy"
+	"merchshop/internal/repository/promo"
 	"merchshop/internal/repository/purchase"
+	"merchshop/internal/repository/report"
+	"merchshop/internal/repository/rule"
+	"merchshop/internal/repository/schedule"
+	"merchshop/internal/repository/stats"
 	"merchshop/internal/repository/transaction"
 	"merchshop/internal/repository/user"
+	"merchshop/internal/repository/webhook"
+	"merchshop/internal/repository/wishlist"
 )
 
 type Repositories struct {
-	User        user.Repository
-	Transaction transaction.Repository
-	Purchase    purchase.Repository
-	Merch       merch.Repository
+	User         user.Repository
+	Transaction  transaction.Repository
+	Purchase     purchase.Repository
+	Merch        merch.Repository
+	Webhook      webhook.Repository
+	Schedule     schedule.Repository
+	CoinRequest  coinrequest.Repository
+	Escrow       escrow.Repository
+	Policy       policy.Repository
+	Fraud        fraud.Repository
+	Account      account.Repository
+	Promo        promo.Repository
+	Rule         rule.Repository
+	Notification notification.Repository
+	Wishlist     wishlist.Repository
+	Leaderboard  leaderboard.Repository
+	Stats        stats.Repository
+	Report       report.Repository
+	DataExport   dataexport.Repository
+	AuditLog     auditlog.Repository
 }
 
 func NewRepositories(db *sql.DB) *Repositories {
 	return &Repositories{
-		User:        user.NewUserRepository(db),
-		Transaction: transaction.NewTransactionRepository(db),
-		Purchase:    purchase.NewPurchaseRepository(db),
-		Merch:       merch.NewMerchRepository(db),
+		User:         user.NewUserRepository(db),
+		Transaction:  transaction.NewTransactionRepository(db),
+		Purchase:     purchase.NewPurchaseRepository(db),
+		Merch:        merch.NewMerchRepository(db),
+		Webhook:      webhook.NewWebhookRepository(db),
+		Schedule:     schedule.NewScheduleRepository(db),
+		CoinRequest:  coinrequest.NewCoinRequestRepository(db),
+		Escrow:       escrow.NewEscrowRepository(db),
+		Policy:       policy.NewPolicyRepository(db),
+		Fraud:        fraud.NewFraudRepository(db),
+		Account:      account.NewAccountRepository(db),
+		Promo:        promo.NewPromoRepository(db),
+		Rule:         rule.NewRuleRepository(db),
+		Notification: notification.NewNotificationRepository(db),
+		Wishlist:     wishlist.NewWishlistRepository(db),
+		Leaderboard:  leaderboard.NewLeaderboardRepository(db),
+		Stats:        stats.NewStatsRepository(db),
+		Report:       report.NewReportRepository(db),
+		DataExport:   dataexport.NewDataExportRepository(db),
+		AuditLog:     auditlog.NewAuditLogRepository(db),
 	}
 }
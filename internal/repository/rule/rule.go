@@ -0,0 +1,136 @@
+package rule
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	entities "merchshop/internal/entity"
+)
+
+type Repository interface {
+	// SaveRule создает правило товара или заменяет существующее
+	SaveRule(ctx context.Context, rule entities.PurchaseRule) error
+	GetRule(ctx context.Context, item string) (*entities.PurchaseRule, error)
+	ListRules(ctx context.Context) ([]entities.PurchaseRule, error)
+	// DeleteRule снимает ограничения с товара, если правила нет, возвращает sql.ErrNoRows
+	DeleteRule(ctx context.Context, item string) error
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewRuleRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+const ruleColumns = `merch_name, max_per_user, roles, departments, min_account_age_seconds,
+               available_from, available_until, updated_at`
+
+func scanRule(row interface{ Scan(...any) error }) (*entities.PurchaseRule, error) {
+	var (
+		rule          entities.PurchaseRule
+		minAgeSeconds int64
+	)
+
+	err := row.Scan(&rule.Item, &rule.MaxPerUser, pq.Array(&rule.Roles), pq.Array(&rule.Departments), &minAgeSeconds,
+		&rule.AvailableFrom, &rule.AvailableUntil, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.MinAccountAge = time.Duration(minAgeSeconds) * time.Second
+
+	return &rule, nil
+}
+
+func (r *Repo) SaveRule(ctx context.Context, rule entities.PurchaseRule) error {
+	const query = `
+        INSERT INTO purchase_rules (merch_name, max_per_user, roles, departments, min_account_age_seconds,
+                                    available_from, available_until)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (merch_name) DO UPDATE
+        SET max_per_user = EXCLUDED.max_per_user,
+            roles = EXCLUDED.roles,
+            departments = EXCLUDED.departments,
+            min_account_age_seconds = EXCLUDED.min_account_age_seconds,
+            available_from = EXCLUDED.available_from,
+            available_until = EXCLUDED.available_until,
+            updated_at = NOW()`
+
+	_, err := r.db.ExecContext(ctx, query, rule.Item, rule.MaxPerUser, pq.Array(rule.Roles),
+		pq.Array(rule.Departments), int64(rule.MinAccountAge/time.Second), rule.AvailableFrom, rule.AvailableUntil)
+	if err != nil {
+		return fmt.Errorf("save purchase rule for %s: %w", rule.Item, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) GetRule(ctx context.Context, item string) (*entities.PurchaseRule, error) {
+	const query = `
+        SELECT ` + ruleColumns + `
+        FROM purchase_rules
+        WHERE merch_name = $1`
+
+	rule, err := scanRule(r.db.QueryRowContext(ctx, query, item))
+	if err != nil {
+		return nil, fmt.Errorf("get purchase rule for %s: %w", item, err)
+	}
+
+	return rule, nil
+}
+
+func (r *Repo) ListRules(ctx context.Context) ([]entities.PurchaseRule, error) {
+	const query = `
+        SELECT ` + ruleColumns + `
+        FROM purchase_rules
+        ORDER BY merch_name`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query purchase rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []entities.PurchaseRule
+
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan purchase rule: %w", err)
+		}
+
+		rules = append(rules, *rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (r *Repo) DeleteRule(ctx context.Context, item string) error {
+	const query = `DELETE FROM purchase_rules WHERE merch_name = $1`
+
+	result, err := r.db.ExecContext(ctx, query, item)
+	if err != nil {
+		return fmt.Errorf("delete purchase rule for %s: %w", item, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("delete purchase rule for %s: %w", item, sql.ErrNoRows)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package rule_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/repository/rule"
+)
+
+// Тест чтения правила: стаж хранится в секундах
+func TestRule_GetRule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := rule.NewRuleRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT merch_name, max_per_user, roles, departments, min_account_age_seconds, .* ` +
+		`FROM purchase_rules WHERE merch_name = \$1`).
+		WithArgs("pink-hoody").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"merch_name", "max_per_user", "roles", "departments", "min_account_age_seconds",
+			"available_from", "available_until", "updated_at",
+		}).AddRow("pink-hoody", 1, "{}", "{design}", 86400, now, nil, now))
+
+	r, err := repo.GetRule(context.Background(), "pink-hoody")
+	require.NoError(t, err)
+	require.Equal(t, 1, r.MaxPerUser)
+	require.Empty(t, r.Roles)
+	require.Equal(t, []string{"design"}, r.Departments)
+	require.Equal(t, 24*time.Hour, r.MinAccountAge)
+	require.Nil(t, r.AvailableUntil)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест удаления правила, которого нет
+func TestRule_DeleteRule_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := rule.NewRuleRepository(db)
+
+	mock.ExpectExec(`DELETE FROM purchase_rules WHERE merch_name = \$1`).
+		WithArgs("cup").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.DeleteRule(context.Background(), "cup")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,252 @@
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	entities "merchshop/internal/entity"
+)
+
+type Repository interface {
+	Create(ctx context.Context, s entities.ScheduledTransfer) (*entities.ScheduledTransfer, error)
+	GetByID(ctx context.Context, id int) (*entities.ScheduledTransfer, error)
+	ListBySender(ctx context.Context, senderID int) ([]entities.ScheduledTransfer, error)
+	// UpdateStatus меняет статус, только если текущий входит в from. nextRunAt == nil оставляет время запуска
+	UpdateStatus(ctx context.Context, id int, from []string, status string, nextRunAt *time.Time) error
+	GetDue(ctx context.Context, now time.Time, limit int) ([]entities.ScheduledTransfer, error)
+	// ClaimOccurrence резервирует срабатывание и переносит расписание на next.
+	// claimed == false, если срабатывание уже забрала другая реплика
+	ClaimOccurrence(ctx context.Context, id int, occurrence time.Time, next *time.Time) (runID int, claimed bool, err error)
+	// FinishRun сохраняет результат срабатывания. После maxFailures неудач подряд расписание ставится на паузу
+	FinishRun(ctx context.Context, runID int, status, runErr string, maxFailures int) (*entities.ScheduledTransfer, error)
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewScheduleRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+const scheduleColumns = `s.id, s.sender_id, s.receiver_id, r.username, s.amount, s.memo, s.cron, s.status,
+               s.next_run_at, s.last_run_at, s.last_error, s.failures, s.created_at`
+
+func scanSchedule(row interface{ Scan(...any) error }) (*entities.ScheduledTransfer, error) {
+	var s entities.ScheduledTransfer
+
+	err := row.Scan(
+		&s.ID, &s.SenderID, &s.ReceiverID, &s.ReceiverName, &s.Amount, &s.Memo, &s.Cron, &s.Status,
+		&s.NextRunAt, &s.LastRunAt, &s.LastError, &s.Failures, &s.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (r *Repo) Create(ctx context.Context, s entities.ScheduledTransfer) (*entities.ScheduledTransfer, error) {
+	const query = `
+        WITH s AS (
+            INSERT INTO scheduled_transfers (sender_id, receiver_id, amount, memo, cron, next_run_at)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            RETURNING *
+        )
+        SELECT ` + scheduleColumns + `
+        FROM s
+        JOIN users r ON s.receiver_id = r.id`
+
+	created, err := scanSchedule(r.db.QueryRowContext(ctx, query,
+		s.SenderID, s.ReceiverID, s.Amount, s.Memo, s.Cron, s.NextRunAt,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduled transfer: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *Repo) GetByID(ctx context.Context, id int) (*entities.ScheduledTransfer, error) {
+	const query = `
+        SELECT ` + scheduleColumns + `
+        FROM scheduled_transfers s
+        JOIN users r ON s.receiver_id = r.id
+        WHERE s.id = $1`
+
+	s, err := scanSchedule(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled transfer by id: %w", err)
+	}
+
+	return s, nil
+}
+
+func (r *Repo) querySchedules(ctx context.Context, query string, args ...interface{}) ([]entities.ScheduledTransfer, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query scheduled transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []entities.ScheduledTransfer
+
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan scheduled transfer: %w", err)
+		}
+
+		schedules = append(schedules, *s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return schedules, nil
+}
+
+func (r *Repo) ListBySender(ctx context.Context, senderID int) ([]entities.ScheduledTransfer, error) {
+	const query = `
+        SELECT ` + scheduleColumns + `
+        FROM scheduled_transfers s
+        JOIN users r ON s.receiver_id = r.id
+        WHERE s.sender_id = $1
+        ORDER BY s.id`
+
+	return r.querySchedules(ctx, query, senderID)
+}
+
+func (r *Repo) UpdateStatus(ctx context.Context, id int, from []string, status string, nextRunAt *time.Time) error {
+	const query = `
+        UPDATE scheduled_transfers
+        SET status = $3,
+            next_run_at = COALESCE($4, next_run_at),
+            failures = CASE WHEN $3 = 'active' THEN 0 ELSE failures END
+        WHERE id = $1 AND status = ANY($2)`
+
+	result, err := r.db.ExecContext(ctx, query, id, pq.Array(from), status, nextRunAt)
+	if err != nil {
+		return fmt.Errorf("update scheduled transfer status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("update scheduled transfer status: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *Repo) GetDue(ctx context.Context, now time.Time, limit int) ([]entities.ScheduledTransfer, error) {
+	const query = `
+        SELECT ` + scheduleColumns + `
+        FROM scheduled_transfers s
+        JOIN users r ON s.receiver_id = r.id
+        WHERE s.status = 'active' AND s.next_run_at <= $1
+        ORDER BY s.next_run_at
+        LIMIT $2`
+
+	return r.querySchedules(ctx, query, now, limit)
+}
+
+func (r *Repo) ClaimOccurrence(ctx context.Context, id int, occurrence time.Time, next *time.Time) (int, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	const insertRun = `
+        INSERT INTO scheduled_transfer_runs (schedule_id, scheduled_for)
+        VALUES ($1, $2)
+        ON CONFLICT (schedule_id, scheduled_for) DO NOTHING
+        RETURNING id`
+
+	var runID int
+
+	err = tx.QueryRowContext(ctx, insertRun, id, occurrence).Scan(&runID)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, fmt.Errorf("insert scheduled transfer run: %w", err)
+	}
+
+	// Разовый перевод завершается сразу после резервирования
+	const advance = `
+        UPDATE scheduled_transfers
+        SET next_run_at = COALESCE($3, next_run_at),
+            status = CASE WHEN $3::timestamptz IS NULL THEN 'completed' ELSE status END
+        WHERE id = $1 AND next_run_at = $2 AND status = 'active'`
+
+	result, err := tx.ExecContext(ctx, advance, id, occurrence, next)
+	if err != nil {
+		return 0, false, fmt.Errorf("advance scheduled transfer: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	// Расписание успели поставить на паузу или отменить
+	if rowsAffected == 0 {
+		return 0, false, nil
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return runID, true, nil
+}
+
+func (r *Repo) FinishRun(ctx context.Context, runID int, status, runErr string, maxFailures int) (*entities.ScheduledTransfer, error) {
+	const query = `
+        WITH run AS (
+            UPDATE scheduled_transfer_runs
+            SET status = $2, error = $3, finished_at = NOW()
+            WHERE id = $1
+            RETURNING schedule_id
+        ), s AS (
+            UPDATE scheduled_transfers t
+            SET last_run_at = NOW(),
+                last_error = $3,
+                failures = CASE WHEN $2 = 'succeeded' THEN 0 ELSE t.failures + 1 END,
+                status = CASE
+                    WHEN $2 = 'succeeded' THEN t.status
+                    WHEN t.cron = '' THEN 'failed'
+                    WHEN t.status = 'active' AND t.failures + 1 >= $4 THEN 'paused'
+                    ELSE t.status
+                END
+            FROM run
+            WHERE t.id = run.schedule_id
+            RETURNING t.*
+        )
+        SELECT ` + scheduleColumns + `
+        FROM s
+        JOIN users r ON s.receiver_id = r.id`
+
+	s, err := scanSchedule(r.db.QueryRowContext(ctx, query, runID, status, runErr, maxFailures))
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish scheduled transfer run: %w", err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,117 @@
+package schedule_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/repository/schedule"
+)
+
+var scheduleColumns = []string{
+	"id", "sender_id", "receiver_id", "username", "amount", "memo", "cron", "status",
+	"next_run_at", "last_run_at", "last_error", "failures", "created_at",
+}
+
+// срабатывание резервируется и расписание переносится на следующий запуск
+func TestRepo_ClaimOccurrence_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := schedule.NewScheduleRepository(db)
+
+	occurrence := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	next := occurrence.AddDate(0, 1, 0)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`INSERT INTO scheduled_transfer_runs`).
+		WithArgs(7, occurrence).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+	mock.ExpectExec(`UPDATE scheduled_transfers`).
+		WithArgs(7, occurrence, &next).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	runID, claimed, err := repo.ClaimOccurrence(context.Background(), 7, occurrence, &next)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.Equal(t, 3, runID)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// срабатывание уже забрала другая реплика
+func TestRepo_ClaimOccurrence_AlreadyClaimed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := schedule.NewScheduleRepository(db)
+
+	occurrence := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`INSERT INTO scheduled_transfer_runs`).
+		WithArgs(7, occurrence).
+		WillReturnError(sql.ErrNoRows)
+
+	mock.ExpectRollback()
+
+	_, claimed, err := repo.ClaimOccurrence(context.Background(), 7, occurrence, nil)
+	require.NoError(t, err)
+	require.False(t, claimed)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// смена статуса из недопустимого состояния
+func TestRepo_UpdateStatus_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := schedule.NewScheduleRepository(db)
+
+	mock.ExpectExec(`UPDATE scheduled_transfers`).
+		WithArgs(7, sqlmock.AnyArg(), "paused", nil).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.UpdateStatus(context.Background(), 7, []string{"active"}, "paused", nil)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// список переводов отправителя
+func TestRepo_ListBySender(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := schedule.NewScheduleRepository(db)
+
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT s.id, s.sender_id`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(scheduleColumns).
+			AddRow(7, 1, 2, "bob", 20, "ментору", "0 10 1 * *", "active", now, nil, "", 0, now))
+
+	schedules, err := repo.ListBySender(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	require.Equal(t, "bob", schedules[0].ReceiverName)
+	require.Equal(t, "0 10 1 * *", schedules[0].Cron)
+	require.Nil(t, schedules[0].LastRunAt)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
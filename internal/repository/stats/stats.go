@@ -0,0 +1,212 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	entities "merchshop/internal/entity"
+)
+
+type Repository interface {
+	// Totals возвращает суммы отправленного, полученного и потраченного за неделю (с weekSince),
+	// месяц (с monthSince) и все время. Отмененные заказы не считаются
+	Totals(ctx context.Context, userID int, weekSince, monthSince time.Time) ([]entities.PeriodTotals, error)
+	// Counterparties возвращает limit пользователей, с которыми обменялись больше всего монет
+	Counterparties(ctx context.Context, userID, limit int) ([]entities.Counterparty, error)
+	// Items возвращает траты пользователя по товарам, самые дорогие первыми
+	Items(ctx context.Context, userID int) ([]entities.ItemSpending, error)
+	// DailyChanges возвращает изменения баланса по дням (UTC) начиная с since. Дни без
+	// изменений пропускаются
+	DailyChanges(ctx context.Context, userID int, since time.Time) ([]entities.BalanceChange, error)
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewStatsRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+// Покупки, оплаченные пользователем: свои без подарков и подарки, которые он оплатил
+const paidByUser = `(user_id = $1 AND buyer_id IS NULL OR buyer_id = $1)`
+
+func (r *Repo) Totals(ctx context.Context, userID int, weekSince, monthSince time.Time) ([]entities.PeriodTotals, error) {
+	const query = `
+        WITH activity (kind, amount, created_at) AS (
+            SELECT 'sent', amount, created_at FROM transactions WHERE sender_id = $1
+            UNION ALL
+            SELECT 'received', amount, created_at FROM transactions WHERE receiver_id = $1
+            UNION ALL
+            SELECT 'spent', total_price, created_at FROM purchases
+            WHERE ` + paidByUser + ` AND status <> 'cancelled'
+        )
+        SELECT kind,
+               COALESCE(SUM(amount) FILTER (WHERE created_at >= $2), 0),
+               COALESCE(SUM(amount) FILTER (WHERE created_at >= $3), 0),
+               COALESCE(SUM(amount), 0)
+        FROM activity
+        GROUP BY kind`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, weekSince, monthSince)
+	if err != nil {
+		return nil, fmt.Errorf("query totals of user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	totals := []entities.PeriodTotals{
+		{Period: entities.PeriodWeek},
+		{Period: entities.PeriodMonth},
+		{Period: entities.PeriodAll},
+	}
+
+	for rows.Next() {
+		var (
+			kind   string
+			amount [3]int
+		)
+
+		if err := rows.Scan(&kind, &amount[0], &amount[1], &amount[2]); err != nil {
+			return nil, fmt.Errorf("scan totals: %w", err)
+		}
+
+		for i := range totals {
+			switch kind {
+			case "sent":
+				totals[i].Sent = amount[i]
+			case "received":
+				totals[i].Received = amount[i]
+			case "spent":
+				totals[i].Spent = amount[i]
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return totals, nil
+}
+
+func (r *Repo) Counterparties(ctx context.Context, userID, limit int) ([]entities.Counterparty, error) {
+	const query = `
+        SELECT u.id, u.username, SUM(t.sent), SUM(t.received), COUNT(*)
+        FROM (
+            SELECT receiver_id AS other_id, amount AS sent, 0 AS received FROM transactions WHERE sender_id = $1
+            UNION ALL
+            SELECT sender_id, 0, amount FROM transactions WHERE receiver_id = $1
+        ) t
+        JOIN users u ON u.id = t.other_id
+        GROUP BY u.id, u.username
+        ORDER BY SUM(t.sent) + SUM(t.received) DESC, u.username
+        LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query counterparties of user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var counterparties []entities.Counterparty
+
+	for rows.Next() {
+		var c entities.Counterparty
+		if err := rows.Scan(&c.UserID, &c.Username, &c.Sent, &c.Received, &c.Transfers); err != nil {
+			return nil, fmt.Errorf("scan counterparty: %w", err)
+		}
+
+		counterparties = append(counterparties, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return counterparties, nil
+}
+
+func (r *Repo) Items(ctx context.Context, userID int) ([]entities.ItemSpending, error) {
+	const query = `
+        SELECT merch_name, SUM(quantity), SUM(total_price), COUNT(*)
+        FROM purchases
+        WHERE ` + paidByUser + ` AND status <> 'cancelled'
+        GROUP BY merch_name
+        ORDER BY SUM(total_price) DESC, merch_name`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query spending of user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var items []entities.ItemSpending
+
+	for rows.Next() {
+		var i entities.ItemSpending
+		if err := rows.Scan(&i.Item, &i.Quantity, &i.Spent, &i.Orders); err != nil {
+			return nil, fmt.Errorf("scan item spending: %w", err)
+		}
+
+		items = append(items, i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return items, nil
+}
+
+func (r *Repo) DailyChanges(ctx context.Context, userID int, since time.Time) ([]entities.BalanceChange, error) {
+	// Удерживаемый перевод списывается при создании, а не при получении: перевод, которым
+	// он зачислен получателю, баланс отправителя уже не меняет. Отмененный заказ и
+	// возвращенный перевод возвращают монеты в день возврата
+	const query = `
+        WITH changes (at, delta) AS (
+            SELECT t.created_at, -t.amount FROM transactions t
+            WHERE t.sender_id = $1 AND t.created_at >= $2
+              AND NOT EXISTS (SELECT 1 FROM escrow_transfers e WHERE e.transaction_id = t.id)
+            UNION ALL
+            SELECT created_at, amount FROM transactions WHERE receiver_id = $1 AND created_at >= $2
+            UNION ALL
+            SELECT created_at, -total_price FROM purchases WHERE ` + paidByUser + ` AND created_at >= $2
+            UNION ALL
+            SELECT cancelled_at, total_price FROM purchases
+            WHERE ` + paidByUser + ` AND status = 'cancelled' AND cancelled_at >= $2
+            UNION ALL
+            SELECT created_at, -amount FROM escrow_transfers WHERE sender_id = $1 AND created_at >= $2
+            UNION ALL
+            SELECT resolved_at, amount FROM escrow_transfers
+            WHERE sender_id = $1 AND status = 'refunded' AND resolved_at >= $2
+        )
+        SELECT (at AT TIME ZONE 'UTC')::date AS day, SUM(delta)
+        FROM changes
+        GROUP BY day
+        ORDER BY day`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("query balance changes of user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var changes []entities.BalanceChange
+
+	for rows.Next() {
+		var c entities.BalanceChange
+		if err := rows.Scan(&c.Day, &c.Delta); err != nil {
+			return nil, fmt.Errorf("scan balance change: %w", err)
+		}
+
+		changes = append(changes, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,67 @@
+package stats_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/repository/stats"
+)
+
+// Тест сумм за периоды: виды операций без записей остаются нулевыми
+func TestStats_Totals(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := stats.NewStatsRepository(db)
+
+	weekSince := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	monthSince := time.Date(2026, 9, 19, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`WITH activity \(kind, amount, created_at\) AS .* FROM purchases `+
+		`WHERE \(user_id = \$1 AND buyer_id IS NULL OR buyer_id = \$1\) AND status <> 'cancelled' .* GROUP BY kind`).
+		WithArgs(1, weekSince, monthSince).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "week", "month", "all"}).
+			AddRow("sent", 10, 40, 100).
+			AddRow("spent", 0, 500, 800))
+
+	totals, err := repo.Totals(context.Background(), 1, weekSince, monthSince)
+	require.NoError(t, err)
+	require.Equal(t, []entity.PeriodTotals{
+		{Period: entity.PeriodWeek, Sent: 10},
+		{Period: entity.PeriodMonth, Sent: 40, Spent: 500},
+		{Period: entity.PeriodAll, Sent: 100, Spent: 800},
+	}, totals)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест изменений баланса по дням с учетом удерживаемых переводов и возвратов
+func TestStats_DailyChanges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := stats.NewStatsRepository(db)
+
+	since := time.Date(2026, 9, 20, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`WITH changes \(at, delta\) AS .* NOT EXISTS \(SELECT 1 FROM escrow_transfers e WHERE e.transaction_id = t.id\) `+
+		`.* SELECT resolved_at, amount FROM escrow_transfers WHERE sender_id = \$1 AND status = 'refunded' .* GROUP BY day ORDER BY day`).
+		WithArgs(1, since).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "delta"}).
+			AddRow(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), -50).
+			AddRow(time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC), 20))
+
+	changes, err := repo.DailyChanges(context.Background(), 1, since)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, -50, changes[0].Delta)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,16 +3,27 @@ package transaction
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
+	"merchshop/internal/audit"
 	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/auditlog"
+	"merchshop/internal/repository/policy"
 )
 
+// ErrInsufficientFunds у отправителя не хватило монет на момент списания
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
 type Repository interface {
-	CreateTransaction(ctx context.Context, senderID, receiverID int, amount int) error
+	// CreateTransaction и CreateBatchTransaction выполняют guard в транзакции списания, nil не проверяет политику
+	CreateTransaction(ctx context.Context, senderID, receiverID int, amount int, memo string, guard *policy.Guard) error
+	CreateBatchTransaction(ctx context.Context, senderID int, items []entities.BatchTransferItem, guard *policy.Guard) (int, error)
 	GetByUserID(ctx context.Context, userID int) ([]entities.Transaction, error)
 	GetBySenderID(ctx context.Context, senderID int) ([]entities.Transaction, error)
 	GetByReceiverID(ctx context.Context, receiverID int) ([]entities.Transaction, error)
+	// SetReaction сохраняет реакцию получателя и возвращает перевод
+	SetReaction(ctx context.Context, transactionID, receiverID int, reaction string) (*entities.Transaction, error)
 }
 
 type Repo struct {
@@ -23,7 +34,7 @@ func NewTransactionRepository(db *sql.DB) Repository {
 	return &Repo{db: db}
 }
 
-func (r *Repo) CreateTransaction(ctx context.Context, senderID, receiverID, amount int) error {
+func (r *Repo) CreateTransaction(ctx context.Context, senderID, receiverID, amount int, memo string, guard *policy.Guard) error {
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
 		return fmt.Errorf("begin transaction: %w", err)
@@ -35,6 +46,10 @@ func (r *Repo) CreateTransaction(ctx context.Context, senderID, receiverID, amou
 		}
 	}()
 
+	if err = guard.CheckInTx(ctx, tx); err != nil {
+		return err
+	}
+
 	const updateSender = `
         UPDATE users 
         SET balance = balance - $1 
@@ -51,7 +66,7 @@ func (r *Repo) CreateTransaction(ctx context.Context, senderID, receiverID, amou
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("insufficient funds")
+		return ErrInsufficientFunds
 	}
 
 	const updateReceiver = `
@@ -64,16 +79,107 @@ func (r *Repo) CreateTransaction(ctx context.Context, senderID, receiverID, amou
 	}
 
 	const insertTx = `
-        INSERT INTO transactions (sender_id, receiver_id, amount) 
-        VALUES ($1, $2, $3)`
+        INSERT INTO transactions (sender_id, receiver_id, amount, memo) 
+        VALUES ($1, $2, $3, $4)`
 
-	if _, err = tx.ExecContext(ctx, insertTx, senderID, receiverID, amount); err != nil {
+	if _, err = tx.ExecContext(ctx, insertTx, senderID, receiverID, amount, memo); err != nil {
 		return fmt.Errorf("insert transaction: %w", err)
 	}
 
+	if err = auditlog.Enqueue(ctx, tx, audit.Transfer(senderID, receiverID, amount)); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
+// CreateBatchTransaction списывает у отправителя сумму пакета одним обновлением и зачисляет всем получателям
+// в одной транзакции. Возвращает id пакета
+func (r *Repo) CreateBatchTransaction(ctx context.Context, senderID int, items []entities.BatchTransferItem, guard *policy.Guard) (int, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			fmt.Printf("rollback failed: %v\n", err)
+		}
+	}()
+
+	total := 0
+	for _, item := range items {
+		total += item.Amount
+	}
+
+	if err = guard.CheckInTx(ctx, tx); err != nil {
+		return 0, err
+	}
+
+	const updateSender = `
+        UPDATE users 
+        SET balance = balance - $1 
+        WHERE id = $2 AND balance >= $1`
+
+	result, err := tx.ExecContext(ctx, updateSender, total, senderID)
+	if err != nil {
+		return 0, fmt.Errorf("update sender balance: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return 0, ErrInsufficientFunds
+	}
+
+	const insertBatch = `
+        INSERT INTO transfer_batches (sender_id, total_amount) 
+        VALUES ($1, $2)
+        RETURNING id`
+
+	var batchID int
+	if err = tx.QueryRowContext(ctx, insertBatch, senderID, total).Scan(&batchID); err != nil {
+		return 0, fmt.Errorf("insert transfer batch: %w", err)
+	}
+
+	const updateReceiver = `
+        UPDATE users 
+        SET balance = balance + $1 
+        WHERE id = $2`
+
+	const insertTx = `
+        INSERT INTO transactions (sender_id, receiver_id, amount, batch_id, memo) 
+        VALUES ($1, $2, $3, $4, $5)`
+
+	for _, item := range items {
+		result, err := tx.ExecContext(ctx, updateReceiver, item.Amount, item.ReceiverID)
+		if err != nil {
+			return 0, fmt.Errorf("update receiver balance: %w", err)
+		}
+
+		if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+			return 0, fmt.Errorf("receiver %d not found", item.ReceiverID)
+		}
+
+		if _, err = tx.ExecContext(ctx, insertTx, senderID, item.ReceiverID, item.Amount, batchID, item.Memo); err != nil {
+			return 0, fmt.Errorf("insert transaction: %w", err)
+		}
+
+		if err = auditlog.Enqueue(ctx, tx, audit.Transfer(senderID, item.ReceiverID, item.Amount)); err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return batchID, nil
+}
+
 func (r *Repo) queryTransactions(ctx context.Context, query string, args ...interface{}) ([]entities.Transaction, error) {
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -87,7 +193,7 @@ func (r *Repo) queryTransactions(ctx context.Context, query string, args ...inte
 		var t entities.Transaction
 
 		err := rows.Scan(
-			&t.ID, &t.SenderID, &t.ReceiverID, &t.Amount, &t.CreatedAt,
+			&t.ID, &t.SenderID, &t.ReceiverID, &t.Amount, &t.BatchID, &t.Memo, &t.Reaction, &t.CreatedAt,
 			&t.SenderName, &t.ReceiverName,
 		)
 
@@ -107,7 +213,7 @@ func (r *Repo) queryTransactions(ctx context.Context, query string, args ...inte
 
 func (r *Repo) GetByUserID(ctx context.Context, userID int) ([]entities.Transaction, error) {
 	const query = `
-        SELECT t.id, t.sender_id, t.receiver_id, t.amount, t.created_at,
+        SELECT t.id, t.sender_id, t.receiver_id, t.amount, COALESCE(t.batch_id, 0), t.memo, t.reaction, t.created_at,
                s.username as sender_name, r.username as receiver_name
         FROM transactions t
         JOIN users s ON t.sender_id = s.id
@@ -119,7 +225,7 @@ func (r *Repo) GetByUserID(ctx context.Context, userID int) ([]entities.Transact
 
 func (r *Repo) GetBySenderID(ctx context.Context, senderID int) ([]entities.Transaction, error) {
 	const query = `
-        SELECT t.id, t.sender_id, t.receiver_id, t.amount, t.created_at,
+        SELECT t.id, t.sender_id, t.receiver_id, t.amount, COALESCE(t.batch_id, 0), t.memo, t.reaction, t.created_at,
                s.username as sender_name, r.username as receiver_name
         FROM transactions t
         JOIN users s ON t.sender_id = s.id
@@ -131,7 +237,7 @@ func (r *Repo) GetBySenderID(ctx context.Context, senderID int) ([]entities.Tran
 
 func (r *Repo) GetByReceiverID(ctx context.Context, receiverID int) ([]entities.Transaction, error) {
 	const query = `
-        SELECT t.id, t.sender_id, t.receiver_id, t.amount, t.created_at,
+        SELECT t.id, t.sender_id, t.receiver_id, t.amount, COALESCE(t.batch_id, 0), t.memo, t.reaction, t.created_at,
                s.username as sender_name, r.username as receiver_name
         FROM transactions t
         JOIN users s ON t.sender_id = s.id
@@ -140,3 +246,22 @@ func (r *Repo) GetByReceiverID(ctx context.Context, receiverID int) ([]entities.
 
 	return r.queryTransactions(ctx, query, receiverID)
 }
+
+func (r *Repo) SetReaction(ctx context.Context, transactionID, receiverID int, reaction string) (*entities.Transaction, error) {
+	const query = `
+        UPDATE transactions
+        SET reaction = $1, reacted_at = NOW()
+        WHERE id = $2 AND receiver_id = $3
+        RETURNING id, sender_id, receiver_id, amount, COALESCE(batch_id, 0), memo, reaction, created_at`
+
+	var t entities.Transaction
+
+	err := r.db.QueryRowContext(ctx, query, reaction, transactionID, receiverID).Scan(
+		&t.ID, &t.SenderID, &t.ReceiverID, &t.Amount, &t.BatchID, &t.Memo, &t.Reaction, &t.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("set reaction: %w", err)
+	}
+
+	return &t, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/require"
 
+	"merchshop/internal/entity"
 	"merchshop/internal/repository/transaction"
 )
 
@@ -40,12 +41,17 @@ func TestRepo_CreateTransaction_Success(t *testing.T) {
 
 	// Мокаем запись транзакции
 	mock.ExpectExec(`INSERT INTO transactions`).
-		WithArgs(senderID, receiverID, amount).
+		WithArgs(senderID, receiverID, amount, "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	// в журнал попадают только ID и сумма, без комментария
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WithArgs(0, "coin.transfer", "user", "2", "", "", nil, `{"amount":100,"receiverId":2,"senderId":1}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
 	mock.ExpectCommit()
 
-	err = repo.CreateTransaction(ctx, senderID, receiverID, amount)
+	err = repo.CreateTransaction(ctx, senderID, receiverID, amount, "", nil)
 	require.NoError(t, err)
 
 	require.NoError(t, mock.ExpectationsWereMet())
@@ -75,7 +81,7 @@ func TestRepo_CreateTransaction_InsufficientFunds(t *testing.T) {
 
 	mock.ExpectRollback()
 
-	err = repo.CreateTransaction(ctx, senderID, receiverID, amount)
+	err = repo.CreateTransaction(ctx, senderID, receiverID, amount, "", nil)
 	require.ErrorContains(t, err, "insufficient funds")
 
 	require.NoError(t, mock.ExpectationsWereMet())
@@ -93,8 +99,8 @@ func TestRepo_GetBySenderID(t *testing.T) {
 	now := time.Now()
 
 	rows := sqlmock.NewRows([]string{
-		"id", "sender_id", "receiver_id", "amount", "created_at", "sender_name", "receiver_name",
-	}).AddRow(1, 1, 2, 100, now, "alice", "bob")
+		"id", "sender_id", "receiver_id", "amount", "batch_id", "memo", "reaction", "created_at", "sender_name", "receiver_name",
+	}).AddRow(1, 1, 2, 100, 0, "за обед", "", now, "alice", "bob")
 
 	mock.ExpectQuery(`SELECT t.id, t.sender_id, t.receiver_id, t.amount`).
 		WithArgs(1).
@@ -109,6 +115,7 @@ func TestRepo_GetBySenderID(t *testing.T) {
 	require.Equal(t, 1, tx.SenderID)
 	require.Equal(t, 2, tx.ReceiverID)
 	require.Equal(t, 100, tx.Amount)
+	require.Equal(t, "за обед", tx.Memo)
 	require.Equal(t, "alice", tx.SenderName)
 	require.Equal(t, "bob", tx.ReceiverName)
 }
@@ -125,10 +132,116 @@ func TestRepo_GetByReceiverID_Empty(t *testing.T) {
 	mock.ExpectQuery(`SELECT t.id, t.sender_id, t.receiver_id`).
 		WithArgs(99).
 		WillReturnRows(sqlmock.NewRows([]string{
-			"id", "sender_id", "receiver_id", "amount", "created_at", "sender_name", "receiver_name",
+			"id", "sender_id", "receiver_id", "amount", "batch_id", "memo", "reaction", "created_at", "sender_name", "receiver_name",
 		}))
 
 	txs, err := repo.GetByReceiverID(ctx, 99)
 	require.NoError(t, err)
 	require.Empty(t, txs)
 }
+
+// пакетный перевод: одно списание и зачисление каждому получателю
+func TestRepo_CreateBatchTransaction_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := transaction.NewTransactionRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`UPDATE users SET balance = balance - \$1`).
+		WithArgs(30, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectQuery(`INSERT INTO transfer_batches`).
+		WithArgs(1, 30).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
+	mock.ExpectExec(`UPDATE users SET balance = balance \+ \$1`).
+		WithArgs(10, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`INSERT INTO transactions`).
+		WithArgs(1, 2, 10, 4, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`UPDATE users SET balance = balance \+ \$1`).
+		WithArgs(20, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec(`INSERT INTO transactions`).
+		WithArgs(1, 3, 20, 4, "").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	mock.ExpectExec(`INSERT INTO audit_outbox`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	batchID, err := repo.CreateBatchTransaction(context.Background(), 1, []entity.BatchTransferItem{
+		{ReceiverID: 2, Amount: 10},
+		{ReceiverID: 3, Amount: 20},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 4, batchID)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// пакетный перевод откатывается целиком, если получатель не найден
+func TestRepo_CreateBatchTransaction_UnknownReceiver(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := transaction.NewTransactionRepository(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`UPDATE users SET balance = balance - \$1`).
+		WithArgs(10, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectQuery(`INSERT INTO transfer_batches`).
+		WithArgs(1, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
+	mock.ExpectExec(`UPDATE users SET balance = balance \+ \$1`).
+		WithArgs(10, 99).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectRollback()
+
+	_, err = repo.CreateBatchTransaction(context.Background(), 1, []entity.BatchTransferItem{
+		{ReceiverID: 99, Amount: 10},
+	}, nil)
+	require.ErrorContains(t, err, "receiver 99 not found")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// реакция получателя на перевод
+func TestRepo_SetReaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := transaction.NewTransactionRepository(db)
+
+	mock.ExpectQuery(`UPDATE transactions\s+SET reaction = \$1`).
+		WithArgs("🎉", 7, 2).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "sender_id", "receiver_id", "amount", "batch_id", "memo", "reaction", "created_at",
+		}).AddRow(7, 1, 2, 50, 0, "", "🎉", time.Now()))
+
+	tx, err := repo.SetReaction(context.Background(), 7, 2, "🎉")
+	require.NoError(t, err)
+	require.Equal(t, 1, tx.SenderID)
+	require.Equal(t, "🎉", tx.Reaction)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
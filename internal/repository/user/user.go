@@ -3,11 +3,31 @@ package user
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	entities "merchshop/internal/entity"
 )
 
+var (
+	// ErrAccountFrozen аккаунт заморожен и не может отправлять, получать и тратить монеты
+	ErrAccountFrozen = errors.New("account is frozen")
+	// ErrAccountDeactivated аккаунт деактивирован при увольнении, войти в него нельзя
+	ErrAccountDeactivated = errors.New("account is deactivated")
+)
+
+// CheckActive возвращает ErrAccountFrozen или ErrAccountDeactivated для неактивного аккаунта
+func CheckActive(u *entities.User) error {
+	switch u.Status {
+	case entities.UserFrozen:
+		return fmt.Errorf("%w: %s", ErrAccountFrozen, u.Username)
+	case entities.UserDeactivated:
+		return fmt.Errorf("%w: %s", ErrAccountDeactivated, u.Username)
+	}
+
+	return nil
+}
+
 type Repository interface {
 	CreateUser(ctx context.Context, username string, password string) (*entities.User, error)
 	GetByID(ctx context.Context, id int) (*entities.User, error)
@@ -26,12 +46,13 @@ func (r *Repo) CreateUser(ctx context.Context, username string, password string)
 	const query = `
         INSERT INTO users (username, password_hash, balance)
         VALUES ($1, $2, 1000)
-        RETURNING id, username, password_hash, balance, created_at`
+        RETURNING id, username, password_hash, balance, role, department, status, created_at, tokens_revoked_at`
 
 	var user entities.User
 
 	err := r.db.QueryRowContext(ctx, query, username, password).
-		Scan(&user.ID, &user.Username, &user.Password, &user.Balance, &user.CreatedAt)
+		Scan(&user.ID, &user.Username, &user.Password, &user.Balance, &user.Role, &user.Department, &user.Status, &user.CreatedAt,
+			&user.TokensRevokedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
@@ -42,13 +63,14 @@ func (r *Repo) CreateUser(ctx context.Context, username string, password string)
 
 func (r *Repo) GetByID(ctx context.Context, id int) (*entities.User, error) {
 	const query = `
-        SELECT id, username, password_hash, balance, created_at
+        SELECT id, username, password_hash, balance, role, department, status, created_at, tokens_revoked_at
         FROM users
         WHERE id = $1`
 
 	var user entities.User
 	err := r.db.QueryRowContext(ctx, query, id).
-		Scan(&user.ID, &user.Username, &user.Password, &user.Balance, &user.CreatedAt)
+		Scan(&user.ID, &user.Username, &user.Password, &user.Balance, &user.Role, &user.Department, &user.Status, &user.CreatedAt,
+			&user.TokensRevokedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by id: %w", err)
@@ -59,14 +81,15 @@ func (r *Repo) GetByID(ctx context.Context, id int) (*entities.User, error) {
 
 func (r *Repo) GetByUsername(ctx context.Context, username string) (*entities.User, error) {
 	const query = `
-        SELECT id, username, password_hash, balance, created_at
+        SELECT id, username, password_hash, balance, role, department, status, created_at, tokens_revoked_at
         FROM users
         WHERE username = $1`
 
 	var user entities.User
 
 	err := r.db.QueryRowContext(ctx, query, username).
-		Scan(&user.ID, &user.Username, &user.Password, &user.Balance, &user.CreatedAt)
+		Scan(&user.ID, &user.Username, &user.Password, &user.Balance, &user.Role, &user.Department, &user.Status, &user.CreatedAt,
+			&user.TokensRevokedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by username: %w", err)
@@ -28,8 +28,8 @@ func TestRepo_CreateUser(t *testing.T) {
 
 	mock.ExpectQuery(`INSERT INTO users`).
 		WithArgs(username, password).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "balance", "created_at"}).
-			AddRow(1, username, password, 1000, createdAt))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "balance", "role", "department", "status", "created_at", "tokens_revoked_at"}).
+			AddRow(1, username, password, 1000, "employee", "", "active", createdAt, nil))
 
 	ctx := context.Background()
 	u, err := repo.CreateUser(ctx, username, password)
@@ -56,7 +56,7 @@ func TestRepo_FailedCreateUser(t *testing.T) {
 	password := "securepassword"
 	mock.ExpectQuery(`INSERT INTO users`).
 		WithArgs().
-		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "balance", "created_at"}))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "balance", "role", "department", "status", "created_at", "tokens_revoked_at"}))
 
 	ctx := context.Background()
 	u, err := repo.CreateUser(ctx, username, password)
@@ -76,10 +76,10 @@ func TestRepo_GetByID(t *testing.T) {
 
 	createdAt := time.Now()
 
-	mock.ExpectQuery(`SELECT id, username, password_hash, balance, created_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, username, password_hash, balance, role, department, status, created_at, tokens_revoked_at FROM users WHERE id = \$1`).
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "balance", "created_at"}).
-			AddRow(1, "user1", "hashpass", 800, createdAt))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "balance", "role", "department", "status", "created_at", "tokens_revoked_at"}).
+			AddRow(1, "user1", "hashpass", 800, "employee", "", "active", createdAt, nil))
 
 	ctx := context.Background()
 	u, err := repo.GetByID(ctx, 1)
@@ -104,10 +104,10 @@ func TestRepo_GetByUsername(t *testing.T) {
 
 	createdAt := time.Now()
 
-	mock.ExpectQuery(`SELECT id, username, password_hash, balance, created_at FROM users WHERE username = \$1`).
+	mock.ExpectQuery(`SELECT id, username, password_hash, balance, role, department, status, created_at, tokens_revoked_at FROM users WHERE username = \$1`).
 		WithArgs("user1").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "balance", "created_at"}).
-			AddRow(2, "user1", "pass123", 700, createdAt))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "balance", "role", "department", "status", "created_at", "tokens_revoked_at"}).
+			AddRow(2, "user1", "pass123", 700, "admin", "design", "active", createdAt, nil))
 
 	ctx := context.Background()
 	u, err := repo.GetByUsername(ctx, "user1")
@@ -117,6 +117,7 @@ func TestRepo_GetByUsername(t *testing.T) {
 	require.Equal(t, "user1", u.Username)
 	require.Equal(t, "pass123", u.Password)
 	require.Equal(t, 700, u.Balance)
+	require.Equal(t, "admin", u.Role)
 	require.WithinDuration(t, createdAt, u.CreatedAt, time.Second)
 
 	require.NoError(t, mock.ExpectationsWereMet())
@@ -130,7 +131,7 @@ func TestRepo_GetByID_NotFound(t *testing.T) {
 
 	repo := user.NewUserRepository(db)
 
-	mock.ExpectQuery(`SELECT id, username, password_hash, balance, created_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, username, password_hash, balance, role, department, status, created_at, tokens_revoked_at FROM users WHERE id = \$1`).
 		WithArgs(999).
 		WillReturnError(sql.ErrNoRows)
 
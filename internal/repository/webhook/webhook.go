@@ -0,0 +1,244 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	entities "merchshop/internal/entity"
+)
+
+type DeliveryFilter struct {
+	WebhookID int
+	Status    string
+	Limit     int
+}
+
+type Repository interface {
+	Create(ctx context.Context, url string, events []string, secret string) (*entities.Webhook, error)
+	GetByID(ctx context.Context, id int) (*entities.Webhook, error)
+	List(ctx context.Context) ([]entities.Webhook, error)
+	Deactivate(ctx context.Context, id int) error
+	GetActiveByEvent(ctx context.Context, eventType string) ([]entities.Webhook, error)
+	CreateDelivery(ctx context.Context, webhookID int, eventType string, payload []byte) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entities.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, id, statusCode int) error
+	MarkAttemptFailed(ctx context.Context, id, statusCode int, lastErr string, nextAttemptAt *time.Time) error
+	ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]entities.WebhookDelivery, error)
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+func (r *Repo) Create(ctx context.Context, url string, events []string, secret string) (*entities.Webhook, error) {
+	const query = `
+        INSERT INTO webhooks (url, events, secret)
+        VALUES ($1, $2, $3)
+        RETURNING id, url, events, secret, active, created_at`
+
+	var w entities.Webhook
+
+	err := r.db.QueryRowContext(ctx, query, url, pq.Array(events), secret).
+		Scan(&w.ID, &w.URL, pq.Array(&w.Events), &w.Secret, &w.Active, &w.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return &w, nil
+}
+
+func (r *Repo) GetByID(ctx context.Context, id int) (*entities.Webhook, error) {
+	const query = `
+        SELECT id, url, events, secret, active, created_at
+        FROM webhooks
+        WHERE id = $1`
+
+	var w entities.Webhook
+
+	err := r.db.QueryRowContext(ctx, query, id).
+		Scan(&w.ID, &w.URL, pq.Array(&w.Events), &w.Secret, &w.Active, &w.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook by id: %w", err)
+	}
+
+	return &w, nil
+}
+
+func (r *Repo) queryWebhooks(ctx context.Context, query string, args ...interface{}) ([]entities.Webhook, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []entities.Webhook
+
+	for rows.Next() {
+		var w entities.Webhook
+
+		if err := rows.Scan(&w.ID, &w.URL, pq.Array(&w.Events), &w.Secret, &w.Active, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan webhook: %w", err)
+		}
+
+		webhooks = append(webhooks, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *Repo) List(ctx context.Context) ([]entities.Webhook, error) {
+	const query = `
+        SELECT id, url, events, secret, active, created_at
+        FROM webhooks
+        ORDER BY id`
+
+	return r.queryWebhooks(ctx, query)
+}
+
+func (r *Repo) GetActiveByEvent(ctx context.Context, eventType string) ([]entities.Webhook, error) {
+	const query = `
+        SELECT id, url, events, secret, active, created_at
+        FROM webhooks
+        WHERE active AND $1 = ANY(events)`
+
+	return r.queryWebhooks(ctx, query, eventType)
+}
+
+func (r *Repo) Deactivate(ctx context.Context, id int) error {
+	const query = `
+        UPDATE webhooks
+        SET active = FALSE
+        WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("deactivate webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("deactivate webhook: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *Repo) CreateDelivery(ctx context.Context, webhookID int, eventType string, payload []byte) error {
+	const query = `
+        INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+        VALUES ($1, $2, $3)`
+
+	if _, err := r.db.ExecContext(ctx, query, webhookID, eventType, payload); err != nil {
+		return fmt.Errorf("insert webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries забирает доставки, время которых пришло, и сдвигает next_attempt_at на lease,
+// чтобы другие реплики не отправили их повторно, пока идет попытка
+func (r *Repo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entities.WebhookDelivery, error) {
+	const query = `
+        UPDATE webhook_deliveries
+        SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+        WHERE id IN (
+            SELECT id
+            FROM webhook_deliveries
+            WHERE status = 'pending' AND next_attempt_at <= NOW()
+            ORDER BY next_attempt_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, webhook_id, event_type, payload, status, attempts, next_attempt_at,
+                  last_status_code, last_error, created_at, delivered_at`
+
+	return r.queryDeliveries(ctx, query, limit, lease.Seconds())
+}
+
+func (r *Repo) MarkDelivered(ctx context.Context, id, statusCode int) error {
+	const query = `
+        UPDATE webhook_deliveries
+        SET status = 'delivered', attempts = attempts + 1, last_status_code = $2,
+            last_error = '', delivered_at = NOW()
+        WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, statusCode); err != nil {
+		return fmt.Errorf("mark delivery delivered: %w", err)
+	}
+
+	return nil
+}
+
+// MarkAttemptFailed фиксирует неудачную попытку. Если nextAttemptAt == nil, доставка считается окончательно проваленной
+func (r *Repo) MarkAttemptFailed(ctx context.Context, id, statusCode int, lastErr string, nextAttemptAt *time.Time) error {
+	const query = `
+        UPDATE webhook_deliveries
+        SET attempts = attempts + 1, last_status_code = $2, last_error = $3,
+            status = CASE WHEN $4::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+            next_attempt_at = COALESCE($4, next_attempt_at)
+        WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, statusCode, lastErr, nextAttemptAt); err != nil {
+		return fmt.Errorf("mark delivery attempt failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]entities.WebhookDelivery, error) {
+	const query = `
+        SELECT id, webhook_id, event_type, payload, status, attempts, next_attempt_at,
+               last_status_code, last_error, created_at, delivered_at
+        FROM webhook_deliveries
+        WHERE ($1 = 0 OR webhook_id = $1) AND ($2 = '' OR status = $2)
+        ORDER BY id DESC
+        LIMIT $3`
+
+	return r.queryDeliveries(ctx, query, filter.WebhookID, filter.Status, filter.Limit)
+}
+
+func (r *Repo) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]entities.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []entities.WebhookDelivery
+
+	for rows.Next() {
+		var d entities.WebhookDelivery
+
+		err := rows.Scan(
+			&d.ID, &d.WebhookID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,129 @@
+package webhook_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/repository/webhook"
+)
+
+var webhookColumns = []string{"id", "url", "events", "secret", "active", "created_at"}
+
+var deliveryColumns = []string{
+	"id", "webhook_id", "event_type", "payload", "status", "attempts", "next_attempt_at",
+	"last_status_code", "last_error", "created_at", "delivered_at",
+}
+
+// создание подписки
+func TestRepo_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := webhook.NewWebhookRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery(`INSERT INTO webhooks`).
+		WithArgs("https://hr.example.com/hook", sqlmock.AnyArg(), "secret").
+		WillReturnRows(sqlmock.NewRows(webhookColumns).
+			AddRow(1, "https://hr.example.com/hook", "{coin.received,purchase.completed}", "secret", true, now))
+
+	w, err := repo.Create(context.Background(), "https://hr.example.com/hook",
+		[]string{"coin.received", "purchase.completed"}, "secret")
+
+	require.NoError(t, err)
+	require.Equal(t, 1, w.ID)
+	require.Equal(t, []string{"coin.received", "purchase.completed"}, w.Events)
+	require.True(t, w.Active)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// подписчики на событие
+func TestRepo_GetActiveByEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := webhook.NewWebhookRepository(db)
+
+	mock.ExpectQuery(`SELECT id, url, events, secret, active, created_at FROM webhooks WHERE active AND \$1 = ANY\(events\)`).
+		WithArgs("coin.received").
+		WillReturnRows(sqlmock.NewRows(webhookColumns).
+			AddRow(1, "https://a.example.com", "{coin.received}", "s1", true, time.Now()).
+			AddRow(2, "https://b.example.com", "{coin.received,coin.sent}", "s2", true, time.Now()))
+
+	hooks, err := repo.GetActiveByEvent(context.Background(), "coin.received")
+
+	require.NoError(t, err)
+	require.Len(t, hooks, 2)
+	require.Equal(t, "https://b.example.com", hooks[1].URL)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// отключение несуществующей подписки
+func TestRepo_Deactivate_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := webhook.NewWebhookRepository(db)
+
+	mock.ExpectExec(`UPDATE webhooks SET active = FALSE WHERE id = \$1`).
+		WithArgs(42).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.Deactivate(context.Background(), 42)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// резервирование доставок для отправки
+func TestRepo_ClaimDueDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := webhook.NewWebhookRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery(`UPDATE webhook_deliveries SET next_attempt_at = NOW\(\) \+ \$2 \* INTERVAL '1 second'`).
+		WithArgs(10, float64(300)).
+		WillReturnRows(sqlmock.NewRows(deliveryColumns).
+			AddRow(7, 1, "coin.sent", []byte(`{"type":"coin.sent"}`), "pending", 2, now, 500, "boom", now, nil))
+
+	deliveries, err := repo.ClaimDueDeliveries(context.Background(), 10, 5*time.Minute)
+
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, 7, deliveries[0].ID)
+	require.Equal(t, 2, deliveries[0].Attempts)
+	require.Nil(t, deliveries[0].DeliveredAt)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// окончательный провал доставки
+func TestRepo_MarkAttemptFailed_Final(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := webhook.NewWebhookRepository(db)
+
+	mock.ExpectExec(`UPDATE webhook_deliveries SET attempts = attempts \+ 1`).
+		WithArgs(7, 503, "unexpected status code: 503", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.MarkAttemptFailed(context.Background(), 7, 503, "unexpected status code: 503", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
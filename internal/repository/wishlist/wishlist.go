@@ -0,0 +1,131 @@
+package wishlist
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	entities "merchshop/internal/entity"
+)
+
+type Repository interface {
+	// Add добавляет товар в список с начальными LastPrice и AffordableNotified. Повторное
+	// добавление ничего не меняет
+	Add(ctx context.Context, w entities.WishlistItem) error
+	// Remove убирает товар из списка, если его там нет, возвращает sql.ErrNoRows
+	Remove(ctx context.Context, userID int, item string) error
+	// List возвращает список пользователя с текущими ценами и балансом
+	List(ctx context.Context, userID int) ([]entities.WishlistItem, error)
+	// ListActive возвращает списки всех активных пользователей для проверки уведомлений
+	ListActive(ctx context.Context) ([]entities.WishlistItem, error)
+	// SaveState запоминает цену и факт уведомления о достаточном балансе
+	SaveState(ctx context.Context, userID int, item string, lastPrice int, affordableNotified bool) error
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewWishlistRepository(db *sql.DB) Repository {
+	return &Repo{db: db}
+}
+
+// Скидка берется у лучшей распродажи, действующей сейчас, как при покупке
+const wishlistQuery = `
+        SELECT w.user_id, w.item, w.merch_name, COALESCE(w.sku, ''), COALESCE(v.price, m.price),
+               COALESCE((SELECT MAX(s.percent)
+                         FROM sales s
+                         WHERE s.starts_at <= NOW() AND s.ends_at > NOW()
+                           AND (cardinality(s.items) = 0 OR w.merch_name = ANY(s.items))), 0),
+               u.balance, w.last_price, w.affordable_notified, w.added_at
+        FROM wishlist_items w
+        JOIN merchandise m ON m.name = w.merch_name
+        JOIN users u ON u.id = w.user_id
+        LEFT JOIN merch_variants v ON v.sku = w.sku`
+
+func (r *Repo) Add(ctx context.Context, w entities.WishlistItem) error {
+	const query = `
+        INSERT INTO wishlist_items (user_id, item, merch_name, sku, last_price, affordable_notified)
+        VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+        ON CONFLICT (user_id, item) DO NOTHING`
+
+	_, err := r.db.ExecContext(ctx, query, w.UserID, w.Item, w.MerchName, w.SKU, w.LastPrice, w.AffordableNotified)
+	if err != nil {
+		return fmt.Errorf("add %s to wishlist of user %d: %w", w.Item, w.UserID, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) Remove(ctx context.Context, userID int, item string) error {
+	const query = `DELETE FROM wishlist_items WHERE user_id = $1 AND item = $2`
+
+	result, err := r.db.ExecContext(ctx, query, userID, item)
+	if err != nil {
+		return fmt.Errorf("remove %s from wishlist of user %d: %w", item, userID, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("remove %s from wishlist of user %d: %w", item, userID, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *Repo) List(ctx context.Context, userID int) ([]entities.WishlistItem, error) {
+	return r.query(ctx, wishlistQuery+`
+        WHERE w.user_id = $1
+        ORDER BY w.added_at, w.item`, userID)
+}
+
+func (r *Repo) ListActive(ctx context.Context) ([]entities.WishlistItem, error) {
+	return r.query(ctx, wishlistQuery+`
+        WHERE u.status = 'active'
+        ORDER BY w.user_id, w.item`)
+}
+
+func (r *Repo) SaveState(ctx context.Context, userID int, item string, lastPrice int, affordableNotified bool) error {
+	const query = `
+        UPDATE wishlist_items
+        SET last_price = $3, affordable_notified = $4
+        WHERE user_id = $1 AND item = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, item, lastPrice, affordableNotified); err != nil {
+		return fmt.Errorf("save wishlist state of %s for user %d: %w", item, userID, err)
+	}
+
+	return nil
+}
+
+func (r *Repo) query(ctx context.Context, query string, args ...interface{}) ([]entities.WishlistItem, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query wishlist: %w", err)
+	}
+	defer rows.Close()
+
+	var items []entities.WishlistItem
+
+	for rows.Next() {
+		var w entities.WishlistItem
+
+		err := rows.Scan(&w.UserID, &w.Item, &w.MerchName, &w.SKU, &w.ListPrice, &w.SalePercent, &w.Balance,
+			&w.LastPrice, &w.AffordableNotified, &w.AddedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan wishlist item: %w", err)
+		}
+
+		items = append(items, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return items, nil
+}
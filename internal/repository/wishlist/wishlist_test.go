@@ -0,0 +1,57 @@
+package wishlist_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/repository/wishlist"
+)
+
+// Тест чтения списка желаний с ценой варианта и скидкой распродажи
+func TestWishlist_List(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := wishlist.NewWishlistRepository(db)
+
+	mock.ExpectQuery(`SELECT w.user_id, w.item, .* FROM wishlist_items w JOIN merchandise m ON m.name = w.merch_name ` +
+		`JOIN users u ON u.id = w.user_id LEFT JOIN merch_variants v ON v.sku = w.sku WHERE w.user_id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"user_id", "item", "merch_name", "sku", "price", "percent", "balance", "last_price", "affordable_notified",
+			"added_at",
+		}).AddRow(1, "hoody-pink-m", "hoody", "hoody-pink-m", 500, 20, 150, 500, false, time.Now()))
+
+	items, err := repo.List(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "hoody", items[0].MerchName)
+	require.Equal(t, 500, items[0].ListPrice)
+	require.Equal(t, 20, items[0].SalePercent)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Тест удаления товара, которого нет в списке
+func TestWishlist_Remove_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := wishlist.NewWishlistRepository(db)
+
+	mock.ExpectExec(`DELETE FROM wishlist_items WHERE user_id = \$1 AND item = \$2`).
+		WithArgs(1, "cup").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.Remove(context.Background(), 1, "cup")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,119 @@
+package sheet
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// MaxXLSXRows предел строк листа XLSX, включая заголовок
+const MaxXLSXRows = 1 << 20
+
+var (
+	// ErrUnknownFormat формат выгрузки не поддерживается
+	ErrUnknownFormat = errors.New("unknown format")
+	// ErrTooManyRows выгрузка не помещается на лист XLSX
+	ErrTooManyRows = fmt.Errorf("more than %d rows, use csv or a shorter period", MaxXLSXRows)
+)
+
+// Writer пишет таблицу построчно, не накапливая строки в памяти. Значения ячеек: строки, целые
+// числа и время; nil дает пустую ячейку. Close дописывает файл и должен вызываться всегда
+type Writer interface {
+	WriteRow(values ...any) error
+	Close() error
+}
+
+// NewWriter создает Writer формата format поверх w
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// ContentType MIME-тип файла формата format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "text/csv; charset=utf-8"
+}
+
+// CheckFormat возвращает ErrUnknownFormat, если формат не поддерживается
+func CheckFormat(format string) error {
+	if format != FormatCSV && format != FormatXLSX {
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+
+	return nil
+}
+
+// escapeFormula экранирует строку, которую табличный редактор принял бы за формулу
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
+
+// formatValue текстовое представление ячейки. Время пишется в UTC в RFC 3339
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+
+		return v.UTC().Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (c *csvWriter) WriteRow(values ...any) error {
+	c.record = c.record[:0]
+	for _, v := range values {
+		// В CSV нет типа ячейки: текст из memo или имени может оказаться формулой
+		if s, ok := v.(string); ok {
+			c.record = append(c.record, escapeFormula(s))
+			continue
+		}
+
+		c.record = append(c.record, formatValue(v))
+	}
+
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
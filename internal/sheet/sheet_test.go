@@ -0,0 +1,94 @@
+package sheet_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/sheet"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := sheet.NewWriter(&buf, sheet.FormatCSV)
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteRow("id", "memo", "created_at"))
+	require.NoError(t, w.WriteRow(1, "за помощь, с релизом", time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "id,memo,created_at\n1,\"за помощь, с релизом\",2026-10-01T12:00:00Z\n", buf.String())
+}
+
+func TestCSVWriter_EscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := sheet.NewWriter(&buf, sheet.FormatCSV)
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteRow(-5, "=HYPERLINK(\"http://x\")", "+1", "@SUM(A1)", "\tcmd", "a=b"))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "-5,\"'=HYPERLINK(\"\"http://x\"\")\",'+1,'@SUM(A1),'\tcmd,a=b\n", buf.String())
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := sheet.NewWriter(&buf, sheet.FormatXLSX)
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteRow("item", "total"))
+	require.NoError(t, w.WriteRow("cup & <book>", 20, nil))
+
+	wide := make([]any, 27)
+	wide[26] = 1
+	require.NoError(t, w.WriteRow(wide...))
+	require.NoError(t, w.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	var sheet string
+
+	for _, f := range archive.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+
+		r, err := f.Open()
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(r)
+		require.NoError(t, err)
+
+		sheet = string(body)
+	}
+
+	assert.Len(t, archive.File, 5)
+	assert.Contains(t, sheet, `<row r="2"><c r="A2" t="inlineStr"><is><t xml:space="preserve">cup &amp; &lt;book&gt;</t></is></c>`+
+		`<c r="B2"><v>20</v></c></row>`)
+	assert.Contains(t, sheet, `<row r="3"><c r="AA3"><v>1</v></c></row>`)
+}
+
+func TestXLSXWriter_TooManyRows(t *testing.T) {
+	w, err := sheet.NewWriter(io.Discard, sheet.FormatXLSX)
+	require.NoError(t, err)
+
+	for range sheet.MaxXLSXRows {
+		require.NoError(t, w.WriteRow())
+	}
+
+	assert.ErrorIs(t, w.WriteRow(), sheet.ErrTooManyRows)
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	_, err := sheet.NewWriter(io.Discard, "pdf")
+	assert.ErrorIs(t, err, sheet.ErrUnknownFormat)
+}
@@ -0,0 +1,180 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/event"
+	"merchshop/internal/repository/account"
+	"merchshop/internal/repository/user"
+	"merchshop/internal/usecase/notification"
+	"merchshop/internal/usecase/transaction"
+)
+
+// SweepMemo комментарий к переводу остатка уволенного сотрудника в пул
+const SweepMemo = "offboarding: balance sweep"
+
+const (
+	maxDepartmentLength = 100
+	maxErasureReason    = 500
+)
+
+// ErrNotDeactivated стереть можно только аккаунт, который уже прошел Offboard: так остаток
+// баланса и токены обрабатываются до обезличивания
+var ErrNotDeactivated = errors.New("account must be offboarded before erasure")
+
+type UseCase interface {
+	// SetStatus замораживает аккаунт или возвращает его в работу. Деактивация только через Offboard
+	SetStatus(ctx context.Context, userID int, status string) (*entities.User, error)
+	// SetDepartment меняет отдел сотрудника, пустой отдел убирает его из отдела
+	SetDepartment(ctx context.Context, userID int, department string) (*entities.User, error)
+	// Offboard деактивирует аккаунт и отзывает токены, при sweep переводит остаток в пул
+	Offboard(ctx context.Context, userID int, sweep bool) (*entities.Offboarding, error)
+	// Erase обезличивает деактивированный аккаунт по запросу на удаление данных. Переводы и покупки
+	// остаются, контрагенты видят в них DeletedUsername, в том числе в уведомлениях и вебхуках. Комментарии
+	// к переводам и подаркам не меняются. adminID и reason попадают в журнал стираний
+	Erase(ctx context.Context, adminID, userID int, reason string) (*entities.Erasure, error)
+}
+
+type useCase struct {
+	accountRepo  account.Repository
+	userRepo     user.Repository
+	events       event.Publisher
+	notifier     notification.Notifier
+	poolUsername string
+}
+
+// NewUseCase poolUsername пользователь, которому достаются остатки. Пустой запрещает перевод остатка
+func NewUseCase(
+	accountRepo account.Repository,
+	userRepo user.Repository,
+	events event.Publisher,
+	notifier notification.Notifier,
+	poolUsername string,
+) UseCase {
+	return &useCase{
+		accountRepo:  accountRepo,
+		userRepo:     userRepo,
+		events:       events,
+		notifier:     notifier,
+		poolUsername: poolUsername,
+	}
+}
+
+func (u *useCase) SetStatus(ctx context.Context, userID int, status string) (*entities.User, error) {
+	if status != entities.UserActive && status != entities.UserFrozen {
+		return nil, fmt.Errorf("invalid status: %q", status)
+	}
+
+	if err := u.accountRepo.SetStatus(ctx, userID, status); err != nil {
+		return nil, fmt.Errorf("failed to set status of user %d: %w", userID, err)
+	}
+
+	return u.userRepo.GetByID(ctx, userID)
+}
+
+func (u *useCase) SetDepartment(ctx context.Context, userID int, department string) (*entities.User, error) {
+	department = strings.TrimSpace(department)
+	if len([]rune(department)) > maxDepartmentLength {
+		return nil, fmt.Errorf("department name is longer than %d characters", maxDepartmentLength)
+	}
+
+	if err := u.accountRepo.SetDepartment(ctx, userID, department); err != nil {
+		return nil, fmt.Errorf("failed to set department of user %d: %w", userID, err)
+	}
+
+	return u.userRepo.GetByID(ctx, userID)
+}
+
+func (u *useCase) Offboard(ctx context.Context, userID int, sweep bool) (*entities.Offboarding, error) {
+	target, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+
+	if target.Status == entities.UserDeactivated {
+		return nil, fmt.Errorf("user %s is already deactivated", target.Username)
+	}
+
+	var pool *entities.User
+
+	if sweep {
+		if u.poolUsername == "" {
+			return nil, fmt.Errorf("pool account is not configured")
+		}
+
+		pool, err = u.userRepo.GetByUsername(ctx, u.poolUsername)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pool account %s: %w", u.poolUsername, err)
+		}
+
+		if pool.ID == userID {
+			return nil, fmt.Errorf("cannot offboard the pool account")
+		}
+
+		if pool.Status == entities.UserDeactivated {
+			return nil, fmt.Errorf("pool account %s is deactivated", pool.Username)
+		}
+	}
+
+	poolID := 0
+	if pool != nil {
+		poolID = pool.ID
+	}
+
+	result, err := u.accountRepo.Offboard(ctx, userID, poolID, SweepMemo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to offboard user %d: %w", userID, err)
+	}
+
+	data := event.Offboarding{Username: target.Username}
+
+	if result.Swept > 0 {
+		data.PoolUser = pool.Username
+		data.Swept = result.Swept
+
+		transfer := event.CoinTransfer{FromUser: target.Username, ToUser: pool.Username, Amount: result.Swept, Memo: SweepMemo}
+		u.events.Publish(ctx, event.Event{Type: event.CoinReceived, UserID: pool.ID, Data: transfer})
+		notification.Deliver(ctx, u.notifier, transaction.CoinReceived(userID, pool.ID, transfer))
+	}
+
+	u.events.Publish(ctx, event.Event{Type: event.UserDeactivated, UserID: userID, Data: data})
+
+	return result, nil
+}
+
+func (u *useCase) Erase(ctx context.Context, adminID, userID int, reason string) (*entities.Erasure, error) {
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) > maxErasureReason {
+		return nil, fmt.Errorf("reason is longer than %d characters", maxErasureReason)
+	}
+
+	target, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+
+	if target.Status != entities.UserDeactivated {
+		return nil, fmt.Errorf("user %d: %w", userID, ErrNotDeactivated)
+	}
+
+	if u.poolUsername != "" && target.Username == u.poolUsername {
+		return nil, fmt.Errorf("cannot erase the pool account")
+	}
+
+	result, err := u.accountRepo.Erase(ctx, userID, adminID, ErasedUsername(userID), reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase user %d: %w", userID, err)
+	}
+
+	return result, nil
+}
+
+// ErasedUsername имя стертого пользователя, номер делает его уникальным. Зарегистрировать имя
+// с префиксом DeletedUsername нельзя, поэтому оно ни с кем не совпадет
+func ErasedUsername(userID int) string {
+	return fmt.Sprintf("%s #%d", entities.DeletedUsername, userID)
+}
@@ -0,0 +1,170 @@
+package account_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/event"
+	"merchshop/internal/usecase/account"
+)
+
+type mockAccountRepo struct {
+	SetStatusFunc func(ctx context.Context, userID int, status string) error
+	OffboardFunc  func(ctx context.Context, userID, poolID int, memo string) (*entity.Offboarding, error)
+	EraseFunc     func(ctx context.Context, userID, erasedBy int, username, reason string) (*entity.Erasure, error)
+}
+
+func (m *mockAccountRepo) SetDepartment(ctx context.Context, userID int, department string) error {
+	return nil
+}
+
+func (m *mockAccountRepo) SetStatus(ctx context.Context, userID int, status string) error {
+	return m.SetStatusFunc(ctx, userID, status)
+}
+
+func (m *mockAccountRepo) Offboard(ctx context.Context, userID, poolID int, memo string) (*entity.Offboarding, error) {
+	return m.OffboardFunc(ctx, userID, poolID, memo)
+}
+
+func (m *mockAccountRepo) Erase(ctx context.Context, userID, erasedBy int, username, reason string) (*entity.Erasure, error) {
+	return m.EraseFunc(ctx, userID, erasedBy, username, reason)
+}
+
+type mockUserRepo struct {
+	users map[string]*entity.User
+}
+
+func (m *mockUserRepo) CreateUser(ctx context.Context, username string, password string) (*entity.User, error) {
+	return nil, nil
+}
+
+func (m *mockUserRepo) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	for _, u := range m.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+
+	return nil, assert.AnError
+}
+
+func (m *mockUserRepo) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	if u, ok := m.users[username]; ok {
+		return u, nil
+	}
+
+	return nil, assert.AnError
+}
+
+type recordingPublisher struct {
+	events []event.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, e event.Event) {
+	p.events = append(p.events, e)
+}
+
+type recordingNotifier struct {
+	notifications []entity.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func newUsers() *mockUserRepo {
+	return &mockUserRepo{users: map[string]*entity.User{
+		"pool":  {ID: 1, Username: "pool", Status: entity.UserActive},
+		"alice": {ID: 7, Username: "alice", Balance: 350, Status: entity.UserActive},
+	}}
+}
+
+func TestOffboard_SweepsToPool(t *testing.T) {
+	repo := &mockAccountRepo{
+		OffboardFunc: func(ctx context.Context, userID, poolID int, memo string) (*entity.Offboarding, error) {
+			assert.Equal(t, 7, userID)
+			assert.Equal(t, 1, poolID)
+			return &entity.Offboarding{UserID: userID, PoolID: poolID, Swept: 350, TransactionID: 99, DeactivatedAt: time.Now()}, nil
+		},
+	}
+	events := &recordingPublisher{}
+	notifier := &recordingNotifier{}
+
+	uc := account.NewUseCase(repo, newUsers(), events, notifier, "pool")
+	result, err := uc.Offboard(context.Background(), 7, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 350, result.Swept)
+
+	if assert.Len(t, events.events, 2) {
+		assert.Equal(t, event.CoinReceived, events.events[0].Type)
+		assert.Equal(t, 1, events.events[0].UserID)
+		assert.Equal(t, event.UserDeactivated, events.events[1].Type)
+		assert.Equal(t, event.Offboarding{Username: "alice", PoolUser: "pool", Swept: 350}, events.events[1].Data)
+	}
+
+	if assert.Len(t, notifier.notifications, 1) {
+		assert.Equal(t, entity.NotifyCoinReceived, notifier.notifications[0].Kind)
+		assert.Equal(t, 1, notifier.notifications[0].UserID)
+	}
+}
+
+// без настроенного пула перевести остаток нельзя, но деактивировать можно
+func TestOffboard_PoolNotConfigured(t *testing.T) {
+	repo := &mockAccountRepo{
+		OffboardFunc: func(ctx context.Context, userID, poolID int, memo string) (*entity.Offboarding, error) {
+			assert.Zero(t, poolID)
+			return &entity.Offboarding{UserID: userID}, nil
+		},
+	}
+
+	uc := account.NewUseCase(repo, newUsers(), &recordingPublisher{}, &recordingNotifier{}, "")
+
+	_, err := uc.Offboard(context.Background(), 7, true)
+	assert.ErrorContains(t, err, "pool account is not configured")
+
+	_, err = uc.Offboard(context.Background(), 7, false)
+	assert.NoError(t, err)
+}
+
+func TestOffboard_PoolItself(t *testing.T) {
+	uc := account.NewUseCase(&mockAccountRepo{}, newUsers(), &recordingPublisher{}, &recordingNotifier{}, "pool")
+
+	_, err := uc.Offboard(context.Background(), 1, true)
+	assert.ErrorContains(t, err, "cannot offboard the pool account")
+}
+
+func TestSetStatus_RejectsDeactivation(t *testing.T) {
+	uc := account.NewUseCase(&mockAccountRepo{}, newUsers(), &recordingPublisher{}, &recordingNotifier{}, "pool")
+
+	_, err := uc.SetStatus(context.Background(), 7, entity.UserDeactivated)
+	assert.ErrorContains(t, err, "invalid status")
+}
+
+// стирается только деактивированный аккаунт, имя заменяется обезличенным
+func TestErase(t *testing.T) {
+	repo := &mockAccountRepo{
+		EraseFunc: func(ctx context.Context, userID, erasedBy int, username, reason string) (*entity.Erasure, error) {
+			assert.Equal(t, 1, erasedBy)
+			assert.Equal(t, "employee request", reason)
+			return &entity.Erasure{UserID: userID, ErasedBy: erasedBy, Username: username, Reason: reason}, nil
+		},
+	}
+
+	users := newUsers()
+	uc := account.NewUseCase(repo, users, &recordingPublisher{}, &recordingNotifier{}, "pool")
+
+	_, err := uc.Erase(context.Background(), 1, 7, "employee request")
+	assert.ErrorIs(t, err, account.ErrNotDeactivated)
+
+	users.users["alice"].Status = entity.UserDeactivated
+
+	result, err := uc.Erase(context.Background(), 1, 7, " employee request ")
+	assert.NoError(t, err)
+	assert.Equal(t, "deleted user #7", result.Username)
+}
@@ -0,0 +1,154 @@
+package auditlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"merchshop/internal/audit"
+	entities "merchshop/internal/entity"
+	"merchshop/internal/event"
+	"merchshop/internal/repository/auditlog"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+	flushBatch   = 500
+)
+
+// errChainBroken останавливает обход цепочки на первой несошедшейся записи
+var errChainBroken = errors.New("audit chain is broken")
+
+// Page страница журнала. NextBefore передается как before за следующей страницей,
+// 0 если страниц больше нет
+type Page struct {
+	Entries    []entities.AuditEntry
+	NextBefore int
+}
+
+type UseCase interface {
+	audit.Recorder
+	// Publish записывает регистрации из шины событий. На переводы и покупки сразу переносит
+	// в цепочку записи, сохраненные вместе с ними
+	Publish(ctx context.Context, e event.Event)
+	// Flush переносит в цепочку записи, сохраненные в транзакциях переводов и покупок
+	Flush(ctx context.Context) (int, error)
+	// List записи по фильтру, новые первыми. limit по умолчанию 50, не больше 500
+	List(ctx context.Context, f entities.AuditFilter, before, limit int) (*Page, error)
+	// Walk передает в fn записи по фильтру в порядке цепочки
+	Walk(ctx context.Context, f entities.AuditFilter, fn func(entities.AuditEntry) error) error
+	// Verify пересчитывает хеши всей цепочки. Несошедшаяся запись не ошибка, она возвращается
+	// в BrokenID
+	Verify(ctx context.Context) (*entities.AuditVerification, error)
+}
+
+type useCase struct {
+	repo auditlog.Repository
+}
+
+func NewUseCase(repo auditlog.Repository) UseCase {
+	return &useCase{repo: repo}
+}
+
+func (u *useCase) Record(ctx context.Context, e entities.AuditEntry) {
+	e = audit.Stamp(ctx, e)
+
+	if _, err := u.repo.Append(ctx, e); err != nil {
+		log.Printf("auditlog: record %s: %v", e.Action, err)
+	}
+}
+
+// Publish ошибки переноса только логирует: записи остаются в очереди до следующего Flush
+func (u *useCase) Publish(ctx context.Context, e event.Event) {
+	switch e.Type {
+	case event.CoinReceived, event.PurchaseCompleted:
+		if _, err := u.Flush(ctx); err != nil {
+			log.Printf("auditlog: %v", err)
+		}
+	case event.UserRegistered:
+		// Имя в журнал не пишется: его нельзя будет стереть
+		u.Record(ctx, entities.AuditEntry{
+			ActorID:    e.UserID,
+			Action:     audit.ActionRegister,
+			TargetType: audit.TargetUser,
+			TargetID:   strconv.Itoa(e.UserID),
+		})
+	}
+}
+
+func (u *useCase) Flush(ctx context.Context) (int, error) {
+	flushed, err := u.repo.Flush(ctx, flushBatch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to flush audit entries: %w", err)
+	}
+
+	return flushed, nil
+}
+
+func (u *useCase) List(ctx context.Context, f entities.AuditFilter, before, limit int) (*Page, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	// Лишняя запись показывает, есть ли следующая страница
+	entries, err := u.repo.List(ctx, f, before, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+
+	page := &Page{Entries: entries}
+
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.NextBefore = entries[limit-1].ID
+	}
+
+	return page, nil
+}
+
+func (u *useCase) Walk(ctx context.Context, f entities.AuditFilter, fn func(entities.AuditEntry) error) error {
+	return u.repo.Walk(ctx, f, fn)
+}
+
+func (u *useCase) Verify(ctx context.Context) (*entities.AuditVerification, error) {
+	var result entities.AuditVerification
+
+	broken := func(e entities.AuditEntry, problem string) error {
+		result.BrokenID = e.ID
+		result.Problem = problem
+
+		return errChainBroken
+	}
+
+	err := u.repo.Walk(ctx, entities.AuditFilter{}, func(e entities.AuditEntry) error {
+		if e.PrevHash != result.Head {
+			return broken(e, "previous hash does not match, an entry before it was removed or changed")
+		}
+
+		hash, err := audit.Hash(e.PrevHash, e)
+		if err != nil {
+			return broken(e, fmt.Sprintf("cannot hash entry: %v", err))
+		}
+
+		if hash != e.Hash {
+			return broken(e, "entry hash does not match its contents")
+		}
+
+		result.Entries++
+		result.Head = e.Hash
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errChainBroken) {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,177 @@
+package auditlog_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/audit"
+	"merchshop/internal/entity"
+	"merchshop/internal/event"
+	"merchshop/internal/usecase/auditlog"
+)
+
+// mockRepo держит цепочку в памяти и считает хеши так же, как репозиторий. queued очередь
+// записей, сохраненных вместе с операциями
+type mockRepo struct {
+	entries []entity.AuditEntry
+	queued  []entity.AuditEntry
+}
+
+func (m *mockRepo) Append(ctx context.Context, e entity.AuditEntry) (*entity.AuditEntry, error) {
+	if len(m.entries) > 0 {
+		e.PrevHash = m.entries[len(m.entries)-1].Hash
+	}
+
+	e.ID = len(m.entries) + 1
+	e.CreatedAt = time.Date(2026, 10, 19, 12, 0, e.ID, 0, time.UTC)
+
+	hash, err := audit.Hash(e.PrevHash, e)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Hash = hash
+	m.entries = append(m.entries, e)
+
+	return &e, nil
+}
+
+func (m *mockRepo) Flush(ctx context.Context, limit int) (int, error) {
+	flushed := 0
+
+	for len(m.queued) > 0 && flushed < limit {
+		if _, err := m.Append(ctx, m.queued[0]); err != nil {
+			return flushed, err
+		}
+
+		m.queued = m.queued[1:]
+		flushed++
+	}
+
+	return flushed, nil
+}
+
+func (m *mockRepo) List(ctx context.Context, f entity.AuditFilter, before, limit int) ([]entity.AuditEntry, error) {
+	return m.entries, nil
+}
+
+func (m *mockRepo) Walk(ctx context.Context, f entity.AuditFilter, fn func(entity.AuditEntry) error) error {
+	for _, e := range m.entries {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestRecord_TakesRequestFromContext(t *testing.T) {
+	repo := &mockRepo{}
+	useCase := auditlog.NewUseCase(repo)
+
+	ctx := audit.WithActor(audit.WithRequest(context.Background(), audit.Request{ID: "req-1", IP: "10.0.0.1"}), 3)
+	useCase.Record(ctx, entity.AuditEntry{Action: audit.ActionLogin, TargetType: audit.TargetUser, TargetID: "3"})
+
+	require.Len(t, repo.entries, 1)
+	e := repo.entries[0]
+	assert.Equal(t, 3, e.ActorID)
+	assert.Equal(t, audit.ActionLogin, e.Action)
+	assert.Equal(t, "req-1", e.RequestID)
+	assert.Equal(t, "10.0.0.1", e.IP)
+}
+
+// перевод уже лежит в очереди, событие только переносит его в цепочку
+func TestPublish_FlushesQueuedTransfer(t *testing.T) {
+	repo := &mockRepo{queued: []entity.AuditEntry{audit.Transfer(1, 5, 10)}}
+	useCase := auditlog.NewUseCase(repo)
+
+	useCase.Publish(context.Background(), event.Event{
+		Type:   event.CoinReceived,
+		UserID: 5,
+		Data:   event.CoinTransfer{FromUser: "alice", ToUser: "bob", Amount: 10, Memo: "за помощь"},
+	})
+
+	require.Len(t, repo.entries, 1)
+	assert.Empty(t, repo.queued)
+	assert.Equal(t, audit.ActionTransfer, repo.entries[0].Action)
+	assert.Equal(t, "5", repo.entries[0].TargetID)
+	assert.JSONEq(t, `{"senderId":1,"receiverId":5,"amount":10}`, string(repo.entries[0].After))
+}
+
+// имя при регистрации в журнал не попадает
+func TestPublish_RegistrationWithoutUsername(t *testing.T) {
+	repo := &mockRepo{}
+	useCase := auditlog.NewUseCase(repo)
+
+	useCase.Publish(context.Background(), event.Event{
+		Type:   event.UserRegistered,
+		UserID: 7,
+		Data:   event.Registration{Username: "alice"},
+	})
+
+	require.Len(t, repo.entries, 1)
+	assert.Equal(t, 7, repo.entries[0].ActorID)
+	assert.Equal(t, "7", repo.entries[0].TargetID)
+	assert.Empty(t, repo.entries[0].After)
+}
+
+// события без отношения к аудиту не записываются
+func TestPublish_IgnoresOtherEvents(t *testing.T) {
+	repo := &mockRepo{}
+	useCase := auditlog.NewUseCase(repo)
+
+	useCase.Publish(context.Background(), event.Event{Type: event.CoinReaction, UserID: 5})
+	assert.Empty(t, repo.entries)
+}
+
+func TestVerify(t *testing.T) {
+	repo := &mockRepo{}
+	useCase := auditlog.NewUseCase(repo)
+
+	for i := 1; i <= 3; i++ {
+		useCase.Record(context.Background(), entity.AuditEntry{
+			ActorID: i,
+			Action:  audit.ActionLogin,
+			After:   json.RawMessage(`{"attempt": 1}`),
+		})
+	}
+
+	result, err := useCase.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Entries)
+	assert.Zero(t, result.BrokenID)
+	assert.Equal(t, repo.entries[2].Hash, result.Head)
+
+	// правка записи в обход журнала видна по ее хешу
+	repo.entries[1].ActorID = 9
+
+	result, err = useCase.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Entries)
+	assert.Equal(t, 2, result.BrokenID)
+
+	// удаление записи видно по следующей за ней
+	repo.entries = append(repo.entries[:1], repo.entries[2:]...)
+
+	result, err = useCase.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.BrokenID)
+}
+
+// ключи JSONB переставляются базой, хеш от этого не меняется
+func TestVerify_KeyOrder(t *testing.T) {
+	repo := &mockRepo{}
+	useCase := auditlog.NewUseCase(repo)
+
+	useCase.Record(context.Background(), entity.AuditEntry{Action: audit.ActionLogin, After: json.RawMessage(`{"b":1,"a":2}`)})
+	repo.entries[0].After = json.RawMessage(`{"a": 2, "b": 1}`)
+
+	result, err := useCase.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, result.BrokenID)
+}
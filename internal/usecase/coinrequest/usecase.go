@@ -0,0 +1,234 @@
+package coinrequest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/event"
+	"merchshop/internal/repository/coinrequest"
+	"merchshop/internal/repository/user"
+	"merchshop/internal/usecase/notification"
+	"merchshop/internal/usecase/policy"
+	"merchshop/internal/usecase/transaction"
+)
+
+const (
+	defaultTTL        = 72 * time.Hour
+	defaultMaxPending = 3
+)
+
+// ErrTooManyPending слишком много открытых запросов к одному плательщику
+var ErrTooManyPending = coinrequest.ErrTooManyPending
+
+type UseCase interface {
+	// Create просит пользователя payer перевести amount монет
+	Create(ctx context.Context, requesterID int, payer string, amount int, memo string) (*entities.CoinRequest, error)
+	List(ctx context.Context, userID int) ([]entities.CoinRequest, error)
+	Approve(ctx context.Context, payerID, id int) (*entities.CoinRequest, error)
+	Decline(ctx context.Context, payerID, id int) (*entities.CoinRequest, error)
+	// ExpirePending закрывает просроченные запросы и возвращает их количество
+	ExpirePending(ctx context.Context) (int, error)
+}
+
+type useCase struct {
+	requestRepo coinrequest.Repository
+	userRepo    user.Repository
+	policies    policy.Checker
+	events      event.Publisher
+	notifier    notification.Notifier
+	ttl         time.Duration
+	maxPending  int
+	now         func() time.Time
+}
+
+func NewUseCase(
+	requestRepo coinrequest.Repository,
+	userRepo user.Repository,
+	policies policy.Checker,
+	events event.Publisher,
+	notifier notification.Notifier,
+	ttl time.Duration,
+	maxPending int,
+) UseCase {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+
+	if maxPending <= 0 {
+		maxPending = defaultMaxPending
+	}
+
+	return &useCase{
+		requestRepo: requestRepo,
+		userRepo:    userRepo,
+		policies:    policies,
+		events:      events,
+		notifier:    notifier,
+		ttl:         ttl,
+		maxPending:  maxPending,
+		now:         time.Now,
+	}
+}
+
+func (u *useCase) Create(ctx context.Context, requesterID int, payer string, amount int, memo string) (*entities.CoinRequest, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("invalid amount: %d", amount)
+	}
+
+	memo, err := transaction.SanitizeMemo(memo)
+	if err != nil {
+		return nil, err
+	}
+
+	payerUser, err := u.userRepo.GetByUsername(ctx, payer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payer %q: %w", payer, err)
+	}
+
+	if payerUser.ID == requesterID {
+		return nil, fmt.Errorf("requester and payer are the same user: %d", requesterID)
+	}
+
+	requester, err := u.userRepo.GetByID(ctx, requesterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get requester %d: %w", requesterID, err)
+	}
+
+	// Замороженные и уволенные не могут ни просить монеты, ни получать запросы
+	for _, usr := range []*entities.User{requester, payerUser} {
+		if err := user.CheckActive(usr); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := u.requestRepo.Create(ctx, requesterID, payerUser.ID, amount, memo, u.now().Add(u.ttl), u.maxPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create coin request: %w", err)
+	}
+
+	u.publish(ctx, event.RequestCreated, c.PayerID, c)
+
+	return c, nil
+}
+
+func (u *useCase) List(ctx context.Context, userID int) ([]entities.CoinRequest, error) {
+	requests, err := u.requestRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coin requests: %w", err)
+	}
+
+	// Фоновая очистка могла еще не дойти до просроченных запросов
+	now := u.now()
+	for i := range requests {
+		if requests[i].Status == entities.RequestPending && !requests[i].ExpiresAt.After(now) {
+			requests[i].Status = entities.RequestExpired
+		}
+	}
+
+	return requests, nil
+}
+
+func (u *useCase) Approve(ctx context.Context, payerID, id int) (*entities.CoinRequest, error) {
+	pending, err := u.getPending(ctx, payerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range []int{pending.PayerID, pending.RequesterID} {
+		usr, err := u.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user %d: %w", userID, err)
+		}
+
+		if err := user.CheckActive(usr); err != nil {
+			return nil, err
+		}
+	}
+
+	guard, err := u.policies.Guard(ctx, policy.Operation{Kind: policy.KindTransfer, UserID: payerID, Amounts: []int{pending.Amount}})
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := u.requestRepo.Approve(ctx, id, payerID, guard)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve coin request %d: %w", id, err)
+	}
+
+	data := event.CoinTransfer{FromUser: c.PayerName, ToUser: c.RequesterName, Amount: c.Amount, Memo: c.Memo}
+	u.events.Publish(ctx, event.Event{Type: event.CoinSent, UserID: c.PayerID, Data: data})
+	u.events.Publish(ctx, event.Event{Type: event.CoinReceived, UserID: c.RequesterID, Data: data})
+	notification.Deliver(ctx, u.notifier, transaction.CoinReceived(c.PayerID, c.RequesterID, data))
+	u.publish(ctx, event.RequestResolved, c.RequesterID, c)
+
+	return c, nil
+}
+
+func (u *useCase) Decline(ctx context.Context, payerID, id int) (*entities.CoinRequest, error) {
+	if _, err := u.getPending(ctx, payerID, id); err != nil {
+		return nil, err
+	}
+
+	c, err := u.requestRepo.Decline(ctx, id, payerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decline coin request %d: %w", id, err)
+	}
+
+	u.publish(ctx, event.RequestResolved, c.RequesterID, c)
+
+	return c, nil
+}
+
+func (u *useCase) ExpirePending(ctx context.Context) (int, error) {
+	expired, err := u.requestRepo.ExpirePending(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire coin requests: %w", err)
+	}
+
+	for i := range expired {
+		u.publish(ctx, event.RequestResolved, expired[i].RequesterID, &expired[i])
+	}
+
+	return len(expired), nil
+}
+
+// getPending возвращает запрос, адресованный payerID и ожидающий ответа.
+// Запросы к другим пользователям неотличимы от несуществующих
+func (u *useCase) getPending(ctx context.Context, payerID, id int) (*entities.CoinRequest, error) {
+	c, err := u.requestRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coin request %d: %w", id, err)
+	}
+
+	if c.PayerID != payerID {
+		return nil, fmt.Errorf("failed to get coin request %d: %w", id, sql.ErrNoRows)
+	}
+
+	if c.Status == entities.RequestPending && !c.ExpiresAt.After(u.now()) {
+		c.Status = entities.RequestExpired
+	}
+
+	if c.Status != entities.RequestPending {
+		return nil, fmt.Errorf("coin request %d is %s", id, c.Status)
+	}
+
+	return c, nil
+}
+
+func (u *useCase) publish(ctx context.Context, t event.Type, userID int, c *entities.CoinRequest) {
+	u.events.Publish(ctx, event.Event{
+		Type:   t,
+		UserID: userID,
+		Data: event.CoinRequest{
+			RequestID: c.ID,
+			FromUser:  c.RequesterName,
+			ToUser:    c.PayerName,
+			Amount:    c.Amount,
+			Memo:      c.Memo,
+			Status:    c.Status,
+		},
+	})
+}
@@ -0,0 +1,281 @@
+package coinrequest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/event"
+	policyRepo "merchshop/internal/repository/policy"
+	"merchshop/internal/repository/user"
+	"merchshop/internal/usecase/coinrequest"
+	"merchshop/internal/usecase/policy"
+)
+
+type mockRepos struct {
+	CreateFunc        func(ctx context.Context, requesterID, payerID, amount int, memo string, expiresAt time.Time, maxPending int) (*entity.CoinRequest, error)
+	GetByIDFunc       func(ctx context.Context, id int) (*entity.CoinRequest, error)
+	ListByUserFunc    func(ctx context.Context, userID int) ([]entity.CoinRequest, error)
+	ApproveFunc       func(ctx context.Context, id, payerID int) (*entity.CoinRequest, error)
+	DeclineFunc       func(ctx context.Context, id, payerID int) (*entity.CoinRequest, error)
+	ExpirePendingFunc func(ctx context.Context) ([]entity.CoinRequest, error)
+}
+
+func (m *mockRepos) Create(ctx context.Context, requesterID, payerID, amount int, memo string, expiresAt time.Time, maxPending int) (*entity.CoinRequest, error) {
+	return m.CreateFunc(ctx, requesterID, payerID, amount, memo, expiresAt, maxPending)
+}
+
+func (m *mockRepos) GetByID(ctx context.Context, id int) (*entity.CoinRequest, error) {
+	return m.GetByIDFunc(ctx, id)
+}
+
+func (m *mockRepos) ListByUser(ctx context.Context, userID int) ([]entity.CoinRequest, error) {
+	return m.ListByUserFunc(ctx, userID)
+}
+
+func (m *mockRepos) Approve(ctx context.Context, id, payerID int, guard *policyRepo.Guard) (*entity.CoinRequest, error) {
+	return m.ApproveFunc(ctx, id, payerID)
+}
+
+func (m *mockRepos) Decline(ctx context.Context, id, payerID int) (*entity.CoinRequest, error) {
+	return m.DeclineFunc(ctx, id, payerID)
+}
+
+func (m *mockRepos) ExpirePending(ctx context.Context) ([]entity.CoinRequest, error) {
+	return m.ExpirePendingFunc(ctx)
+}
+
+type mockUserRepo struct {
+	GetByUsernameFunc func(ctx context.Context, username string) (*entity.User, error)
+	status            string
+}
+
+func (m *mockUserRepo) CreateUser(ctx context.Context, username string, password string) (*entity.User, error) {
+	return nil, nil
+}
+
+func (m *mockUserRepo) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	status := m.status
+	if status == "" {
+		status = entity.UserActive
+	}
+
+	return &entity.User{ID: id, Status: status}, nil
+}
+
+func (m *mockUserRepo) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	return m.GetByUsernameFunc(ctx, username)
+}
+
+type recordingPublisher struct {
+	events []event.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, e event.Event) {
+	p.events = append(p.events, e)
+}
+
+type recordingNotifier struct {
+	notifications []entity.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+type mockPolicy struct {
+	CheckFunc func(ctx context.Context, op policy.Operation) error
+}
+
+func (m *mockPolicy) Guard(ctx context.Context, op policy.Operation) (*policyRepo.Guard, error) {
+	if m.CheckFunc == nil {
+		return nil, nil
+	}
+
+	return nil, m.CheckFunc(ctx, op)
+}
+
+func TestCreate_NotifiesPayer(t *testing.T) {
+	users := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return &entity.User{ID: 2, Username: username}, nil
+		},
+	}
+
+	var expiresAt time.Time
+	repo := &mockRepos{
+		CreateFunc: func(ctx context.Context, requesterID, payerID, amount int, memo string, exp time.Time, maxPending int) (*entity.CoinRequest, error) {
+			expiresAt = exp
+			return &entity.CoinRequest{
+				ID: 5, RequesterID: requesterID, PayerID: payerID, RequesterName: "alice", PayerName: "bob",
+				Amount: amount, Memo: memo, Status: entity.RequestPending, ExpiresAt: exp,
+			}, nil
+		},
+	}
+
+	pub := &recordingPublisher{}
+	uc := coinrequest.NewUseCase(repo, users, &mockPolicy{}, pub, &recordingNotifier{}, time.Hour, 0)
+	c, err := uc.Create(context.Background(), 1, "bob", 30, "за пиццу")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, c.ID)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+	assert.Len(t, pub.events, 1)
+	assert.Equal(t, event.RequestCreated, pub.events[0].Type)
+	assert.Equal(t, 2, pub.events[0].UserID)
+}
+
+func TestCreate_SelfRequest(t *testing.T) {
+	users := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return &entity.User{ID: 1, Username: username}, nil
+		},
+	}
+
+	uc := coinrequest.NewUseCase(&mockRepos{}, users, &mockPolicy{}, event.NewBus(), &recordingNotifier{}, 0, 0)
+	_, err := uc.Create(context.Background(), 1, "alice", 30, "")
+
+	assert.Error(t, err)
+}
+
+// замороженный пользователь не может просить монеты
+func TestCreate_FrozenRequester(t *testing.T) {
+	users := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return &entity.User{ID: 2, Username: username, Status: entity.UserActive}, nil
+		},
+		status: entity.UserFrozen,
+	}
+
+	uc := coinrequest.NewUseCase(&mockRepos{}, users, &mockPolicy{}, event.NewBus(), &recordingNotifier{}, 0, 0)
+	_, err := uc.Create(context.Background(), 1, "bob", 30, "")
+
+	assert.ErrorIs(t, err, user.ErrAccountFrozen)
+}
+
+// лимит открытых запросов передается в репозиторий, его ошибка возвращается как есть
+func TestCreate_TooManyPending(t *testing.T) {
+	users := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return &entity.User{ID: 2, Username: username}, nil
+		},
+	}
+
+	var limit int
+	repo := &mockRepos{
+		CreateFunc: func(ctx context.Context, requesterID, payerID, amount int, memo string, exp time.Time, maxPending int) (*entity.CoinRequest, error) {
+			limit = maxPending
+			return nil, coinrequest.ErrTooManyPending
+		},
+	}
+
+	uc := coinrequest.NewUseCase(repo, users, &mockPolicy{}, event.NewBus(), &recordingNotifier{}, 0, 2)
+	_, err := uc.Create(context.Background(), 1, "bob", 30, "")
+
+	assert.ErrorIs(t, err, coinrequest.ErrTooManyPending)
+	assert.Equal(t, 2, limit)
+}
+
+func TestApprove_PublishesTransfer(t *testing.T) {
+	pending := &entity.CoinRequest{
+		ID: 5, RequesterID: 1, PayerID: 2, RequesterName: "alice", PayerName: "bob", Amount: 30,
+		Status: entity.RequestPending, ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	repo := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.CoinRequest, error) {
+			return pending, nil
+		},
+		ApproveFunc: func(ctx context.Context, id, payerID int) (*entity.CoinRequest, error) {
+			approved := *pending
+			approved.Status = entity.RequestApproved
+			return &approved, nil
+		},
+	}
+
+	pub := &recordingPublisher{}
+	notifier := &recordingNotifier{}
+	uc := coinrequest.NewUseCase(repo, &mockUserRepo{}, &mockPolicy{}, pub, notifier, 0, 0)
+	c, err := uc.Approve(context.Background(), 2, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entity.RequestApproved, c.Status)
+	assert.Len(t, pub.events, 3)
+	assert.Equal(t, event.CoinSent, pub.events[0].Type)
+	assert.Equal(t, 2, pub.events[0].UserID)
+	assert.Equal(t, event.CoinReceived, pub.events[1].Type)
+	assert.Equal(t, 1, pub.events[1].UserID)
+	assert.Equal(t, event.RequestResolved, pub.events[2].Type)
+
+	if assert.Len(t, notifier.notifications, 1) {
+		assert.Equal(t, entity.NotifyCoinReceived, notifier.notifications[0].Kind)
+		assert.Equal(t, 1, notifier.notifications[0].UserID)
+	}
+}
+
+func TestApprove_Expired(t *testing.T) {
+	repo := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.CoinRequest, error) {
+			return &entity.CoinRequest{
+				ID: 5, RequesterID: 1, PayerID: 2, Status: entity.RequestPending, ExpiresAt: time.Now().Add(-time.Minute),
+			}, nil
+		},
+	}
+
+	uc := coinrequest.NewUseCase(repo, &mockUserRepo{}, &mockPolicy{}, event.NewBus(), &recordingNotifier{}, 0, 0)
+	_, err := uc.Approve(context.Background(), 2, 5)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expired")
+}
+
+func TestDecline_OnlyPayer(t *testing.T) {
+	repo := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.CoinRequest, error) {
+			return &entity.CoinRequest{
+				ID: 5, RequesterID: 1, PayerID: 2, Status: entity.RequestPending, ExpiresAt: time.Now().Add(time.Hour),
+			}, nil
+		},
+	}
+
+	uc := coinrequest.NewUseCase(repo, &mockUserRepo{}, &mockPolicy{}, event.NewBus(), &recordingNotifier{}, 0, 0)
+	_, err := uc.Decline(context.Background(), 1, 5)
+
+	assert.Error(t, err)
+}
+
+func TestList_MarksExpired(t *testing.T) {
+	repo := &mockRepos{
+		ListByUserFunc: func(ctx context.Context, userID int) ([]entity.CoinRequest, error) {
+			return []entity.CoinRequest{
+				{ID: 1, Status: entity.RequestPending, ExpiresAt: time.Now().Add(-time.Minute)},
+				{ID: 2, Status: entity.RequestPending, ExpiresAt: time.Now().Add(time.Hour)},
+			}, nil
+		},
+	}
+
+	uc := coinrequest.NewUseCase(repo, &mockUserRepo{}, &mockPolicy{}, event.NewBus(), &recordingNotifier{}, 0, 0)
+	requests, err := uc.List(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entity.RequestExpired, requests[0].Status)
+	assert.Equal(t, entity.RequestPending, requests[1].Status)
+}
+
+func TestExpirePending_RepoError(t *testing.T) {
+	repo := &mockRepos{
+		ExpirePendingFunc: func(ctx context.Context) ([]entity.CoinRequest, error) {
+			return nil, errors.New("connection reset")
+		},
+	}
+
+	uc := coinrequest.NewUseCase(repo, &mockUserRepo{}, &mockPolicy{}, event.NewBus(), &recordingNotifier{}, 0, 0)
+	_, err := uc.ExpirePending(context.Background())
+
+	assert.Error(t, err)
+}
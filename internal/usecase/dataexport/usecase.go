@@ -0,0 +1,452 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/auditlog"
+	"merchshop/internal/repository/dataexport"
+	"merchshop/internal/repository/leaderboard"
+	"merchshop/internal/repository/notification"
+	"merchshop/internal/repository/user"
+	"merchshop/internal/sheet"
+	"merchshop/internal/usecase/report"
+)
+
+const (
+	defaultLinkTTL   = 15 * time.Minute
+	defaultRetention = 24 * time.Hour
+	defaultLease     = 10 * time.Minute
+	defaultBatchSize = 5
+
+	notificationsPage = 500
+)
+
+var (
+	// ErrInvalidLink подпись ссылки на скачивание не сходится
+	ErrInvalidLink = errors.New("invalid download link")
+	// ErrLinkExpired срок ссылки или самого архива истек
+	ErrLinkExpired = errors.New("download link has expired")
+)
+
+// Options Secret подписывает ссылки на скачивание. LinkTTL сколько действует ссылка,
+// Retention сколько хранится готовый архив. Lease сколько одна реплика держит выгрузку
+// на сборке, BatchSize сколько выгрузок собирается за один запуск
+type Options struct {
+	Secret    []byte
+	LinkTTL   time.Duration
+	Retention time.Duration
+	Lease     time.Duration
+	BatchSize int
+}
+
+type UseCase interface {
+	// Request ставит в очередь выгрузку данных userID. requestedBy сам пользователь или администратор
+	Request(ctx context.Context, userID, requestedBy int) (*entities.DataExport, error)
+	// Get возвращает выгрузку, если viewerID ее владелец или заказчик, иначе sql.ErrNoRows
+	Get(ctx context.Context, viewerID, id int) (*entities.DataExport, error)
+	// Link выпускает подписанную ссылку на скачивание готовой выгрузки
+	Link(e *entities.DataExport) (string, time.Time)
+	// Download проверяет подпись ссылки и возвращает архив
+	Download(ctx context.Context, id int, expires int64, signature string) ([]byte, error)
+	// Build пишет архив с данными пользователя в w
+	Build(ctx context.Context, userID int, w io.Writer) error
+	// Process собирает выгрузки из очереди и удаляет истекшие архивы. Возвращает число собранных
+	Process(ctx context.Context) (int, error)
+}
+
+type useCase struct {
+	exportRepo       dataexport.Repository
+	userRepo         user.Repository
+	leaderboardRepo  leaderboard.Repository
+	notificationRepo notification.Repository
+	reports          report.UseCase
+	auditRepo        auditlog.Repository
+	opts             Options
+	now              func() time.Time
+}
+
+func NewUseCase(
+	exportRepo dataexport.Repository,
+	userRepo user.Repository,
+	leaderboardRepo leaderboard.Repository,
+	notificationRepo notification.Repository,
+	reports report.UseCase,
+	auditRepo auditlog.Repository,
+	opts Options,
+) UseCase {
+	if opts.LinkTTL <= 0 {
+		opts.LinkTTL = defaultLinkTTL
+	}
+
+	if opts.Retention <= 0 {
+		opts.Retention = defaultRetention
+	}
+
+	if opts.Lease <= 0 {
+		opts.Lease = defaultLease
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+
+	return &useCase{
+		exportRepo:       exportRepo,
+		userRepo:         userRepo,
+		leaderboardRepo:  leaderboardRepo,
+		notificationRepo: notificationRepo,
+		reports:          reports,
+		auditRepo:        auditRepo,
+		opts:             opts,
+		now:              time.Now,
+	}
+}
+
+func (u *useCase) Request(ctx context.Context, userID, requestedBy int) (*entities.DataExport, error) {
+	if _, err := u.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+
+	e, err := u.exportRepo.Create(ctx, userID, requestedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request data export: %w", err)
+	}
+
+	return e, nil
+}
+
+func (u *useCase) Get(ctx context.Context, viewerID, id int) (*entities.DataExport, error) {
+	e, err := u.exportRepo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+
+	// Чужая выгрузка неотличима от несуществующей
+	if e.UserID != viewerID && e.RequestedBy != viewerID {
+		return nil, fmt.Errorf("data export %d: %w", id, sql.ErrNoRows)
+	}
+
+	return e, nil
+}
+
+func (u *useCase) Link(e *entities.DataExport) (string, time.Time) {
+	expiresAt := u.now().Add(u.opts.LinkTTL).Truncate(time.Second)
+	if e.ExpiresAt != nil && e.ExpiresAt.Before(expiresAt) {
+		expiresAt = e.ExpiresAt.Truncate(time.Second)
+	}
+
+	link := fmt.Sprintf("/api/exports/%d/download?expires=%d&signature=%s",
+		e.ID, expiresAt.Unix(), u.sign(e.ID, expiresAt.Unix()))
+
+	return link, expiresAt
+}
+
+func (u *useCase) Download(ctx context.Context, id int, expires int64, signature string) ([]byte, error) {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return nil, ErrInvalidLink
+	}
+
+	want, _ := hex.DecodeString(u.sign(id, expires))
+	if !hmac.Equal(got, want) {
+		return nil, ErrInvalidLink
+	}
+
+	if !u.now().Before(time.Unix(expires, 0)) {
+		return nil, ErrLinkExpired
+	}
+
+	archive, err := u.exportRepo.Archive(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLinkExpired
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archive: %w", err)
+	}
+
+	return archive, nil
+}
+
+// sign подпись ссылки на выгрузку id, действующей до expires
+func (u *useCase) sign(id int, expires int64) string {
+	mac := hmac.New(sha256.New, u.opts.Secret)
+	fmt.Fprintf(mac, "data-export:%d:%d", id, expires)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (u *useCase) Process(ctx context.Context) (int, error) {
+	built := 0
+
+	for built < u.opts.BatchSize {
+		e, err := u.exportRepo.Claim(ctx, u.opts.Lease)
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+
+		if err != nil {
+			return built, fmt.Errorf("failed to claim data export: %w", err)
+		}
+
+		var archive bytes.Buffer
+
+		if err := u.Build(ctx, e.UserID, &archive); err != nil {
+			log.Printf("dataexport: build export %d: %v", e.ID, err)
+
+			if err := u.exportRepo.Fail(ctx, e.ID, err.Error()); err != nil {
+				return built, fmt.Errorf("failed to mark data export failed: %w", err)
+			}
+
+			continue
+		}
+
+		if err := u.exportRepo.Complete(ctx, e.ID, archive.Bytes(), u.now().Add(u.opts.Retention)); err != nil {
+			return built, fmt.Errorf("failed to save data export: %w", err)
+		}
+
+		built++
+	}
+
+	if _, err := u.exportRepo.Expire(ctx); err != nil {
+		return built, fmt.Errorf("failed to expire data exports: %w", err)
+	}
+
+	return built, nil
+}
+
+type profile struct {
+	ID                      int                      `json:"id"`
+	Username                string                   `json:"username"`
+	Role                    string                   `json:"role"`
+	Department              string                   `json:"department"`
+	Status                  string                   `json:"status"`
+	Balance                 int                      `json:"balance"`
+	CreatedAt               time.Time                `json:"createdAt"`
+	LeaderboardOptOut       bool                     `json:"leaderboardOptOut"`
+	NotificationPreferences []notificationPreference `json:"notificationPreferences"`
+}
+
+type notificationPreference struct {
+	Kind    string `json:"kind"`
+	Enabled bool   `json:"enabled"`
+}
+
+type notificationRecord struct {
+	ID        int            `json:"id"`
+	Kind      string         `json:"kind"`
+	Message   string         `json:"message"`
+	Data      map[string]any `json:"data"`
+	ReadAt    *time.Time     `json:"readAt,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// Build собирает zip-архив: profile.json без хеша пароля, transactions.csv и purchases.csv
+// в формате админских отчетов, notifications.json и audit.json с записями журнала аудита,
+// где пользователь автор или объект действия
+func (u *useCase) Build(ctx context.Context, userID int, w io.Writer) error {
+	owner, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+
+	archive := zip.NewWriter(w)
+
+	if err := u.writeProfile(ctx, archive, owner); err != nil {
+		return err
+	}
+
+	for _, kind := range []string{report.KindTransactions, report.KindPurchases} {
+		f, err := archive.Create(kind + ".csv")
+		if err != nil {
+			return fmt.Errorf("failed to create %s.csv: %w", kind, err)
+		}
+
+		req := report.Request{
+			Kind:   kind,
+			Format: sheet.FormatCSV,
+			Filter: entities.ReportFilter{Username: owner.Username},
+		}
+
+		if err := u.reports.Export(ctx, f, req); err != nil {
+			return err
+		}
+	}
+
+	if err := u.writeNotifications(ctx, archive, owner.ID); err != nil {
+		return err
+	}
+
+	if err := u.writeAudit(ctx, archive, owner.ID); err != nil {
+		return err
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	return nil
+}
+
+func (u *useCase) writeProfile(ctx context.Context, archive *zip.Writer, owner *entities.User) error {
+	optOut, err := u.leaderboardRepo.OptedOut(ctx, owner.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get leaderboard opt-out: %w", err)
+	}
+
+	prefs, err := u.notificationRepo.Preferences(ctx, owner.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	p := profile{
+		ID:                      owner.ID,
+		Username:                owner.Username,
+		Role:                    owner.Role,
+		Department:              owner.Department,
+		Status:                  owner.Status,
+		Balance:                 owner.Balance,
+		CreatedAt:               owner.CreatedAt,
+		LeaderboardOptOut:       optOut,
+		NotificationPreferences: make([]notificationPreference, len(prefs)),
+	}
+
+	for i, pref := range prefs {
+		p.NotificationPreferences[i] = notificationPreference{Kind: pref.Kind, Enabled: pref.Enabled}
+	}
+
+	f, err := archive.Create("profile.json")
+	if err != nil {
+		return fmt.Errorf("failed to create profile.json: %w", err)
+	}
+
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(p)
+}
+
+// writeNotifications пишет уведомления постранично, не загружая их все сразу
+func (u *useCase) writeNotifications(ctx context.Context, archive *zip.Writer, userID int) error {
+	f, err := archive.Create("notifications.json")
+	if err != nil {
+		return fmt.Errorf("failed to create notifications.json: %w", err)
+	}
+
+	list := newJSONArray(f)
+	before := 0
+
+	for {
+		page, err := u.notificationRepo.List(ctx, userID, false, before, notificationsPage)
+		if err != nil {
+			return fmt.Errorf("failed to list notifications: %w", err)
+		}
+
+		for _, n := range page {
+			err := list.add(notificationRecord{
+				ID:        n.ID,
+				Kind:      n.Kind,
+				Message:   n.Message,
+				Data:      n.Data,
+				ReadAt:    n.ReadAt,
+				CreatedAt: n.CreatedAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(page) < notificationsPage {
+			break
+		}
+
+		before = page[len(page)-1].ID
+	}
+
+	return list.close()
+}
+
+type auditRecord struct {
+	ID         int             `json:"id"`
+	ActorID    int             `json:"actorId,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"targetType,omitempty"`
+	TargetID   string          `json:"targetId,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+func (u *useCase) writeAudit(ctx context.Context, archive *zip.Writer, userID int) error {
+	f, err := archive.Create("audit.json")
+	if err != nil {
+		return fmt.Errorf("failed to create audit.json: %w", err)
+	}
+
+	list := newJSONArray(f)
+
+	err = u.auditRepo.Walk(ctx, entities.AuditFilter{UserID: userID}, func(e entities.AuditEntry) error {
+		return list.add(auditRecord{
+			ID:         e.ID,
+			ActorID:    e.ActorID,
+			Action:     e.Action,
+			TargetType: e.TargetType,
+			TargetID:   e.TargetID,
+			IP:         e.IP,
+			Before:     e.Before,
+			After:      e.After,
+			CreatedAt:  e.CreatedAt,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return list.close()
+}
+
+// jsonArray пишет JSON-массив по одному элементу, не собирая его в памяти
+type jsonArray struct {
+	w         io.Writer
+	encoder   *json.Encoder
+	separator string
+}
+
+func newJSONArray(w io.Writer) *jsonArray {
+	return &jsonArray{w: w, encoder: json.NewEncoder(w), separator: "["}
+}
+
+func (a *jsonArray) add(v any) error {
+	if _, err := io.WriteString(a.w, a.separator); err != nil {
+		return err
+	}
+
+	a.separator = ","
+
+	return a.encoder.Encode(v)
+}
+
+func (a *jsonArray) close() error {
+	if a.separator == "[" {
+		_, err := io.WriteString(a.w, "[]\n")
+		return err
+	}
+
+	_, err := io.WriteString(a.w, "]\n")
+
+	return err
+}
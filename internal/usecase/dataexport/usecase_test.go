@@ -0,0 +1,271 @@
+package dataexport_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/usecase/dataexport"
+	"merchshop/internal/usecase/report"
+)
+
+type mockExportRepo struct {
+	exports map[int]*entity.DataExport
+	archive []byte
+}
+
+func (m *mockExportRepo) Create(ctx context.Context, userID, requestedBy int) (*entity.DataExport, error) {
+	e := &entity.DataExport{ID: len(m.exports) + 1, UserID: userID, RequestedBy: requestedBy, Status: entity.ExportPending}
+	m.exports[e.ID] = e
+	return e, nil
+}
+
+func (m *mockExportRepo) Get(ctx context.Context, id int) (*entity.DataExport, error) {
+	e, ok := m.exports[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func (m *mockExportRepo) Claim(ctx context.Context, lease time.Duration) (*entity.DataExport, error) {
+	for _, e := range m.exports {
+		if e.Status == entity.ExportPending {
+			return e, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockExportRepo) Complete(ctx context.Context, id int, archive []byte, expiresAt time.Time) error {
+	m.exports[id].Status = entity.ExportReady
+	m.exports[id].ExpiresAt = &expiresAt
+	m.archive = archive
+	return nil
+}
+
+func (m *mockExportRepo) Fail(ctx context.Context, id int, reason string) error {
+	m.exports[id].Status = entity.ExportFailed
+	return nil
+}
+
+func (m *mockExportRepo) Archive(ctx context.Context, id int) ([]byte, error) {
+	if m.archive == nil {
+		return nil, sql.ErrNoRows
+	}
+	return m.archive, nil
+}
+
+func (m *mockExportRepo) Expire(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+type mockUserRepo struct{}
+
+func (m *mockUserRepo) CreateUser(ctx context.Context, username string, password string) (*entity.User, error) {
+	return nil, nil
+}
+
+func (m *mockUserRepo) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	return &entity.User{ID: id, Username: "alice", Password: "secret-hash", Balance: 700, Role: entity.RoleEmployee}, nil
+}
+
+func (m *mockUserRepo) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	return nil, sql.ErrNoRows
+}
+
+type mockLeaderboardRepo struct{}
+
+func (m *mockLeaderboardRepo) Refresh(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockLeaderboardRepo) Top(ctx context.Context, board, period string, limit int) ([]entity.LeaderboardEntry, error) {
+	return nil, nil
+}
+
+func (m *mockLeaderboardRepo) OptedOut(ctx context.Context, userID int) (bool, error) {
+	return true, nil
+}
+
+func (m *mockLeaderboardRepo) SetOptOut(ctx context.Context, userID int, optOut bool) error {
+	return nil
+}
+
+type mockNotificationRepo struct {
+	notifications []entity.Notification
+}
+
+func (m *mockNotificationRepo) Create(ctx context.Context, n entity.Notification) (*entity.Notification, error) {
+	return &n, nil
+}
+
+func (m *mockNotificationRepo) List(ctx context.Context, userID int, unreadOnly bool, before, limit int) ([]entity.Notification, error) {
+	return m.notifications, nil
+}
+
+func (m *mockNotificationRepo) CountUnread(ctx context.Context, userID int) (int, error) {
+	return 0, nil
+}
+
+func (m *mockNotificationRepo) MarkRead(ctx context.Context, userID int, ids []int) (int, error) {
+	return 0, nil
+}
+
+func (m *mockNotificationRepo) Preferences(ctx context.Context, userID int) ([]entity.NotificationPreference, error) {
+	return []entity.NotificationPreference{{Kind: entity.NotifyPriceDrop, Enabled: false}}, nil
+}
+
+func (m *mockNotificationRepo) SetPreference(ctx context.Context, userID int, p entity.NotificationPreference) error {
+	return nil
+}
+
+type mockAuditRepo struct {
+	filters []entity.AuditFilter
+}
+
+func (m *mockAuditRepo) Append(ctx context.Context, e entity.AuditEntry) (*entity.AuditEntry, error) {
+	return &e, nil
+}
+
+func (m *mockAuditRepo) Flush(ctx context.Context, limit int) (int, error) {
+	return 0, nil
+}
+
+func (m *mockAuditRepo) List(ctx context.Context, f entity.AuditFilter, before, limit int) ([]entity.AuditEntry, error) {
+	return nil, nil
+}
+
+func (m *mockAuditRepo) Walk(ctx context.Context, f entity.AuditFilter, fn func(entity.AuditEntry) error) error {
+	m.filters = append(m.filters, f)
+	return fn(entity.AuditEntry{ID: 4, ActorID: f.UserID, Action: "auth.login", RequestID: "req-1"})
+}
+
+type mockReports struct {
+	requests []report.Request
+}
+
+func (m *mockReports) Export(ctx context.Context, w io.Writer, req report.Request) error {
+	m.requests = append(m.requests, req)
+	_, err := io.WriteString(w, "id\n1\n")
+	return err
+}
+
+func newUseCase(repo *mockExportRepo, reports *mockReports) dataexport.UseCase {
+	notifications := &mockNotificationRepo{notifications: []entity.Notification{
+		{ID: 3, Kind: entity.NotifyCoinReceived, Message: "Перевод от bob: 10 монет"},
+	}}
+
+	return dataexport.NewUseCase(repo, &mockUserRepo{}, &mockLeaderboardRepo{}, notifications, reports,
+		&mockAuditRepo{}, dataexport.Options{Secret: []byte("test-secret")})
+}
+
+func readArchive(t *testing.T, archive []byte) map[string]string {
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(rc)
+		require.NoError(t, err)
+
+		files[f.Name] = string(body)
+	}
+
+	return files
+}
+
+func TestProcess_BuildsArchive(t *testing.T) {
+	repo := &mockExportRepo{exports: map[int]*entity.DataExport{}}
+	reports := &mockReports{}
+	useCase := newUseCase(repo, reports)
+
+	e, err := useCase.Request(context.Background(), 1, 1)
+	require.NoError(t, err)
+
+	built, err := useCase.Process(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, built)
+	assert.Equal(t, entity.ExportReady, repo.exports[e.ID].Status)
+
+	files := readArchive(t, repo.archive)
+	assert.Len(t, files, 5)
+	assert.Contains(t, files["profile.json"], `"leaderboardOptOut": true`)
+	assert.NotContains(t, files["profile.json"], "secret-hash")
+	assert.Equal(t, "id\n1\n", files["transactions.csv"])
+
+	var notifications []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(files["notifications.json"]), &notifications))
+	assert.Len(t, notifications, 1)
+
+	var entries []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(files["audit.json"]), &entries))
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "auth.login", entries[0]["action"])
+		assert.NotContains(t, entries[0], "requestId")
+	}
+
+	require.Len(t, reports.requests, 2)
+	assert.Equal(t, "alice", reports.requests[0].Filter.Username)
+	assert.Equal(t, report.KindPurchases, reports.requests[1].Kind)
+}
+
+func TestGet_OtherUser(t *testing.T) {
+	repo := &mockExportRepo{exports: map[int]*entity.DataExport{}}
+	useCase := newUseCase(repo, &mockReports{})
+
+	e, err := useCase.Request(context.Background(), 1, 9)
+	require.NoError(t, err)
+
+	_, err = useCase.Get(context.Background(), 9, e.ID)
+	assert.NoError(t, err)
+
+	_, err = useCase.Get(context.Background(), 2, e.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestDownload_SignedLink(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	repo := &mockExportRepo{
+		exports: map[int]*entity.DataExport{7: {ID: 7, UserID: 1, Status: entity.ExportReady, ExpiresAt: &expiresAt}},
+		archive: []byte("zip"),
+	}
+	useCase := newUseCase(repo, &mockReports{})
+
+	link, linkExpiresAt := useCase.Link(repo.exports[7])
+	assert.True(t, strings.HasPrefix(link, "/api/exports/7/download?"))
+	assert.True(t, linkExpiresAt.Before(expiresAt))
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+
+	expires, err := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	require.NoError(t, err)
+
+	archive, err := useCase.Download(context.Background(), 7, expires, u.Query().Get("signature"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("zip"), archive)
+
+	// подпись не переносится на другую выгрузку и другой срок
+	_, err = useCase.Download(context.Background(), 8, expires, u.Query().Get("signature"))
+	assert.ErrorIs(t, err, dataexport.ErrInvalidLink)
+
+	_, err = useCase.Download(context.Background(), 7, expires+3600, u.Query().Get("signature"))
+	assert.ErrorIs(t, err, dataexport.ErrInvalidLink)
+}
@@ -0,0 +1,193 @@
+package escrow
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/event"
+	"merchshop/internal/repository/escrow"
+	"merchshop/internal/repository/user"
+	"merchshop/internal/usecase/notification"
+	"merchshop/internal/usecase/policy"
+	"merchshop/internal/usecase/transaction"
+)
+
+const (
+	defaultTTL       = 30 * 24 * time.Hour
+	defaultBatchSize = 100
+)
+
+type UseCase interface {
+	// Publish забирает удерживаемые переводы, когда пользователь регистрируется
+	event.Publisher
+
+	// Send удерживает монеты для пользователя, который еще не входил в магазин
+	Send(ctx context.Context, senderID int, username string, amount int, memo string) (*entities.Escrow, error)
+	List(ctx context.Context, senderID int) ([]entities.Escrow, error)
+	Claim(ctx context.Context, userID int, username string) (int, error)
+	// Sweep зачисляет переводы, пропущенные при регистрации, и возвращает просроченные отправителям
+	Sweep(ctx context.Context) (claimed, refunded int, err error)
+}
+
+type useCase struct {
+	escrowRepo escrow.Repository
+	userRepo   user.Repository
+	policies   policy.Checker
+	events     event.Publisher
+	notifier   notification.Notifier
+	ttl        time.Duration
+	now        func() time.Time
+}
+
+func NewUseCase(
+	escrowRepo escrow.Repository,
+	userRepo user.Repository,
+	policies policy.Checker,
+	events event.Publisher,
+	notifier notification.Notifier,
+	ttl time.Duration,
+) UseCase {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+
+	return &useCase{
+		escrowRepo: escrowRepo,
+		userRepo:   userRepo,
+		policies:   policies,
+		events:     events,
+		notifier:   notifier,
+		ttl:        ttl,
+		now:        time.Now,
+	}
+}
+
+func (u *useCase) Send(ctx context.Context, senderID int, username string, amount int, memo string) (*entities.Escrow, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, fmt.Errorf("empty username")
+	}
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("invalid amount: %d", amount)
+	}
+
+	memo, err := transaction.SanitizeMemo(memo)
+	if err != nil {
+		return nil, err
+	}
+
+	sender, err := u.userRepo.GetByID(ctx, senderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sender %d: %w", senderID, err)
+	}
+
+	if err := user.CheckActive(sender); err != nil {
+		return nil, err
+	}
+
+	_, err = u.userRepo.GetByUsername(ctx, username)
+	if err == nil {
+		return nil, fmt.Errorf("user %s is already registered, send coins directly", username)
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+
+	guard, err := u.policies.Guard(ctx, policy.Operation{Kind: policy.KindTransfer, UserID: senderID, Amounts: []int{amount}})
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := u.escrowRepo.Create(ctx, senderID, username, amount, memo, u.now().Add(u.ttl), guard)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create escrow transfer: %w", err)
+	}
+
+	return e, nil
+}
+
+func (u *useCase) List(ctx context.Context, senderID int) ([]entities.Escrow, error) {
+	escrows, err := u.escrowRepo.ListBySender(ctx, senderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list escrow transfers: %w", err)
+	}
+
+	return escrows, nil
+}
+
+func (u *useCase) Claim(ctx context.Context, userID int, username string) (int, error) {
+	claimed, err := u.escrowRepo.Claim(ctx, userID, username)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim escrow transfers for %s: %w", username, err)
+	}
+
+	for _, e := range claimed {
+		u.publish(ctx, event.EscrowClaimed, e)
+		data := event.CoinTransfer{FromUser: e.SenderName, ToUser: username, Amount: e.Amount, Memo: e.Memo}
+		u.events.Publish(ctx, event.Event{Type: event.CoinReceived, UserID: userID, Data: data})
+		notification.Deliver(ctx, u.notifier, transaction.CoinReceived(e.SenderID, userID, data))
+	}
+
+	return len(claimed), nil
+}
+
+// Publish ошибки только логирует: регистрация уже прошла, а пропущенные переводы заберет Sweep
+func (u *useCase) Publish(ctx context.Context, e event.Event) {
+	if e.Type != event.UserRegistered {
+		return
+	}
+
+	registration, ok := e.Data.(event.Registration)
+	if !ok {
+		return
+	}
+
+	if _, err := u.Claim(ctx, e.UserID, registration.Username); err != nil {
+		log.Printf("escrow: %v", err)
+	}
+}
+
+func (u *useCase) Sweep(ctx context.Context) (int, int, error) {
+	users, err := u.escrowRepo.ListClaimable(ctx, defaultBatchSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list claimable escrow transfers: %w", err)
+	}
+
+	claimed := 0
+
+	for _, usr := range users {
+		n, err := u.Claim(ctx, usr.ID, usr.Username)
+		if err != nil {
+			return claimed, 0, err
+		}
+
+		claimed += n
+	}
+
+	refunded, err := u.escrowRepo.RefundExpired(ctx, defaultBatchSize)
+	if err != nil {
+		return claimed, 0, fmt.Errorf("failed to refund expired escrow transfers: %w", err)
+	}
+
+	for _, e := range refunded {
+		u.publish(ctx, event.EscrowRefunded, e)
+	}
+
+	return claimed, len(refunded), nil
+}
+
+func (u *useCase) publish(ctx context.Context, t event.Type, e entities.Escrow) {
+	u.events.Publish(ctx, event.Event{
+		Type:   t,
+		UserID: e.SenderID,
+		Data:   event.Escrow{EscrowID: e.ID, ToUser: e.Username, Amount: e.Amount, Status: e.Status},
+	})
+}
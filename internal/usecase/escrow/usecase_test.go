@@ -0,0 +1,178 @@
+package escrow_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/event"
+	policyRepo "merchshop/internal/repository/policy"
+	"merchshop/internal/usecase/escrow"
+	"merchshop/internal/usecase/policy"
+)
+
+type mockRepos struct {
+	CreateFunc        func(ctx context.Context, senderID int, username string, amount int, memo string, expiresAt time.Time) (*entity.Escrow, error)
+	ClaimFunc         func(ctx context.Context, receiverID int, username string) ([]entity.Escrow, error)
+	ListClaimableFunc func(ctx context.Context, limit int) ([]entity.User, error)
+	RefundExpiredFunc func(ctx context.Context, limit int) ([]entity.Escrow, error)
+}
+
+func (m *mockRepos) Create(ctx context.Context, senderID int, username string, amount int, memo string, expiresAt time.Time, guard *policyRepo.Guard) (*entity.Escrow, error) {
+	return m.CreateFunc(ctx, senderID, username, amount, memo, expiresAt)
+}
+
+func (m *mockRepos) ListBySender(ctx context.Context, senderID int) ([]entity.Escrow, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) Claim(ctx context.Context, receiverID int, username string) ([]entity.Escrow, error) {
+	return m.ClaimFunc(ctx, receiverID, username)
+}
+
+func (m *mockRepos) ListClaimable(ctx context.Context, limit int) ([]entity.User, error) {
+	return m.ListClaimableFunc(ctx, limit)
+}
+
+func (m *mockRepos) RefundExpired(ctx context.Context, limit int) ([]entity.Escrow, error) {
+	return m.RefundExpiredFunc(ctx, limit)
+}
+
+type mockUserRepo struct {
+	GetByUsernameFunc func(ctx context.Context, username string) (*entity.User, error)
+}
+
+func (m *mockUserRepo) CreateUser(ctx context.Context, username string, password string) (*entity.User, error) {
+	return nil, nil
+}
+
+func (m *mockUserRepo) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	return &entity.User{ID: id, Status: entity.UserActive}, nil
+}
+
+func (m *mockUserRepo) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	return m.GetByUsernameFunc(ctx, username)
+}
+
+type recordingPublisher struct {
+	events []event.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, e event.Event) {
+	p.events = append(p.events, e)
+}
+
+type recordingNotifier struct {
+	notifications []entity.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+type mockPolicy struct {
+	CheckFunc func(ctx context.Context, op policy.Operation) error
+}
+
+func (m *mockPolicy) Guard(ctx context.Context, op policy.Operation) (*policyRepo.Guard, error) {
+	if m.CheckFunc == nil {
+		return nil, nil
+	}
+
+	return nil, m.CheckFunc(ctx, op)
+}
+
+func TestSend_HoldsForUnknownUser(t *testing.T) {
+	users := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return nil, sql.ErrNoRows
+		},
+	}
+
+	var expiresAt time.Time
+	repo := &mockRepos{
+		CreateFunc: func(ctx context.Context, senderID int, username string, amount int, memo string, exp time.Time) (*entity.Escrow, error) {
+			expiresAt = exp
+			return &entity.Escrow{ID: 3, SenderID: senderID, Username: username, Amount: amount, Status: entity.EscrowHeld}, nil
+		},
+	}
+
+	uc := escrow.NewUseCase(repo, users, &mockPolicy{}, event.NewBus(), &recordingNotifier{}, time.Hour)
+	e, err := uc.Send(context.Background(), 1, "newbie", 50, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, entity.EscrowHeld, e.Status)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+}
+
+func TestSend_RegisteredUser(t *testing.T) {
+	users := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return &entity.User{ID: 2, Username: username}, nil
+		},
+	}
+
+	uc := escrow.NewUseCase(&mockRepos{}, users, &mockPolicy{}, event.NewBus(), &recordingNotifier{}, 0)
+	_, err := uc.Send(context.Background(), 1, "bob", 50, "")
+
+	assert.Error(t, err)
+}
+
+func TestPublish_ClaimsOnRegistration(t *testing.T) {
+	var claimedFor string
+	repo := &mockRepos{
+		ClaimFunc: func(ctx context.Context, receiverID int, username string) ([]entity.Escrow, error) {
+			claimedFor = username
+			return []entity.Escrow{
+				{ID: 3, SenderID: 1, SenderName: "alice", Username: username, ReceiverID: receiverID, Amount: 50, Status: entity.EscrowClaimed},
+			}, nil
+		},
+	}
+
+	pub := &recordingPublisher{}
+	notifier := &recordingNotifier{}
+	uc := escrow.NewUseCase(repo, &mockUserRepo{}, &mockPolicy{}, pub, notifier, 0)
+	uc.Publish(context.Background(), event.Event{
+		Type:   event.UserRegistered,
+		UserID: 7,
+		Data:   event.Registration{Username: "newbie"},
+	})
+
+	assert.Equal(t, "newbie", claimedFor)
+	assert.Len(t, pub.events, 2)
+	assert.Equal(t, event.EscrowClaimed, pub.events[0].Type)
+	assert.Equal(t, 1, pub.events[0].UserID)
+	assert.Equal(t, event.CoinReceived, pub.events[1].Type)
+	assert.Equal(t, 7, pub.events[1].UserID)
+
+	if assert.Len(t, notifier.notifications, 1) {
+		assert.Equal(t, entity.NotifyCoinReceived, notifier.notifications[0].Kind)
+		assert.Equal(t, 7, notifier.notifications[0].UserID)
+	}
+}
+
+func TestSweep_RefundsExpired(t *testing.T) {
+	repo := &mockRepos{
+		ListClaimableFunc: func(ctx context.Context, limit int) ([]entity.User, error) {
+			return nil, nil
+		},
+		RefundExpiredFunc: func(ctx context.Context, limit int) ([]entity.Escrow, error) {
+			return []entity.Escrow{{ID: 3, SenderID: 1, Username: "ghost", Amount: 50, Status: entity.EscrowRefunded}}, nil
+		},
+	}
+
+	pub := &recordingPublisher{}
+	uc := escrow.NewUseCase(repo, &mockUserRepo{}, &mockPolicy{}, pub, &recordingNotifier{}, 0)
+	claimed, refunded, err := uc.Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, claimed)
+	assert.Equal(t, 1, refunded)
+	assert.Len(t, pub.events, 1)
+	assert.Equal(t, event.EscrowRefunded, pub.events[0].Type)
+}
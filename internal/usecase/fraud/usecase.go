@@ -0,0 +1,181 @@
+package fraud
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/fraud"
+)
+
+const (
+	defaultWindow        = 24 * time.Hour
+	defaultBurstWindow   = 10 * time.Minute
+	defaultBurstOps      = 10
+	defaultFunnelSenders = 10
+	defaultListLimit     = 50
+	maxListLimit         = 200
+)
+
+type Options struct {
+	// Window насколько далеко назад смотрит проверка и за сколько должно замкнуться кольцо
+	Window time.Duration
+	// BurstOps операций одного отправителя за BurstWindow считаются всплеском
+	BurstWindow time.Duration
+	BurstOps    int
+	// FunnelSenders разных отправителей одному получателю за Window считаются воронкой
+	FunnelSenders int
+}
+
+type UseCase interface {
+	// Scan ищет подозрительные паттерны и возвращает число новых случаев
+	Scan(ctx context.Context) (int, error)
+	List(ctx context.Context, status string, limit int) ([]entities.FraudCase, error)
+	Dismiss(ctx context.Context, adminID, id int) (*entities.FraudCase, error)
+	// Freeze замораживает userIDs из участников случая, пустой список замораживает всех
+	Freeze(ctx context.Context, adminID, id int, userIDs []int) (*entities.FraudCase, error)
+}
+
+type useCase struct {
+	fraudRepo fraud.Repository
+	opts      Options
+	now       func() time.Time
+}
+
+func NewUseCase(fraudRepo fraud.Repository, opts Options) UseCase {
+	if opts.Window <= 0 {
+		opts.Window = defaultWindow
+	}
+
+	if opts.BurstWindow <= 0 {
+		opts.BurstWindow = defaultBurstWindow
+	}
+
+	if opts.BurstOps <= 0 {
+		opts.BurstOps = defaultBurstOps
+	}
+
+	if opts.FunnelSenders <= 0 {
+		opts.FunnelSenders = defaultFunnelSenders
+	}
+
+	return &useCase{
+		fraudRepo: fraudRepo,
+		opts:      opts,
+		now:       time.Now,
+	}
+}
+
+func (u *useCase) Scan(ctx context.Context) (int, error) {
+	since := u.now().Add(-u.opts.Window)
+
+	rings, err := u.fraudRepo.FindRings(ctx, since, u.opts.Window)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find rings: %w", err)
+	}
+
+	bursts, err := u.fraudRepo.FindBursts(ctx, since, u.opts.BurstWindow, u.opts.BurstOps)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find bursts: %w", err)
+	}
+
+	funnels, err := u.fraudRepo.FindFunnels(ctx, since, u.opts.FunnelSenders)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find funnels: %w", err)
+	}
+
+	flagged := 0
+
+	for _, findings := range [][]entities.FraudCase{rings, bursts, funnels} {
+		for _, c := range findings {
+			created, err := u.fraudRepo.Flag(ctx, c)
+			if err != nil {
+				return flagged, fmt.Errorf("failed to flag %s case: %w", c.Kind, err)
+			}
+
+			if created {
+				flagged++
+			}
+		}
+	}
+
+	return flagged, nil
+}
+
+func (u *useCase) List(ctx context.Context, status string, limit int) ([]entities.FraudCase, error) {
+	switch status {
+	case "", entities.CaseOpen, entities.CaseDismissed, entities.CaseFrozen:
+	default:
+		return nil, fmt.Errorf("invalid status: %q", status)
+	}
+
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	cases, err := u.fraudRepo.List(ctx, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fraud cases: %w", err)
+	}
+
+	return cases, nil
+}
+
+func (u *useCase) Dismiss(ctx context.Context, adminID, id int) (*entities.FraudCase, error) {
+	if _, err := u.getOpen(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := u.fraudRepo.Dismiss(ctx, id, adminID); err != nil {
+		return nil, fmt.Errorf("failed to dismiss fraud case %d: %w", id, err)
+	}
+
+	return u.fraudRepo.GetByID(ctx, id)
+}
+
+func (u *useCase) Freeze(ctx context.Context, adminID, id int, userIDs []int) (*entities.FraudCase, error) {
+	c, err := u.getOpen(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userIDs) == 0 {
+		userIDs = c.UserIDs
+	}
+
+	members := make(map[int]bool, len(c.UserIDs))
+	for _, memberID := range c.UserIDs {
+		members[memberID] = true
+	}
+
+	for _, userID := range userIDs {
+		if !members[userID] {
+			return nil, fmt.Errorf("user %d is not part of fraud case %d", userID, id)
+		}
+	}
+
+	if err := u.fraudRepo.Freeze(ctx, id, adminID, userIDs); err != nil {
+		return nil, fmt.Errorf("failed to freeze fraud case %d: %w", id, err)
+	}
+
+	return u.fraudRepo.GetByID(ctx, id)
+}
+
+// getOpen возвращает sql.ErrNoRows, если случая нет, и ошибку, если он уже рассмотрен
+func (u *useCase) getOpen(ctx context.Context, id int) (*entities.FraudCase, error) {
+	c, err := u.fraudRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fraud case %d: %w", id, err)
+	}
+
+	if c.Status != entities.CaseOpen {
+		return nil, fmt.Errorf("fraud case %d is already %s", id, c.Status)
+	}
+
+	return c, nil
+}
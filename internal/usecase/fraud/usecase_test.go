@@ -0,0 +1,129 @@
+package fraud_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/usecase/fraud"
+)
+
+type mockRepos struct {
+	FindRingsFunc   func(ctx context.Context, since time.Time, window time.Duration) ([]entity.FraudCase, error)
+	FindBurstsFunc  func(ctx context.Context, since time.Time, window time.Duration, minOps int) ([]entity.FraudCase, error)
+	FindFunnelsFunc func(ctx context.Context, since time.Time, minSenders int) ([]entity.FraudCase, error)
+	FlagFunc        func(ctx context.Context, c entity.FraudCase) (bool, error)
+	GetByIDFunc     func(ctx context.Context, id int) (*entity.FraudCase, error)
+	FreezeFunc      func(ctx context.Context, id, adminID int, userIDs []int) error
+}
+
+func (m *mockRepos) FindRings(ctx context.Context, since time.Time, window time.Duration) ([]entity.FraudCase, error) {
+	return m.FindRingsFunc(ctx, since, window)
+}
+
+func (m *mockRepos) FindBursts(ctx context.Context, since time.Time, window time.Duration, minOps int) ([]entity.FraudCase, error) {
+	return m.FindBurstsFunc(ctx, since, window, minOps)
+}
+
+func (m *mockRepos) FindFunnels(ctx context.Context, since time.Time, minSenders int) ([]entity.FraudCase, error) {
+	return m.FindFunnelsFunc(ctx, since, minSenders)
+}
+
+func (m *mockRepos) Flag(ctx context.Context, c entity.FraudCase) (bool, error) {
+	return m.FlagFunc(ctx, c)
+}
+
+func (m *mockRepos) GetByID(ctx context.Context, id int) (*entity.FraudCase, error) {
+	return m.GetByIDFunc(ctx, id)
+}
+
+func (m *mockRepos) List(ctx context.Context, status string, limit int) ([]entity.FraudCase, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) Dismiss(ctx context.Context, id, adminID int) error {
+	return nil
+}
+
+func (m *mockRepos) Freeze(ctx context.Context, id, adminID int, userIDs []int) error {
+	return m.FreezeFunc(ctx, id, adminID, userIDs)
+}
+
+func TestScan_CountsNewCases(t *testing.T) {
+	repo := &mockRepos{
+		FindRingsFunc: func(ctx context.Context, since time.Time, window time.Duration) ([]entity.FraudCase, error) {
+			return []entity.FraudCase{{Kind: entity.FraudRing, UserIDs: []int{1, 2, 3}}}, nil
+		},
+		FindBurstsFunc: func(ctx context.Context, since time.Time, window time.Duration, minOps int) ([]entity.FraudCase, error) {
+			assert.Equal(t, 5, minOps)
+			return []entity.FraudCase{{Kind: entity.FraudBurst, UserIDs: []int{4}}}, nil
+		},
+		FindFunnelsFunc: func(ctx context.Context, since time.Time, minSenders int) ([]entity.FraudCase, error) {
+			return nil, nil
+		},
+		FlagFunc: func(ctx context.Context, c entity.FraudCase) (bool, error) {
+			// всплеск уже рассмотрен
+			return c.Kind == entity.FraudRing, nil
+		},
+	}
+
+	uc := fraud.NewUseCase(repo, fraud.Options{BurstOps: 5})
+	flagged, err := uc.Scan(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, flagged)
+}
+
+func TestFreeze_DefaultsToAllMembers(t *testing.T) {
+	var frozen []int
+	repo := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.FraudCase, error) {
+			status := entity.CaseOpen
+			if frozen != nil {
+				status = entity.CaseFrozen
+			}
+			return &entity.FraudCase{ID: id, UserIDs: []int{1, 2, 3}, Status: status}, nil
+		},
+		FreezeFunc: func(ctx context.Context, id, adminID int, userIDs []int) error {
+			frozen = userIDs
+			return nil
+		},
+	}
+
+	uc := fraud.NewUseCase(repo, fraud.Options{})
+	c, err := uc.Freeze(context.Background(), 9, 5, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, frozen)
+	assert.Equal(t, entity.CaseFrozen, c.Status)
+}
+
+func TestFreeze_RejectsOutsider(t *testing.T) {
+	repo := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.FraudCase, error) {
+			return &entity.FraudCase{ID: id, UserIDs: []int{1, 2, 3}, Status: entity.CaseOpen}, nil
+		},
+	}
+
+	uc := fraud.NewUseCase(repo, fraud.Options{})
+	_, err := uc.Freeze(context.Background(), 9, 5, []int{4})
+
+	assert.Error(t, err)
+}
+
+func TestDismiss_AlreadyResolved(t *testing.T) {
+	repo := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.FraudCase, error) {
+			return &entity.FraudCase{ID: id, Status: entity.CaseDismissed}, nil
+		},
+	}
+
+	uc := fraud.NewUseCase(repo, fraud.Options{})
+	_, err := uc.Dismiss(context.Background(), 9, 5)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already dismissed")
+}
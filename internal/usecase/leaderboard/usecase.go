@@ -0,0 +1,108 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/leaderboard"
+)
+
+const (
+	defaultTopLimit = 10
+	maxTopLimit     = 100
+)
+
+// ErrUnknownBoard запрошен рейтинг или период, которого нет
+var ErrUnknownBoard = errors.New("unknown leaderboard")
+
+// Board рейтинг board за период period. RefreshedAt нулевой, если в рейтинге пока никого нет
+type Board struct {
+	Board       string
+	Period      string
+	RefreshedAt time.Time
+	Entries     []entities.LeaderboardEntry
+}
+
+type UseCase interface {
+	// Top возвращает первые limit мест рейтинга из последнего пересчета
+	Top(ctx context.Context, board, period string, limit int) (*Board, error)
+	// Refresh пересчитывает все рейтинги, его вызывает фоновая задача
+	Refresh(ctx context.Context) error
+	OptedOut(ctx context.Context, userID int) (bool, error)
+	// SetOptOut скрывает пользователя из публичных рейтингов или возвращает обратно. Действует
+	// сразу, не дожидаясь пересчета
+	SetOptOut(ctx context.Context, userID int, optOut bool) error
+}
+
+type useCase struct {
+	leaderboardRepo leaderboard.Repository
+}
+
+func NewUseCase(leaderboardRepo leaderboard.Repository) UseCase {
+	return &useCase{
+		leaderboardRepo: leaderboardRepo,
+	}
+}
+
+func (u *useCase) Top(ctx context.Context, board, period string, limit int) (*Board, error) {
+	if !slices.Contains(entities.LeaderboardBoards, board) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBoard, board)
+	}
+
+	if period == "" {
+		period = entities.PeriodWeek
+	}
+
+	if !slices.Contains(entities.LeaderboardPeriods, period) {
+		return nil, fmt.Errorf("%w: unknown period %q", ErrUnknownBoard, period)
+	}
+
+	if limit <= 0 {
+		limit = defaultTopLimit
+	}
+
+	if limit > maxTopLimit {
+		limit = maxTopLimit
+	}
+
+	entries, err := u.leaderboardRepo.Top(ctx, board, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+
+	result := &Board{Board: board, Period: period, Entries: entries}
+	if len(entries) > 0 {
+		result.RefreshedAt = entries[0].RefreshedAt
+	}
+
+	return result, nil
+}
+
+func (u *useCase) Refresh(ctx context.Context) error {
+	if err := u.leaderboardRepo.Refresh(ctx); err != nil {
+		return fmt.Errorf("failed to refresh leaderboards: %w", err)
+	}
+
+	return nil
+}
+
+func (u *useCase) OptedOut(ctx context.Context, userID int) (bool, error) {
+	optOut, err := u.leaderboardRepo.OptedOut(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get leaderboard opt-out: %w", err)
+	}
+
+	return optOut, nil
+}
+
+func (u *useCase) SetOptOut(ctx context.Context, userID int, optOut bool) error {
+	if err := u.leaderboardRepo.SetOptOut(ctx, userID, optOut); err != nil {
+		return fmt.Errorf("failed to set leaderboard opt-out: %w", err)
+	}
+
+	return nil
+}
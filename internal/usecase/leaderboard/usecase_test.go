@@ -0,0 +1,67 @@
+package leaderboard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/usecase/leaderboard"
+)
+
+type mockLeaderboardRepo struct {
+	entries []entity.LeaderboardEntry
+	board   string
+	period  string
+	limit   int
+}
+
+func (m *mockLeaderboardRepo) Refresh(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockLeaderboardRepo) Top(ctx context.Context, board, period string, limit int) ([]entity.LeaderboardEntry, error) {
+	m.board, m.period, m.limit = board, period, limit
+	return m.entries, nil
+}
+
+func (m *mockLeaderboardRepo) OptedOut(ctx context.Context, userID int) (bool, error) {
+	return false, nil
+}
+
+func (m *mockLeaderboardRepo) SetOptOut(ctx context.Context, userID int, optOut bool) error {
+	return nil
+}
+
+func TestTop_Defaults(t *testing.T) {
+	refreshedAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	repo := &mockLeaderboardRepo{entries: []entity.LeaderboardEntry{
+		{Rank: 1, Username: "bob", Total: 300, RefreshedAt: refreshedAt},
+	}}
+	useCase := leaderboard.NewUseCase(repo)
+
+	board, err := useCase.Top(context.Background(), entity.BoardSenders, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.PeriodWeek, repo.period)
+	assert.Equal(t, 10, repo.limit)
+	assert.Equal(t, refreshedAt, board.RefreshedAt)
+	assert.Len(t, board.Entries, 1)
+
+	_, err = useCase.Top(context.Background(), entity.BoardSpenders, entity.PeriodAll, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, repo.limit)
+}
+
+func TestTop_UnknownBoard(t *testing.T) {
+	repo := &mockLeaderboardRepo{}
+	useCase := leaderboard.NewUseCase(repo)
+
+	_, err := useCase.Top(context.Background(), "buyers", entity.PeriodWeek, 10)
+	assert.ErrorIs(t, err, leaderboard.ErrUnknownBoard)
+
+	_, err = useCase.Top(context.Background(), entity.BoardReceivers, "year", 10)
+	assert.ErrorIs(t, err, leaderboard.ErrUnknownBoard)
+	assert.Empty(t, repo.board)
+}
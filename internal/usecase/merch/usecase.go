@@ -3,6 +3,7 @@ package merch
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	entities "merchshop/internal/entity"
 	"merchshop/internal/repository/merch"
@@ -11,6 +12,11 @@ import (
 type UseCase interface {
 	List(ctx context.Context) ([]entities.Merchandise, error)
 	GetByName(ctx context.Context, name string) (*entities.Merchandise, error)
+	// Restock пополняет склад товара без вариантов, threshold nil оставляет порог предупреждения прежним
+	Restock(ctx context.Context, name string, quantity int, threshold *int) (*entities.Merchandise, error)
+	// SaveVariant создает вариант товара или обновляет атрибуты, цену и порог существующего
+	SaveVariant(ctx context.Context, v entities.Variant) (*entities.Variant, error)
+	RestockVariant(ctx context.Context, merchName, sku string, quantity int, threshold *int) (*entities.Variant, error)
 }
 
 type useCase struct {
@@ -44,3 +50,85 @@ func (u *useCase) List(ctx context.Context) ([]entities.Merchandise, error) {
 
 	return merch, nil
 }
+
+func (u *useCase) Restock(ctx context.Context, name string, quantity int, threshold *int) (*entities.Merchandise, error) {
+	if err := validateRestock(quantity, threshold); err != nil {
+		return nil, err
+	}
+
+	current, err := u.merchRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merchandise by name %s: %w", name, err)
+	}
+
+	if len(current.Variants) > 0 {
+		return nil, fmt.Errorf("%s comes in variants, restock a variant instead", name)
+	}
+
+	merch, err := u.merchRepo.Restock(ctx, name, quantity, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restock merchandise %s: %w", name, err)
+	}
+
+	return merch, nil
+}
+
+func (u *useCase) SaveVariant(ctx context.Context, v entities.Variant) (*entities.Variant, error) {
+	v.SKU = strings.TrimSpace(v.SKU)
+	if v.SKU == "" {
+		return nil, fmt.Errorf("empty sku")
+	}
+
+	for name, value := range v.Attributes {
+		if strings.TrimSpace(name) == "" || strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("empty attribute %q of %s", name, v.SKU)
+		}
+	}
+
+	if v.PriceOverride != nil && *v.PriceOverride <= 0 {
+		return nil, fmt.Errorf("invalid price: %d", *v.PriceOverride)
+	}
+
+	if v.Stock < 0 || v.LowStockThreshold < 0 {
+		return nil, fmt.Errorf("invalid stock or low stock threshold")
+	}
+
+	if _, err := u.merchRepo.GetByName(ctx, v.MerchName); err != nil {
+		return nil, fmt.Errorf("failed to get merchandise by name %s: %w", v.MerchName, err)
+	}
+
+	if err := u.merchRepo.SaveVariant(ctx, v); err != nil {
+		return nil, fmt.Errorf("failed to save variant %s: %w", v.SKU, err)
+	}
+
+	return u.merchRepo.GetVariant(ctx, v.SKU)
+}
+
+func (u *useCase) RestockVariant(ctx context.Context, merchName, sku string, quantity int, threshold *int) (*entities.Variant, error) {
+	if err := validateRestock(quantity, threshold); err != nil {
+		return nil, err
+	}
+
+	v, err := u.merchRepo.RestockVariant(ctx, merchName, sku, quantity, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restock variant %s: %w", sku, err)
+	}
+
+	return v, nil
+}
+
+func validateRestock(quantity int, threshold *int) error {
+	if quantity < 0 {
+		return fmt.Errorf("invalid quantity: %d", quantity)
+	}
+
+	if threshold != nil && *threshold < 0 {
+		return fmt.Errorf("invalid low stock threshold: %d", *threshold)
+	}
+
+	if quantity == 0 && threshold == nil {
+		return fmt.Errorf("nothing to update")
+	}
+
+	return nil
+}
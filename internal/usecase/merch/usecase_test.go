@@ -15,6 +15,7 @@ import (
 type mockMerchRepo struct {
 	ListFunc      func(ctx context.Context) ([]entity.Merchandise, error)
 	GetByNameFunc func(ctx context.Context, name string) (*entity.Merchandise, error)
+	RestockFunc   func(ctx context.Context, name string, quantity int, threshold *int) (*entity.Merchandise, error)
 }
 
 func (m *mockMerchRepo) List(ctx context.Context) ([]entity.Merchandise, error) {
@@ -25,6 +26,22 @@ func (m *mockMerchRepo) GetByName(ctx context.Context, name string) (*entity.Mer
 	return m.GetByNameFunc(ctx, name)
 }
 
+func (m *mockMerchRepo) Restock(ctx context.Context, name string, quantity int, threshold *int) (*entity.Merchandise, error) {
+	return m.RestockFunc(ctx, name, quantity, threshold)
+}
+
+func (m *mockMerchRepo) GetVariant(ctx context.Context, sku string) (*entity.Variant, error) {
+	return &entity.Variant{SKU: sku}, nil
+}
+
+func (m *mockMerchRepo) SaveVariant(ctx context.Context, v entity.Variant) error {
+	return nil
+}
+
+func (m *mockMerchRepo) RestockVariant(ctx context.Context, merchName, sku string, quantity int, threshold *int) (*entity.Variant, error) {
+	return nil, nil
+}
+
 func TestUseCase_List(t *testing.T) {
 	expected := []entity.Merchandise{
 		{Name: "hoody", Price: 300},
@@ -88,3 +105,53 @@ func TestUseCase_GetByName_ErrorFromRepo(t *testing.T) {
 	require.Nil(t, result)
 	require.Contains(t, err.Error(), "failed to get merchandise by name")
 }
+
+// отрицательное пополнение и пустой запрос отклоняются до обращения к репозиторию
+func TestUseCase_Restock_Validation(t *testing.T) {
+	uc := merch.NewUseCase(&mockMerchRepo{})
+	negative := -1
+
+	_, err := uc.Restock(context.Background(), "cup", -5, nil)
+	require.ErrorContains(t, err, "invalid quantity")
+
+	_, err = uc.Restock(context.Background(), "cup", 5, &negative)
+	require.ErrorContains(t, err, "invalid low stock threshold")
+
+	_, err = uc.Restock(context.Background(), "cup", 0, nil)
+	require.ErrorContains(t, err, "nothing to update")
+}
+
+// остаток товара с вариантами не продается, пополнять нужно варианты
+func TestUseCase_Restock_MerchWithVariants(t *testing.T) {
+	restocked := false
+
+	mockRepo := &mockMerchRepo{
+		GetByNameFunc: func(ctx context.Context, name string) (*entity.Merchandise, error) {
+			return &entity.Merchandise{Name: name, Variants: []entity.Variant{{SKU: "hoody-m"}}}, nil
+		},
+		RestockFunc: func(ctx context.Context, name string, quantity int, threshold *int) (*entity.Merchandise, error) {
+			restocked = true
+			return nil, nil
+		},
+	}
+
+	uc := merch.NewUseCase(mockRepo)
+
+	_, err := uc.Restock(context.Background(), "hoody", 10, nil)
+	require.ErrorContains(t, err, "restock a variant")
+	require.False(t, restocked)
+}
+
+func TestUseCase_SaveVariant_Validation(t *testing.T) {
+	uc := merch.NewUseCase(&mockMerchRepo{})
+	zero := 0
+
+	_, err := uc.SaveVariant(context.Background(), entity.Variant{SKU: " ", MerchName: "hoody"})
+	require.ErrorContains(t, err, "empty sku")
+
+	_, err = uc.SaveVariant(context.Background(), entity.Variant{SKU: "hoody-m", MerchName: "hoody", Attributes: map[string]string{"size": ""}})
+	require.ErrorContains(t, err, "empty attribute")
+
+	_, err = uc.SaveVariant(context.Background(), entity.Variant{SKU: "hoody-m", MerchName: "hoody", PriceOverride: &zero})
+	require.ErrorContains(t, err, "invalid price")
+}
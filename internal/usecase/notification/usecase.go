@@ -0,0 +1,152 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/notification"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// Notifier сохраняет уведомление, которое пользователь увидит в приложении. Уведомление
+// отключенного пользователем типа молча пропускается
+type Notifier interface {
+	Notify(ctx context.Context, n entities.Notification) error
+}
+
+// Deliver отправляет уведомление об уже совершенной операции: ошибка не должна ее отменять,
+// поэтому только логируется
+func Deliver(ctx context.Context, notifier Notifier, n entities.Notification) {
+	if err := notifier.Notify(ctx, n); err != nil {
+		log.Printf("notification: %s for user %d: %v", n.Kind, n.UserID, err)
+	}
+}
+
+// Page страница уведомлений. NextBefore передается как before за следующей страницей,
+// 0 если страниц больше нет
+type Page struct {
+	Notifications []entities.Notification
+	Unread        int
+	NextBefore    int
+}
+
+type UseCase interface {
+	Notifier
+
+	// List возвращает уведомления пользователя старше before, новые первыми. Нулевой before
+	// начинает с самых новых
+	List(ctx context.Context, userID int, unreadOnly bool, before, limit int) (*Page, error)
+	// MarkRead отмечает прочитанными уведомления ids, пустой список отмечает все. Возвращает
+	// число отмеченных
+	MarkRead(ctx context.Context, userID int, ids []int) (int, error)
+	// Preferences возвращает все типы уведомлений и включены ли они у пользователя
+	Preferences(ctx context.Context, userID int) ([]entities.NotificationPreference, error)
+	SetPreferences(ctx context.Context, userID int, prefs []entities.NotificationPreference) ([]entities.NotificationPreference, error)
+}
+
+type useCase struct {
+	notificationRepo notification.Repository
+}
+
+func NewUseCase(notificationRepo notification.Repository) UseCase {
+	return &useCase{
+		notificationRepo: notificationRepo,
+	}
+}
+
+func (u *useCase) Notify(ctx context.Context, n entities.Notification) error {
+	_, err := u.notificationRepo.Create(ctx, n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return nil
+}
+
+func (u *useCase) List(ctx context.Context, userID int, unreadOnly bool, before, limit int) (*Page, error) {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	// Лишняя запись показывает, есть ли следующая страница
+	notifications, err := u.notificationRepo.List(ctx, userID, unreadOnly, before, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	unread, err := u.notificationRepo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+
+	page := &Page{Notifications: notifications, Unread: unread}
+
+	if len(notifications) > limit {
+		page.Notifications = notifications[:limit]
+		page.NextBefore = notifications[limit-1].ID
+	}
+
+	return page, nil
+}
+
+func (u *useCase) MarkRead(ctx context.Context, userID int, ids []int) (int, error) {
+	marked, err := u.notificationRepo.MarkRead(ctx, userID, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+
+	return marked, nil
+}
+
+func (u *useCase) Preferences(ctx context.Context, userID int) ([]entities.NotificationPreference, error) {
+	saved, err := u.notificationRepo.Preferences(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	enabled := make(map[string]bool, len(saved))
+	for _, p := range saved {
+		enabled[p.Kind] = p.Enabled
+	}
+
+	prefs := make([]entities.NotificationPreference, len(entities.NotificationKinds))
+	for i, kind := range entities.NotificationKinds {
+		on, ok := enabled[kind]
+		prefs[i] = entities.NotificationPreference{Kind: kind, Enabled: on || !ok}
+	}
+
+	return prefs, nil
+}
+
+func (u *useCase) SetPreferences(ctx context.Context, userID int, prefs []entities.NotificationPreference) ([]entities.NotificationPreference, error) {
+	for _, p := range prefs {
+		if !slices.Contains(entities.NotificationKinds, p.Kind) {
+			return nil, fmt.Errorf("unknown notification kind: %q", p.Kind)
+		}
+	}
+
+	for _, p := range prefs {
+		if err := u.notificationRepo.SetPreference(ctx, userID, p); err != nil {
+			return nil, fmt.Errorf("failed to save notification preference: %w", err)
+		}
+	}
+
+	return u.Preferences(ctx, userID)
+}
@@ -0,0 +1,95 @@
+package notification_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/usecase/notification"
+)
+
+type mockNotificationRepo struct {
+	notifications []entity.Notification
+	prefs         []entity.NotificationPreference
+	disabled      map[string]bool
+	limit         int
+}
+
+func (m *mockNotificationRepo) Create(ctx context.Context, n entity.Notification) (*entity.Notification, error) {
+	if m.disabled[n.Kind] {
+		return nil, sql.ErrNoRows
+	}
+
+	m.notifications = append(m.notifications, n)
+	return &n, nil
+}
+
+func (m *mockNotificationRepo) List(ctx context.Context, userID int, unreadOnly bool, before, limit int) ([]entity.Notification, error) {
+	m.limit = limit
+	return m.notifications[:min(limit, len(m.notifications))], nil
+}
+
+func (m *mockNotificationRepo) CountUnread(ctx context.Context, userID int) (int, error) {
+	return len(m.notifications), nil
+}
+
+func (m *mockNotificationRepo) MarkRead(ctx context.Context, userID int, ids []int) (int, error) {
+	return len(ids), nil
+}
+
+func (m *mockNotificationRepo) Preferences(ctx context.Context, userID int) ([]entity.NotificationPreference, error) {
+	return m.prefs, nil
+}
+
+func (m *mockNotificationRepo) SetPreference(ctx context.Context, userID int, p entity.NotificationPreference) error {
+	m.prefs = append(m.prefs, p)
+	return nil
+}
+
+func TestNotify_DisabledKindIsSkipped(t *testing.T) {
+	repo := &mockNotificationRepo{disabled: map[string]bool{entity.NotifyCoinReceived: true}}
+	useCase := notification.NewUseCase(repo)
+
+	err := useCase.Notify(context.Background(), entity.Notification{UserID: 1, Kind: entity.NotifyCoinReceived})
+	assert.NoError(t, err)
+	assert.Empty(t, repo.notifications)
+}
+
+func TestList_NextPage(t *testing.T) {
+	repo := &mockNotificationRepo{notifications: []entity.Notification{{ID: 9}, {ID: 8}, {ID: 5}}}
+	useCase := notification.NewUseCase(repo)
+
+	page, err := useCase.List(context.Background(), 1, false, 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, repo.limit)
+	assert.Len(t, page.Notifications, 2)
+	assert.Equal(t, 8, page.NextBefore)
+	assert.Equal(t, 3, page.Unread)
+
+	page, err = useCase.List(context.Background(), 1, false, 0, 5)
+	assert.NoError(t, err)
+	assert.Len(t, page.Notifications, 3)
+	assert.Zero(t, page.NextBefore)
+}
+
+func TestSetPreferences(t *testing.T) {
+	repo := &mockNotificationRepo{}
+	useCase := notification.NewUseCase(repo)
+
+	_, err := useCase.SetPreferences(context.Background(), 1, []entity.NotificationPreference{{Kind: "coin.sent"}})
+	assert.ErrorContains(t, err, "unknown notification kind")
+	assert.Empty(t, repo.prefs)
+
+	prefs, err := useCase.SetPreferences(context.Background(), 1, []entity.NotificationPreference{
+		{Kind: entity.NotifyPriceDrop, Enabled: false},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, prefs, len(entity.NotificationKinds))
+
+	for _, p := range prefs {
+		assert.Equal(t, p.Kind != entity.NotifyPriceDrop, p.Enabled, p.Kind)
+	}
+}
@@ -0,0 +1,216 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/event"
+	"merchshop/internal/repository/purchase"
+	"merchshop/internal/usecase/notification"
+)
+
+const (
+	defaultListLimit  = 50
+	maxListLimit      = 200
+	maxLocationLength = 100
+)
+
+// UseCase выдача купленного товара: placed → ready_for_pickup → delivered, до выдачи заказ можно отменить
+type UseCase interface {
+	List(ctx context.Context, status string, limit int) ([]entities.Purchase, error)
+	MarkReady(ctx context.Context, id int, location string) (*entities.Purchase, error)
+	MarkDelivered(ctx context.Context, id int) (*entities.Purchase, error)
+	// Cancel отменяет заказ пользователя userID или оплаченный им подарок, CancelOrder отменяет любой заказ.
+	// Монеты возвращаются покупателю
+	Cancel(ctx context.Context, userID, id int) (*entities.Purchase, error)
+	CancelOrder(ctx context.Context, id int) (*entities.Purchase, error)
+}
+
+type useCase struct {
+	purchaseRepo purchase.Repository
+	events       event.Publisher
+	notifier     notification.Notifier
+}
+
+func NewUseCase(purchaseRepo purchase.Repository, events event.Publisher, notifier notification.Notifier) UseCase {
+	return &useCase{
+		purchaseRepo: purchaseRepo,
+		events:       events,
+		notifier:     notifier,
+	}
+}
+
+func (u *useCase) List(ctx context.Context, status string, limit int) ([]entities.Purchase, error) {
+	switch status {
+	case "", entities.OrderPlaced, entities.OrderReady, entities.OrderDelivered, entities.OrderCancelled:
+	default:
+		return nil, fmt.Errorf("invalid status: %q", status)
+	}
+
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	orders, err := u.purchaseRepo.ListOrders(ctx, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+func (u *useCase) MarkReady(ctx context.Context, id int, location string) (*entities.Purchase, error) {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return nil, fmt.Errorf("empty pickup location")
+	}
+
+	if utf8.RuneCountInString(location) > maxLocationLength {
+		return nil, fmt.Errorf("pickup location too long, max %d characters", maxLocationLength)
+	}
+
+	if _, err := u.getInStatus(ctx, id, entities.OrderPlaced); err != nil {
+		return nil, err
+	}
+
+	if err := u.purchaseRepo.MarkReady(ctx, id, location); err != nil {
+		return nil, fmt.Errorf("failed to mark order %d ready: %w", id, err)
+	}
+
+	return u.publish(ctx, id, 0)
+}
+
+func (u *useCase) MarkDelivered(ctx context.Context, id int) (*entities.Purchase, error) {
+	if _, err := u.getInStatus(ctx, id, entities.OrderReady); err != nil {
+		return nil, err
+	}
+
+	if err := u.purchaseRepo.MarkDelivered(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to mark order %d delivered: %w", id, err)
+	}
+
+	return u.publish(ctx, id, 0)
+}
+
+func (u *useCase) Cancel(ctx context.Context, userID, id int) (*entities.Purchase, error) {
+	order, err := u.purchaseRepo.GetOrder(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order %d: %w", id, err)
+	}
+
+	// Чужой заказ для пользователя не существует. Подарок отменяет только оплативший, монеты возвращаются ему
+	if order.UserID != userID && order.BuyerID != userID {
+		return nil, fmt.Errorf("failed to get order %d: %w", id, sql.ErrNoRows)
+	}
+
+	if order.IsGift() && order.BuyerID != userID {
+		return nil, fmt.Errorf("gift %d can only be cancelled by the buyer", id)
+	}
+
+	return u.cancel(ctx, order, userID)
+}
+
+func (u *useCase) CancelOrder(ctx context.Context, id int) (*entities.Purchase, error) {
+	order, err := u.purchaseRepo.GetOrder(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order %d: %w", id, err)
+	}
+
+	return u.cancel(ctx, order, 0)
+}
+
+func (u *useCase) cancel(ctx context.Context, order *entities.Purchase, userID int) (*entities.Purchase, error) {
+	if order.Status != entities.OrderPlaced && order.Status != entities.OrderReady {
+		return nil, fmt.Errorf("order %d is already %s", order.ID, order.Status)
+	}
+
+	refund, err := u.purchaseRepo.Cancel(ctx, order.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel order %d: %w", order.ID, err)
+	}
+
+	return u.publish(ctx, order.ID, refund)
+}
+
+// getInStatus возвращает sql.ErrNoRows, если заказа нет, и ошибку, если он не в статусе status
+func (u *useCase) getInStatus(ctx context.Context, id int, status string) (*entities.Purchase, error) {
+	order, err := u.purchaseRepo.GetOrder(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order %d: %w", id, err)
+	}
+
+	if order.Status != status {
+		return nil, fmt.Errorf("order %d is %s, expected %s", id, order.Status, status)
+	}
+
+	return order, nil
+}
+
+// publish перечитывает заказ после смены статуса и уведомляет покупателя
+func (u *useCase) publish(ctx context.Context, id, refund int) (*entities.Purchase, error) {
+	order, err := u.purchaseRepo.GetOrder(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order %d: %w", id, err)
+	}
+
+	u.events.Publish(ctx, event.Event{
+		Type:   event.OrderUpdated,
+		UserID: order.UserID,
+		Data: event.Order{
+			OrderID:        order.ID,
+			Item:           order.MerchName,
+			SKU:            order.SKU,
+			Quantity:       order.Quantity,
+			Status:         order.Status,
+			PickupLocation: order.PickupLocation,
+			Refund:         refund,
+		},
+	})
+
+	notification.Deliver(ctx, u.notifier, entities.Notification{
+		UserID:  order.UserID,
+		Kind:    entities.NotifyOrderUpdated,
+		Message: orderMessage(order, refund),
+		Data: map[string]any{
+			"orderId": order.ID, "item": order.MerchName, "status": order.Status,
+			"pickupLocation": order.PickupLocation, "refund": refund,
+		},
+	})
+
+	// Возврат за отмененный подарок получает оплативший, о нем он и узнает
+	if order.IsGift() && order.Status == entities.OrderCancelled {
+		notification.Deliver(ctx, u.notifier, entities.Notification{
+			UserID:  order.BuyerID,
+			Kind:    entities.NotifyOrderUpdated,
+			Message: fmt.Sprintf("Подарок %d (%s) отменен, возвращено %d монет", order.ID, order.MerchName, refund),
+			Data:    map[string]any{"orderId": order.ID, "item": order.MerchName, "status": order.Status, "refund": refund},
+		})
+	}
+
+	return order, nil
+}
+
+func orderMessage(order *entities.Purchase, refund int) string {
+	switch order.Status {
+	case entities.OrderReady:
+		return fmt.Sprintf("Заказ %d (%s) готов к выдаче: %s", order.ID, order.MerchName, order.PickupLocation)
+	case entities.OrderDelivered:
+		return fmt.Sprintf("Заказ %d (%s) выдан", order.ID, order.MerchName)
+	case entities.OrderCancelled:
+		if refund > 0 && !order.IsGift() {
+			return fmt.Sprintf("Заказ %d (%s) отменен, возвращено %d монет", order.ID, order.MerchName, refund)
+		}
+
+		return fmt.Sprintf("Заказ %d (%s) отменен", order.ID, order.MerchName)
+	default:
+		return fmt.Sprintf("Заказ %d (%s): %s", order.ID, order.MerchName, order.Status)
+	}
+}
@@ -0,0 +1,198 @@
+package order_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/event"
+	policyRepo "merchshop/internal/repository/policy"
+	"merchshop/internal/usecase/order"
+)
+
+type mockPurchaseRepo struct {
+	orders map[int]*entity.Purchase
+
+	MarkReadyFunc func(ctx context.Context, id int, location string) error
+	CancelFunc    func(ctx context.Context, id, userID int) (int, error)
+}
+
+func (m *mockPurchaseRepo) CreatePurchase(ctx context.Context, userID int, merchName, sku string, quantity int, promoCode string, guard *policyRepo.Guard) (*entity.Purchase, int, error) {
+	return nil, 0, nil
+}
+
+func (m *mockPurchaseRepo) CreateGift(ctx context.Context, buyerID, recipientID int, merchName, sku string, quantity int, promoCode, message string, guard *policyRepo.Guard) (*entity.Purchase, int, error) {
+	return nil, 0, nil
+}
+
+func (m *mockPurchaseRepo) GetGiftsSent(ctx context.Context, buyerID int) ([]entity.Purchase, error) {
+	return nil, nil
+}
+
+func (m *mockPurchaseRepo) GetByUserId(ctx context.Context, userID int) ([]entity.Purchase, error) {
+	return nil, nil
+}
+
+func (m *mockPurchaseRepo) GetOrder(ctx context.Context, id int) (*entity.Purchase, error) {
+	o, ok := m.orders[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	copied := *o
+
+	return &copied, nil
+}
+
+func (m *mockPurchaseRepo) ListOrders(ctx context.Context, status string, limit int) ([]entity.Purchase, error) {
+	return nil, nil
+}
+
+func (m *mockPurchaseRepo) MarkReady(ctx context.Context, id int, location string) error {
+	return m.MarkReadyFunc(ctx, id, location)
+}
+
+func (m *mockPurchaseRepo) MarkDelivered(ctx context.Context, id int) error {
+	return nil
+}
+
+func (m *mockPurchaseRepo) Cancel(ctx context.Context, id, userID int) (int, error) {
+	return m.CancelFunc(ctx, id, userID)
+}
+
+type recordingPublisher struct {
+	events []event.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, e event.Event) {
+	p.events = append(p.events, e)
+}
+
+type recordingNotifier struct {
+	notifications []entity.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func TestMarkReady_Success(t *testing.T) {
+	repo := &mockPurchaseRepo{
+		orders: map[int]*entity.Purchase{7: {ID: 7, UserID: 1, MerchName: "cup", Quantity: 1, Status: entity.OrderPlaced}},
+	}
+	repo.MarkReadyFunc = func(ctx context.Context, id int, location string) error {
+		assert.Equal(t, "reception", location)
+		repo.orders[id].Status = entity.OrderReady
+		repo.orders[id].PickupLocation = location
+
+		return nil
+	}
+
+	events := &recordingPublisher{}
+	notifier := &recordingNotifier{}
+	useCase := order.NewUseCase(repo, events, notifier)
+
+	o, err := useCase.MarkReady(context.Background(), 7, "  reception ")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.OrderReady, o.Status)
+
+	assert.Len(t, events.events, 1)
+	assert.Equal(t, event.OrderUpdated, events.events[0].Type)
+	assert.Equal(t, 1, events.events[0].UserID)
+	assert.Equal(t, "reception", events.events[0].Data.(event.Order).PickupLocation)
+
+	assert.Len(t, notifier.notifications, 1)
+	assert.Equal(t, entity.NotifyOrderUpdated, notifier.notifications[0].Kind)
+	assert.Equal(t, "Заказ 7 (cup) готов к выдаче: reception", notifier.notifications[0].Message)
+}
+
+func TestMarkReady_WrongStatus(t *testing.T) {
+	repo := &mockPurchaseRepo{
+		orders: map[int]*entity.Purchase{7: {ID: 7, UserID: 1, Status: entity.OrderDelivered}},
+	}
+
+	useCase := order.NewUseCase(repo, &recordingPublisher{}, &recordingNotifier{})
+
+	_, err := useCase.MarkReady(context.Background(), 7, "reception")
+	assert.EqualError(t, err, "order 7 is delivered, expected placed")
+
+	_, err = useCase.MarkReady(context.Background(), 7, " ")
+	assert.EqualError(t, err, "empty pickup location")
+}
+
+func TestCancel_Refund(t *testing.T) {
+	repo := &mockPurchaseRepo{
+		orders: map[int]*entity.Purchase{7: {ID: 7, UserID: 1, MerchName: "cup", Quantity: 2, TotalPrice: 40, Status: entity.OrderReady}},
+	}
+	repo.CancelFunc = func(ctx context.Context, id, userID int) (int, error) {
+		assert.Equal(t, 1, userID)
+		repo.orders[id].Status = entity.OrderCancelled
+
+		return 40, nil
+	}
+
+	events := &recordingPublisher{}
+	useCase := order.NewUseCase(repo, events, &recordingNotifier{})
+
+	o, err := useCase.Cancel(context.Background(), 1, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.OrderCancelled, o.Status)
+
+	assert.Len(t, events.events, 1)
+	assert.Equal(t, 40, events.events[0].Data.(event.Order).Refund)
+}
+
+func TestCancel_NotCancellable(t *testing.T) {
+	repo := &mockPurchaseRepo{
+		orders: map[int]*entity.Purchase{
+			7: {ID: 7, UserID: 1, Status: entity.OrderDelivered},
+			8: {ID: 8, UserID: 2, Status: entity.OrderPlaced},
+		},
+	}
+
+	useCase := order.NewUseCase(repo, &recordingPublisher{}, &recordingNotifier{})
+
+	_, err := useCase.Cancel(context.Background(), 1, 7)
+	assert.EqualError(t, err, "order 7 is already delivered")
+
+	// Чужой заказ выглядит как несуществующий
+	_, err = useCase.Cancel(context.Background(), 1, 8)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCancel_Gift(t *testing.T) {
+	repo := &mockPurchaseRepo{
+		orders: map[int]*entity.Purchase{7: {ID: 7, UserID: 2, BuyerID: 1, MerchName: "cup", Quantity: 1, TotalPrice: 20, Status: entity.OrderPlaced}},
+	}
+	repo.CancelFunc = func(ctx context.Context, id, userID int) (int, error) {
+		repo.orders[id].Status = entity.OrderCancelled
+		return 20, nil
+	}
+
+	notifier := &recordingNotifier{}
+	useCase := order.NewUseCase(repo, &recordingPublisher{}, notifier)
+
+	// Получатель не может вернуть монеты оплатившему
+	_, err := useCase.Cancel(context.Background(), 2, 7)
+	assert.EqualError(t, err, "gift 7 can only be cancelled by the buyer")
+	assert.Empty(t, notifier.notifications)
+
+	_, err = useCase.Cancel(context.Background(), 1, 7)
+	assert.NoError(t, err)
+
+	assert.Len(t, notifier.notifications, 2)
+	assert.Equal(t, 2, notifier.notifications[0].UserID)
+	assert.Equal(t, 1, notifier.notifications[1].UserID)
+	assert.Equal(t, 20, notifier.notifications[1].Data["refund"])
+}
+
+func TestList_InvalidStatus(t *testing.T) {
+	useCase := order.NewUseCase(&mockPurchaseRepo{}, &recordingPublisher{}, &recordingNotifier{})
+
+	_, err := useCase.List(context.Background(), "lost", 10)
+	assert.EqualError(t, err, `invalid status: "lost"`)
+}
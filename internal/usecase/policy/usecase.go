@@ -0,0 +1,285 @@
+package policy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/policy"
+	"merchshop/internal/repository/user"
+)
+
+const (
+	KindTransfer = "transfer"
+	KindPurchase = "purchase"
+)
+
+// Коды нарушений, по которым клиент отличает одно ограничение от другого
+const (
+	CodeMaxTransfer   = "max_transfer_exceeded"
+	CodeDailyLimit    = "daily_limit_exceeded"
+	CodeWeeklyLimit   = "weekly_limit_exceeded"
+	CodeAccountTooNew = "account_too_new"
+	CodeVelocity      = "velocity_exceeded"
+)
+
+// Violation операция нарушает политику роли пользователя
+type Violation struct {
+	Code    string
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Code, v.Message)
+}
+
+// Operation исходящая операция. Amounts суммы отдельных переводов, у покупки одна сумма
+type Operation struct {
+	Kind    string
+	UserID  int
+	Amounts []int
+}
+
+func (o Operation) Total() int {
+	total := 0
+	for _, amount := range o.Amounts {
+		total += amount
+	}
+
+	return total
+}
+
+// Check все, что нужно правилу для решения
+type Check struct {
+	Policy entities.TransferPolicy
+	User   entities.User
+	Op     Operation
+	Usage  entities.PolicyUsage
+	Now    time.Time
+}
+
+// Rule возвращает *Violation, если операция нарушает ограничение
+type Rule func(c Check) error
+
+// DefaultRules правила в порядке проверки
+var DefaultRules = []Rule{MinAccountAge, MaxTransfer, Velocity, DailyLimit, WeeklyLimit}
+
+// Checker проверка, которую usecase'ы вызывают перед списанием монет
+type Checker interface {
+	// Guard проверяет правила, которым не нужны расходы пользователя, и возвращает проверку
+	// расходов для репозитория. Репозиторий выполняет ее в транзакции списания, иначе
+	// параллельные операции видят одни и те же расходы и вместе превышают лимиты
+	Guard(ctx context.Context, op Operation) (*policy.Guard, error)
+}
+
+type UseCase interface {
+	Checker
+
+	// Check проверяет операцию по текущим расходам без блокировки, как подсказку до списания
+	Check(ctx context.Context, op Operation) error
+
+	List(ctx context.Context) ([]entities.TransferPolicy, error)
+	Set(ctx context.Context, p entities.TransferPolicy) (*entities.TransferPolicy, error)
+	Delete(ctx context.Context, role string) error
+}
+
+type useCase struct {
+	policyRepo policy.Repository
+	userRepo   user.Repository
+	rules      []Rule
+	now        func() time.Time
+}
+
+func NewUseCase(policyRepo policy.Repository, userRepo user.Repository, rules ...Rule) UseCase {
+	if len(rules) == 0 {
+		rules = DefaultRules
+	}
+
+	return &useCase{
+		policyRepo: policyRepo,
+		userRepo:   userRepo,
+		rules:      rules,
+		now:        time.Now,
+	}
+}
+
+func (u *useCase) Check(ctx context.Context, op Operation) error {
+	guard, err := u.Guard(ctx, op)
+	if err != nil || guard == nil {
+		return err
+	}
+
+	usage, err := u.policyRepo.Usage(ctx, op.UserID, guard.DayStart, guard.WeekStart, guard.VelocityStart)
+	if err != nil {
+		return fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	return guard.Allow(*usage)
+}
+
+func (u *useCase) Guard(ctx context.Context, op Operation) (*policy.Guard, error) {
+	usr, err := u.userRepo.GetByID(ctx, op.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", op.UserID, err)
+	}
+
+	p, err := u.policyRepo.GetByRole(ctx, usr.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy: %w", err)
+	}
+
+	now := u.now()
+
+	allow := func(usage entities.PolicyUsage) error {
+		c := Check{Policy: *p, User: *usr, Op: op, Usage: usage, Now: now}
+		for _, rule := range u.rules {
+			if err := rule(c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// Расходы не бывают отрицательными, поэтому то, что нарушено без них, нарушено и с ними
+	if err := allow(entities.PolicyUsage{}); err != nil {
+		return nil, err
+	}
+
+	return &policy.Guard{
+		UserID:        op.UserID,
+		DayStart:      now.Add(-24 * time.Hour),
+		WeekStart:     now.Add(-7 * 24 * time.Hour),
+		VelocityStart: now.Add(-p.VelocityWindow),
+		Allow:         allow,
+	}, nil
+}
+
+func (u *useCase) List(ctx context.Context) ([]entities.TransferPolicy, error) {
+	policies, err := u.policyRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+func (u *useCase) Set(ctx context.Context, p entities.TransferPolicy) (*entities.TransferPolicy, error) {
+	if p.Role != entities.RoleEmployee && p.Role != entities.RoleAdmin {
+		return nil, fmt.Errorf("unknown role: %q", p.Role)
+	}
+
+	if p.DailyLimit < 0 || p.WeeklyLimit < 0 || p.MaxTransfer < 0 || p.MinAccountAge < 0 ||
+		p.VelocityCount < 0 || p.VelocityWindow < 0 {
+		return nil, fmt.Errorf("limits must not be negative")
+	}
+
+	if (p.VelocityCount == 0) != (p.VelocityWindow == 0) {
+		return nil, fmt.Errorf("velocity count and window must be set together")
+	}
+
+	saved, err := u.policyRepo.Upsert(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save policy: %w", err)
+	}
+
+	return saved, nil
+}
+
+func (u *useCase) Delete(ctx context.Context, role string) error {
+	if err := u.policyRepo.Delete(ctx, role); err != nil {
+		return fmt.Errorf("failed to delete policy: %w", err)
+	}
+
+	return nil
+}
+
+// MinAccountAge запрещает переводы с только что созданных аккаунтов. Покупки не ограничивает
+func MinAccountAge(c Check) error {
+	if c.Op.Kind != KindTransfer || c.Policy.MinAccountAge == 0 {
+		return nil
+	}
+
+	if age := c.Now.Sub(c.User.CreatedAt); age < c.Policy.MinAccountAge {
+		return &Violation{
+			Code:    CodeAccountTooNew,
+			Message: fmt.Sprintf("account must be at least %s old to transfer coins", c.Policy.MinAccountAge),
+		}
+	}
+
+	return nil
+}
+
+// MaxTransfer ограничивает каждый отдельный перевод, в том числе внутри пакета
+func MaxTransfer(c Check) error {
+	if c.Op.Kind != KindTransfer || c.Policy.MaxTransfer == 0 {
+		return nil
+	}
+
+	for _, amount := range c.Op.Amounts {
+		if amount > c.Policy.MaxTransfer {
+			return &Violation{
+				Code:    CodeMaxTransfer,
+				Message: fmt.Sprintf("transfer of %d exceeds max %d", amount, c.Policy.MaxTransfer),
+			}
+		}
+	}
+
+	return nil
+}
+
+// Velocity ограничивает число исходящих операций в скользящем окне
+func Velocity(c Check) error {
+	if c.Policy.VelocityCount == 0 {
+		return nil
+	}
+
+	if c.Usage.Operations+len(c.Op.Amounts) > c.Policy.VelocityCount {
+		return &Violation{
+			Code: CodeVelocity,
+			Message: fmt.Sprintf("more than %d outgoing operations in %s",
+				c.Policy.VelocityCount, c.Policy.VelocityWindow),
+		}
+	}
+
+	return nil
+}
+
+// DailyLimit ограничивает исходящие монеты за последние 24 часа
+func DailyLimit(c Check) error {
+	if c.Policy.DailyLimit == 0 {
+		return nil
+	}
+
+	if spent := c.Usage.Day + c.Op.Total(); spent > c.Policy.DailyLimit {
+		return &Violation{
+			Code:    CodeDailyLimit,
+			Message: fmt.Sprintf("daily limit %d exceeded: already spent %d", c.Policy.DailyLimit, c.Usage.Day),
+		}
+	}
+
+	return nil
+}
+
+// WeeklyLimit ограничивает исходящие монеты за последние 7 дней
+func WeeklyLimit(c Check) error {
+	if c.Policy.WeeklyLimit == 0 {
+		return nil
+	}
+
+	if spent := c.Usage.Week + c.Op.Total(); spent > c.Policy.WeeklyLimit {
+		return &Violation{
+			Code:    CodeWeeklyLimit,
+			Message: fmt.Sprintf("weekly limit %d exceeded: already spent %d", c.Policy.WeeklyLimit, c.Usage.Week),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package policy_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/usecase/policy"
+)
+
+type mockRepos struct {
+	GetByRoleFunc func(ctx context.Context, role string) (*entity.TransferPolicy, error)
+	UsageFunc     func(ctx context.Context, userID int, dayStart, weekStart, velocityStart time.Time) (*entity.PolicyUsage, error)
+	UpsertFunc    func(ctx context.Context, p entity.TransferPolicy) (*entity.TransferPolicy, error)
+}
+
+func (m *mockRepos) GetByRole(ctx context.Context, role string) (*entity.TransferPolicy, error) {
+	return m.GetByRoleFunc(ctx, role)
+}
+
+func (m *mockRepos) List(ctx context.Context) ([]entity.TransferPolicy, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) Upsert(ctx context.Context, p entity.TransferPolicy) (*entity.TransferPolicy, error) {
+	return m.UpsertFunc(ctx, p)
+}
+
+func (m *mockRepos) Delete(ctx context.Context, role string) error {
+	return nil
+}
+
+func (m *mockRepos) Usage(ctx context.Context, userID int, dayStart, weekStart, velocityStart time.Time) (*entity.PolicyUsage, error) {
+	return m.UsageFunc(ctx, userID, dayStart, weekStart, velocityStart)
+}
+
+type mockUserRepo struct {
+	user entity.User
+}
+
+func (m *mockUserRepo) CreateUser(ctx context.Context, username string, password string) (*entity.User, error) {
+	return nil, nil
+}
+
+func (m *mockUserRepo) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	return &m.user, nil
+}
+
+func (m *mockUserRepo) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	return nil, nil
+}
+
+func newUseCase(p *entity.TransferPolicy, usage entity.PolicyUsage, createdAt time.Time) policy.UseCase {
+	repo := &mockRepos{
+		GetByRoleFunc: func(ctx context.Context, role string) (*entity.TransferPolicy, error) {
+			if p == nil {
+				return nil, fmt.Errorf("get policy: %w", sql.ErrNoRows)
+			}
+			return p, nil
+		},
+		UsageFunc: func(ctx context.Context, userID int, dayStart, weekStart, velocityStart time.Time) (*entity.PolicyUsage, error) {
+			return &usage, nil
+		},
+	}
+
+	return policy.NewUseCase(repo, &mockUserRepo{user: entity.User{ID: 1, Role: entity.RoleEmployee, CreatedAt: createdAt}})
+}
+
+func transfer(amounts ...int) policy.Operation {
+	return policy.Operation{Kind: policy.KindTransfer, UserID: 1, Amounts: amounts}
+}
+
+func violationCode(err error) string {
+	var violation *policy.Violation
+	if errors.As(err, &violation) {
+		return violation.Code
+	}
+
+	return ""
+}
+
+func TestCheck_NoPolicy(t *testing.T) {
+	uc := newUseCase(nil, entity.PolicyUsage{}, time.Now())
+
+	assert.NoError(t, uc.Check(context.Background(), transfer(10000)))
+}
+
+func TestCheck_MaxTransferInBatch(t *testing.T) {
+	uc := newUseCase(&entity.TransferPolicy{MaxTransfer: 100}, entity.PolicyUsage{}, time.Now().Add(-time.Hour))
+
+	assert.NoError(t, uc.Check(context.Background(), transfer(100, 50)))
+	assert.Equal(t, policy.CodeMaxTransfer, violationCode(uc.Check(context.Background(), transfer(50, 101))))
+}
+
+func TestCheck_DailyLimitCountsUsage(t *testing.T) {
+	uc := newUseCase(&entity.TransferPolicy{DailyLimit: 300}, entity.PolicyUsage{Day: 250}, time.Now())
+
+	assert.NoError(t, uc.Check(context.Background(), transfer(50)))
+	assert.Equal(t, policy.CodeDailyLimit, violationCode(uc.Check(context.Background(), transfer(51))))
+}
+
+func TestCheck_WeeklyLimitAppliesToPurchases(t *testing.T) {
+	uc := newUseCase(&entity.TransferPolicy{WeeklyLimit: 1000}, entity.PolicyUsage{Week: 900}, time.Now())
+	err := uc.Check(context.Background(), policy.Operation{Kind: policy.KindPurchase, UserID: 1, Amounts: []int{200}})
+
+	assert.Equal(t, policy.CodeWeeklyLimit, violationCode(err))
+}
+
+func TestCheck_AccountTooNew(t *testing.T) {
+	uc := newUseCase(&entity.TransferPolicy{MinAccountAge: 24 * time.Hour}, entity.PolicyUsage{}, time.Now().Add(-time.Hour))
+
+	assert.Equal(t, policy.CodeAccountTooNew, violationCode(uc.Check(context.Background(), transfer(10))))
+
+	// Покупки новым сотрудникам доступны сразу
+	purchase := policy.Operation{Kind: policy.KindPurchase, UserID: 1, Amounts: []int{10}}
+	assert.NoError(t, uc.Check(context.Background(), purchase))
+}
+
+func TestCheck_Velocity(t *testing.T) {
+	p := &entity.TransferPolicy{VelocityCount: 5, VelocityWindow: time.Minute}
+	uc := newUseCase(p, entity.PolicyUsage{Operations: 4}, time.Now())
+
+	assert.NoError(t, uc.Check(context.Background(), transfer(1)))
+	assert.Equal(t, policy.CodeVelocity, violationCode(uc.Check(context.Background(), transfer(1, 1))))
+}
+
+func TestSet_Validation(t *testing.T) {
+	repo := &mockRepos{
+		UpsertFunc: func(ctx context.Context, p entity.TransferPolicy) (*entity.TransferPolicy, error) {
+			return &p, nil
+		},
+	}
+	uc := policy.NewUseCase(repo, &mockUserRepo{})
+
+	_, err := uc.Set(context.Background(), entity.TransferPolicy{Role: "intern"})
+	assert.Error(t, err)
+
+	_, err = uc.Set(context.Background(), entity.TransferPolicy{Role: entity.RoleEmployee, DailyLimit: -1})
+	assert.Error(t, err)
+
+	_, err = uc.Set(context.Background(), entity.TransferPolicy{Role: entity.RoleEmployee, VelocityCount: 3})
+	assert.Error(t, err)
+
+	p, err := uc.Set(context.Background(), entity.TransferPolicy{Role: entity.RoleEmployee, DailyLimit: 500})
+	assert.NoError(t, err)
+	assert.Equal(t, 500, p.DailyLimit)
+}
+
+// правила без расходов проверяются сразу, расходы проверяет Allow в транзакции списания
+func TestGuard(t *testing.T) {
+	uc := newUseCase(&entity.TransferPolicy{DailyLimit: 300}, entity.PolicyUsage{}, time.Now())
+
+	_, err := uc.Guard(context.Background(), transfer(301))
+	assert.Equal(t, policy.CodeDailyLimit, violationCode(err))
+
+	guard, err := uc.Guard(context.Background(), transfer(50))
+	assert.NoError(t, err)
+	assert.NoError(t, guard.Allow(entity.PolicyUsage{Day: 250}))
+	assert.Equal(t, policy.CodeDailyLimit, violationCode(guard.Allow(entity.PolicyUsage{Day: 251})))
+
+	guard, err = newUseCase(nil, entity.PolicyUsage{}, time.Now()).Guard(context.Background(), transfer(10000))
+	assert.NoError(t, err)
+	assert.Nil(t, guard)
+}
@@ -0,0 +1,173 @@
+package promo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/pricing"
+	"merchshop/internal/repository/merch"
+	"merchshop/internal/repository/promo"
+)
+
+const maxSaleNameLength = 100
+
+var codePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,40}$`)
+
+type UseCase interface {
+	// SavePromo создает промокод или меняет условия существующего. Нулевой StartsAt означает сейчас
+	SavePromo(ctx context.Context, p entities.PromoCode) (*entities.PromoCode, error)
+	ListPromos(ctx context.Context) ([]entities.PromoCode, error)
+	// CreateSale планирует распродажу. Нулевой StartsAt начинает ее сразу
+	CreateSale(ctx context.Context, s entities.Sale) (*entities.Sale, error)
+	ListSales(ctx context.Context) ([]entities.Sale, error)
+	DeleteSale(ctx context.Context, id int) error
+}
+
+type useCase struct {
+	promoRepo promo.Repository
+	merchRepo merch.Repository
+	now       func() time.Time
+}
+
+func NewUseCase(promoRepo promo.Repository, merchRepo merch.Repository) UseCase {
+	return &useCase{
+		promoRepo: promoRepo,
+		merchRepo: merchRepo,
+		now:       time.Now,
+	}
+}
+
+func (u *useCase) SavePromo(ctx context.Context, p entities.PromoCode) (*entities.PromoCode, error) {
+	p.Code = pricing.NormalizeCode(p.Code)
+	if !codePattern.MatchString(p.Code) {
+		return nil, fmt.Errorf("invalid promo code %q: use 3-40 letters, digits, '-' or '_'", p.Code)
+	}
+
+	switch p.Kind {
+	case entities.PromoPercent:
+		if p.Value <= 0 || p.Value > 100 {
+			return nil, fmt.Errorf("invalid percent: %d", p.Value)
+		}
+	case entities.PromoFixed:
+		if p.Value <= 0 {
+			return nil, fmt.Errorf("invalid amount: %d", p.Value)
+		}
+	default:
+		return nil, fmt.Errorf("invalid promo kind: %q", p.Kind)
+	}
+
+	if p.MaxUses < 0 || p.PerUserLimit < 0 {
+		return nil, fmt.Errorf("usage limits must not be negative")
+	}
+
+	if p.StartsAt.IsZero() {
+		p.StartsAt = u.now()
+	}
+
+	if p.EndsAt != nil && !p.EndsAt.After(p.StartsAt) {
+		return nil, fmt.Errorf("promo code must end after it starts")
+	}
+
+	items, err := u.validateItems(ctx, p.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Items = items
+
+	if err := u.promoRepo.SavePromo(ctx, p); err != nil {
+		return nil, fmt.Errorf("failed to save promo code: %w", err)
+	}
+
+	saved, err := u.promoRepo.GetPromo(ctx, p.Code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo code %s: %w", p.Code, err)
+	}
+
+	return saved, nil
+}
+
+func (u *useCase) ListPromos(ctx context.Context) ([]entities.PromoCode, error) {
+	promos, err := u.promoRepo.ListPromos(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list promo codes: %w", err)
+	}
+
+	return promos, nil
+}
+
+func (u *useCase) CreateSale(ctx context.Context, s entities.Sale) (*entities.Sale, error) {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" || len([]rune(s.Name)) > maxSaleNameLength {
+		return nil, fmt.Errorf("sale name must be 1-%d characters", maxSaleNameLength)
+	}
+
+	if s.Percent <= 0 || s.Percent >= 100 {
+		return nil, fmt.Errorf("invalid percent: %d", s.Percent)
+	}
+
+	if s.StartsAt.IsZero() {
+		s.StartsAt = u.now()
+	}
+
+	if !s.EndsAt.After(s.StartsAt) {
+		return nil, fmt.Errorf("sale must end after it starts")
+	}
+
+	items, err := u.validateItems(ctx, s.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Items = items
+
+	created, err := u.promoRepo.CreateSale(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sale: %w", err)
+	}
+
+	return created, nil
+}
+
+func (u *useCase) ListSales(ctx context.Context) ([]entities.Sale, error) {
+	sales, err := u.promoRepo.ListSales(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sales: %w", err)
+	}
+
+	return sales, nil
+}
+
+func (u *useCase) DeleteSale(ctx context.Context, id int) error {
+	if err := u.promoRepo.DeleteSale(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete sale %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// validateItems проверяет, что товары из списка есть в каталоге, и убирает повторы
+func (u *useCase) validateItems(ctx context.Context, items []string) ([]string, error) {
+	result := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if seen[item] {
+			continue
+		}
+
+		if _, err := u.merchRepo.GetByName(ctx, item); err != nil {
+			return nil, fmt.Errorf("unknown merchandise %q: %w", item, err)
+		}
+
+		seen[item] = true
+		result = append(result, item)
+	}
+
+	return result, nil
+}
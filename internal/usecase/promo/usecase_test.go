@@ -0,0 +1,151 @@
+package promo_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/usecase/promo"
+)
+
+type mockRepos struct {
+	saved *entity.PromoCode
+	sale  *entity.Sale
+
+	items map[string]bool
+}
+
+func (m *mockRepos) SavePromo(ctx context.Context, p entity.PromoCode) error {
+	m.saved = &p
+	return nil
+}
+
+func (m *mockRepos) GetPromo(ctx context.Context, code string) (*entity.PromoCode, error) {
+	if m.saved == nil || m.saved.Code != code {
+		return nil, sql.ErrNoRows
+	}
+
+	return m.saved, nil
+}
+
+func (m *mockRepos) ListPromos(ctx context.Context) ([]entity.PromoCode, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) CountUserUses(ctx context.Context, code string, userID int) (int, error) {
+	return 0, nil
+}
+
+func (m *mockRepos) CreateSale(ctx context.Context, s entity.Sale) (*entity.Sale, error) {
+	s.ID = 1
+	m.sale = &s
+
+	return m.sale, nil
+}
+
+func (m *mockRepos) ListSales(ctx context.Context) ([]entity.Sale, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) DeleteSale(ctx context.Context, id int) error {
+	return nil
+}
+
+func (m *mockRepos) ActiveSale(ctx context.Context, item string, at time.Time) (*entity.Sale, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockRepos) GetByName(ctx context.Context, name string) (*entity.Merchandise, error) {
+	if !m.items[name] {
+		return nil, sql.ErrNoRows
+	}
+
+	return &entity.Merchandise{Name: name}, nil
+}
+
+func (m *mockRepos) List(ctx context.Context) ([]entity.Merchandise, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) Restock(ctx context.Context, name string, quantity int, threshold *int) (*entity.Merchandise, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) GetVariant(ctx context.Context, sku string) (*entity.Variant, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) SaveVariant(ctx context.Context, v entity.Variant) error {
+	return nil
+}
+
+func (m *mockRepos) RestockVariant(ctx context.Context, merchName, sku string, quantity int, threshold *int) (*entity.Variant, error) {
+	return nil, nil
+}
+
+func TestSavePromo_Normalizes(t *testing.T) {
+	repo := &mockRepos{items: map[string]bool{"cup": true}}
+	useCase := promo.NewUseCase(repo, repo)
+
+	p, err := useCase.SavePromo(context.Background(), entity.PromoCode{
+		Code:   " spring-24 ",
+		Kind:   entity.PromoPercent,
+		Value:  15,
+		Items:  []string{"cup", " cup"},
+		Active: true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "SPRING-24", p.Code)
+	assert.Equal(t, []string{"cup"}, p.Items)
+	assert.False(t, p.StartsAt.IsZero())
+}
+
+func TestSavePromo_Invalid(t *testing.T) {
+	repo := &mockRepos{items: map[string]bool{"cup": true}}
+	useCase := promo.NewUseCase(repo, repo)
+	past := time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		name  string
+		promo entity.PromoCode
+		err   string
+	}{
+		{"short code", entity.PromoCode{Code: "ab", Kind: entity.PromoFixed, Value: 10}, `invalid promo code "AB": use 3-40 letters, digits, '-' or '_'`},
+		{"percent over 100", entity.PromoCode{Code: "BIG", Kind: entity.PromoPercent, Value: 120}, "invalid percent: 120"},
+		{"unknown kind", entity.PromoCode{Code: "BIG", Kind: "bogo", Value: 1}, `invalid promo kind: "bogo"`},
+		{"ended", entity.PromoCode{Code: "OLD", Kind: entity.PromoFixed, Value: 10, EndsAt: &past}, "promo code must end after it starts"},
+		{"negative limit", entity.PromoCode{Code: "NEG", Kind: entity.PromoFixed, Value: 10, MaxUses: -1}, "usage limits must not be negative"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := useCase.SavePromo(context.Background(), tc.promo)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+
+	assert.Nil(t, repo.saved)
+}
+
+func TestCreateSale_UnknownItem(t *testing.T) {
+	repo := &mockRepos{items: map[string]bool{"cup": true}}
+	useCase := promo.NewUseCase(repo, repo)
+
+	_, err := useCase.CreateSale(context.Background(), entity.Sale{
+		Name:    "Black Friday",
+		Percent: 30,
+		Items:   []string{"cup", "yacht"},
+		EndsAt:  time.Now().Add(24 * time.Hour),
+	})
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Nil(t, repo.sale)
+
+	_, err = useCase.CreateSale(context.Background(), entity.Sale{Name: "Black Friday", Percent: 100, EndsAt: time.Now().Add(time.Hour)})
+	assert.EqualError(t, err, "invalid percent: 100")
+}
@@ -2,30 +2,75 @@ package purchase
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
+	"merchshop/internal/eligibility"
 	entities "merchshop/internal/entity"
+	"merchshop/internal/event"
+	"merchshop/internal/pricing"
 	"merchshop/internal/repository/merch"
+	"merchshop/internal/repository/promo"
 	"merchshop/internal/repository/purchase"
 	"merchshop/internal/repository/user"
+	"merchshop/internal/usecase/notification"
+	"merchshop/internal/usecase/policy"
+	"merchshop/internal/usecase/transaction"
+)
+
+var (
+	// ErrOutOfStock товар закончился на складе
+	ErrOutOfStock = purchase.ErrOutOfStock
+	// ErrInvalidPromo промокод не существует или не подходит к покупке
+	ErrInvalidPromo = pricing.ErrInvalidPromo
+	// ErrNotEligible покупатель не проходит ограничения товара: лимит, роль, отдел, стаж или окно продаж
+	ErrNotEligible = eligibility.ErrNotEligible
 )
 
 type UseCase interface {
-	Purchase(ctx context.Context, userID, quantity int, merchName string) error
+	// Purchase покупает товар по артикулу варианта или по названию, если у товара нет вариантов.
+	// Пустой promoCode покупает без промокода
+	Purchase(ctx context.Context, userID, quantity int, item, promoCode string) error
+	// Gift покупает товар за счет buyerID в инвентарь recipientID. Сообщение видно в истории обоих
+	Gift(ctx context.Context, buyerID, recipientID, quantity int, item, promoCode, message string) error
+	// Quote считает цену покупки со скидками, ничего не списывая
+	Quote(ctx context.Context, userID, quantity int, item, promoCode string) (*pricing.Quote, error)
 	GetUserPurchases(ctx context.Context, userID int) ([]entities.Purchase, error)
+	// GetSentGifts возвращает подарки, оплаченные пользователем
+	GetSentGifts(ctx context.Context, userID int) ([]entities.Purchase, error)
 }
 
 type useCase struct {
 	purchaseRepo purchase.Repository
 	userRepo     user.Repository
 	merchRepo    merch.Repository
+	promoRepo    promo.Repository
+	policies     policy.Checker
+	events       event.Publisher
+	notifier     notification.Notifier
+	now          func() time.Time
 }
 
-func NewUseCase(purchaseRepo purchase.Repository, userRepo user.Repository, merchRepo merch.Repository) UseCase {
+func NewUseCase(
+	purchaseRepo purchase.Repository,
+	userRepo user.Repository,
+	merchRepo merch.Repository,
+	promoRepo promo.Repository,
+	policies policy.Checker,
+	events event.Publisher,
+	notifier notification.Notifier,
+) UseCase {
 	return &useCase{
 		purchaseRepo: purchaseRepo,
 		userRepo:     userRepo,
 		merchRepo:    merchRepo,
+		promoRepo:    promoRepo,
+		policies:     policies,
+		events:       events,
+		notifier:     notifier,
+		now:          time.Now,
 	}
 }
 
@@ -43,30 +88,219 @@ func (u *useCase) GetUserPurchases(ctx context.Context, userID int) ([]entities.
 	return purchases, nil
 }
 
-func (u *useCase) Purchase(ctx context.Context, userID, quantity int, merchName string) error {
+func (u *useCase) GetSentGifts(ctx context.Context, userID int) ([]entities.Purchase, error) {
+	gifts, err := u.purchaseRepo.GetGiftsSent(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sent gifts: %w", err)
+	}
+
+	return gifts, nil
+}
+
+func (u *useCase) Purchase(ctx context.Context, userID, quantity int, item, promoCode string) error {
 
-	user, err := u.userRepo.GetByID(ctx, userID)
+	buyer, err := u.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user %d: %w", userID, err)
 	}
 
-	merch, err := u.merchRepo.GetByName(ctx, merchName)
+	if err := user.CheckActive(buyer); err != nil {
+		return err
+	}
+
+	_, err = u.buy(ctx, buyer, buyer, quantity, item, promoCode, "")
+	return err
+}
+
+func (u *useCase) Gift(ctx context.Context, buyerID, recipientID, quantity int, item, promoCode, message string) error {
+	if buyerID == recipientID {
+		return fmt.Errorf("buyer and recipient are the same user: %d", buyerID)
+	}
+
+	buyer, err := u.userRepo.GetByID(ctx, buyerID)
 	if err != nil {
-		return fmt.Errorf("failed to get merchandise %s: %w", merchName, err)
+		return fmt.Errorf("failed to get user %d: %w", buyerID, err)
 	}
 
-	if quantity <= 0 {
-		return fmt.Errorf("invalid quantity: %d", quantity)
+	recipient, err := u.userRepo.GetByID(ctx, recipientID)
+	if err != nil {
+		return fmt.Errorf("failed to get recipient %d: %w", recipientID, err)
 	}
 
-	totalPrice := merch.Price * quantity
-	if user.Balance < totalPrice {
-		return fmt.Errorf("insufficient funds: have %d, need %d", user.Balance, totalPrice)
+	if err := user.CheckActive(buyer); err != nil {
+		return err
+	}
+
+	if err := user.CheckActive(recipient); err != nil {
+		return fmt.Errorf("recipient %s: %w", recipient.Username, err)
+	}
+
+	message, err = transaction.SanitizeMemo(message)
+	if err != nil {
+		return err
 	}
 
-	if err := u.purchaseRepo.CreatePurchase(ctx, userID, merchName, quantity); err != nil {
-		return fmt.Errorf("failed to process purchase: %w", err)
+	p, err := u.buy(ctx, buyer, recipient, quantity, item, promoCode, message)
+	if err != nil {
+		return err
 	}
 
+	u.events.Publish(ctx, event.Event{
+		Type:   event.GiftReceived,
+		UserID: recipientID,
+		Data: event.Gift{
+			OrderID:  p.ID,
+			FromUser: buyer.Username,
+			Item:     p.MerchName,
+			SKU:      p.SKU,
+			Quantity: p.Quantity,
+			Message:  p.GiftMessage,
+		},
+	})
+
+	notification.Deliver(ctx, u.notifier, entities.Notification{
+		UserID:  recipientID,
+		Kind:    entities.NotifyGiftReceived,
+		Message: fmt.Sprintf("%s дарит вам %s", buyer.Username, p.MerchName),
+		Data: map[string]any{
+			"orderId": p.ID, "fromUserId": buyer.ID, "fromUser": buyer.Username, "item": p.MerchName, "sku": p.SKU, "quantity": p.Quantity,
+			"message": p.GiftMessage,
+		},
+	})
+
 	return nil
 }
+
+// buy списывает монеты с buyer и кладет товар в инвентарь recipient. Для обычной покупки это
+// один и тот же пользователь
+func (u *useCase) buy(ctx context.Context, buyer, recipient *entities.User, quantity int, item, promoCode, message string) (*entities.Purchase, error) {
+	userID := buyer.ID
+
+	variant, err := merch.Resolve(ctx, u.merchRepo, item)
+	if err != nil {
+		return nil, err
+	}
+
+	if quantity <= 0 {
+		return nil, fmt.Errorf("invalid quantity: %d", quantity)
+	}
+
+	if variant.Stock < quantity {
+		return nil, fmt.Errorf("%w: %s has %d left", ErrOutOfStock, item, variant.Stock)
+	}
+
+	promoCode = pricing.NormalizeCode(promoCode)
+
+	quote, err := u.quote(ctx, userID, variant, quantity, promoCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if buyer.Balance < quote.Total {
+		return nil, fmt.Errorf("insufficient funds: have %d, need %d", buyer.Balance, quote.Total)
+	}
+
+	guard, err := u.policies.Guard(ctx, policy.Operation{Kind: policy.KindPurchase, UserID: userID, Amounts: []int{quote.Total}})
+	if err != nil {
+		return nil, err
+	}
+
+	// Цена пересчитывается в транзакции покупки: распродажа могла закончиться, а промокод исчерпаться.
+	// Там же проверяются ограничения товара и политика, чтобы параллельные покупки не обошли лимиты
+	var (
+		p      *entities.Purchase
+		stock  int
+		toUser string
+	)
+
+	if recipient.ID == userID {
+		p, stock, err = u.purchaseRepo.CreatePurchase(ctx, userID, variant.MerchName, variant.SKU, quantity, promoCode, guard)
+	} else {
+		toUser = recipient.Username
+		p, stock, err = u.purchaseRepo.CreateGift(ctx, userID, recipient.ID, variant.MerchName, variant.SKU, quantity,
+			promoCode, message, guard)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to process purchase: %w", err)
+	}
+
+	u.events.Publish(ctx, event.Event{
+		Type:   event.PurchaseCompleted,
+		UserID: userID,
+		Data: event.Purchase{
+			Item:       variant.MerchName,
+			SKU:        variant.SKU,
+			Quantity:   quantity,
+			TotalPrice: p.TotalPrice,
+			ListPrice:  p.ListPrice,
+			Discount:   p.Discount,
+			PromoCode:  p.PromoCode,
+			ToUser:     toUser,
+		},
+	})
+
+	// Предупреждаем один раз, когда покупка опустила остаток до порога
+	if stock <= variant.LowStockThreshold && stock+quantity > variant.LowStockThreshold {
+		u.events.Publish(ctx, event.Event{
+			Type: event.MerchLowStock,
+			Data: event.LowStock{Item: item, Stock: stock, Threshold: variant.LowStockThreshold},
+		})
+	}
+
+	return p, nil
+}
+
+func (u *useCase) Quote(ctx context.Context, userID, quantity int, item, promoCode string) (*pricing.Quote, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("invalid quantity: %d", quantity)
+	}
+
+	variant, err := merch.Resolve(ctx, u.merchRepo, item)
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := u.quote(ctx, userID, variant, quantity, pricing.NormalizeCode(promoCode))
+	if err != nil {
+		return nil, err
+	}
+
+	return &quote, nil
+}
+
+// quote считает цену по распродаже, действующей сейчас, и промокоду с учетом его лимитов
+func (u *useCase) quote(ctx context.Context, userID int, variant *entities.Variant, quantity int, code string) (pricing.Quote, error) {
+	now := u.now()
+
+	sale, err := u.promoRepo.ActiveSale(ctx, variant.MerchName, now)
+	if errors.Is(err, sql.ErrNoRows) {
+		sale = nil
+	} else if err != nil {
+		return pricing.Quote{}, fmt.Errorf("failed to get active sale: %w", err)
+	}
+
+	if code == "" {
+		return pricing.Apply(variant.Price, quantity, sale, nil), nil
+	}
+
+	promo, err := u.promoRepo.GetPromo(ctx, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return pricing.Quote{}, fmt.Errorf("%w: %s not found", ErrInvalidPromo, code)
+	}
+
+	if err != nil {
+		return pricing.Quote{}, fmt.Errorf("failed to get promo code: %w", err)
+	}
+
+	userUses, err := u.promoRepo.CountUserUses(ctx, code, userID)
+	if err != nil {
+		return pricing.Quote{}, fmt.Errorf("failed to count promo code uses: %w", err)
+	}
+
+	if err := pricing.CheckPromo(*promo, variant.MerchName, userUses, now); err != nil {
+		return pricing.Quote{}, err
+	}
+
+	return pricing.Apply(variant.Price, quantity, sale, promo), nil
+}
@@ -2,6 +2,7 @@ package purchase_test
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
@@ -9,14 +10,22 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"merchshop/internal/entity"
+	"merchshop/internal/event"
+	policyRepo "merchshop/internal/repository/policy"
+	"merchshop/internal/usecase/policy"
 	"merchshop/internal/usecase/purchase"
 )
 
 type mockRepos struct {
 	GetByIDFunc        func(ctx context.Context, id int) (*entity.User, error)
 	GetByNameFunc      func(ctx context.Context, name string) (*entity.Merchandise, error)
-	CreatePurchaseFunc func(ctx context.Context, userID int, merchName string, quantity int) error
+	GetVariantFunc     func(ctx context.Context, sku string) (*entity.Variant, error)
+	CreatePurchaseFunc func(ctx context.Context, userID int, merchName, sku string, quantity int, promoCode string) (*entity.Purchase, int, error)
+	CreateGiftFunc     func(ctx context.Context, buyerID, recipientID int, merchName, sku string, quantity int, promoCode, message string) (*entity.Purchase, int, error)
 	GetByUserIdFunc    func(ctx context.Context, userID int) ([]entity.Purchase, error)
+	ActiveSaleFunc     func(ctx context.Context, item string, at time.Time) (*entity.Sale, error)
+	GetPromoFunc       func(ctx context.Context, code string) (*entity.PromoCode, error)
+	CountUserUsesFunc  func(ctx context.Context, code string, userID int) (int, error)
 }
 
 func (m *mockRepos) GetByID(ctx context.Context, id int) (*entity.User, error) {
@@ -27,8 +36,16 @@ func (m *mockRepos) GetByName(ctx context.Context, name string) (*entity.Merchan
 	return m.GetByNameFunc(ctx, name)
 }
 
-func (m *mockRepos) CreatePurchase(ctx context.Context, userID int, merchName string, quantity int) error {
-	return m.CreatePurchaseFunc(ctx, userID, merchName, quantity)
+func (m *mockRepos) CreatePurchase(ctx context.Context, userID int, merchName, sku string, quantity int, promoCode string, guard *policyRepo.Guard) (*entity.Purchase, int, error) {
+	return m.CreatePurchaseFunc(ctx, userID, merchName, sku, quantity, promoCode)
+}
+
+func (m *mockRepos) CreateGift(ctx context.Context, buyerID, recipientID int, merchName, sku string, quantity int, promoCode, message string, guard *policyRepo.Guard) (*entity.Purchase, int, error) {
+	return m.CreateGiftFunc(ctx, buyerID, recipientID, merchName, sku, quantity, promoCode, message)
+}
+
+func (m *mockRepos) GetGiftsSent(ctx context.Context, buyerID int) ([]entity.Purchase, error) {
+	return nil, nil
 }
 
 func (m *mockRepos) GetByUserId(ctx context.Context, userID int) ([]entity.Purchase, error) {
@@ -47,21 +64,128 @@ func (m *mockRepos) List(ctx context.Context) ([]entity.Merchandise, error) {
 	return nil, nil
 }
 
+func (m *mockRepos) Restock(ctx context.Context, name string, quantity int, threshold *int) (*entity.Merchandise, error) {
+	return nil, nil
+}
+
+// GetVariant без GetVariantFunc ведет себя так, будто артикула нет
+func (m *mockRepos) GetVariant(ctx context.Context, sku string) (*entity.Variant, error) {
+	if m.GetVariantFunc == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	return m.GetVariantFunc(ctx, sku)
+}
+
+func (m *mockRepos) SaveVariant(ctx context.Context, v entity.Variant) error {
+	return nil
+}
+
+func (m *mockRepos) RestockVariant(ctx context.Context, merchName, sku string, quantity int, threshold *int) (*entity.Variant, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) GetOrder(ctx context.Context, id int) (*entity.Purchase, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) ListOrders(ctx context.Context, status string, limit int) ([]entity.Purchase, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) MarkReady(ctx context.Context, id int, location string) error {
+	return nil
+}
+
+func (m *mockRepos) MarkDelivered(ctx context.Context, id int) error {
+	return nil
+}
+
+func (m *mockRepos) Cancel(ctx context.Context, id, userID int) (int, error) {
+	return 0, nil
+}
+
+func (m *mockRepos) SavePromo(ctx context.Context, p entity.PromoCode) error {
+	return nil
+}
+
+func (m *mockRepos) GetPromo(ctx context.Context, code string) (*entity.PromoCode, error) {
+	return m.GetPromoFunc(ctx, code)
+}
+
+func (m *mockRepos) ListPromos(ctx context.Context) ([]entity.PromoCode, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) CountUserUses(ctx context.Context, code string, userID int) (int, error) {
+	return m.CountUserUsesFunc(ctx, code, userID)
+}
+
+func (m *mockRepos) CreateSale(ctx context.Context, s entity.Sale) (*entity.Sale, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) ListSales(ctx context.Context) ([]entity.Sale, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) DeleteSale(ctx context.Context, id int) error {
+	return nil
+}
+
+// ActiveSale без ActiveSaleFunc ведет себя так, будто распродаж нет
+func (m *mockRepos) ActiveSale(ctx context.Context, item string, at time.Time) (*entity.Sale, error) {
+	if m.ActiveSaleFunc == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	return m.ActiveSaleFunc(ctx, item, at)
+}
+
+type recordingPublisher struct {
+	events []event.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, e event.Event) {
+	p.events = append(p.events, e)
+}
+
+type recordingNotifier struct {
+	notifications []entity.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+type mockPolicy struct {
+	CheckFunc func(ctx context.Context, op policy.Operation) error
+}
+
+func (m *mockPolicy) Guard(ctx context.Context, op policy.Operation) (*policyRepo.Guard, error) {
+	if m.CheckFunc == nil {
+		return nil, nil
+	}
+
+	return nil, m.CheckFunc(ctx, op)
+}
+
 func TestPurchase_Success(t *testing.T) {
 	mock := &mockRepos{
 		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
 			return &entity.User{ID: id, Balance: 1000}, nil
 		},
 		GetByNameFunc: func(ctx context.Context, name string) (*entity.Merchandise, error) {
-			return &entity.Merchandise{Name: name, Price: 100}, nil
+			return &entity.Merchandise{Name: name, Price: 100, Stock: 50, LowStockThreshold: 5}, nil
 		},
-		CreatePurchaseFunc: func(ctx context.Context, userID int, merchName string, quantity int) error {
-			return nil
+		CreatePurchaseFunc: func(ctx context.Context, userID int, merchName, sku string, quantity int, promoCode string) (*entity.Purchase, int, error) {
+			return &entity.Purchase{ListPrice: 200, TotalPrice: 200}, 48, nil
 		},
 	}
 
-	useCase := purchase.NewUseCase(mock, mock, mock)
-	err := useCase.Purchase(context.Background(), 1, 2, "hoody")
+	useCase := purchase.NewUseCase(mock, mock, mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := useCase.Purchase(context.Background(), 1, 2, "hoody", "")
 
 	assert.NoError(t, err)
 }
@@ -72,12 +196,12 @@ func TestPurchase_InsufficientFunds(t *testing.T) {
 			return &entity.User{ID: id, Balance: 100}, nil
 		},
 		GetByNameFunc: func(ctx context.Context, name string) (*entity.Merchandise, error) {
-			return &entity.Merchandise{Name: name, Price: 100}, nil
+			return &entity.Merchandise{Name: name, Price: 100, Stock: 50, LowStockThreshold: 5}, nil
 		},
 	}
 
-	useCase := purchase.NewUseCase(mock, mock, mock)
-	err := useCase.Purchase(context.Background(), 1, 2, "hoody")
+	useCase := purchase.NewUseCase(mock, mock, mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := useCase.Purchase(context.Background(), 1, 2, "hoody", "")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "insufficient funds")
@@ -89,17 +213,167 @@ func TestPurchase_InvalidQuantity(t *testing.T) {
 			return &entity.User{ID: id, Balance: 1000}, nil
 		},
 		GetByNameFunc: func(ctx context.Context, name string) (*entity.Merchandise, error) {
-			return &entity.Merchandise{Name: name, Price: 100}, nil
+			return &entity.Merchandise{Name: name, Price: 100, Stock: 50, LowStockThreshold: 5}, nil
 		},
 	}
 
-	useCase := purchase.NewUseCase(mock, mock, mock)
-	err := useCase.Purchase(context.Background(), 1, 0, "hoody")
+	useCase := purchase.NewUseCase(mock, mock, mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := useCase.Purchase(context.Background(), 1, 0, "hoody", "")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid quantity")
 }
 
+func TestPurchase_Variant(t *testing.T) {
+	mock := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Balance: 1000}, nil
+		},
+		GetVariantFunc: func(ctx context.Context, sku string) (*entity.Variant, error) {
+			return &entity.Variant{SKU: sku, MerchName: "hoody", Price: 500, Stock: 10}, nil
+		},
+		CreatePurchaseFunc: func(ctx context.Context, userID int, merchName, sku string, quantity int, promoCode string) (*entity.Purchase, int, error) {
+			assert.Equal(t, "hoody", merchName)
+			assert.Equal(t, "hoody-pink-m", sku)
+			return &entity.Purchase{ListPrice: 500, TotalPrice: 500}, 9, nil
+		},
+	}
+	events := &recordingPublisher{}
+
+	useCase := purchase.NewUseCase(mock, mock, mock, mock, &mockPolicy{}, events, &recordingNotifier{})
+	err := useCase.Purchase(context.Background(), 1, 1, "hoody-pink-m", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, event.Purchase{Item: "hoody", SKU: "hoody-pink-m", Quantity: 1, TotalPrice: 500, ListPrice: 500}, events.events[0].Data)
+}
+
+// Товар с вариантами по названию не покупается
+func TestPurchase_ProductWithVariants(t *testing.T) {
+	mock := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Balance: 1000}, nil
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*entity.Merchandise, error) {
+			return &entity.Merchandise{Name: name, Price: 80, Variants: []entity.Variant{{SKU: "t-shirt-s"}, {SKU: "t-shirt-m"}}}, nil
+		},
+	}
+
+	useCase := purchase.NewUseCase(mock, mock, mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := useCase.Purchase(context.Background(), 1, 1, "t-shirt", "")
+
+	assert.ErrorContains(t, err, "buy one of: t-shirt-s, t-shirt-m")
+}
+
+func TestPurchase_OutOfStock(t *testing.T) {
+	mock := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Balance: 1000}, nil
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*entity.Merchandise, error) {
+			return &entity.Merchandise{Name: name, Price: 100, Stock: 1}, nil
+		},
+	}
+
+	useCase := purchase.NewUseCase(mock, mock, mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := useCase.Purchase(context.Background(), 1, 2, "hoody", "")
+
+	assert.ErrorIs(t, err, purchase.ErrOutOfStock)
+}
+
+// Предупреждение отправляется только при переходе через порог
+func TestPurchase_LowStockAlert(t *testing.T) {
+	for _, tc := range []struct {
+		left  int
+		alert bool
+	}{
+		{left: 6, alert: false},
+		{left: 5, alert: true},
+		{left: 4, alert: true},
+		// остаток уже был на пороге до покупки
+		{left: 3, alert: false},
+	} {
+		mock := &mockRepos{
+			GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+				return &entity.User{ID: id, Balance: 1000}, nil
+			},
+			GetByNameFunc: func(ctx context.Context, name string) (*entity.Merchandise, error) {
+				return &entity.Merchandise{Name: name, Price: 100, Stock: tc.left + 2, LowStockThreshold: 5}, nil
+			},
+			CreatePurchaseFunc: func(ctx context.Context, userID int, merchName, sku string, quantity int, promoCode string) (*entity.Purchase, int, error) {
+				return &entity.Purchase{}, tc.left, nil
+			},
+		}
+		events := &recordingPublisher{}
+
+		useCase := purchase.NewUseCase(mock, mock, mock, mock, &mockPolicy{}, events, &recordingNotifier{})
+		assert.NoError(t, useCase.Purchase(context.Background(), 1, 2, "hoody", ""))
+
+		last := events.events[len(events.events)-1]
+		assert.Equal(t, tc.alert, last.Type == event.MerchLowStock, "left %d", tc.left)
+	}
+}
+
+// Баланса хватает только на цену со скидкой распродажи и промокода
+func TestPurchase_SaleAndPromo(t *testing.T) {
+	mock := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Balance: 150}, nil
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*entity.Merchandise, error) {
+			return &entity.Merchandise{Name: name, Price: 100, Stock: 50, LowStockThreshold: 5}, nil
+		},
+		ActiveSaleFunc: func(ctx context.Context, item string, at time.Time) (*entity.Sale, error) {
+			return &entity.Sale{ID: 1, Percent: 20}, nil
+		},
+		GetPromoFunc: func(ctx context.Context, code string) (*entity.PromoCode, error) {
+			return &entity.PromoCode{Code: code, Kind: entity.PromoFixed, Value: 10, Active: true}, nil
+		},
+		CountUserUsesFunc: func(ctx context.Context, code string, userID int) (int, error) {
+			return 0, nil
+		},
+		CreatePurchaseFunc: func(ctx context.Context, userID int, merchName, sku string, quantity int, promoCode string) (*entity.Purchase, int, error) {
+			assert.Equal(t, "SPRING", promoCode)
+			return &entity.Purchase{ListPrice: 200, Discount: 50, PromoCode: promoCode, TotalPrice: 150}, 48, nil
+		},
+	}
+
+	var charged []int
+	policies := &mockPolicy{CheckFunc: func(ctx context.Context, op policy.Operation) error {
+		charged = op.Amounts
+		return nil
+	}}
+
+	useCase := purchase.NewUseCase(mock, mock, mock, mock, policies, event.NewBus(), &recordingNotifier{})
+
+	q, err := useCase.Quote(context.Background(), 1, 2, "hoody", " spring ")
+	assert.NoError(t, err)
+	assert.Equal(t, 150, q.Total)
+	assert.Equal(t, 50, q.Discount)
+
+	assert.NoError(t, useCase.Purchase(context.Background(), 1, 2, "hoody", "spring"))
+	assert.Equal(t, []int{150}, charged)
+}
+
+func TestPurchase_PromoNotFound(t *testing.T) {
+	mock := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Balance: 1000}, nil
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*entity.Merchandise, error) {
+			return &entity.Merchandise{Name: name, Price: 100, Stock: 50}, nil
+		},
+		GetPromoFunc: func(ctx context.Context, code string) (*entity.PromoCode, error) {
+			return nil, sql.ErrNoRows
+		},
+	}
+
+	useCase := purchase.NewUseCase(mock, mock, mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := useCase.Purchase(context.Background(), 1, 1, "hoody", "NOPE")
+
+	assert.ErrorIs(t, err, purchase.ErrInvalidPromo)
+	assert.NotErrorIs(t, err, sql.ErrNoRows)
+}
+
 func TestGetUserPurchases_Success(t *testing.T) {
 	now := time.Now()
 
@@ -120,7 +394,7 @@ func TestGetUserPurchases_Success(t *testing.T) {
 		},
 	}
 
-	useCase := purchase.NewUseCase(mock, mock, mock)
+	useCase := purchase.NewUseCase(mock, mock, mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
 	purchases, err := useCase.GetUserPurchases(context.Background(), 1)
 
 	assert.NoError(t, err)
@@ -135,10 +409,79 @@ func TestGetUserPurchases_UserNotFound(t *testing.T) {
 		},
 	}
 
-	useCase := purchase.NewUseCase(mock, mock, mock)
+	useCase := purchase.NewUseCase(mock, mock, mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
 	purchases, err := useCase.GetUserPurchases(context.Background(), 99)
 
 	assert.Error(t, err)
 	assert.Nil(t, purchases)
 	assert.Contains(t, err.Error(), "failed to get user")
 }
+
+func TestGift_Success(t *testing.T) {
+	users := map[int]*entity.User{
+		1: {ID: 1, Username: "alice", Balance: 1000},
+		2: {ID: 2, Username: "bob"},
+	}
+
+	mock := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return users[id], nil
+		},
+		GetByNameFunc: func(ctx context.Context, name string) (*entity.Merchandise, error) {
+			return &entity.Merchandise{Name: name, Price: 300, Stock: 50, LowStockThreshold: 5}, nil
+		},
+		CreateGiftFunc: func(ctx context.Context, buyerID, recipientID int, merchName, sku string, quantity int, promoCode, message string) (*entity.Purchase, int, error) {
+			assert.Equal(t, 1, buyerID)
+			assert.Equal(t, 2, recipientID)
+			assert.Equal(t, "С днем рождения!", message)
+
+			return &entity.Purchase{
+				ID: 7, UserID: recipientID, BuyerID: buyerID, MerchName: merchName, Quantity: quantity,
+				ListPrice: 300, TotalPrice: 300, GiftMessage: message,
+			}, 49, nil
+		},
+	}
+
+	events := &recordingPublisher{}
+	notifier := &recordingNotifier{}
+	useCase := purchase.NewUseCase(mock, mock, mock, mock, &mockPolicy{}, events, notifier)
+
+	err := useCase.Gift(context.Background(), 1, 2, 1, "hoody", "", "  С днем\nрождения!")
+	assert.NoError(t, err)
+
+	assert.Len(t, events.events, 2)
+	assert.Equal(t, event.PurchaseCompleted, events.events[0].Type)
+	assert.Equal(t, 1, events.events[0].UserID)
+	assert.Equal(t, "bob", events.events[0].Data.(event.Purchase).ToUser)
+	assert.Equal(t, event.GiftReceived, events.events[1].Type)
+	assert.Equal(t, 2, events.events[1].UserID)
+	assert.Equal(t, event.Gift{OrderID: 7, FromUser: "alice", Item: "hoody", Quantity: 1, Message: "С днем рождения!"},
+		events.events[1].Data)
+
+	assert.Len(t, notifier.notifications, 1)
+	assert.Equal(t, 2, notifier.notifications[0].UserID)
+	assert.Equal(t, entity.NotifyGiftReceived, notifier.notifications[0].Kind)
+	assert.Equal(t, "alice дарит вам hoody", notifier.notifications[0].Message)
+	assert.Equal(t, 1, notifier.notifications[0].Data["fromUserId"])
+}
+
+func TestGift_Invalid(t *testing.T) {
+	users := map[int]*entity.User{
+		1: {ID: 1, Username: "alice", Balance: 1000},
+		3: {ID: 3, Username: "carol", Status: entity.UserFrozen},
+	}
+
+	mock := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return users[id], nil
+		},
+	}
+
+	useCase := purchase.NewUseCase(mock, mock, mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+
+	err := useCase.Gift(context.Background(), 1, 1, 1, "hoody", "", "")
+	assert.ErrorContains(t, err, "same user")
+
+	err = useCase.Gift(context.Background(), 1, 3, 1, "hoody", "", "")
+	assert.ErrorContains(t, err, "recipient carol")
+}
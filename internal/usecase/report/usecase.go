@@ -0,0 +1,177 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/report"
+	"merchshop/internal/sheet"
+)
+
+const (
+	KindTransactions = "transactions"
+	KindPurchases    = "purchases"
+	KindBalances     = "balances"
+)
+
+var Kinds = []string{KindTransactions, KindPurchases, KindBalances}
+
+// ErrInvalidRequest неизвестный отчет, формат или фильтр, который к отчету не применим
+var ErrInvalidRequest = errors.New("invalid report request")
+
+// Request какой отчет выгрузить и в каком формате
+type Request struct {
+	Kind   string
+	Format string
+	Filter entities.ReportFilter
+}
+
+// Validate проверяет запрос до начала выгрузки, пока клиенту еще можно вернуть ошибку
+func (r Request) Validate() error {
+	if !slices.Contains(Kinds, r.Kind) {
+		return fmt.Errorf("%w: unknown report %q", ErrInvalidRequest, r.Kind)
+	}
+
+	if err := sheet.CheckFormat(r.Format); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+
+	if r.Filter.Item != "" && r.Kind != KindPurchases {
+		return fmt.Errorf("%w: item filter applies to purchases only", ErrInvalidRequest)
+	}
+
+	if r.Filter.From != nil && r.Filter.To != nil && !r.Filter.From.Before(*r.Filter.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidRequest)
+	}
+
+	return nil
+}
+
+// ParseFilter собирает фильтр из текстовых параметров HTTP-запроса или командной строки.
+// Границы периода принимаются как дата YYYY-MM-DD или время RFC 3339, дата в to включает
+// весь день
+func ParseFilter(from, to, username, item string) (entities.ReportFilter, error) {
+	f := entities.ReportFilter{Username: username, Item: item}
+
+	var err error
+	if f.From, err = parseBound(from, false); err != nil {
+		return f, fmt.Errorf("%w: invalid from: %w", ErrInvalidRequest, err)
+	}
+
+	if f.To, err = parseBound(to, true); err != nil {
+		return f, fmt.Errorf("%w: invalid to: %w", ErrInvalidRequest, err)
+	}
+
+	return f, nil
+}
+
+func parseBound(raw string, end bool) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	if day, err := time.Parse(time.DateOnly, raw); err == nil {
+		if end {
+			day = day.AddDate(0, 0, 1)
+		}
+
+		return &day, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+type UseCase interface {
+	// Export пишет отчет в w по одной строке, первая строка заголовок. Если запрос неверный,
+	// ничего не пишет
+	Export(ctx context.Context, w io.Writer, req Request) error
+}
+
+type useCase struct {
+	reportRepo report.Repository
+}
+
+func NewUseCase(reportRepo report.Repository) UseCase {
+	return &useCase{
+		reportRepo: reportRepo,
+	}
+}
+
+func (u *useCase) Export(ctx context.Context, w io.Writer, req Request) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	out, err := sheet.NewWriter(w, req.Format)
+	if err != nil {
+		return err
+	}
+
+	switch req.Kind {
+	case KindTransactions:
+		err = u.transactions(ctx, out, req.Filter)
+	case KindPurchases:
+		err = u.purchases(ctx, out, req.Filter)
+	case KindBalances:
+		err = u.balances(ctx, out, req.Filter)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", req.Kind, err)
+	}
+
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to finish %s report: %w", req.Kind, err)
+	}
+
+	return nil
+}
+
+func (u *useCase) transactions(ctx context.Context, out sheet.Writer, f entities.ReportFilter) error {
+	if err := out.WriteRow("id", "created_at", "sender", "receiver", "amount", "batch_id", "memo"); err != nil {
+		return err
+	}
+
+	return u.reportRepo.Transactions(ctx, f, func(t entities.Transaction) error {
+		var batchID any
+		if t.BatchID != 0 {
+			batchID = t.BatchID
+		}
+
+		return out.WriteRow(t.ID, t.CreatedAt, t.SenderName, t.ReceiverName, t.Amount, batchID, t.Memo)
+	})
+}
+
+func (u *useCase) purchases(ctx context.Context, out sheet.Writer, f entities.ReportFilter) error {
+	err := out.WriteRow("id", "created_at", "user", "buyer", "item", "sku", "quantity", "list_price", "discount",
+		"promo_code", "total_price", "status", "cancelled_at")
+	if err != nil {
+		return err
+	}
+
+	return u.reportRepo.Purchases(ctx, f, func(p entities.Purchase) error {
+		return out.WriteRow(p.ID, p.CreatedAt, p.Username, p.BuyerName, p.MerchName, p.SKU, p.Quantity, p.ListPrice,
+			p.Discount, p.PromoCode, p.TotalPrice, p.Status, p.CancelledAt)
+	})
+}
+
+func (u *useCase) balances(ctx context.Context, out sheet.Writer, f entities.ReportFilter) error {
+	err := out.WriteRow("user_id", "user", "department", "status", "balance", "received", "sent", "spent")
+	if err != nil {
+		return err
+	}
+
+	return u.reportRepo.Balances(ctx, f, func(b entities.UserBalance) error {
+		return out.WriteRow(b.UserID, b.Username, b.Department, b.Status, b.Balance, b.Received, b.Sent, b.Spent)
+	})
+}
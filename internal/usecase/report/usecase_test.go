@@ -0,0 +1,99 @@
+package report_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/usecase/report"
+)
+
+type mockReportRepo struct {
+	purchases []entity.Purchase
+	filter    entity.ReportFilter
+}
+
+func (m *mockReportRepo) Transactions(ctx context.Context, f entity.ReportFilter, fn func(entity.Transaction) error) error {
+	return nil
+}
+
+func (m *mockReportRepo) Purchases(ctx context.Context, f entity.ReportFilter, fn func(entity.Purchase) error) error {
+	m.filter = f
+
+	for _, p := range m.purchases {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *mockReportRepo) Balances(ctx context.Context, f entity.ReportFilter, fn func(entity.UserBalance) error) error {
+	return nil
+}
+
+func TestExport_PurchasesCSV(t *testing.T) {
+	createdAt := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	repo := &mockReportRepo{purchases: []entity.Purchase{
+		{ID: 7, CreatedAt: createdAt, Username: "bob", BuyerName: "alice", MerchName: "cup", Quantity: 2,
+			ListPrice: 40, Discount: 10, PromoCode: "AUTUMN", TotalPrice: 30, Status: entity.OrderPlaced},
+	}}
+	useCase := report.NewUseCase(repo)
+
+	var buf bytes.Buffer
+
+	err := useCase.Export(context.Background(), &buf, report.Request{
+		Kind:   report.KindPurchases,
+		Format: "csv",
+		Filter: entity.ReportFilter{Item: "cup"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "cup", repo.filter.Item)
+	assert.Equal(t,
+		"id,created_at,user,buyer,item,sku,quantity,list_price,discount,promo_code,total_price,status,cancelled_at\n"+
+			"7,2026-10-01T09:30:00Z,bob,alice,cup,,2,40,10,AUTUMN,30,placed,\n",
+		buf.String())
+}
+
+func TestExport_InvalidRequest(t *testing.T) {
+	useCase := report.NewUseCase(&mockReportRepo{})
+
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, -1)
+
+	requests := []report.Request{
+		{Kind: "orders", Format: "csv"},
+		{Kind: report.KindPurchases, Format: "pdf"},
+		{Kind: report.KindTransactions, Format: "csv", Filter: entity.ReportFilter{Item: "cup"}},
+		{Kind: report.KindBalances, Format: "xlsx", Filter: entity.ReportFilter{From: &from, To: &to}},
+	}
+
+	for _, req := range requests {
+		var buf bytes.Buffer
+
+		err := useCase.Export(context.Background(), &buf, req)
+		assert.ErrorIs(t, err, report.ErrInvalidRequest)
+		assert.Zero(t, buf.Len())
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	f, err := report.ParseFilter("2026-09-01", "2026-09-30", "alice", "")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), *f.From)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), *f.To)
+	assert.Equal(t, "alice", f.Username)
+
+	f, err = report.ParseFilter("", "2026-09-30T12:00:00Z", "", "")
+	assert.NoError(t, err)
+	assert.Nil(t, f.From)
+	assert.Equal(t, time.Date(2026, 9, 30, 12, 0, 0, 0, time.UTC), *f.To)
+
+	_, err = report.ParseFilter("yesterday", "", "", "")
+	assert.ErrorIs(t, err, report.ErrInvalidRequest)
+}
@@ -0,0 +1,103 @@
+package rule
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/merch"
+	"merchshop/internal/repository/rule"
+)
+
+type UseCase interface {
+	// SaveRule задает ограничения на покупку товара, заменяя прежние
+	SaveRule(ctx context.Context, r entities.PurchaseRule) (*entities.PurchaseRule, error)
+	ListRules(ctx context.Context) ([]entities.PurchaseRule, error)
+	DeleteRule(ctx context.Context, item string) error
+}
+
+type useCase struct {
+	ruleRepo  rule.Repository
+	merchRepo merch.Repository
+}
+
+func NewUseCase(ruleRepo rule.Repository, merchRepo merch.Repository) UseCase {
+	return &useCase{
+		ruleRepo:  ruleRepo,
+		merchRepo: merchRepo,
+	}
+}
+
+func (u *useCase) SaveRule(ctx context.Context, r entities.PurchaseRule) (*entities.PurchaseRule, error) {
+	if r.MaxPerUser < 0 {
+		return nil, fmt.Errorf("invalid max per user: %d", r.MaxPerUser)
+	}
+
+	if r.MinAccountAge < 0 {
+		return nil, fmt.Errorf("invalid min account age: %s", r.MinAccountAge)
+	}
+
+	if r.AvailableFrom != nil && r.AvailableUntil != nil && !r.AvailableUntil.After(*r.AvailableFrom) {
+		return nil, fmt.Errorf("release window must end after it starts")
+	}
+
+	for _, role := range r.Roles {
+		if role != entities.RoleEmployee && role != entities.RoleAdmin {
+			return nil, fmt.Errorf("unknown role: %q", role)
+		}
+	}
+
+	r.Roles = dedupe(r.Roles)
+	r.Departments = dedupe(r.Departments)
+
+	if _, err := u.merchRepo.GetByName(ctx, r.Item); err != nil {
+		return nil, fmt.Errorf("failed to get merchandise by name %s: %w", r.Item, err)
+	}
+
+	if err := u.ruleRepo.SaveRule(ctx, r); err != nil {
+		return nil, fmt.Errorf("failed to save purchase rule: %w", err)
+	}
+
+	saved, err := u.ruleRepo.GetRule(ctx, r.Item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purchase rule for %s: %w", r.Item, err)
+	}
+
+	return saved, nil
+}
+
+func (u *useCase) ListRules(ctx context.Context) ([]entities.PurchaseRule, error) {
+	rules, err := u.ruleRepo.ListRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list purchase rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (u *useCase) DeleteRule(ctx context.Context, item string) error {
+	if err := u.ruleRepo.DeleteRule(ctx, item); err != nil {
+		return fmt.Errorf("failed to delete purchase rule for %s: %w", item, err)
+	}
+
+	return nil
+}
+
+// dedupe убирает пробелы по краям, пустые значения и повторы
+func dedupe(values []string) []string {
+	result := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+
+		seen[v] = true
+		result = append(result, v)
+	}
+
+	return result
+}
@@ -0,0 +1,110 @@
+package rule_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/usecase/rule"
+)
+
+type mockRepos struct {
+	saved *entity.PurchaseRule
+}
+
+func (m *mockRepos) SaveRule(ctx context.Context, r entity.PurchaseRule) error {
+	m.saved = &r
+	return nil
+}
+
+func (m *mockRepos) GetRule(ctx context.Context, item string) (*entity.PurchaseRule, error) {
+	if m.saved == nil || m.saved.Item != item {
+		return nil, sql.ErrNoRows
+	}
+
+	return m.saved, nil
+}
+
+func (m *mockRepos) ListRules(ctx context.Context) ([]entity.PurchaseRule, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) DeleteRule(ctx context.Context, item string) error {
+	return nil
+}
+
+func (m *mockRepos) GetByName(ctx context.Context, name string) (*entity.Merchandise, error) {
+	if name != "pink-hoody" {
+		return nil, sql.ErrNoRows
+	}
+
+	return &entity.Merchandise{Name: name}, nil
+}
+
+func (m *mockRepos) List(ctx context.Context) ([]entity.Merchandise, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) Restock(ctx context.Context, name string, quantity int, threshold *int) (*entity.Merchandise, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) GetVariant(ctx context.Context, sku string) (*entity.Variant, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) SaveVariant(ctx context.Context, v entity.Variant) error {
+	return nil
+}
+
+func (m *mockRepos) RestockVariant(ctx context.Context, merchName, sku string, quantity int, threshold *int) (*entity.Variant, error) {
+	return nil, nil
+}
+
+func TestSaveRule_Dedupes(t *testing.T) {
+	repo := &mockRepos{}
+	useCase := rule.NewUseCase(repo, repo)
+
+	r, err := useCase.SaveRule(context.Background(), entity.PurchaseRule{
+		Item:        "pink-hoody",
+		MaxPerUser:  1,
+		Roles:       []string{"employee", "employee"},
+		Departments: []string{" design", "design ", ""},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"employee"}, r.Roles)
+	assert.Equal(t, []string{"design"}, r.Departments)
+}
+
+func TestSaveRule_Invalid(t *testing.T) {
+	repo := &mockRepos{}
+	useCase := rule.NewUseCase(repo, repo)
+	now := time.Now()
+
+	cases := []struct {
+		name string
+		rule entity.PurchaseRule
+		err  string
+	}{
+		{"negative limit", entity.PurchaseRule{Item: "pink-hoody", MaxPerUser: -1}, "invalid max per user: -1"},
+		{"unknown role", entity.PurchaseRule{Item: "pink-hoody", Roles: []string{"intern"}}, `unknown role: "intern"`},
+		{"empty window", entity.PurchaseRule{Item: "pink-hoody", AvailableFrom: &now, AvailableUntil: &now},
+			"release window must end after it starts"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := useCase.SaveRule(context.Background(), tc.rule)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+
+	_, err := useCase.SaveRule(context.Background(), entity.PurchaseRule{Item: "yacht"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Nil(t, repo.saved)
+}
@@ -0,0 +1,292 @@
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/event"
+	"merchshop/internal/repository/schedule"
+	"merchshop/internal/repository/user"
+	"merchshop/internal/usecase/transaction"
+)
+
+const (
+	defaultBatchSize   = 50
+	defaultMaxFailures = 3
+)
+
+type Options struct {
+	BatchSize int
+	// MaxFailures неудач подряд, после которых повторяющийся перевод ставится на паузу
+	MaxFailures int
+}
+
+// Params параметры нового перевода. Без Cron перевод разовый и выполняется в RunAt,
+// с Cron RunAt задает момент, не раньше которого начнутся срабатывания
+type Params struct {
+	ToUser string
+	Amount int
+	Memo   string
+	RunAt  time.Time
+	Cron   string
+}
+
+type UseCase interface {
+	Create(ctx context.Context, senderID int, params Params) (*entities.ScheduledTransfer, error)
+	List(ctx context.Context, senderID int) ([]entities.ScheduledTransfer, error)
+	Pause(ctx context.Context, senderID, id int) error
+	Resume(ctx context.Context, senderID, id int) error
+	Cancel(ctx context.Context, senderID, id int) error
+	// RunDue выполняет наступившие срабатывания и возвращает количество выполненных
+	RunDue(ctx context.Context) (int, error)
+}
+
+type useCase struct {
+	scheduleRepo schedule.Repository
+	userRepo     user.Repository
+	transfers    transaction.UseCase
+	events       event.Publisher
+	opts         Options
+	now          func() time.Time
+}
+
+func NewUseCase(
+	scheduleRepo schedule.Repository,
+	userRepo user.Repository,
+	transfers transaction.UseCase,
+	events event.Publisher,
+	opts Options,
+) UseCase {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+
+	if opts.MaxFailures <= 0 {
+		opts.MaxFailures = defaultMaxFailures
+	}
+
+	return &useCase{
+		scheduleRepo: scheduleRepo,
+		userRepo:     userRepo,
+		transfers:    transfers,
+		events:       events,
+		opts:         opts,
+		now:          time.Now,
+	}
+}
+
+func (u *useCase) Create(ctx context.Context, senderID int, params Params) (*entities.ScheduledTransfer, error) {
+	if params.Amount <= 0 {
+		return nil, fmt.Errorf("invalid amount: %d", params.Amount)
+	}
+
+	memo, err := transaction.SanitizeMemo(params.Memo)
+	if err != nil {
+		return nil, err
+	}
+
+	receiver, err := u.userRepo.GetByUsername(ctx, params.ToUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receiver %q: %w", params.ToUser, err)
+	}
+
+	if receiver.ID == senderID {
+		return nil, fmt.Errorf("sender and receiver are the same user: %d", senderID)
+	}
+
+	now := u.now()
+
+	var nextRunAt time.Time
+
+	if params.Cron == "" {
+		if !params.RunAt.After(now) {
+			return nil, fmt.Errorf("runAt must be in the future")
+		}
+
+		nextRunAt = params.RunAt
+	} else {
+		cron, err := ParseCron(params.Cron)
+		if err != nil {
+			return nil, err
+		}
+
+		start := now
+		if params.RunAt.After(now) {
+			start = params.RunAt.Add(-time.Minute)
+		}
+
+		nextRunAt = cron.Next(start)
+	}
+
+	s, err := u.scheduleRepo.Create(ctx, entities.ScheduledTransfer{
+		SenderID:   senderID,
+		ReceiverID: receiver.ID,
+		Amount:     params.Amount,
+		Memo:       memo,
+		Cron:       params.Cron,
+		NextRunAt:  nextRunAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduled transfer: %w", err)
+	}
+
+	return s, nil
+}
+
+func (u *useCase) List(ctx context.Context, senderID int) ([]entities.ScheduledTransfer, error) {
+	schedules, err := u.scheduleRepo.ListBySender(ctx, senderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled transfers: %w", err)
+	}
+
+	return schedules, nil
+}
+
+func (u *useCase) Pause(ctx context.Context, senderID, id int) error {
+	if _, err := u.getOwn(ctx, senderID, id, entities.ScheduleActive); err != nil {
+		return err
+	}
+
+	err := u.scheduleRepo.UpdateStatus(ctx, id, []string{entities.ScheduleActive}, entities.SchedulePaused, nil)
+	if err != nil {
+		return fmt.Errorf("failed to pause scheduled transfer %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// Resume снова включает перевод. Пропущенные за время паузы срабатывания не выполняются
+func (u *useCase) Resume(ctx context.Context, senderID, id int) error {
+	s, err := u.getOwn(ctx, senderID, id, entities.SchedulePaused)
+	if err != nil {
+		return err
+	}
+
+	now := u.now()
+	next := s.NextRunAt
+
+	if s.Cron != "" {
+		cron, err := ParseCron(s.Cron)
+		if err != nil {
+			return err
+		}
+
+		next = cron.Next(now)
+	} else if next.Before(now) {
+		next = now
+	}
+
+	err = u.scheduleRepo.UpdateStatus(ctx, id, []string{entities.SchedulePaused}, entities.ScheduleActive, &next)
+	if err != nil {
+		return fmt.Errorf("failed to resume scheduled transfer %d: %w", id, err)
+	}
+
+	return nil
+}
+
+func (u *useCase) Cancel(ctx context.Context, senderID, id int) error {
+	from := []string{entities.ScheduleActive, entities.SchedulePaused}
+
+	if _, err := u.getOwn(ctx, senderID, id, from...); err != nil {
+		return err
+	}
+
+	if err := u.scheduleRepo.UpdateStatus(ctx, id, from, entities.ScheduleCancelled, nil); err != nil {
+		return fmt.Errorf("failed to cancel scheduled transfer %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// getOwn возвращает перевод отправителя в одном из статусов statuses. Чужие переводы неотличимы от несуществующих
+func (u *useCase) getOwn(ctx context.Context, senderID, id int, statuses ...string) (*entities.ScheduledTransfer, error) {
+	s, err := u.scheduleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled transfer %d: %w", id, err)
+	}
+
+	if s.SenderID != senderID {
+		return nil, fmt.Errorf("failed to get scheduled transfer %d: %w", id, sql.ErrNoRows)
+	}
+
+	if !slices.Contains(statuses, s.Status) {
+		return nil, fmt.Errorf("scheduled transfer %d is %s", id, s.Status)
+	}
+
+	return s, nil
+}
+
+func (u *useCase) RunDue(ctx context.Context) (int, error) {
+	now := u.now()
+
+	due, err := u.scheduleRepo.GetDue(ctx, now, u.opts.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due scheduled transfers: %w", err)
+	}
+
+	executed := 0
+
+	for _, s := range due {
+		var next *time.Time
+
+		if s.Cron != "" {
+			cron, err := ParseCron(s.Cron)
+			if err != nil {
+				return executed, fmt.Errorf("scheduled transfer %d: %w", s.ID, err)
+			}
+
+			// Срабатывания, пропущенные пока сервис стоял, не догоняются
+			at := cron.Next(now)
+			next = &at
+		}
+
+		runID, claimed, err := u.scheduleRepo.ClaimOccurrence(ctx, s.ID, s.NextRunAt, next)
+		if err != nil {
+			return executed, fmt.Errorf("failed to claim scheduled transfer %d: %w", s.ID, err)
+		}
+
+		if !claimed {
+			continue
+		}
+
+		executed++
+
+		status, runErr := entities.RunSucceeded, ""
+
+		if err := u.transfers.Transfer(ctx, s.SenderID, s.ReceiverID, s.Amount, s.Memo); err != nil {
+			status, runErr = entities.RunFailed, err.Error()
+			if errors.Is(err, transaction.ErrInsufficientFunds) {
+				status = entities.RunInsufficientFunds
+			}
+		}
+
+		updated, err := u.scheduleRepo.FinishRun(ctx, runID, status, runErr, u.opts.MaxFailures)
+		if err != nil {
+			return executed, err
+		}
+
+		if status != entities.RunSucceeded {
+			log.Printf("schedule: transfer %d failed: %s", s.ID, runErr)
+
+			u.events.Publish(ctx, event.Event{
+				Type:   event.ScheduleFailed,
+				UserID: s.SenderID,
+				Data: event.ScheduleFailure{
+					ScheduleID: s.ID,
+					ToUser:     s.ReceiverName,
+					Amount:     s.Amount,
+					Reason:     status,
+					Status:     updated.Status,
+				},
+			})
+		}
+	}
+
+	return executed, nil
+}
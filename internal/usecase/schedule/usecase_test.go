@@ -0,0 +1,248 @@
+package schedule_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/event"
+	"merchshop/internal/usecase/schedule"
+	"merchshop/internal/usecase/transaction"
+)
+
+type mockRepos struct {
+	CreateFunc          func(ctx context.Context, s entity.ScheduledTransfer) (*entity.ScheduledTransfer, error)
+	GetByIDFunc         func(ctx context.Context, id int) (*entity.ScheduledTransfer, error)
+	UpdateStatusFunc    func(ctx context.Context, id int, from []string, status string, next *time.Time) error
+	GetDueFunc          func(ctx context.Context, now time.Time, limit int) ([]entity.ScheduledTransfer, error)
+	ClaimOccurrenceFunc func(ctx context.Context, id int, occurrence time.Time, next *time.Time) (int, bool, error)
+	FinishRunFunc       func(ctx context.Context, runID int, status, runErr string, maxFailures int) (*entity.ScheduledTransfer, error)
+}
+
+func (m *mockRepos) Create(ctx context.Context, s entity.ScheduledTransfer) (*entity.ScheduledTransfer, error) {
+	return m.CreateFunc(ctx, s)
+}
+
+func (m *mockRepos) GetByID(ctx context.Context, id int) (*entity.ScheduledTransfer, error) {
+	return m.GetByIDFunc(ctx, id)
+}
+
+func (m *mockRepos) ListBySender(ctx context.Context, senderID int) ([]entity.ScheduledTransfer, error) {
+	return nil, nil
+}
+
+func (m *mockRepos) UpdateStatus(ctx context.Context, id int, from []string, status string, next *time.Time) error {
+	return m.UpdateStatusFunc(ctx, id, from, status, next)
+}
+
+func (m *mockRepos) GetDue(ctx context.Context, now time.Time, limit int) ([]entity.ScheduledTransfer, error) {
+	return m.GetDueFunc(ctx, now, limit)
+}
+
+func (m *mockRepos) ClaimOccurrence(ctx context.Context, id int, occurrence time.Time, next *time.Time) (int, bool, error) {
+	return m.ClaimOccurrenceFunc(ctx, id, occurrence, next)
+}
+
+func (m *mockRepos) FinishRun(ctx context.Context, runID int, status, runErr string, maxFailures int) (*entity.ScheduledTransfer, error) {
+	return m.FinishRunFunc(ctx, runID, status, runErr, maxFailures)
+}
+
+type mockUserRepo struct {
+	GetByUsernameFunc func(ctx context.Context, username string) (*entity.User, error)
+}
+
+func (m *mockUserRepo) CreateUser(ctx context.Context, username string, password string) (*entity.User, error) {
+	return nil, nil
+}
+
+func (m *mockUserRepo) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	return nil, nil
+}
+
+func (m *mockUserRepo) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	return m.GetByUsernameFunc(ctx, username)
+}
+
+type mockTransfers struct {
+	transaction.UseCase
+
+	TransferFunc func(ctx context.Context, senderID, receiverID, amount int, memo string) error
+}
+
+func (m *mockTransfers) Transfer(ctx context.Context, senderID, receiverID, amount int, memo string) error {
+	return m.TransferFunc(ctx, senderID, receiverID, amount, memo)
+}
+
+type recordingPublisher struct {
+	events []event.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, e event.Event) {
+	p.events = append(p.events, e)
+}
+
+func TestParseCron_Next(t *testing.T) {
+	from := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 10 1 * *", time.Date(2026, 11, 1, 10, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 19, 12, 45, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2026, 10, 25, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range cases {
+		cron, err := schedule.ParseCron(tc.spec)
+		if assert.NoError(t, err, tc.spec) {
+			assert.Equal(t, tc.want, cron.Next(from), tc.spec)
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "0 0 30 2 *", "*/0 * * * *", "a b c d e"} {
+		_, err := schedule.ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCreate_OneShotInPast(t *testing.T) {
+	users := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return &entity.User{ID: 2, Username: username}, nil
+		},
+	}
+
+	uc := schedule.NewUseCase(&mockRepos{}, users, &mockTransfers{}, event.NewBus(), schedule.Options{})
+	_, err := uc.Create(context.Background(), 1, schedule.Params{
+		ToUser: "bob",
+		Amount: 20,
+		RunAt:  time.Now().Add(-time.Hour),
+	})
+
+	assert.Error(t, err)
+}
+
+func TestCreate_Recurring(t *testing.T) {
+	users := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return &entity.User{ID: 2, Username: username}, nil
+		},
+	}
+
+	var created entity.ScheduledTransfer
+	repo := &mockRepos{
+		CreateFunc: func(ctx context.Context, s entity.ScheduledTransfer) (*entity.ScheduledTransfer, error) {
+			created = s
+			return &s, nil
+		},
+	}
+
+	uc := schedule.NewUseCase(repo, users, &mockTransfers{}, event.NewBus(), schedule.Options{})
+	_, err := uc.Create(context.Background(), 1, schedule.Params{ToUser: "bob", Amount: 20, Cron: "@monthly"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, created.ReceiverID)
+	assert.Equal(t, 1, created.NextRunAt.Day())
+	assert.True(t, created.NextRunAt.After(time.Now()))
+}
+
+func TestPause_ForeignSchedule(t *testing.T) {
+	repo := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.ScheduledTransfer, error) {
+			return &entity.ScheduledTransfer{ID: id, SenderID: 5, Status: entity.ScheduleActive}, nil
+		},
+	}
+
+	uc := schedule.NewUseCase(repo, &mockUserRepo{}, &mockTransfers{}, event.NewBus(), schedule.Options{})
+	err := uc.Pause(context.Background(), 1, 7)
+
+	assert.Error(t, err)
+}
+
+func TestRunDue_SkipsClaimedOccurrence(t *testing.T) {
+	repo := &mockRepos{
+		GetDueFunc: func(ctx context.Context, now time.Time, limit int) ([]entity.ScheduledTransfer, error) {
+			return []entity.ScheduledTransfer{{ID: 7, SenderID: 1, ReceiverID: 2, Amount: 20}}, nil
+		},
+		ClaimOccurrenceFunc: func(ctx context.Context, id int, occurrence time.Time, next *time.Time) (int, bool, error) {
+			return 0, false, nil
+		},
+	}
+
+	transfers := &mockTransfers{
+		TransferFunc: func(ctx context.Context, senderID, receiverID, amount int, memo string) error {
+			t.Fatal("transfer must not run for an occurrence claimed by another replica")
+			return nil
+		},
+	}
+
+	uc := schedule.NewUseCase(repo, &mockUserRepo{}, transfers, event.NewBus(), schedule.Options{})
+	executed, err := uc.RunDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, executed)
+}
+
+func TestRunDue_InsufficientFunds(t *testing.T) {
+	var finishedStatus string
+	repo := &mockRepos{
+		GetDueFunc: func(ctx context.Context, now time.Time, limit int) ([]entity.ScheduledTransfer, error) {
+			return []entity.ScheduledTransfer{
+				{ID: 7, SenderID: 1, ReceiverID: 2, ReceiverName: "bob", Amount: 20, Cron: "@monthly"},
+			}, nil
+		},
+		ClaimOccurrenceFunc: func(ctx context.Context, id int, occurrence time.Time, next *time.Time) (int, bool, error) {
+			assert.NotNil(t, next)
+			return 3, true, nil
+		},
+		FinishRunFunc: func(ctx context.Context, runID int, status, runErr string, maxFailures int) (*entity.ScheduledTransfer, error) {
+			finishedStatus = status
+			return &entity.ScheduledTransfer{ID: 7, Status: entity.SchedulePaused}, nil
+		},
+	}
+
+	transfers := &mockTransfers{
+		TransferFunc: func(ctx context.Context, senderID, receiverID, amount int, memo string) error {
+			return fmt.Errorf("%w: have 5, need 20", transaction.ErrInsufficientFunds)
+		},
+	}
+
+	pub := &recordingPublisher{}
+	uc := schedule.NewUseCase(repo, &mockUserRepo{}, transfers, pub, schedule.Options{})
+	executed, err := uc.RunDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, executed)
+	assert.Equal(t, entity.RunInsufficientFunds, finishedStatus)
+	assert.Len(t, pub.events, 1)
+	assert.Equal(t, event.ScheduleFailed, pub.events[0].Type)
+	assert.Equal(t, 1, pub.events[0].UserID)
+	assert.Equal(t, entity.SchedulePaused, pub.events[0].Data.(event.ScheduleFailure).Status)
+}
+
+func TestRunDue_ClaimError(t *testing.T) {
+	repo := &mockRepos{
+		GetDueFunc: func(ctx context.Context, now time.Time, limit int) ([]entity.ScheduledTransfer, error) {
+			return []entity.ScheduledTransfer{{ID: 7}}, nil
+		},
+		ClaimOccurrenceFunc: func(ctx context.Context, id int, occurrence time.Time, next *time.Time) (int, bool, error) {
+			return 0, false, errors.New("connection reset")
+		},
+	}
+
+	uc := schedule.NewUseCase(repo, &mockUserRepo{}, &mockTransfers{}, event.NewBus(), schedule.Options{})
+	_, err := uc.RunDue(context.Background())
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/repository/stats"
+	"merchshop/internal/repository/user"
+)
+
+const (
+	defaultDays       = 30
+	maxDays           = 365
+	topCounterparties = 5
+)
+
+// BalancePoint баланс пользователя на конец дня Day (UTC)
+type BalancePoint struct {
+	Day     time.Time
+	Balance int
+}
+
+// Stats личная статистика пользователя. Периоды те же, что у рейтингов: неделя и месяц
+// отсчитываются назад от текущего момента
+type Stats struct {
+	Totals         []entities.PeriodTotals
+	Counterparties []entities.Counterparty
+	Items          []entities.ItemSpending
+	// Balance баланс по дням от самого раннего к сегодняшнему
+	Balance []BalancePoint
+}
+
+type UseCase interface {
+	// Get возвращает статистику пользователя с балансом за последние days дней
+	Get(ctx context.Context, userID, days int) (*Stats, error)
+}
+
+type useCase struct {
+	statsRepo stats.Repository
+	userRepo  user.Repository
+	now       func() time.Time
+}
+
+func NewUseCase(statsRepo stats.Repository, userRepo user.Repository) UseCase {
+	return &useCase{
+		statsRepo: statsRepo,
+		userRepo:  userRepo,
+		now:       time.Now,
+	}
+}
+
+func (u *useCase) Get(ctx context.Context, userID, days int) (*Stats, error) {
+	if days <= 0 {
+		days = defaultDays
+	}
+
+	if days > maxDays {
+		days = maxDays
+	}
+
+	owner, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+
+	now := u.now()
+
+	totals, err := u.statsRepo.Totals(ctx, userID, now.AddDate(0, 0, -7), now.AddDate(0, 0, -30))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get totals: %w", err)
+	}
+
+	counterparties, err := u.statsRepo.Counterparties(ctx, userID, topCounterparties)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get counterparties: %w", err)
+	}
+
+	items, err := u.statsRepo.Items(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spending by item: %w", err)
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+
+	changes, err := u.statsRepo.DailyChanges(ctx, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance changes: %w", err)
+	}
+
+	return &Stats{
+		Totals:         totals,
+		Counterparties: counterparties,
+		Items:          items,
+		Balance:        balanceSeries(owner.Balance, since, days, changes),
+	}, nil
+}
+
+// balanceSeries восстанавливает баланс на конец каждого дня, откатывая изменения назад от
+// текущего баланса
+func balanceSeries(balance int, since time.Time, days int, changes []entities.BalanceChange) []BalancePoint {
+	delta := make(map[time.Time]int, len(changes))
+	for _, c := range changes {
+		delta[c.Day.UTC().Truncate(24*time.Hour)] += c.Delta
+	}
+
+	series := make([]BalancePoint, days)
+	for i := days - 1; i >= 0; i-- {
+		day := since.AddDate(0, 0, i)
+		series[i] = BalancePoint{Day: day, Balance: balance}
+		balance -= delta[day]
+	}
+
+	return series
+}
@@ -0,0 +1,85 @@
+package stats_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/usecase/stats"
+)
+
+type mockStatsRepo struct {
+	changes []entity.BalanceChange
+	since   time.Time
+}
+
+func (m *mockStatsRepo) Totals(ctx context.Context, userID int, weekSince, monthSince time.Time) ([]entity.PeriodTotals, error) {
+	return nil, nil
+}
+
+func (m *mockStatsRepo) Counterparties(ctx context.Context, userID, limit int) ([]entity.Counterparty, error) {
+	return nil, nil
+}
+
+func (m *mockStatsRepo) Items(ctx context.Context, userID int) ([]entity.ItemSpending, error) {
+	return nil, nil
+}
+
+func (m *mockStatsRepo) DailyChanges(ctx context.Context, userID int, since time.Time) ([]entity.BalanceChange, error) {
+	m.since = since
+	return m.changes, nil
+}
+
+type mockUserRepo struct {
+	user *entity.User
+}
+
+func (m *mockUserRepo) CreateUser(ctx context.Context, username string, password string) (*entity.User, error) {
+	return nil, nil
+}
+
+func (m *mockUserRepo) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	return m.user, nil
+}
+
+func (m *mockUserRepo) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	return m.user, nil
+}
+
+func TestGet_BalanceSeries(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	repo := &mockStatsRepo{changes: []entity.BalanceChange{
+		{Day: today.AddDate(0, 0, -3), Delta: -300},
+		{Day: today.AddDate(0, 0, -1), Delta: 100},
+		{Day: today, Delta: -50},
+	}}
+	useCase := stats.NewUseCase(repo, &mockUserRepo{user: &entity.User{ID: 1, Balance: 500}})
+
+	result, err := useCase.Get(context.Background(), 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, today.AddDate(0, 0, -2), repo.since)
+
+	// изменение до начала периода уже учтено в балансе первого дня
+	assert.Equal(t, []stats.BalancePoint{
+		{Day: today.AddDate(0, 0, -2), Balance: 450},
+		{Day: today.AddDate(0, 0, -1), Balance: 550},
+		{Day: today, Balance: 500},
+	}, result.Balance)
+}
+
+func TestGet_DaysLimit(t *testing.T) {
+	repo := &mockStatsRepo{}
+	useCase := stats.NewUseCase(repo, &mockUserRepo{user: &entity.User{ID: 1}})
+
+	result, err := useCase.Get(context.Background(), 1, 0)
+	assert.NoError(t, err)
+	assert.Len(t, result.Balance, 30)
+
+	result, err = useCase.Get(context.Background(), 1, 10000)
+	assert.NoError(t, err)
+	assert.Len(t, result.Balance, 365)
+}
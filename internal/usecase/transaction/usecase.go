@@ -3,39 +3,89 @@ package transaction
 import (
 	"context"
 	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	entities "merchshop/internal/entity"
+	"merchshop/internal/event"
 	"merchshop/internal/repository/transaction"
 	"merchshop/internal/repository/user"
+	"merchshop/internal/usecase/notification"
+	"merchshop/internal/usecase/policy"
 )
 
+const (
+	MaxBatchSize  = 100
+	MaxMemoLength = 140
+)
+
+// ErrInsufficientFunds не хватает монет на перевод
+var ErrInsufficientFunds = transaction.ErrInsufficientFunds
+
+// Reactions допустимые реакции получателя на перевод
+var Reactions = []string{"thanks", "👍", "❤️", "🎉", "🙏", "😊", "🔥", "👏"}
+
+// Recipient получатель пакетного перевода
+type Recipient struct {
+	Username string
+	Amount   int
+	Memo     string
+}
+
 type UseCase interface {
-	Transfer(ctx context.Context, senderID, receiverID int, amount int) error
+	Transfer(ctx context.Context, senderID, receiverID int, amount int, memo string) error
+	// TransferBatch переводит монеты нескольким получателям атомарно и возвращает id пакета
+	TransferBatch(ctx context.Context, senderID int, recipients []Recipient) (int, error)
 	GetUserTransactions(ctx context.Context, userID int) ([]entities.Transaction, error)
 	GetReceivedTransactions(ctx context.Context, userID int) ([]entities.Transaction, error)
 	GetSentTransactions(ctx context.Context, userID int) ([]entities.Transaction, error)
+	// React ставит реакцию получателя на перевод, повторный вызов заменяет ее
+	React(ctx context.Context, userID, transactionID int, reaction string) error
 }
 
 type useCase struct {
 	transactionRepo transaction.Repository
 	userRepo        user.Repository
+	policies        policy.Checker
+	events          event.Publisher
+	notifier        notification.Notifier
 }
 
-func NewUseCase(transactionRepo transaction.Repository, userRepo user.Repository) UseCase {
+func NewUseCase(
+	transactionRepo transaction.Repository,
+	userRepo user.Repository,
+	policies policy.Checker,
+	events event.Publisher,
+	notifier notification.Notifier,
+) UseCase {
 	return &useCase{
 		transactionRepo: transactionRepo,
 		userRepo:        userRepo,
+		policies:        policies,
+		events:          events,
+		notifier:        notifier,
 	}
 }
 
-func (u *useCase) Transfer(ctx context.Context, senderID, receiverID, amount int) error {
+// CoinReceived уведомление получателя о переводе. fromUserId позволяет найти уведомление при стирании отправителя
+func CoinReceived(senderID, receiverID int, data event.CoinTransfer) entities.Notification {
+	return entities.Notification{
+		UserID:  receiverID,
+		Kind:    entities.NotifyCoinReceived,
+		Message: fmt.Sprintf("Перевод от %s: %d монет", data.FromUser, data.Amount),
+		Data:    map[string]any{"fromUserId": senderID, "fromUser": data.FromUser, "amount": data.Amount, "memo": data.Memo},
+	}
+}
+
+func (u *useCase) Transfer(ctx context.Context, senderID, receiverID, amount int, memo string) error {
 
 	sender, err := u.userRepo.GetByID(ctx, senderID)
 	if err != nil {
 		return fmt.Errorf("failed to get sender %d: %w", senderID, err)
 	}
 
-	_, err = u.userRepo.GetByID(ctx, receiverID)
+	receiver, err := u.userRepo.GetByID(ctx, receiverID)
 	if err != nil {
 		return fmt.Errorf("failed to get receiver %d: %w", receiverID, err)
 	}
@@ -44,28 +94,224 @@ func (u *useCase) Transfer(ctx context.Context, senderID, receiverID, amount int
 		return fmt.Errorf("sender and receiver are the same user: %d", senderID)
 	}
 
+	if err := user.CheckActive(sender); err != nil {
+		return err
+	}
+
+	if err := user.CheckActive(receiver); err != nil {
+		return err
+	}
+
 	if amount <= 0 {
 		return fmt.Errorf("invalid amount: %d", amount)
 	}
 
+	memo, err = SanitizeMemo(memo)
+	if err != nil {
+		return err
+	}
+
 	if sender.Balance < amount {
-		return fmt.Errorf("insufficient funds: have %d, need %d", sender.Balance, amount)
+		return fmt.Errorf("%w: have %d, need %d", ErrInsufficientFunds, sender.Balance, amount)
 	}
 
-	if err := u.transactionRepo.CreateTransaction(ctx, senderID, receiverID, amount); err != nil {
+	guard, err := u.policies.Guard(ctx, policy.Operation{Kind: policy.KindTransfer, UserID: senderID, Amounts: []int{amount}})
+	if err != nil {
+		return err
+	}
+
+	if err := u.transactionRepo.CreateTransaction(ctx, senderID, receiverID, amount, memo, guard); err != nil {
 		return fmt.Errorf("failed to transfer money: %w", err)
 	}
 
+	data := event.CoinTransfer{FromUser: sender.Username, ToUser: receiver.Username, Amount: amount, Memo: memo}
+	u.events.Publish(ctx, event.Event{Type: event.CoinSent, UserID: senderID, Data: data})
+	u.events.Publish(ctx, event.Event{Type: event.CoinReceived, UserID: receiverID, Data: data})
+	notification.Deliver(ctx, u.notifier, CoinReceived(senderID, receiverID, data))
+
+	return nil
+}
+
+func (u *useCase) TransferBatch(ctx context.Context, senderID int, recipients []Recipient) (int, error) {
+	if len(recipients) == 0 || len(recipients) > MaxBatchSize {
+		return 0, fmt.Errorf("invalid batch size: %d", len(recipients))
+	}
+
+	sender, err := u.userRepo.GetByID(ctx, senderID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get sender %d: %w", senderID, err)
+	}
+
+	if err := user.CheckActive(sender); err != nil {
+		return 0, err
+	}
+
+	items := make([]entities.BatchTransferItem, 0, len(recipients))
+	names := make(map[int]string, len(recipients))
+	amounts := make([]int, 0, len(recipients))
+	total := 0
+
+	for _, r := range recipients {
+		if r.Amount <= 0 {
+			return 0, fmt.Errorf("invalid amount for %s: %d", r.Username, r.Amount)
+		}
+
+		memo, err := SanitizeMemo(r.Memo)
+		if err != nil {
+			return 0, err
+		}
+
+		receiver, err := u.userRepo.GetByUsername(ctx, r.Username)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get receiver %s: %w", r.Username, err)
+		}
+
+		if receiver.ID == senderID {
+			return 0, fmt.Errorf("sender and receiver are the same user: %d", senderID)
+		}
+
+		if err := user.CheckActive(receiver); err != nil {
+			return 0, err
+		}
+
+		if _, ok := names[receiver.ID]; ok {
+			return 0, fmt.Errorf("duplicate receiver: %s", r.Username)
+		}
+
+		names[receiver.ID] = receiver.Username
+		items = append(items, entities.BatchTransferItem{ReceiverID: receiver.ID, Amount: r.Amount, Memo: memo})
+		amounts = append(amounts, r.Amount)
+		total += r.Amount
+	}
+
+	if sender.Balance < total {
+		return 0, fmt.Errorf("%w: have %d, need %d", ErrInsufficientFunds, sender.Balance, total)
+	}
+
+	guard, err := u.policies.Guard(ctx, policy.Operation{Kind: policy.KindTransfer, UserID: senderID, Amounts: amounts})
+	if err != nil {
+		return 0, err
+	}
+
+	batchID, err := u.transactionRepo.CreateBatchTransaction(ctx, senderID, items, guard)
+	if err != nil {
+		return 0, fmt.Errorf("failed to transfer money: %w", err)
+	}
+
+	for _, item := range items {
+		data := event.CoinTransfer{
+			FromUser: sender.Username,
+			ToUser:   names[item.ReceiverID],
+			Amount:   item.Amount,
+			BatchID:  batchID,
+			Memo:     item.Memo,
+		}
+		u.events.Publish(ctx, event.Event{Type: event.CoinSent, UserID: senderID, Data: data})
+		u.events.Publish(ctx, event.Event{Type: event.CoinReceived, UserID: item.ReceiverID, Data: data})
+		notification.Deliver(ctx, u.notifier, CoinReceived(senderID, item.ReceiverID, data))
+	}
+
+	return batchID, nil
+}
+
+// SanitizeMemo убирает управляющие и невидимые символы, схлопывает пробелы и проверяет длину
+func SanitizeMemo(memo string) (string, error) {
+	if !utf8.ValidString(memo) {
+		return "", fmt.Errorf("invalid memo encoding")
+	}
+
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r), unicode.In(r, unicode.Cf) && r != '\u200d':
+			return -1
+		default:
+			return r
+		}
+	}, memo)
+
+	cleaned = strings.Join(strings.Fields(cleaned), " ")
+
+	if n := utf8.RuneCountInString(cleaned); n > MaxMemoLength {
+		return "", fmt.Errorf("memo too long: %d characters, max %d", n, MaxMemoLength)
+	}
+
+	return cleaned, nil
+}
+
+func (u *useCase) React(ctx context.Context, userID, transactionID int, reaction string) error {
+	if !isAllowedReaction(reaction) {
+		return fmt.Errorf("unsupported reaction: %q", reaction)
+	}
+
+	tx, err := u.transactionRepo.SetReaction(ctx, transactionID, userID, reaction)
+	if err != nil {
+		return fmt.Errorf("failed to react to transaction %d: %w", transactionID, err)
+	}
+
+	receiver, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get receiver %d: %w", userID, err)
+	}
+
+	u.events.Publish(ctx, event.Event{
+		Type:   event.CoinReaction,
+		UserID: tx.SenderID,
+		Data:   event.Reaction{TransactionID: tx.ID, FromUser: receiver.Username, Reaction: reaction},
+	})
+
 	return nil
 }
 
+func isAllowedReaction(reaction string) bool {
+	for _, allowed := range Reactions {
+		if reaction == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupBatches схлопывает исходящие переводы одного пакета в одну запись с суммой пакета
+func groupBatches(userID int, transactions []entities.Transaction) []entities.Transaction {
+	result := make([]entities.Transaction, 0, len(transactions))
+	batches := make(map[int]int)
+
+	for _, tx := range transactions {
+		if tx.BatchID == 0 || tx.SenderID != userID {
+			result = append(result, tx)
+			continue
+		}
+
+		idx, ok := batches[tx.BatchID]
+		if !ok {
+			batches[tx.BatchID] = len(result)
+			result = append(result, entities.Transaction{
+				ID:         tx.ID,
+				SenderID:   tx.SenderID,
+				SenderName: tx.SenderName,
+				BatchID:    tx.BatchID,
+				CreatedAt:  tx.CreatedAt,
+			})
+			idx = len(result) - 1
+		}
+
+		result[idx].Amount += tx.Amount
+		result[idx].Items = append(result[idx].Items, tx)
+	}
+
+	return result
+}
+
 func (u *useCase) GetUserTransactions(ctx context.Context, userID int) ([]entities.Transaction, error) {
 	transactions, err := u.transactionRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions for user %d: %w", userID, err)
 	}
 
-	return transactions, nil
+	return groupBatches(userID, transactions), nil
 }
 
 func (u *useCase) GetSentTransactions(ctx context.Context, userID int) ([]entities.Transaction, error) {
@@ -74,7 +320,7 @@ func (u *useCase) GetSentTransactions(ctx context.Context, userID int) ([]entiti
 		return nil, fmt.Errorf("failed to get sent transactions for user %d: %w", userID, err)
 	}
 
-	return transactions, nil
+	return groupBatches(userID, transactions), nil
 }
 
 func (u *useCase) GetReceivedTransactions(ctx context.Context, userID int) ([]entities.Transaction, error) {
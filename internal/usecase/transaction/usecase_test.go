@@ -3,29 +3,45 @@ package transaction_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"merchshop/internal/entity"
+	"merchshop/internal/event"
+	policyRepo "merchshop/internal/repository/policy"
+	"merchshop/internal/usecase/policy"
 	"merchshop/internal/usecase/transaction"
+	"merchshop/internal/usecase/user"
 )
 
 type mockRepos struct {
-	GetByIDFunc           func(ctx context.Context, id int) (*entity.User, error)
-	CreateTransactionFunc func(ctx context.Context, senderID, receiverID, amount int) error
-	GetByUserIDFunc       func(ctx context.Context, userID int) ([]entity.Transaction, error)
-	GetBySenderIDFunc     func(ctx context.Context, userID int) ([]entity.Transaction, error)
-	GetByReceiverIDFunc   func(ctx context.Context, userID int) ([]entity.Transaction, error)
+	GetByIDFunc                func(ctx context.Context, id int) (*entity.User, error)
+	GetByUsernameFunc          func(ctx context.Context, username string) (*entity.User, error)
+	CreateTransactionFunc      func(ctx context.Context, senderID, receiverID, amount int, memo string) error
+	CreateBatchTransactionFunc func(ctx context.Context, senderID int, items []entity.BatchTransferItem) (int, error)
+	GetByUserIDFunc            func(ctx context.Context, userID int) ([]entity.Transaction, error)
+	GetBySenderIDFunc          func(ctx context.Context, userID int) ([]entity.Transaction, error)
+	GetByReceiverIDFunc        func(ctx context.Context, userID int) ([]entity.Transaction, error)
+	SetReactionFunc            func(ctx context.Context, transactionID, receiverID int, reaction string) (*entity.Transaction, error)
 }
 
 func (m *mockRepos) GetByID(ctx context.Context, id int) (*entity.User, error) {
 	return m.GetByIDFunc(ctx, id)
 }
 
-func (m *mockRepos) CreateTransaction(ctx context.Context, senderID, receiverID, amount int) error {
-	return m.CreateTransactionFunc(ctx, senderID, receiverID, amount)
+func (m *mockRepos) CreateTransaction(ctx context.Context, senderID, receiverID, amount int, memo string, guard *policyRepo.Guard) error {
+	return m.CreateTransactionFunc(ctx, senderID, receiverID, amount, memo)
+}
+
+func (m *mockRepos) SetReaction(ctx context.Context, transactionID, receiverID int, reaction string) (*entity.Transaction, error) {
+	return m.SetReactionFunc(ctx, transactionID, receiverID, reaction)
+}
+
+func (m *mockRepos) CreateBatchTransaction(ctx context.Context, senderID int, items []entity.BatchTransferItem, guard *policyRepo.Guard) (int, error) {
+	return m.CreateBatchTransactionFunc(ctx, senderID, items)
 }
 
 func (m *mockRepos) GetByUserID(ctx context.Context, userID int) ([]entity.Transaction, error) {
@@ -41,28 +57,88 @@ func (m *mockRepos) GetByReceiverID(ctx context.Context, userID int) ([]entity.T
 }
 
 func (m *mockRepos) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
-	return nil, nil
+	return m.GetByUsernameFunc(ctx, username)
 }
 
 func (m *mockRepos) CreateUser(ctx context.Context, username string, password string) (*entity.User, error) {
 	return nil, nil
 }
+
+type mockPolicy struct {
+	CheckFunc func(ctx context.Context, op policy.Operation) error
+}
+
+func (m *mockPolicy) Guard(ctx context.Context, op policy.Operation) (*policyRepo.Guard, error) {
+	if m.CheckFunc == nil {
+		return nil, nil
+	}
+
+	return nil, m.CheckFunc(ctx, op)
+}
+
 func TestTransfer_Success(t *testing.T) {
 	mock := &mockRepos{
 		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
 			return &entity.User{ID: id, Balance: 1000}, nil
 		},
-		CreateTransactionFunc: func(ctx context.Context, senderID, receiverID, amount int) error {
+		CreateTransactionFunc: func(ctx context.Context, senderID, receiverID, amount int, memo string) error {
 			return nil
 		},
 	}
 
-	uc := transaction.NewUseCase(mock, mock)
-	err := uc.Transfer(context.Background(), 1, 2, 500)
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := uc.Transfer(context.Background(), 1, 2, 500, "")
 
 	assert.NoError(t, err)
 }
 
+type recordingPublisher struct {
+	events []event.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, e event.Event) {
+	p.events = append(p.events, e)
+}
+
+type recordingNotifier struct {
+	notifications []entity.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func TestTransfer_PublishesEvents(t *testing.T) {
+	mock := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Username: map[int]string{1: "alice", 2: "bob"}[id], Balance: 1000}, nil
+		},
+		CreateTransactionFunc: func(ctx context.Context, senderID, receiverID, amount int, memo string) error {
+			return nil
+		},
+	}
+
+	pub := &recordingPublisher{}
+	notifier := &recordingNotifier{}
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, pub, notifier)
+	err := uc.Transfer(context.Background(), 1, 2, 50, "")
+
+	assert.NoError(t, err)
+	assert.Len(t, pub.events, 2)
+	assert.Equal(t, event.CoinSent, pub.events[0].Type)
+	assert.Equal(t, 1, pub.events[0].UserID)
+	assert.Equal(t, event.CoinReceived, pub.events[1].Type)
+	assert.Equal(t, 2, pub.events[1].UserID)
+	assert.Equal(t, event.CoinTransfer{FromUser: "alice", ToUser: "bob", Amount: 50}, pub.events[1].Data)
+
+	assert.Len(t, notifier.notifications, 1)
+	assert.Equal(t, 2, notifier.notifications[0].UserID)
+	assert.Equal(t, entity.NotifyCoinReceived, notifier.notifications[0].Kind)
+	assert.Equal(t, "Перевод от alice: 50 монет", notifier.notifications[0].Message)
+	assert.Equal(t, 1, notifier.notifications[0].Data["fromUserId"])
+}
+
 func TestTransfer_InsufficientFunds(t *testing.T) {
 	mock := &mockRepos{
 		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
@@ -70,8 +146,8 @@ func TestTransfer_InsufficientFunds(t *testing.T) {
 		},
 	}
 
-	uc := transaction.NewUseCase(mock, mock)
-	err := uc.Transfer(context.Background(), 1, 2, 200)
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := uc.Transfer(context.Background(), 1, 2, 200, "")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "insufficient funds")
@@ -84,8 +160,8 @@ func TestTransfer_InvalidAmount(t *testing.T) {
 		},
 	}
 
-	uc := transaction.NewUseCase(mock, mock)
-	err := uc.Transfer(context.Background(), 1, 2, 0)
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := uc.Transfer(context.Background(), 1, 2, 0, "")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid amount")
@@ -98,8 +174,8 @@ func TestTransfer_SameUser(t *testing.T) {
 		},
 	}
 
-	uc := transaction.NewUseCase(mock, mock)
-	err := uc.Transfer(context.Background(), 1, 1, 100)
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := uc.Transfer(context.Background(), 1, 1, 100, "")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "sender and receiver are the same user")
@@ -117,8 +193,8 @@ func TestTransfer_UserNotFound(t *testing.T) {
 		},
 	}
 
-	uc := transaction.NewUseCase(mock, mock)
-	err := uc.Transfer(context.Background(), 1, 2, 100)
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := uc.Transfer(context.Background(), 1, 2, 100, "")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to get receiver")
@@ -134,7 +210,7 @@ func TestGetUserTransactions_Success(t *testing.T) {
 		},
 	}
 
-	uc := transaction.NewUseCase(mock, mock)
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
 	txns, err := uc.GetUserTransactions(context.Background(), 1)
 
 	assert.NoError(t, err)
@@ -149,7 +225,7 @@ func TestGetSentTransactions_Success(t *testing.T) {
 		},
 	}
 
-	uc := transaction.NewUseCase(mock, mock)
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
 	txns, err := uc.GetSentTransactions(context.Background(), 1)
 
 	assert.NoError(t, err)
@@ -163,9 +239,219 @@ func TestGetReceivedTransactions_Success(t *testing.T) {
 		},
 	}
 
-	uc := transaction.NewUseCase(mock, mock)
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
 	txns, err := uc.GetReceivedTransactions(context.Background(), 1)
 
 	assert.NoError(t, err)
 	assert.Len(t, txns, 1)
 }
+
+func batchMock(balance int) *mockRepos {
+	users := map[string]int{"alice": 1, "bob": 2, "carol": 3}
+
+	return &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Username: "alice", Balance: balance}, nil
+		},
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			id, ok := users[username]
+			if !ok {
+				return nil, errors.New("not found")
+			}
+			return &entity.User{ID: id, Username: username}, nil
+		},
+		CreateBatchTransactionFunc: func(ctx context.Context, senderID int, items []entity.BatchTransferItem) (int, error) {
+			return 9, nil
+		},
+	}
+}
+
+func TestTransferBatch_Success(t *testing.T) {
+	mock := batchMock(1000)
+
+	var stored []entity.BatchTransferItem
+	mock.CreateBatchTransactionFunc = func(ctx context.Context, senderID int, items []entity.BatchTransferItem) (int, error) {
+		stored = items
+		return 9, nil
+	}
+
+	pub := &recordingPublisher{}
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, pub, &recordingNotifier{})
+	batchID, err := uc.TransferBatch(context.Background(), 1, []transaction.Recipient{
+		{Username: "bob", Amount: 10},
+		{Username: "carol", Amount: 20},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9, batchID)
+	assert.Equal(t, []entity.BatchTransferItem{{ReceiverID: 2, Amount: 10}, {ReceiverID: 3, Amount: 20}}, stored)
+	assert.Len(t, pub.events, 4)
+}
+
+func TestTransferBatch_UnknownRecipient(t *testing.T) {
+	mock := batchMock(1000)
+
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	_, err := uc.TransferBatch(context.Background(), 1, []transaction.Recipient{
+		{Username: "bob", Amount: 10},
+		{Username: "mallory", Amount: 20},
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to get receiver mallory")
+}
+
+func TestTransferBatch_InsufficientFunds(t *testing.T) {
+	mock := batchMock(25)
+
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	_, err := uc.TransferBatch(context.Background(), 1, []transaction.Recipient{
+		{Username: "bob", Amount: 10},
+		{Username: "carol", Amount: 20},
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "insufficient funds: have 25, need 30")
+}
+
+func TestTransferBatch_DuplicateRecipient(t *testing.T) {
+	mock := batchMock(1000)
+
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	_, err := uc.TransferBatch(context.Background(), 1, []transaction.Recipient{
+		{Username: "bob", Amount: 10},
+		{Username: "bob", Amount: 20},
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate receiver")
+}
+
+func TestGetSentTransactions_GroupsBatches(t *testing.T) {
+	mock := &mockRepos{
+		GetBySenderIDFunc: func(ctx context.Context, userID int) ([]entity.Transaction, error) {
+			return []entity.Transaction{
+				{ID: 1, SenderID: userID, ReceiverName: "bob", Amount: 10, BatchID: 5},
+				{ID: 2, SenderID: userID, ReceiverName: "dave", Amount: 7},
+				{ID: 3, SenderID: userID, ReceiverName: "carol", Amount: 20, BatchID: 5},
+			}, nil
+		},
+	}
+
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	txns, err := uc.GetSentTransactions(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, txns, 2)
+	assert.Equal(t, 30, txns[0].Amount)
+	assert.Equal(t, 5, txns[0].BatchID)
+	assert.Len(t, txns[0].Items, 2)
+	assert.Equal(t, "dave", txns[1].ReceiverName)
+}
+
+func TestTransfer_PassesSanitizedMemo(t *testing.T) {
+	var got string
+	mock := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Balance: 1000}, nil
+		},
+		CreateTransactionFunc: func(ctx context.Context, senderID, receiverID, amount int, memo string) error {
+			got = memo
+			return nil
+		},
+	}
+
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := uc.Transfer(context.Background(), 1, 2, 10, "  спасибо\n\tза\u202eпомощь  ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "спасибо запомощь", got)
+}
+
+func TestTransfer_MemoTooLong(t *testing.T) {
+	mock := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Balance: 1000}, nil
+		},
+	}
+
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := uc.Transfer(context.Background(), 1, 2, 10, strings.Repeat("я", transaction.MaxMemoLength+1))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "memo")
+}
+
+func TestSanitizeMemo(t *testing.T) {
+	memo, err := transaction.SanitizeMemo("👨\u200d👩\u200d👧 за   обед")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "👨\u200d👩\u200d👧 за обед", memo)
+}
+
+func TestReact_Success(t *testing.T) {
+	mock := &mockRepos{
+		SetReactionFunc: func(ctx context.Context, transactionID, receiverID int, reaction string) (*entity.Transaction, error) {
+			return &entity.Transaction{ID: transactionID, SenderID: 1, ReceiverID: receiverID, Reaction: reaction}, nil
+		},
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Username: "bob"}, nil
+		},
+	}
+
+	pub := &recordingPublisher{}
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, pub, &recordingNotifier{})
+	err := uc.React(context.Background(), 2, 7, "🎉")
+
+	assert.NoError(t, err)
+	assert.Len(t, pub.events, 1)
+	assert.Equal(t, event.CoinReaction, pub.events[0].Type)
+	assert.Equal(t, 1, pub.events[0].UserID)
+	assert.Equal(t, event.Reaction{TransactionID: 7, FromUser: "bob", Reaction: "🎉"}, pub.events[0].Data)
+}
+
+func TestReact_UnknownReaction(t *testing.T) {
+	mock := &mockRepos{}
+
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := uc.React(context.Background(), 2, 7, "💩")
+
+	assert.Error(t, err)
+}
+
+func TestTransfer_PolicyViolation(t *testing.T) {
+	mock := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Balance: 1000}, nil
+		},
+	}
+
+	policies := &mockPolicy{
+		CheckFunc: func(ctx context.Context, op policy.Operation) error {
+			return &policy.Violation{Code: policy.CodeDailyLimit, Message: "daily limit exceeded"}
+		},
+	}
+
+	uc := transaction.NewUseCase(mock, mock, policies, event.NewBus(), &recordingNotifier{})
+	err := uc.Transfer(context.Background(), 1, 2, 100, "")
+
+	var violation *policy.Violation
+	assert.ErrorAs(t, err, &violation)
+	assert.Equal(t, policy.CodeDailyLimit, violation.Code)
+}
+
+func TestTransfer_FrozenSender(t *testing.T) {
+	mock := &mockRepos{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			if id == 1 {
+				return &entity.User{ID: id, Balance: 1000, Status: entity.UserFrozen}, nil
+			}
+			return &entity.User{ID: id, Balance: 1000, Status: entity.UserActive}, nil
+		},
+	}
+
+	uc := transaction.NewUseCase(mock, mock, &mockPolicy{}, event.NewBus(), &recordingNotifier{})
+	err := uc.Transfer(context.Background(), 1, 2, 100, "")
+
+	assert.ErrorIs(t, err, user.ErrAccountFrozen)
+}
@@ -1,25 +1,142 @@
 package usecase
 
 import (
+	"net/http"
+
+	"merchshop/internal/config"
+	"merchshop/internal/event"
 	"merchshop/internal/repository"
+	"merchshop/internal/usecase/account"
+	"merchshop/internal/usecase/auditlog"
+	"merchshop/internal/usecase/coinrequest"
+	"merchshop/internal/usecase/dataexport"
+	"merchshop/internal/usecase/escrow"
+	"merchshop/internal/usecase/fraud"
+	"merchshop/internal/usecase/leaderboard"
 	"merchshop/internal/usecase/merch"
+	"merchshop/internal/usecase/notification"
+	"merchshop/internal/usecase/order"
+	"merchshop/internal/usecase/policy"
+	"merchshop/internal/usecase/promo"
 	"merchshop/internal/usecase/purchase"
+	"merchshop/internal/usecase/report"
+	"merchshop/internal/usecase/rule"
+	"merchshop/internal/usecase/schedule"
+	"merchshop/internal/usecase/stats"
 	"merchshop/internal/usecase/transaction"
 	"merchshop/internal/usecase/user"
+	"merchshop/internal/usecase/webhook"
+	"merchshop/internal/usecase/wishlist"
 )
 
 type UseCases struct {
-	User        user.UseCase
-	Transaction transaction.UseCase
-	Purchase    purchase.UseCase
-	Merch       merch.UseCase
+	User         user.UseCase
+	Transaction  transaction.UseCase
+	Purchase     purchase.UseCase
+	Merch        merch.UseCase
+	Webhook      webhook.UseCase
+	Schedule     schedule.UseCase
+	CoinRequest  coinrequest.UseCase
+	Escrow       escrow.UseCase
+	Policy       policy.UseCase
+	Fraud        fraud.UseCase
+	Account      account.UseCase
+	Order        order.UseCase
+	Promo        promo.UseCase
+	Rule         rule.UseCase
+	Notification notification.UseCase
+	Wishlist     wishlist.UseCase
+	Leaderboard  leaderboard.UseCase
+	Stats        stats.UseCase
+	Report       report.UseCase
+	DataExport   dataexport.UseCase
+	AuditLog     auditlog.UseCase
+
+	// Events шина доменных событий, Broker раздает их клиентам этой реплики
+	Events *event.Bus
+	Broker *event.Broker
 }
 
-func NewUseCases(repos *repository.Repositories) *UseCases {
+func NewUseCases(repos *repository.Repositories, cfg *config.Config) *UseCases {
+	events := event.NewBus()
+
+	webhooks := webhook.NewUseCase(repos.Webhook, &http.Client{Timeout: cfg.Webhook.Timeout}, webhook.Options{
+		MaxAttempts: cfg.Webhook.MaxAttempts,
+		BaseBackoff: cfg.Webhook.BaseBackoff,
+		MaxBackoff:  cfg.Webhook.MaxBackoff,
+	})
+	events.Subscribe(webhooks)
+
+	// Журнал аудита получает регистрации из шины, входы от usecase пользователей. Переводы и покупки
+	// сохраняются в очередь журнала их репозиториями, шина только ускоряет перенос в цепочку
+	audits := auditlog.NewUseCase(repos.AuditLog)
+	events.Subscribe(audits)
+
+	// С бэкендом postgres брокер получает события через LISTEN/NOTIFY, его подключает main
+	broker := event.NewBroker(0)
+	if cfg.Events.Backend != config.EventsBackendPostgres {
+		events.Subscribe(broker)
+	}
+
+	// Политики проверяются перед каждым списанием монет
+	policies := policy.NewUseCase(repos.Policy, repos.User)
+
+	// Уведомления в приложении создают usecase'ы переводов, покупок и заказов
+	notifications := notification.NewUseCase(repos.Notification)
+
+	transactions := transaction.NewUseCase(repos.Transaction, repos.User, policies, events, notifications)
+
+	schedules := schedule.NewUseCase(repos.Schedule, repos.User, transactions, events, schedule.Options{
+		BatchSize:   cfg.Schedule.BatchSize,
+		MaxFailures: cfg.Schedule.MaxFailures,
+	})
+
+	// Удерживаемые переводы зачисляются по событию регистрации получателя
+	escrows := escrow.NewUseCase(repos.Escrow, repos.User, policies, events, notifications, cfg.Escrow.TTL)
+	events.Subscribe(escrows)
+
+	frauds := fraud.NewUseCase(repos.Fraud, fraud.Options{
+		Window:        cfg.Fraud.Window,
+		BurstWindow:   cfg.Fraud.BurstWindow,
+		BurstOps:      cfg.Fraud.BurstOps,
+		FunnelSenders: cfg.Fraud.FunnelSenders,
+	})
+
+	accounts := account.NewUseCase(repos.Account, repos.User, events, notifications, cfg.Offboarding.PoolUsername)
+
+	// Выгрузка личных данных кладет в архив переводы и покупки в формате отчетов
+	reports := report.NewUseCase(repos.Report)
+
+	exports := dataexport.NewUseCase(repos.DataExport, repos.User, repos.Leaderboard, repos.Notification, reports,
+		repos.AuditLog, dataexport.Options{
+			Secret:    []byte(cfg.Export.SigningKey),
+			LinkTTL:   cfg.Export.LinkTTL,
+			Retention: cfg.Export.Retention,
+		})
+
 	return &UseCases{
-		User:        user.NewUseCase(repos.User),
-		Transaction: transaction.NewUseCase(repos.Transaction, repos.User),
-		Purchase:    purchase.NewUseCase(repos.Purchase, repos.User, repos.Merch),
-		Merch:       merch.NewUseCase(repos.Merch),
+		User:         user.NewUseCase(repos.User, events, audits),
+		Transaction:  transactions,
+		Purchase:     purchase.NewUseCase(repos.Purchase, repos.User, repos.Merch, repos.Promo, policies, events, notifications),
+		Merch:        merch.NewUseCase(repos.Merch),
+		Webhook:      webhooks,
+		Schedule:     schedules,
+		CoinRequest:  coinrequest.NewUseCase(repos.CoinRequest, repos.User, policies, events, notifications, cfg.CoinRequest.TTL, cfg.CoinRequest.MaxPending),
+		Escrow:       escrows,
+		Policy:       policies,
+		Fraud:        frauds,
+		Account:      accounts,
+		Order:        order.NewUseCase(repos.Purchase, events, notifications),
+		Promo:        promo.NewUseCase(repos.Promo, repos.Merch),
+		Rule:         rule.NewUseCase(repos.Rule, repos.Merch),
+		Notification: notifications,
+		Wishlist:     wishlist.NewUseCase(repos.Wishlist, repos.User, repos.Merch, repos.Promo, notifications),
+		Leaderboard:  leaderboard.NewUseCase(repos.Leaderboard),
+		Stats:        stats.NewUseCase(repos.Stats, repos.User),
+		Report:       reports,
+		DataExport:   exports,
+		AuditLog:     audits,
+		Events:       events,
+		Broker:       broker,
 	}
 }
@@ -2,26 +2,132 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	"merchshop/internal/audit"
+	"merchshop/internal/config"
 	entities "merchshop/internal/entity"
+	"merchshop/internal/event"
 	"merchshop/internal/repository/user"
 )
 
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrTokenRevoked токен выдан до отзыва токенов пользователя
+	ErrTokenRevoked = errors.New("token revoked")
+)
+
+var (
+	// ErrAccountFrozen аккаунт заморожен после проверки на мошенничество
+	ErrAccountFrozen = user.ErrAccountFrozen
+	// ErrAccountDeactivated аккаунт деактивирован при увольнении
+	ErrAccountDeactivated = user.ErrAccountDeactivated
+)
+
 type UseCase interface {
+	// Authenticate проверяет пароль пользователя, при первом входе создает его
+	Authenticate(ctx context.Context, username string, password string) (*entities.User, error)
 	Register(ctx context.Context, username string, password string) (*entities.User, error)
+	// Authorize проверяет, что токен, выданный в issuedAt, еще действует. Замороженный аккаунт проходит проверку
+	Authorize(ctx context.Context, userID int, issuedAt time.Time) error
 	GetByID(ctx context.Context, id int) (*entities.User, error)
 	GetByUsername(ctx context.Context, username string) (*entities.User, error)
 }
 
 type useCase struct {
 	userRepo user.Repository
+	events   event.Publisher
+	audit    audit.Recorder
 }
 
-func NewUseCase(userRepo user.Repository) UseCase {
+// NewUseCase recorder получает входы и неудачные попытки входа
+func NewUseCase(userRepo user.Repository, events event.Publisher, recorder audit.Recorder) UseCase {
 	return &useCase{
 		userRepo: userRepo,
+		events:   events,
+		audit:    recorder,
+	}
+}
+
+func (u *useCase) Authenticate(ctx context.Context, username string, password string) (*entities.User, error) {
+	user, err := u.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		// Такие имена получают стертые пользователи. Попытка под несуществующим именем в журнал
+		// не пишется: строка логина может оказаться чужими данными, а журнал не стирается
+		if strings.HasPrefix(username, entities.DeletedUsername) {
+			return nil, ErrInvalidCredentials
+		}
+
+		hashedPassword, err := config.HashPassword(password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+
+		user, err := u.Register(ctx, username, hashedPassword)
+		if err != nil {
+			return nil, err
+		}
+
+		u.recordLogin(ctx, user.ID)
+
+		return user, nil
+	}
+
+	if !config.ComparePasswords(user.Password, password) {
+		u.recordFailure(ctx, user.ID, "invalid credentials")
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.Status == entities.UserDeactivated {
+		u.recordFailure(ctx, user.ID, "account deactivated")
+		return nil, fmt.Errorf("%w: %s", ErrAccountDeactivated, user.Username)
 	}
+
+	u.recordLogin(ctx, user.ID)
+
+	return user, nil
+}
+
+func (u *useCase) recordLogin(ctx context.Context, userID int) {
+	u.audit.Record(ctx, entities.AuditEntry{
+		ActorID:    userID,
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.Itoa(userID),
+	})
+}
+
+// recordFailure записывает неудачный вход в существующий аккаунт. Ни пароль, ни введенное имя
+// в журнал не попадают
+func (u *useCase) recordFailure(ctx context.Context, userID int, reason string) {
+	u.audit.Record(ctx, entities.AuditEntry{
+		Action:     audit.ActionLoginFailed,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.Itoa(userID),
+		After:      audit.Payload(map[string]string{"reason": reason}),
+	})
+}
+
+func (u *useCase) Authorize(ctx context.Context, userID int, issuedAt time.Time) error {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user by id %d: %w", userID, err)
+	}
+
+	if user.Status == entities.UserDeactivated {
+		return fmt.Errorf("%w: %s", ErrAccountDeactivated, user.Username)
+	}
+
+	// iat в JWT хранится с точностью до секунды, поэтому токен той же секунды, что и отзыв, тоже отозван
+	if user.TokensRevokedAt != nil && issuedAt.Unix() <= user.TokensRevokedAt.Unix() {
+		return ErrTokenRevoked
+	}
+
+	return nil
 }
 
 func (u *useCase) Register(ctx context.Context, username string, password string) (*entities.User, error) {
@@ -30,6 +136,12 @@ func (u *useCase) Register(ctx context.Context, username string, password string
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	u.events.Publish(ctx, event.Event{
+		Type:   event.UserRegistered,
+		UserID: user.ID,
+		Data:   event.Registration{Username: user.Username},
+	})
+
 	return user, nil
 }
 
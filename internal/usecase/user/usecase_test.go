@@ -4,8 +4,12 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"merchshop/internal/audit"
+	"merchshop/internal/config"
 	"merchshop/internal/entity"
+	"merchshop/internal/event"
 	"merchshop/internal/usecase/user"
 
 	"github.com/stretchr/testify/assert"
@@ -36,7 +40,7 @@ func TestRegister_Success(t *testing.T) {
 		},
 	}
 
-	uc := user.NewUseCase(mockRepo)
+	uc := user.NewUseCase(mockRepo, event.NewBus(), audit.Discard)
 	user, err := uc.Register(context.Background(), "testuser", "password123")
 
 	assert.NoError(t, err)
@@ -52,7 +56,7 @@ func TestRegister_Failure(t *testing.T) {
 		},
 	}
 
-	uc := user.NewUseCase(mockRepo)
+	uc := user.NewUseCase(mockRepo, event.NewBus(), audit.Discard)
 	user, err := uc.Register(context.Background(), "testuser", "password123")
 
 	assert.Error(t, err)
@@ -67,7 +71,7 @@ func TestGetByUsername_Success(t *testing.T) {
 		},
 	}
 
-	uc := user.NewUseCase(mockRepo)
+	uc := user.NewUseCase(mockRepo, event.NewBus(), audit.Discard)
 	user, err := uc.GetByUsername(context.Background(), "testuser")
 
 	assert.NoError(t, err)
@@ -82,7 +86,7 @@ func TestGetByUsername_Failure(t *testing.T) {
 		},
 	}
 
-	uc := user.NewUseCase(mockRepo)
+	uc := user.NewUseCase(mockRepo, event.NewBus(), audit.Discard)
 	user, err := uc.GetByUsername(context.Background(), "nonexistentuser")
 
 	assert.Error(t, err)
@@ -97,7 +101,7 @@ func TestGetByID_Success(t *testing.T) {
 		},
 	}
 
-	uc := user.NewUseCase(mockRepo)
+	uc := user.NewUseCase(mockRepo, event.NewBus(), audit.Discard)
 	user, err := uc.GetByID(context.Background(), 1)
 
 	assert.NoError(t, err)
@@ -113,10 +117,175 @@ func TestGetByID_Failure(t *testing.T) {
 		},
 	}
 
-	uc := user.NewUseCase(mockRepo)
+	uc := user.NewUseCase(mockRepo, event.NewBus(), audit.Discard)
 	user, err := uc.GetByID(context.Background(), 999)
 
 	assert.Error(t, err)
 	assert.Nil(t, user)
 	assert.Contains(t, err.Error(), "failed to get user by id")
 }
+
+func TestAuthenticate_RegistersNewUser(t *testing.T) {
+	mockRepo := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return nil, errors.New("not found")
+		},
+		CreateUserFunc: func(ctx context.Context, username, password string) (*entity.User, error) {
+			return &entity.User{ID: 3, Username: username, Password: password}, nil
+		},
+	}
+
+	uc := user.NewUseCase(mockRepo, event.NewBus(), audit.Discard)
+	u, err := uc.Authenticate(context.Background(), "newbie", "secret")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, u.ID)
+	assert.NotEqual(t, "secret", u.Password)
+}
+
+// имена стертых пользователей нельзя занять при первом входе
+func TestAuthenticate_ReservedUsername(t *testing.T) {
+	mockRepo := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return nil, errors.New("not found")
+		},
+	}
+
+	recorder := &recordingAudit{}
+	uc := user.NewUseCase(mockRepo, event.NewBus(), recorder)
+	u, err := uc.Authenticate(context.Background(), "deleted user #42", "secret")
+
+	assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+	assert.Nil(t, u)
+
+	// попытка под несуществующим именем в журнал не пишется
+	assert.Empty(t, recorder.entries)
+}
+
+func TestAuthenticate_WrongPassword(t *testing.T) {
+	hashed, err := config.HashPassword("right")
+	assert.NoError(t, err)
+
+	mockRepo := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return &entity.User{ID: 1, Username: username, Password: hashed}, nil
+		},
+	}
+
+	uc := user.NewUseCase(mockRepo, event.NewBus(), audit.Discard)
+	u, err := uc.Authenticate(context.Background(), "alice", "wrong")
+
+	assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+	assert.Nil(t, u)
+}
+
+type recordingAudit struct {
+	entries []entity.AuditEntry
+}
+
+func (r *recordingAudit) Record(ctx context.Context, e entity.AuditEntry) {
+	r.entries = append(r.entries, e)
+}
+
+// неудачный вход пишется в журнал по ID аккаунта, без введенного имени
+func TestAuthenticate_RecordsFailure(t *testing.T) {
+	hashed, err := config.HashPassword("right")
+	assert.NoError(t, err)
+
+	mockRepo := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return &entity.User{ID: 1, Username: username, Password: hashed}, nil
+		},
+	}
+
+	recorder := &recordingAudit{}
+	uc := user.NewUseCase(mockRepo, event.NewBus(), recorder)
+
+	_, err = uc.Authenticate(context.Background(), "alice", "wrong")
+	assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+
+	_, err = uc.Authenticate(context.Background(), "alice", "right")
+	assert.NoError(t, err)
+
+	if assert.Len(t, recorder.entries, 2) {
+		assert.Equal(t, audit.ActionLoginFailed, recorder.entries[0].Action)
+		assert.Equal(t, audit.TargetUser, recorder.entries[0].TargetType)
+		assert.Equal(t, "1", recorder.entries[0].TargetID)
+		assert.NotContains(t, string(recorder.entries[0].After), "wrong")
+		assert.NotContains(t, string(recorder.entries[0].After), "alice")
+		assert.Equal(t, audit.ActionLogin, recorder.entries[1].Action)
+		assert.Equal(t, 1, recorder.entries[1].ActorID)
+	}
+}
+
+func TestAuthenticate_Deactivated(t *testing.T) {
+	hashed, err := config.HashPassword("right")
+	assert.NoError(t, err)
+
+	mockRepo := &mockUserRepo{
+		GetByUsernameFunc: func(ctx context.Context, username string) (*entity.User, error) {
+			return &entity.User{ID: 1, Username: username, Password: hashed, Status: entity.UserDeactivated}, nil
+		},
+	}
+
+	uc := user.NewUseCase(mockRepo, event.NewBus(), audit.Discard)
+	u, err := uc.Authenticate(context.Background(), "alice", "right")
+
+	assert.ErrorIs(t, err, user.ErrAccountDeactivated)
+	assert.Nil(t, u)
+}
+
+// Токены, выданные до отзыва, отклоняются, выданные после — проходят
+func TestAuthorize_TokensRevoked(t *testing.T) {
+	revokedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mockRepo := &mockUserRepo{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Status: entity.UserFrozen, TokensRevokedAt: &revokedAt}, nil
+		},
+	}
+
+	uc := user.NewUseCase(mockRepo, event.NewBus(), audit.Discard)
+
+	assert.ErrorIs(t, uc.Authorize(context.Background(), 1, revokedAt.Add(-time.Hour)), user.ErrTokenRevoked)
+	assert.ErrorIs(t, uc.Authorize(context.Background(), 1, revokedAt), user.ErrTokenRevoked)
+	assert.NoError(t, uc.Authorize(context.Background(), 1, revokedAt.Add(time.Second)))
+}
+
+func TestAuthorize_Deactivated(t *testing.T) {
+	mockRepo := &mockUserRepo{
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.User, error) {
+			return &entity.User{ID: id, Status: entity.UserDeactivated}, nil
+		},
+	}
+
+	uc := user.NewUseCase(mockRepo, event.NewBus(), audit.Discard)
+
+	assert.ErrorIs(t, uc.Authorize(context.Background(), 1, time.Now()), user.ErrAccountDeactivated)
+}
+
+type recordingPublisher struct {
+	events []event.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, e event.Event) {
+	p.events = append(p.events, e)
+}
+
+func TestRegister_PublishesEvent(t *testing.T) {
+	mockRepo := &mockUserRepo{
+		CreateUserFunc: func(ctx context.Context, username, password string) (*entity.User, error) {
+			return &entity.User{ID: 7, Username: username}, nil
+		},
+	}
+
+	pub := &recordingPublisher{}
+	uc := user.NewUseCase(mockRepo, pub, audit.Discard)
+	_, err := uc.Register(context.Background(), "newbie", "hash")
+
+	assert.NoError(t, err)
+	assert.Len(t, pub.events, 1)
+	assert.Equal(t, event.UserRegistered, pub.events[0].Type)
+	assert.Equal(t, 7, pub.events[0].UserID)
+	assert.Equal(t, event.Registration{Username: "newbie"}, pub.events[0].Data)
+}
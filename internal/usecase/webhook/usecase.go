@@ -0,0 +1,296 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/event"
+	"merchshop/internal/repository/webhook"
+)
+
+const (
+	HeaderEvent     = "X-Merchshop-Event"
+	HeaderDelivery  = "X-Merchshop-Delivery"
+	HeaderTimestamp = "X-Merchshop-Timestamp"
+	HeaderSignature = "X-Merchshop-Signature"
+
+	defaultMaxAttempts = 8
+	defaultBaseBackoff = 30 * time.Second
+	defaultMaxBackoff  = time.Hour
+	defaultBatchSize   = 50
+	maxDeliveriesLimit = 100
+
+	// claimLease время, на которое доставка резервируется за репликой на время отправки
+	claimLease = 5 * time.Minute
+)
+
+type Options struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	BatchSize   int
+}
+
+type UseCase interface {
+	event.Publisher
+
+	Create(ctx context.Context, rawURL string, events []string, secret string) (*entities.Webhook, error)
+	List(ctx context.Context) ([]entities.Webhook, error)
+	Delete(ctx context.Context, id int) error
+	ListDeliveries(ctx context.Context, filter webhook.DeliveryFilter) ([]entities.WebhookDelivery, error)
+	DispatchPending(ctx context.Context) (int, error)
+}
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type useCase struct {
+	webhookRepo webhook.Repository
+	client      HTTPClient
+	opts        Options
+	now         func() time.Time
+}
+
+func NewUseCase(webhookRepo webhook.Repository, client HTTPClient, opts Options) UseCase {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = defaultBaseBackoff
+	}
+
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+
+	return &useCase{
+		webhookRepo: webhookRepo,
+		client:      client,
+		opts:        opts,
+		now:         time.Now,
+	}
+}
+
+// Sign считает HMAC-SHA256 от "<timestamp>.<body>" в hex
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (u *useCase) Create(ctx context.Context, rawURL string, events []string, secret string) (*entities.Webhook, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid webhook url: %q", rawURL)
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("empty event filter")
+	}
+
+	for _, e := range events {
+		if !event.IsKnown(event.Type(e)) {
+			return nil, fmt.Errorf("unknown event type: %s", e)
+		}
+	}
+
+	if secret == "" {
+		secret, err = generateSecret()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate secret: %w", err)
+		}
+	}
+
+	w, err := u.webhookRepo.Create(ctx, rawURL, events, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+func (u *useCase) List(ctx context.Context) ([]entities.Webhook, error) {
+	webhooks, err := u.webhookRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (u *useCase) Delete(ctx context.Context, id int) error {
+	if err := u.webhookRepo.Deactivate(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete webhook %d: %w", id, err)
+	}
+
+	return nil
+}
+
+func (u *useCase) ListDeliveries(ctx context.Context, filter webhook.DeliveryFilter) ([]entities.WebhookDelivery, error) {
+	if filter.Limit <= 0 || filter.Limit > maxDeliveriesLimit {
+		filter.Limit = maxDeliveriesLimit
+	}
+
+	deliveries, err := u.webhookRepo.ListDeliveries(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Publish ставит событие в очередь доставки каждому подписанному вебхуку.
+// Ошибки только логируются: операция, породившая событие, уже завершена
+func (u *useCase) Publish(ctx context.Context, e event.Event) {
+	webhooks, err := u.webhookRepo.GetActiveByEvent(ctx, string(e.Type))
+	if err != nil {
+		log.Printf("webhook: failed to get subscriptions for %s: %v", e.Type, err)
+		return
+	}
+
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("webhook: failed to marshal %s event: %v", e.Type, err)
+		return
+	}
+
+	for _, w := range webhooks {
+		if err := u.webhookRepo.CreateDelivery(ctx, w.ID, string(e.Type), payload); err != nil {
+			log.Printf("webhook: failed to enqueue delivery to webhook %d: %v", w.ID, err)
+		}
+	}
+}
+
+// DispatchPending отправляет накопившиеся доставки и возвращает количество обработанных.
+// Ошибка одной доставки логируется и не мешает остальным
+func (u *useCase) DispatchPending(ctx context.Context) (int, error) {
+	deliveries, err := u.webhookRepo.ClaimDueDeliveries(ctx, u.opts.BatchSize, claimLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	for _, d := range deliveries {
+		if err := u.dispatch(ctx, d); err != nil {
+			log.Printf("webhook: failed to dispatch delivery %d: %v", d.ID, err)
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// dispatch отправляет одну доставку и записывает результат. Если вебхук не удалось получить,
+// это записывается как неудачная попытка. Если не удалось записать результат, доставка
+// вернется в очередь после claimLease
+func (u *useCase) dispatch(ctx context.Context, d entities.WebhookDelivery) error {
+	w, err := u.webhookRepo.GetByID(ctx, d.WebhookID)
+	if err != nil {
+		err = fmt.Errorf("failed to get webhook %d: %w", d.WebhookID, err)
+		if markErr := u.markFailed(ctx, d, 0, err); markErr != nil {
+			return errors.Join(err, markErr)
+		}
+
+		return err
+	}
+
+	if !w.Active {
+		return u.webhookRepo.MarkAttemptFailed(ctx, d.ID, 0, "webhook deactivated", nil)
+	}
+
+	statusCode, sendErr := u.send(ctx, w, d)
+	if sendErr == nil {
+		return u.webhookRepo.MarkDelivered(ctx, d.ID, statusCode)
+	}
+
+	return u.markFailed(ctx, d, statusCode, sendErr)
+}
+
+// markFailed записывает неудачную попытку и назначает следующую, пока не исчерпан MaxAttempts
+func (u *useCase) markFailed(ctx context.Context, d entities.WebhookDelivery, statusCode int, cause error) error {
+	var next *time.Time
+
+	if attempts := d.Attempts + 1; attempts < u.opts.MaxAttempts {
+		at := u.now().Add(u.backoff(attempts))
+		next = &at
+	}
+
+	return u.webhookRepo.MarkAttemptFailed(ctx, d.ID, statusCode, cause.Error(), next)
+}
+
+func (u *useCase) send(ctx context.Context, w *entities.Webhook, d entities.WebhookDelivery) (int, error) {
+	timestamp := u.now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.EventType)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(d.ID))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, "sha256="+Sign(w.Secret, timestamp, d.Payload))
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff экспоненциальная задержка перед попыткой номер attempts+1
+func (u *useCase) backoff(attempts int) time.Duration {
+	delay := u.opts.BaseBackoff
+
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= u.opts.MaxBackoff {
+			return u.opts.MaxBackoff
+		}
+	}
+
+	return delay
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,271 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/event"
+	repo "merchshop/internal/repository/webhook"
+	"merchshop/internal/usecase/webhook"
+)
+
+type mockRepo struct {
+	CreateFunc             func(ctx context.Context, url string, events []string, secret string) (*entity.Webhook, error)
+	GetByIDFunc            func(ctx context.Context, id int) (*entity.Webhook, error)
+	GetActiveByEventFunc   func(ctx context.Context, eventType string) ([]entity.Webhook, error)
+	CreateDeliveryFunc     func(ctx context.Context, webhookID int, eventType string, payload []byte) error
+	ClaimDueDeliveriesFunc func(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error)
+	MarkDeliveredFunc      func(ctx context.Context, id, statusCode int) error
+	MarkAttemptFailedFunc  func(ctx context.Context, id, statusCode int, lastErr string, next *time.Time) error
+}
+
+func (m *mockRepo) Create(ctx context.Context, url string, events []string, secret string) (*entity.Webhook, error) {
+	return m.CreateFunc(ctx, url, events, secret)
+}
+
+func (m *mockRepo) GetByID(ctx context.Context, id int) (*entity.Webhook, error) {
+	return m.GetByIDFunc(ctx, id)
+}
+
+func (m *mockRepo) List(ctx context.Context) ([]entity.Webhook, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) Deactivate(ctx context.Context, id int) error {
+	return nil
+}
+
+func (m *mockRepo) GetActiveByEvent(ctx context.Context, eventType string) ([]entity.Webhook, error) {
+	return m.GetActiveByEventFunc(ctx, eventType)
+}
+
+func (m *mockRepo) CreateDelivery(ctx context.Context, webhookID int, eventType string, payload []byte) error {
+	return m.CreateDeliveryFunc(ctx, webhookID, eventType, payload)
+}
+
+func (m *mockRepo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+	return m.ClaimDueDeliveriesFunc(ctx, limit, lease)
+}
+
+func (m *mockRepo) MarkDelivered(ctx context.Context, id, statusCode int) error {
+	return m.MarkDeliveredFunc(ctx, id, statusCode)
+}
+
+func (m *mockRepo) MarkAttemptFailed(ctx context.Context, id, statusCode int, lastErr string, next *time.Time) error {
+	return m.MarkAttemptFailedFunc(ctx, id, statusCode, lastErr, next)
+}
+
+func (m *mockRepo) ListDeliveries(ctx context.Context, filter repo.DeliveryFilter) ([]entity.WebhookDelivery, error) {
+	return nil, nil
+}
+
+type clientFunc func(req *http.Request) (*http.Response, error)
+
+func (f clientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func respond(status int) clientFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+}
+
+func TestCreate_InvalidURL(t *testing.T) {
+	uc := webhook.NewUseCase(&mockRepo{}, respond(200), webhook.Options{})
+	_, err := uc.Create(context.Background(), "ftp://example.com", []string{"coin.sent"}, "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid webhook url")
+}
+
+func TestCreate_UnknownEvent(t *testing.T) {
+	uc := webhook.NewUseCase(&mockRepo{}, respond(200), webhook.Options{})
+	_, err := uc.Create(context.Background(), "https://example.com", []string{"coin.stolen"}, "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown event type")
+}
+
+func TestCreate_GeneratesSecret(t *testing.T) {
+	mock := &mockRepo{
+		CreateFunc: func(ctx context.Context, url string, events []string, secret string) (*entity.Webhook, error) {
+			return &entity.Webhook{ID: 1, URL: url, Events: events, Secret: secret}, nil
+		},
+	}
+
+	uc := webhook.NewUseCase(mock, respond(200), webhook.Options{})
+	w, err := uc.Create(context.Background(), "https://example.com", []string{"coin.sent"}, "")
+
+	assert.NoError(t, err)
+	assert.Len(t, w.Secret, 64)
+}
+
+func TestPublish_EnqueuesForEverySubscriber(t *testing.T) {
+	var enqueued []int
+
+	mock := &mockRepo{
+		GetActiveByEventFunc: func(ctx context.Context, eventType string) ([]entity.Webhook, error) {
+			return []entity.Webhook{{ID: 1}, {ID: 2}}, nil
+		},
+		CreateDeliveryFunc: func(ctx context.Context, webhookID int, eventType string, payload []byte) error {
+			assert.Equal(t, "coin.received", eventType)
+			assert.Contains(t, string(payload), `"amount":10`)
+			enqueued = append(enqueued, webhookID)
+			return nil
+		},
+	}
+
+	uc := webhook.NewUseCase(mock, respond(200), webhook.Options{})
+	uc.Publish(context.Background(), event.Event{
+		Type:   event.CoinReceived,
+		UserID: 2,
+		Data:   event.CoinTransfer{FromUser: "alice", ToUser: "bob", Amount: 10},
+	})
+
+	assert.Equal(t, []int{1, 2}, enqueued)
+}
+
+func TestDispatchPending_SignsAndDelivers(t *testing.T) {
+	payload := []byte(`{"type":"coin.sent"}`)
+	delivered := false
+
+	mock := &mockRepo{
+		ClaimDueDeliveriesFunc: func(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+			return []entity.WebhookDelivery{{ID: 5, WebhookID: 1, EventType: "coin.sent", Payload: payload}}, nil
+		},
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.Webhook, error) {
+			return &entity.Webhook{ID: id, URL: "https://example.com/hook", Secret: "topsecret", Active: true}, nil
+		},
+		MarkDeliveredFunc: func(ctx context.Context, id, statusCode int) error {
+			assert.Equal(t, 5, id)
+			assert.Equal(t, http.StatusNoContent, statusCode)
+			delivered = true
+			return nil
+		},
+	}
+
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		ts, err := strconv.ParseInt(req.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		assert.NoError(t, err)
+
+		body, _ := io.ReadAll(req.Body)
+		assert.Equal(t, "sha256="+webhook.Sign("topsecret", ts, body), req.Header.Get(webhook.HeaderSignature))
+		assert.Equal(t, "coin.sent", req.Header.Get(webhook.HeaderEvent))
+		assert.Equal(t, "5", req.Header.Get(webhook.HeaderDelivery))
+
+		return &http.Response{StatusCode: http.StatusNoContent, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	uc := webhook.NewUseCase(mock, client, webhook.Options{})
+	n, err := uc.DispatchPending(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.True(t, delivered)
+}
+
+func TestDispatchPending_RetriesWithBackoff(t *testing.T) {
+	var next *time.Time
+
+	mock := &mockRepo{
+		ClaimDueDeliveriesFunc: func(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+			return []entity.WebhookDelivery{{ID: 5, WebhookID: 1, Attempts: 2}}, nil
+		},
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.Webhook, error) {
+			return &entity.Webhook{ID: id, URL: "https://example.com/hook", Active: true}, nil
+		},
+		MarkAttemptFailedFunc: func(ctx context.Context, id, statusCode int, lastErr string, nextAttemptAt *time.Time) error {
+			assert.Equal(t, http.StatusBadGateway, statusCode)
+			next = nextAttemptAt
+			return nil
+		},
+	}
+
+	uc := webhook.NewUseCase(mock, respond(http.StatusBadGateway), webhook.Options{BaseBackoff: time.Minute})
+	before := time.Now()
+	_, err := uc.DispatchPending(context.Background())
+
+	assert.NoError(t, err)
+	assert.NotNil(t, next)
+	// третья попытка провалилась: следующая через 1m * 2^2
+	assert.WithinDuration(t, before.Add(4*time.Minute), *next, 5*time.Second)
+}
+
+// сбой одной доставки записывается в нее и не останавливает остальные
+func TestDispatchPending_ContinuesAfterFailure(t *testing.T) {
+	var failed, delivered []int
+
+	mock := &mockRepo{
+		ClaimDueDeliveriesFunc: func(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+			return []entity.WebhookDelivery{{ID: 5, WebhookID: 1}, {ID: 6, WebhookID: 2}, {ID: 7, WebhookID: 2}}, nil
+		},
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.Webhook, error) {
+			if id == 1 {
+				return nil, errors.New("db error")
+			}
+
+			return &entity.Webhook{ID: id, URL: "https://example.com/hook", Active: true}, nil
+		},
+		MarkAttemptFailedFunc: func(ctx context.Context, id, statusCode int, lastErr string, nextAttemptAt *time.Time) error {
+			assert.Contains(t, lastErr, "db error")
+			assert.NotNil(t, nextAttemptAt)
+			failed = append(failed, id)
+			return nil
+		},
+		MarkDeliveredFunc: func(ctx context.Context, id, statusCode int) error {
+			delivered = append(delivered, id)
+			if id == 6 {
+				return errors.New("db error")
+			}
+
+			return nil
+		},
+	}
+
+	uc := webhook.NewUseCase(mock, respond(http.StatusOK), webhook.Options{})
+	n, err := uc.DispatchPending(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []int{5}, failed)
+	assert.Equal(t, []int{6, 7}, delivered)
+}
+
+func TestDispatchPending_GivesUpAfterMaxAttempts(t *testing.T) {
+	calledFailed := false
+
+	mock := &mockRepo{
+		ClaimDueDeliveriesFunc: func(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+			return []entity.WebhookDelivery{{ID: 5, WebhookID: 1, Attempts: 2}}, nil
+		},
+		GetByIDFunc: func(ctx context.Context, id int) (*entity.Webhook, error) {
+			return &entity.Webhook{ID: id, URL: "https://example.com/hook", Active: true}, nil
+		},
+		MarkAttemptFailedFunc: func(ctx context.Context, id, statusCode int, lastErr string, nextAttemptAt *time.Time) error {
+			assert.Nil(t, nextAttemptAt)
+			assert.Contains(t, lastErr, "connection refused")
+			calledFailed = true
+			return nil
+		},
+	}
+
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	uc := webhook.NewUseCase(mock, client, webhook.Options{MaxAttempts: 3})
+	_, err := uc.DispatchPending(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, calledFailed)
+}
@@ -0,0 +1,209 @@
+package wishlist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	entities "merchshop/internal/entity"
+	"merchshop/internal/pricing"
+	"merchshop/internal/repository/merch"
+	"merchshop/internal/repository/promo"
+	"merchshop/internal/repository/user"
+	"merchshop/internal/repository/wishlist"
+	"merchshop/internal/usecase/notification"
+)
+
+type UseCase interface {
+	// Add добавляет в список вариант по артикулу или товар без вариантов по названию
+	Add(ctx context.Context, userID int, item string) (*entities.WishlistItem, error)
+	Remove(ctx context.Context, userID int, item string) error
+	List(ctx context.Context, userID int) ([]entities.WishlistItem, error)
+	// Watch уведомляет о снижении цены и о том, что монет стало хватать. Возвращает число уведомлений
+	Watch(ctx context.Context) (int, error)
+}
+
+type useCase struct {
+	wishlistRepo wishlist.Repository
+	userRepo     user.Repository
+	merchRepo    merch.Repository
+	promoRepo    promo.Repository
+	notifier     notification.Notifier
+	now          func() time.Time
+}
+
+func NewUseCase(
+	wishlistRepo wishlist.Repository,
+	userRepo user.Repository,
+	merchRepo merch.Repository,
+	promoRepo promo.Repository,
+	notifier notification.Notifier,
+) UseCase {
+	return &useCase{
+		wishlistRepo: wishlistRepo,
+		userRepo:     userRepo,
+		merchRepo:    merchRepo,
+		promoRepo:    promoRepo,
+		notifier:     notifier,
+		now:          time.Now,
+	}
+}
+
+// Price цена одной штуки со скидкой распродажи
+func Price(w entities.WishlistItem) int {
+	var sale *entities.Sale
+	if w.SalePercent > 0 {
+		sale = &entities.Sale{Percent: w.SalePercent}
+	}
+
+	return pricing.Apply(w.ListPrice, 1, sale, nil).Total
+}
+
+// CoinsNeeded сколько монет не хватает до покупки, 0 если хватает
+func CoinsNeeded(w entities.WishlistItem) int {
+	return max(Price(w)-w.Balance, 0)
+}
+
+func (u *useCase) Add(ctx context.Context, userID int, item string) (*entities.WishlistItem, error) {
+	owner, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+
+	variant, err := merch.Resolve(ctx, u.merchRepo, item)
+	if err != nil {
+		return nil, err
+	}
+
+	sale, err := u.promoRepo.ActiveSale(ctx, variant.MerchName, u.now())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get active sale: %w", err)
+	}
+
+	w := entities.WishlistItem{
+		UserID:    userID,
+		Item:      item,
+		MerchName: variant.MerchName,
+		SKU:       variant.SKU,
+		ListPrice: variant.Price,
+		Balance:   owner.Balance,
+	}
+
+	if sale != nil {
+		w.SalePercent = sale.Percent
+	}
+
+	// Текущую цену и баланс пользователь видит сам, уведомления только об изменениях
+	w.LastPrice = Price(w)
+	w.AffordableNotified = CoinsNeeded(w) == 0
+
+	if err := u.wishlistRepo.Add(ctx, w); err != nil {
+		return nil, fmt.Errorf("failed to add to wishlist: %w", err)
+	}
+
+	// Повторно добавленный товар сохраняет исходную дату
+	items, err := u.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		if items[i].Item == item {
+			return &items[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s is missing from wishlist of user %d: %w", item, userID, sql.ErrNoRows)
+}
+
+func (u *useCase) Remove(ctx context.Context, userID int, item string) error {
+	if err := u.wishlistRepo.Remove(ctx, userID, item); err != nil {
+		return fmt.Errorf("failed to remove from wishlist: %w", err)
+	}
+
+	return nil
+}
+
+func (u *useCase) List(ctx context.Context, userID int) ([]entities.WishlistItem, error) {
+	items, err := u.wishlistRepo.List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlist: %w", err)
+	}
+
+	return items, nil
+}
+
+func (u *useCase) Watch(ctx context.Context) (int, error) {
+	items, err := u.wishlistRepo.ListActive(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get wishlists: %w", err)
+	}
+
+	sent := 0
+
+	// Ошибка по одному товару не останавливает обход остальных
+	for _, w := range items {
+		n, err := u.watch(ctx, w)
+		sent += n
+
+		if err != nil {
+			log.Printf("wishlist: watch %s for user %d: %v", w.Item, w.UserID, err)
+		}
+	}
+
+	return sent, nil
+}
+
+// watch проверяет один товар. Состояние сохраняется после каждого уведомления,
+// чтобы сбой следующего шага не отправил его повторно
+func (u *useCase) watch(ctx context.Context, w entities.WishlistItem) (int, error) {
+	price := Price(w)
+	affordable := w.Balance >= price
+	sent := 0
+
+	if price < w.LastPrice {
+		err := u.notifier.Notify(ctx, entities.Notification{
+			UserID:  w.UserID,
+			Kind:    entities.NotifyPriceDrop,
+			Message: fmt.Sprintf("%s подешевел с %d до %d монет", w.Item, w.LastPrice, price),
+			Data:    map[string]any{"item": w.Item, "oldPrice": w.LastPrice, "price": price, "coinsNeeded": CoinsNeeded(w)},
+		})
+		if err != nil {
+			return sent, err
+		}
+
+		sent++
+
+		if err := u.wishlistRepo.SaveState(ctx, w.UserID, w.Item, price, w.AffordableNotified); err != nil {
+			return sent, fmt.Errorf("failed to save wishlist state: %w", err)
+		}
+	}
+
+	// Уведомление повторится, если баланс опустится ниже цены и снова ее догонит
+	if affordable && !w.AffordableNotified {
+		err := u.notifier.Notify(ctx, entities.Notification{
+			UserID:  w.UserID,
+			Kind:    entities.NotifyAffordable,
+			Message: fmt.Sprintf("Монет хватает на %s за %d", w.Item, price),
+			Data:    map[string]any{"item": w.Item, "price": price, "balance": w.Balance},
+		})
+		if err != nil {
+			return sent, err
+		}
+
+		sent++
+	}
+
+	if price == w.LastPrice && affordable == w.AffordableNotified {
+		return sent, nil
+	}
+
+	if err := u.wishlistRepo.SaveState(ctx, w.UserID, w.Item, price, affordable); err != nil {
+		return sent, fmt.Errorf("failed to save wishlist state: %w", err)
+	}
+
+	return sent, nil
+}
@@ -0,0 +1,148 @@
+package wishlist_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"merchshop/internal/entity"
+	"merchshop/internal/usecase/wishlist"
+)
+
+type savedState struct {
+	lastPrice  int
+	affordable bool
+}
+
+type mockWishlistRepo struct {
+	items   []entity.WishlistItem
+	saved   map[string]savedState
+	saveErr map[string]error
+}
+
+func (m *mockWishlistRepo) Add(ctx context.Context, w entity.WishlistItem) error {
+	m.items = append(m.items, w)
+	return nil
+}
+
+func (m *mockWishlistRepo) Remove(ctx context.Context, userID int, item string) error {
+	return nil
+}
+
+func (m *mockWishlistRepo) List(ctx context.Context, userID int) ([]entity.WishlistItem, error) {
+	return m.items, nil
+}
+
+func (m *mockWishlistRepo) ListActive(ctx context.Context) ([]entity.WishlistItem, error) {
+	return m.items, nil
+}
+
+func (m *mockWishlistRepo) SaveState(ctx context.Context, userID int, item string, lastPrice int, affordableNotified bool) error {
+	if err := m.saveErr[item]; err != nil {
+		return err
+	}
+
+	m.saved[item] = savedState{lastPrice: lastPrice, affordable: affordableNotified}
+
+	return nil
+}
+
+type recordingNotifier struct {
+	notifications []entity.Notification
+	fail          map[string]error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	if err := n.fail[notification.Kind]; err != nil {
+		return err
+	}
+
+	n.notifications = append(n.notifications, notification)
+
+	return nil
+}
+
+func TestCoinsNeeded(t *testing.T) {
+	w := entity.WishlistItem{ListPrice: 500, SalePercent: 20, Balance: 150}
+
+	assert.Equal(t, 400, wishlist.Price(w))
+	assert.Equal(t, 250, wishlist.CoinsNeeded(w))
+
+	w.Balance = 1000
+	assert.Equal(t, 0, wishlist.CoinsNeeded(w))
+}
+
+func TestWatch(t *testing.T) {
+	repo := &mockWishlistRepo{
+		saved: map[string]savedState{},
+		items: []entity.WishlistItem{
+			// распродажа снизила цену, но монет все еще не хватает
+			{UserID: 1, Item: "hoody-pink-m", ListPrice: 500, SalePercent: 20, Balance: 100, LastPrice: 500},
+			// монет стало хватать
+			{UserID: 1, Item: "cup", ListPrice: 20, Balance: 100, LastPrice: 20},
+			// уже уведомлен, ничего не изменилось
+			{UserID: 2, Item: "cup", ListPrice: 20, Balance: 100, LastPrice: 20, AffordableNotified: true},
+			// баланс упал ниже цены: следующее пополнение снова даст уведомление
+			{UserID: 3, Item: "book", ListPrice: 50, Balance: 10, LastPrice: 50, AffordableNotified: true},
+		},
+	}
+	notifier := &recordingNotifier{}
+
+	useCase := wishlist.NewUseCase(repo, nil, nil, nil, notifier)
+
+	sent, err := useCase.Watch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	assert.Equal(t, entity.NotifyPriceDrop, notifier.notifications[0].Kind)
+	assert.Equal(t, 300, notifier.notifications[0].Data["coinsNeeded"])
+	assert.Equal(t, entity.NotifyAffordable, notifier.notifications[1].Kind)
+	assert.Equal(t, 1, notifier.notifications[1].UserID)
+
+	assert.Equal(t, map[string]savedState{
+		"hoody-pink-m": {lastPrice: 400},
+		"cup":          {lastPrice: 20, affordable: true},
+		"book":         {lastPrice: 50},
+	}, repo.saved)
+}
+
+func TestWatch_PriceDropSavedBeforeAffordable(t *testing.T) {
+	repo := &mockWishlistRepo{
+		saved: map[string]savedState{},
+		items: []entity.WishlistItem{
+			// цена упала и монет стало хватать, но второе уведомление не ушло
+			{UserID: 1, Item: "hoody-m", ListPrice: 500, SalePercent: 20, Balance: 400, LastPrice: 500},
+		},
+	}
+	notifier := &recordingNotifier{fail: map[string]error{entity.NotifyAffordable: errors.New("smtp down")}}
+
+	useCase := wishlist.NewUseCase(repo, nil, nil, nil, notifier)
+
+	sent, err := useCase.Watch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	// Снижение цены уже сохранено и не повторится на следующем проходе
+	assert.Equal(t, map[string]savedState{"hoody-m": {lastPrice: 400}}, repo.saved)
+}
+
+func TestWatch_ContinuesAfterItemError(t *testing.T) {
+	repo := &mockWishlistRepo{
+		saved:   map[string]savedState{},
+		saveErr: map[string]error{"book": errors.New("connection reset")},
+		items: []entity.WishlistItem{
+			{UserID: 1, Item: "book", ListPrice: 50, Balance: 10, LastPrice: 50, AffordableNotified: true},
+			{UserID: 1, Item: "cup", ListPrice: 20, Balance: 100, LastPrice: 20},
+		},
+	}
+	notifier := &recordingNotifier{}
+
+	useCase := wishlist.NewUseCase(repo, nil, nil, nil, notifier)
+
+	sent, err := useCase.Watch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, map[string]savedState{"cup": {lastPrice: 20, affordable: true}}, repo.saved)
+}
@@ -33,14 +33,447 @@ CREATE INDEX idx_transactions_sender ON transactions(sender_id);
 CREATE INDEX idx_transactions_receiver ON transactions(receiver_id);
 CREATE INDEX idx_purchases_user ON purchases(user_id);
 
-INSERT INTO merchandise (name, price) VALUES
-    ('t-shirt', 80),
-    ('cup', 20),
-    ('book', 50),
-    ('pen', 10),
-    ('powerbank', 200),
-    ('hoody', 300),
-    ('umbrella', 200),
-    ('socks', 10),
-    ('wallet', 50),
-    ('pink-hoody', 500);
\ No newline at end of file
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'employee';
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    secret TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id BIGINT NOT NULL REFERENCES webhooks(id),
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_status_code INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id);
+
+CREATE TABLE IF NOT EXISTS transfer_batches (
+    id BIGSERIAL PRIMARY KEY,
+    sender_id BIGINT NOT NULL REFERENCES users(id),
+    total_amount BIGINT NOT NULL CHECK (total_amount > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS batch_id BIGINT REFERENCES transfer_batches(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_batch ON transactions(batch_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS memo VARCHAR(140) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reaction VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reacted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+    id BIGSERIAL PRIMARY KEY,
+    sender_id BIGINT NOT NULL REFERENCES users(id),
+    receiver_id BIGINT NOT NULL REFERENCES users(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    memo VARCHAR(140) NOT NULL DEFAULT '',
+    cron VARCHAR(100) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT NOT NULL DEFAULT '',
+    failures INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT scheduled_different_users CHECK (sender_id != receiver_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_due ON scheduled_transfers(next_run_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_sender ON scheduled_transfers(sender_id);
+
+-- Одна строка на каждое срабатывание: уникальный ключ не дает двум репликам выполнить его дважды
+CREATE TABLE IF NOT EXISTS scheduled_transfer_runs (
+    id BIGSERIAL PRIMARY KEY,
+    schedule_id BIGINT NOT NULL REFERENCES scheduled_transfers(id),
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (schedule_id, scheduled_for)
+);
+
+CREATE TABLE IF NOT EXISTS coin_requests (
+    id BIGSERIAL PRIMARY KEY,
+    requester_id BIGINT NOT NULL REFERENCES users(id),
+    payer_id BIGINT NOT NULL REFERENCES users(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    memo VARCHAR(140) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    transaction_id BIGINT REFERENCES transactions(id),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT coin_requests_different_users CHECK (requester_id != payer_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_coin_requests_requester ON coin_requests(requester_id);
+CREATE INDEX IF NOT EXISTS idx_coin_requests_payer ON coin_requests(payer_id);
+CREATE INDEX IF NOT EXISTS idx_coin_requests_expiry ON coin_requests(expires_at) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS escrow_transfers (
+    id BIGSERIAL PRIMARY KEY,
+    sender_id BIGINT NOT NULL REFERENCES users(id),
+    recipient_username VARCHAR(100) NOT NULL,
+    receiver_id BIGINT REFERENCES users(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    memo VARCHAR(140) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'held',
+    transaction_id BIGINT REFERENCES transactions(id),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_escrow_transfers_recipient ON escrow_transfers(recipient_username) WHERE status = 'held';
+CREATE INDEX IF NOT EXISTS idx_escrow_transfers_expiry ON escrow_transfers(expires_at) WHERE status = 'held';
+CREATE INDEX IF NOT EXISTS idx_escrow_transfers_sender ON escrow_transfers(sender_id);
+
+CREATE TABLE IF NOT EXISTS transfer_policies (
+    role VARCHAR(20) PRIMARY KEY,
+    daily_limit BIGINT NOT NULL DEFAULT 0 CHECK (daily_limit >= 0),
+    weekly_limit BIGINT NOT NULL DEFAULT 0 CHECK (weekly_limit >= 0),
+    max_transfer BIGINT NOT NULL DEFAULT 0 CHECK (max_transfer >= 0),
+    min_account_age_seconds BIGINT NOT NULL DEFAULT 0 CHECK (min_account_age_seconds >= 0),
+    velocity_count INT NOT NULL DEFAULT 0 CHECK (velocity_count >= 0),
+    velocity_window_seconds BIGINT NOT NULL DEFAULT 0 CHECK (velocity_window_seconds >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_sender_created ON transactions(sender_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_purchases_user_created ON purchases(user_id, created_at);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
+
+CREATE TABLE IF NOT EXISTS fraud_cases (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,
+    user_ids BIGINT[] NOT NULL,
+    last_transaction_id BIGINT NOT NULL,
+    transfers INT NOT NULL,
+    amount BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    resolved_by BIGINT REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fraud_cases_members ON fraud_cases(kind, user_ids);
+CREATE INDEX IF NOT EXISTS idx_fraud_cases_status ON fraud_cases(status, id);
+CREATE INDEX IF NOT EXISTS idx_transactions_created ON transactions(created_at);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_revoked_at TIMESTAMP WITH TIME ZONE;
+
+-- Товары, заведенные до учета остатков, получают такой же остаток, как в начальном каталоге,
+-- иначе после обновления все они разом закончились бы
+ALTER TABLE merchandise ADD COLUMN IF NOT EXISTS stock INT NOT NULL DEFAULT 100 CHECK (stock >= 0);
+ALTER TABLE merchandise ALTER COLUMN stock SET DEFAULT 0;
+ALTER TABLE merchandise ADD COLUMN IF NOT EXISTS low_stock_threshold INT NOT NULL DEFAULT 5 CHECK (low_stock_threshold >= 0);
+
+CREATE TABLE IF NOT EXISTS merch_variants (
+    sku VARCHAR(80) PRIMARY KEY,
+    merch_name VARCHAR(50) NOT NULL REFERENCES merchandise(name),
+    attributes JSONB NOT NULL DEFAULT '{}',
+    price BIGINT CHECK (price > 0),
+    stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    low_stock_threshold INT NOT NULL DEFAULT 5 CHECK (low_stock_threshold >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_merch_variants_merch ON merch_variants(merch_name);
+
+-- Вариант по умолчанию продается при покупке по названию товара, так старые клиенты продолжают работать.
+-- У товаров, получивших варианты до этой колонки, по умолчанию становится вариант с наибольшим остатком
+ALTER TABLE merch_variants ADD COLUMN IF NOT EXISTS is_default BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_merch_variants_default ON merch_variants(merch_name) WHERE is_default;
+
+UPDATE merch_variants v
+SET is_default = TRUE
+WHERE v.sku = (SELECT w.sku FROM merch_variants w WHERE w.merch_name = v.merch_name ORDER BY w.stock DESC, w.sku LIMIT 1)
+  AND NOT EXISTS (SELECT 1 FROM merch_variants d WHERE d.merch_name = v.merch_name AND d.is_default);
+
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS sku VARCHAR(80) REFERENCES merch_variants(sku);
+
+-- Покупка ищет сначала артикул, потом название, поэтому товар не может называться как чужой артикул.
+-- Обратное правило для артикулов проверяет SaveVariant
+CREATE OR REPLACE FUNCTION merchandise_name_not_sku() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM merch_variants WHERE sku = NEW.name) THEN
+        RAISE EXCEPTION 'merchandise name % is a variant sku', NEW.name;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS merchandise_name_not_sku ON merchandise;
+CREATE TRIGGER merchandise_name_not_sku BEFORE INSERT OR UPDATE OF name ON merchandise
+    FOR EACH ROW EXECUTE FUNCTION merchandise_name_not_sku();
+
+-- Покупки, сделанные до появления заказов, считаются выданными
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'delivered';
+ALTER TABLE purchases ALTER COLUMN status SET DEFAULT 'placed';
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS pickup_location VARCHAR(100);
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS ready_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_purchases_open ON purchases(id) WHERE status IN ('placed', 'ready_for_pickup');
+
+CREATE TABLE IF NOT EXISTS promo_codes (
+    code VARCHAR(40) PRIMARY KEY,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('percent', 'fixed')),
+    value INT NOT NULL CHECK (value > 0),
+    max_uses INT NOT NULL DEFAULT 0 CHECK (max_uses >= 0),
+    per_user_limit INT NOT NULL DEFAULT 0 CHECK (per_user_limit >= 0),
+    items TEXT[] NOT NULL DEFAULT '{}',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS sales (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    percent INT NOT NULL CHECK (percent BETWEEN 1 AND 99),
+    items TEXT[] NOT NULL DEFAULT '{}',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sales_window ON sales(ends_at, starts_at);
+
+-- Цена без скидок у старых покупок совпадает с уплаченной
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS list_price BIGINT;
+UPDATE purchases SET list_price = total_price WHERE list_price IS NULL;
+ALTER TABLE purchases ALTER COLUMN list_price SET NOT NULL;
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS discount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS promo_code VARCHAR(40) REFERENCES promo_codes(code);
+
+-- Со скидкой покупка может стать бесплатной
+ALTER TABLE purchases DROP CONSTRAINT IF EXISTS purchases_total_price_check;
+ALTER TABLE purchases ADD CONSTRAINT purchases_total_price_check CHECK (total_price >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_purchases_promo ON purchases(promo_code, user_id) WHERE promo_code IS NOT NULL;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS department VARCHAR(100) NOT NULL DEFAULT '';
+
+-- Ограничения на покупку товара. Пустые roles и departments не ограничивают покупателей,
+-- нулевой max_per_user не ограничивает количество
+CREATE TABLE IF NOT EXISTS purchase_rules (
+    merch_name VARCHAR(50) PRIMARY KEY REFERENCES merchandise(name),
+    max_per_user INT NOT NULL DEFAULT 0 CHECK (max_per_user >= 0),
+    roles TEXT[] NOT NULL DEFAULT '{}',
+    departments TEXT[] NOT NULL DEFAULT '{}',
+    min_account_age_seconds BIGINT NOT NULL DEFAULT 0 CHECK (min_account_age_seconds >= 0),
+    available_from TIMESTAMP WITH TIME ZONE,
+    available_until TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (available_until > available_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchases_user_merch ON purchases(user_id, merch_name);
+
+-- Уведомления в приложении
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    kind VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, id DESC);
+
+-- item артикул варианта или название товара без вариантов. last_price цена, о которой
+-- пользователь уже знает, снижение относительно нее дает уведомление
+CREATE TABLE IF NOT EXISTS wishlist_items (
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    item VARCHAR(80) NOT NULL,
+    merch_name VARCHAR(50) NOT NULL REFERENCES merchandise(name),
+    sku VARCHAR(80) REFERENCES merch_variants(sku),
+    last_price BIGINT NOT NULL,
+    affordable_notified BOOLEAN NOT NULL DEFAULT FALSE,
+    added_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, item)
+);
+
+-- Подарок: buyer_id оплатил покупку, товар попадает в инвентарь user_id. У обычной покупки
+-- buyer_id пустой
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS buyer_id BIGINT REFERENCES users(id);
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS gift_message VARCHAR(200);
+
+CREATE INDEX IF NOT EXISTS idx_purchases_buyer ON purchases(buyer_id, created_at) WHERE buyer_id IS NOT NULL;
+
+-- Типы уведомлений, которые пользователь включил или отключил. Без записи тип включен
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    kind VARCHAR(50) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id, id DESC) WHERE read_at IS NULL;
+
+-- Пользователь скрыл себя из публичных рейтингов
+ALTER TABLE users ADD COLUMN IF NOT EXISTS leaderboard_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Суммы для рейтингов по пользователям, пересчитываются фоновой задачей. Подарок засчитывается
+-- в траты тому, кто его оплатил, отмененные заказы не считаются
+CREATE MATERIALIZED VIEW IF NOT EXISTS leaderboard_totals AS
+WITH activity (board, user_id, amount, created_at) AS (
+    SELECT 'senders', sender_id, amount, created_at FROM transactions
+    UNION ALL
+    SELECT 'receivers', receiver_id, amount, created_at FROM transactions
+    UNION ALL
+    SELECT 'spenders', COALESCE(buyer_id, user_id), total_price, created_at
+    FROM purchases
+    WHERE status <> 'cancelled'
+),
+periods (period, since) AS (
+    VALUES ('week', NOW() - INTERVAL '7 days'),
+           ('month', NOW() - INTERVAL '30 days'),
+           ('all', '-infinity'::timestamptz)
+)
+SELECT a.board, p.period, a.user_id, SUM(a.amount)::bigint AS total, NOW() AS refreshed_at
+FROM activity a
+JOIN periods p ON a.created_at >= p.since
+GROUP BY a.board, p.period, a.user_id;
+
+-- Уникальный индекс нужен для REFRESH MATERIALIZED VIEW CONCURRENTLY
+CREATE UNIQUE INDEX IF NOT EXISTS idx_leaderboard_totals_user ON leaderboard_totals(board, period, user_id);
+CREATE INDEX IF NOT EXISTS idx_leaderboard_totals_rank ON leaderboard_totals(board, period, total DESC);
+
+-- Личная статистика выбирает поступления пользователя за период
+CREATE INDEX IF NOT EXISTS idx_transactions_receiver_created ON transactions(receiver_id, created_at);
+
+-- Выгрузки личных данных. Архив собирает фоновая задача и хранит до expires_at, пока
+-- аккаунт может его скачать
+CREATE TABLE IF NOT EXISTS data_exports (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    requested_by BIGINT NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    archive BYTEA,
+    size BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    leased_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    ready_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE
+);
+
+-- У пользователя не больше одной выгрузки в очереди
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_exports_pending ON data_exports(user_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_data_exports_ready ON data_exports(expires_at) WHERE status = 'ready';
+
+-- Стертые аккаунты. Сам пользователь остается, чтобы история переводов и покупок сходилась,
+-- но имя и учетные данные обезличены. Старое имя здесь не хранится
+CREATE TABLE IF NOT EXISTS user_erasures (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id),
+    erased_by BIGINT NOT NULL REFERENCES users(id),
+    reason TEXT NOT NULL DEFAULT '',
+    erased_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- При стирании имя меняется только в известных полях JSON и только при точном совпадении,
+-- совпадения в других полях и ключах не трогаются
+CREATE OR REPLACE FUNCTION jsonb_replace_username(doc JSONB, path TEXT[], old_name TEXT, new_name TEXT) RETURNS JSONB AS $$
+    SELECT CASE WHEN doc #>> path = old_name THEN jsonb_set(doc, path, to_jsonb(new_name)) ELSE doc END
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Поиск уведомлений и доставок вебхуков, в которых упомянут стираемый пользователь
+CREATE INDEX IF NOT EXISTS idx_notifications_data ON notifications USING GIN (data jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_payload ON webhook_deliveries USING GIN (payload jsonb_path_ops);
+
+-- Журнал аудита. Записи только добавляются, каждая хранит хеш предыдущей, так что правка
+-- или удаление записи в обход триггера обнаруживается проверкой цепочки
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id BIGINT REFERENCES users(id),
+    action VARCHAR(200) NOT NULL,
+    target_type VARCHAR(30) NOT NULL DEFAULT '',
+    target_id VARCHAR(200) NOT NULL DEFAULT '',
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+    hash VARCHAR(64) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at);
+
+-- Записи о переводах и покупках сохраняются в транзакции самой операции, а в цепочку audit_log
+-- их по порядку переносит Flush. Так запись не теряется, если операция прошла, а журнал нет
+CREATE TABLE IF NOT EXISTS audit_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id BIGINT REFERENCES users(id),
+    action VARCHAR(200) NOT NULL,
+    target_type VARCHAR(30) NOT NULL DEFAULT '',
+    target_id VARCHAR(200) NOT NULL DEFAULT '',
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+INSERT INTO merchandise (name, price, stock) VALUES
+    ('t-shirt', 80, 0),
+    ('cup', 20, 100),
+    ('book', 50, 100),
+    ('pen', 10, 100),
+    ('powerbank', 200, 100),
+    ('hoody', 300, 0),
+    ('umbrella', 200, 100),
+    ('socks', 10, 100),
+    ('wallet', 50, 100),
+    ('pink-hoody', 500, 100);
+
+-- Розовое худи остается отдельным лимитированным товаром pink-hoody, а не вариантом hoody,
+-- иначе его варианты обходили бы ограничение pink-hoody
+INSERT INTO merch_variants (sku, merch_name, attributes, price, stock, is_default) VALUES
+    ('t-shirt-s', 't-shirt', '{"size": "S"}', NULL, 30, FALSE),
+    ('t-shirt-m', 't-shirt', '{"size": "M"}', NULL, 40, TRUE),
+    ('t-shirt-l', 't-shirt', '{"size": "L"}', NULL, 30, FALSE),
+    ('hoody-m', 'hoody', '{"size": "M"}', NULL, 50, TRUE),
+    ('hoody-l', 'hoody', '{"size": "L"}', NULL, 50, FALSE);
+
+-- Товар с вариантами продается только вариантами, собственный остаток у него не используется
+UPDATE merchandise SET stock = 0
+WHERE stock <> 0 AND name IN (SELECT merch_name FROM merch_variants);
+
+-- Лимитированный товар: одна штука в руки
+INSERT INTO purchase_rules (merch_name, max_per_user) VALUES ('pink-hoody', 1)
+ON CONFLICT (merch_name) DO NOTHING;